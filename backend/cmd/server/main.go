@@ -2,13 +2,23 @@
 package main
 
 import (
+	"context"
 	"log"
+	"log/slog"
 	"os"
+	"os/signal"
+	"syscall"
+	"time"
+	_ "time/tzdata" // HOUSEHOLD_TIMEZONE must resolve in images without zoneinfo
 
 	"github.com/joho/godotenv"
 
 	"github.com/nomenarkt/vitaltrack/backend/internal/background"
+	"github.com/nomenarkt/vitaltrack/backend/internal/config"
 	"github.com/nomenarkt/vitaltrack/backend/internal/di"
+	"github.com/nomenarkt/vitaltrack/backend/internal/infra/airtable"
+	"github.com/nomenarkt/vitaltrack/backend/internal/logger"
+	"github.com/nomenarkt/vitaltrack/backend/internal/tracing"
 )
 
 func main() {
@@ -16,12 +26,64 @@ func main() {
 		log.Printf("godotenv load: %v", err)
 	}
 
-	di.StartTickerFunc = background.StartStockAlertTicker
+	cfg, err := config.Load(os.Getenv("CONFIG_FILE"), airtable.CheckFields)
+	if err != nil {
+		log.Printf("❌ invalid configuration:\n%v", err)
+		os.Exit(1)
+	}
+	lg := logger.New(os.Stderr, cfg.Log.Options())
+	slog.SetDefault(lg.Slog()) // whatever still uses the log package goes through lg
+	di.StartSchedulerFunc = background.StartScheduler
+
+	ctx, stopSignals := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stopSignals()
+	lg.Info(ctx, "configuration loaded", "config", cfg.String())
+
+	stopTracing, err := tracing.Setup(ctx, cfg.Tracing.Options())
+	if err != nil {
+		lg.Error(ctx, "tracing setup failed", "error", err)
+		os.Exit(1)
+	}
+
+	app, stopBackground, err := di.NewApp(ctx, cfg, lg)
+	if err != nil {
+		lg.Error(ctx, "startup failed", "error", err)
+		os.Exit(1)
+	}
 
-	app := di.NewApp()
+	listenErr := make(chan error, 1)
+	go func() { listenErr <- app.Listen(cfg.Server.Addr) }()
+
+	select {
+	case err := <-listenErr:
+		lg.Error(ctx, "server failed to start", "error", err)
+		os.Exit(1)
+	case <-ctx.Done():
+	}
+	stopSignals() // a second signal ends the process at once
+
+	shutdown := context.WithoutCancel(ctx)
+	lg.Info(shutdown, "shutting down, waiting for work in progress",
+		"timeout", cfg.Server.ShutdownTimeout, "background_timeout", cfg.Server.BackgroundShutdownTimeout)
+	// ShutdownWithTimeout gives up on the requests left after the timeout;
+	// the background stop gets its own budget once they are done.
+	if err := app.ShutdownWithTimeout(cfg.Server.ShutdownTimeout); err != nil {
+		lg.Warn(shutdown, "http shutdown failed", "error", err)
+	}
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		stopBackground()
+		if err := stopTracing(shutdown); err != nil {
+			lg.Warn(shutdown, "trace flush failed", "error", err)
+		}
+	}()
 
-	if err := app.Listen(":8787"); err != nil {
-		log.Printf("❌ Server failed to start: %v", err)
+	select {
+	case <-done:
+		lg.Info(shutdown, "shutdown complete")
+	case <-time.After(cfg.Server.BackgroundShutdownTimeout):
+		lg.Error(shutdown, "shutdown deadline exceeded, exiting")
 		os.Exit(1)
 	}
 }
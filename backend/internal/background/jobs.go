@@ -0,0 +1,148 @@
+// Package background defines the scheduled jobs: stock alerts, refill checks,
+// dose reminders, the weekly stock and monthly finance digests and the
+// forecast sync.
+package background
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/nomenarkt/vitaltrack/backend/internal/di"
+	"github.com/nomenarkt/vitaltrack/backend/internal/scheduler"
+)
+
+// Job is a background task and its default schedule. The schedule can be
+// overridden by name in config.Scheduler.Schedules, where "off" disables the
+// job.
+type Job struct {
+	Name    string
+	Default string // cron spec in the household timezone
+	Run     func(ctx context.Context, deps di.Dependencies, at time.Time) error
+}
+
+// Jobs lists the scheduled jobs.
+var Jobs = []Job{
+	{Name: "stock-alerts", Default: "0 8 * * *", Run: StockAlerts},
+	{Name: "refill-check", Default: "*/15 * * * *", Run: RefillCheck},
+	{Name: "dose-reminders", Default: "* * * * *", Run: DoseReminders},
+	{Name: "stock-digest", Default: "0 9 * * mon", Run: StockDigest},
+	{Name: "finance-digest", Default: "0 9 1 * *", Run: FinanceDigest},
+	{Name: "forecast-sync", Default: "0 3 * * *", Run: ForecastSync},
+}
+
+// Register adds every enabled job to s, using the schedule configured in
+// deps.Config when set. A schedule for a job that does not exist is an error.
+func Register(s *scheduler.Scheduler, deps di.Dependencies) error {
+	schedules := deps.Config.Scheduler.Schedules
+	for name := range schedules {
+		if !slices.ContainsFunc(Jobs, func(j Job) bool { return j.Name == name }) {
+			return fmt.Errorf("schedule set for unknown job %q", name)
+		}
+	}
+	for _, j := range Jobs {
+		spec := strings.TrimSpace(schedules[j.Name])
+		if spec == "" {
+			spec = j.Default
+		}
+		if spec == "off" {
+			s.Logger.Info(context.Background(), "job disabled", "job", j.Name)
+			continue
+		}
+		run := j.Run
+		if err := s.Add(j.Name, spec, func(ctx context.Context, at time.Time) error {
+			return run(ctx, deps, at)
+		}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// StartScheduler registers the jobs on deps.Scheduler and runs it until ctx
+// is cancelled. The returned function stops the scheduler and waits for
+// running jobs to return. Nothing is started when a job cannot be
+// registered.
+func StartScheduler(ctx context.Context, deps di.Dependencies) (stop func(), err error) {
+	s := deps.Scheduler
+	if s == nil {
+		s = scheduler.New(deps.Location, deps.Logger)
+	}
+	if err := Register(s, deps); err != nil {
+		return nil, fmt.Errorf("register jobs: %w", err)
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		s.Run(ctx)
+	}()
+	for _, st := range s.Status() {
+		deps.Logger.Info(ctx, "job scheduled", "job", st.Name, "schedule", st.Schedule)
+	}
+	return func() {
+		cancel()
+		<-done
+	}, nil
+}
+
+// StockAlerts runs the alert rules of deps.StockChecker at at: a low-stock
+// alert for every medicine that runs out within
+// usecase.LowStockThresholdDays, at most once per day, and the refills
+// recorded that day.
+func StockAlerts(ctx context.Context, deps di.Dependencies, at time.Time) error {
+	if deps.StockChecker == nil {
+		return errors.New("no stock checker configured")
+	}
+	_, err := deps.StockChecker.EvaluateAlerts(ctx, at, false)
+	return err
+}
+
+// RefillCheck notifies refills recorded today.
+func RefillCheck(ctx context.Context, deps di.Dependencies, _ time.Time) error {
+	if deps.StockChecker == nil {
+		return errors.New("no stock checker configured")
+	}
+	return deps.StockChecker.CheckAndAlertNewRefills(ctx)
+}
+
+// DoseReminders sends a reminder for every dose scheduled at the minute of at.
+func DoseReminders(ctx context.Context, deps di.Dependencies, at time.Time) error {
+	if deps.AdherenceSvc.Sender == nil {
+		return errors.New("no reminder sender configured")
+	}
+	return deps.AdherenceSvc.SendDueReminders(ctx, at)
+}
+
+// StockDigest sends the out-of-stock forecast to the stock digest
+// subscribers.
+func StockDigest(ctx context.Context, deps di.Dependencies, at time.Time) error {
+	if deps.DigestSvc.Sender == nil {
+		return errors.New("no digest sender configured")
+	}
+	return deps.DigestSvc.SendStockDigest(ctx, at)
+}
+
+// FinanceDigest sends the report for the month before at, with the shortfall
+// per need, to the finance digest subscribers.
+func FinanceDigest(ctx context.Context, deps di.Dependencies, at time.Time) error {
+	if deps.DigestSvc.Sender == nil {
+		return errors.New("no digest sender configured")
+	}
+	return deps.DigestSvc.SendFinanceDigest(ctx, at)
+}
+
+// ForecastSync recomputes out-of-stock dates as of at and saves the ones
+// that changed.
+func ForecastSync(ctx context.Context, deps di.Dependencies, at time.Time) error {
+	_, err := deps.ForecastSvc.SyncForecastDates(ctx, at)
+	return err
+}
+
+func init() {
+	di.StartSchedulerFunc = StartScheduler
+}
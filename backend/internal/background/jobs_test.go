@@ -0,0 +1,354 @@
+package background_test
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+	_ "time/tzdata"
+
+	"github.com/nomenarkt/vitaltrack/backend/internal/background"
+	"github.com/nomenarkt/vitaltrack/backend/internal/config"
+	"github.com/nomenarkt/vitaltrack/backend/internal/di"
+	"github.com/nomenarkt/vitaltrack/backend/internal/domain"
+	"github.com/nomenarkt/vitaltrack/backend/internal/scheduler"
+	"github.com/nomenarkt/vitaltrack/backend/internal/testutil"
+	"github.com/nomenarkt/vitaltrack/backend/internal/usecase"
+	"github.com/nomenarkt/vitaltrack/backend/internal/util/richtext"
+)
+
+// mockAirtable returns every financial entry, whatever the month asked.
+type mockAirtable struct {
+	testutil.Store
+	asked   string   // month passed to FetchFinancialEntries
+	alerted []string // medicines whose LastAlertedDate was saved
+}
+
+func (m *mockAirtable) UpdateLastAlertedDates(_ context.Context, medicineIDs []string, _ time.Time) error {
+	m.alerted = append(m.alerted, medicineIDs...)
+	return nil
+}
+
+func (m *mockAirtable) FetchFinancialEntries(_ context.Context, year int, month time.Month) ([]domain.FinancialEntry, error) {
+	m.asked = fmt.Sprintf("%d-%02d", year, month)
+	return m.Financial, nil
+}
+
+type mockTelegram struct{ msgs []string }
+
+func (m *mockTelegram) SendTelegramMessage(_ context.Context, msg richtext.Doc) error {
+	m.msgs = append(m.msgs, richtext.MarkdownV2(msg))
+	return nil
+}
+func (m *mockTelegram) PollForCommands(context.Context, func(context.Context) ([]domain.Medicine, []domain.StockEntry, error), func(context.Context, int, int) (domain.MonthlyFinancialReport, error), func(context.Context) ([]domain.AlertRecord, error)) {
+}
+
+type httpTelegram struct {
+	url    string
+	posted *[]string
+}
+
+func (h *httpTelegram) SendTelegramMessage(_ context.Context, doc richtext.Doc) error {
+	msg := richtext.MarkdownV2(doc)
+	resp, err := http.PostForm(h.url, url.Values{"text": []string{msg}})
+	if err != nil {
+		return err
+	}
+	if err := resp.Body.Close(); err != nil {
+		return err
+	}
+	*h.posted = append(*h.posted, msg)
+	return nil
+}
+
+func (h *httpTelegram) PollForCommands(context.Context, func(context.Context) ([]domain.Medicine, []domain.StockEntry, error), func(context.Context, int, int) (domain.MonthlyFinancialReport, error), func(context.Context) ([]domain.AlertRecord, error)) {
+}
+
+type captureLogger struct {
+	mu      sync.Mutex
+	entries []string
+}
+
+func (c *captureLogger) Debug(_ context.Context, msg string, kv ...any) { c.add(msg, kv) }
+func (c *captureLogger) Info(_ context.Context, msg string, kv ...any)  { c.add(msg, kv) }
+func (c *captureLogger) Warn(_ context.Context, msg string, kv ...any)  { c.add(msg, kv) }
+func (c *captureLogger) Error(_ context.Context, msg string, kv ...any) { c.add(msg, kv) }
+
+func (c *captureLogger) add(msg string, kv []any) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.entries = append(c.entries, logFmt(msg, kv...))
+}
+
+func (c *captureLogger) String() string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return strings.Join(c.entries, "\n")
+}
+
+func logFmt(msg string, kv ...any) string {
+	if len(kv) == 0 {
+		return msg
+	}
+	parts := make([]string, 0, len(kv)/2)
+	for i := 0; i < len(kv); i += 2 {
+		k := fmt.Sprint(kv[i])
+		v := ""
+		if i+1 < len(kv) {
+			v = fmt.Sprint(kv[i+1])
+		}
+		parts = append(parts, k+"="+v)
+	}
+	return msg + " " + strings.Join(parts, " ")
+}
+
+func TestStockAlerts(t *testing.T) {
+	now := time.Date(2025, 6, 4, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name   string
+		med    domain.Medicine
+		expect bool
+	}{
+		{
+			name:   "should_send",
+			med:    domain.Medicine{ID: "m1", Name: "Med1", StartDate: domain.NewFlexibleDate(now.AddDate(0, 0, -3)), InitialStock: 20, DailyDose: 2, UnitPerBox: 10},
+			expect: true,
+		},
+		{
+			name:   "skip_high_stock",
+			med:    domain.Medicine{ID: "m2", Name: "Med2", StartDate: domain.NewFlexibleDate(now.AddDate(0, 0, -1)), InitialStock: 50, DailyDose: 1, UnitPerBox: 10},
+			expect: false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			at := &mockAirtable{Store: testutil.Store{Meds: []domain.Medicine{tt.med}, Entries: []domain.StockEntry{}}}
+			tg := &mockTelegram{}
+			lg := &captureLogger{}
+			deps := di.Dependencies{Airtable: at, Telegram: tg, Logger: lg,
+				StockChecker: &usecase.StockChecker{Airtable: at, Telegram: tg, Logger: lg}}
+
+			if err := background.StockAlerts(context.Background(), deps, now); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if tt.expect && (len(tg.msgs) != 1 || !strings.Contains(tg.msgs[0], tt.med.Name)) {
+				t.Fatalf("sent %v, want one alert for %s", tg.msgs, tt.med.Name)
+			}
+			if !tt.expect && len(tg.msgs) > 0 {
+				t.Fatalf("unexpected alert sent: %v", tg.msgs)
+			}
+			if tt.expect && !strings.Contains(lg.String(), "alert sent key="+usecase.LowStockAlertKey(tt.med.ID, now)) {
+				t.Errorf("expected send log, got:\n%s", lg)
+			}
+			if got := at.alerted; tt.expect != slices.Equal(got, []string{tt.med.ID}) {
+				t.Errorf("LastAlertedDate saved for %v", got)
+			}
+		})
+	}
+}
+
+func TestStockAlerts_HTTP(t *testing.T) {
+	now := time.Now().UTC()
+	tests := []struct {
+		name   string
+		med    domain.Medicine
+		expect bool
+	}{
+		{
+			name:   "alert",
+			med:    domain.Medicine{ID: "a1", Name: "AlertMed", StartDate: domain.NewFlexibleDate(now.AddDate(0, 0, -15)), InitialStock: 20, DailyDose: 1, UnitPerBox: 10},
+			expect: true,
+		},
+		{
+			name:   "skip",
+			med:    domain.Medicine{ID: "a2", Name: "SkipMed", StartDate: domain.NewFlexibleDate(now.AddDate(0, 0, -1)), InitialStock: 50, DailyDose: 1, UnitPerBox: 10},
+			expect: false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			posted := []string{}
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if _, err := io.ReadAll(r.Body); err != nil {
+					t.Errorf("read body: %v", err)
+				}
+				w.WriteHeader(http.StatusOK)
+			}))
+			defer srv.Close()
+
+			at := &mockAirtable{Store: testutil.Store{Meds: []domain.Medicine{tt.med}, Entries: []domain.StockEntry{}}}
+			tg := &httpTelegram{url: srv.URL, posted: &posted}
+			deps := di.Dependencies{Airtable: at, Telegram: tg, Logger: &captureLogger{},
+				StockChecker: &usecase.StockChecker{Airtable: at, Telegram: tg}}
+
+			if err := background.StockAlerts(context.Background(), deps, now); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if tt.expect && len(posted) == 0 {
+				t.Fatalf("expected alert POST")
+			}
+			if !tt.expect && len(posted) > 0 {
+				t.Fatalf("unexpected alert sent: %v", posted)
+			}
+		})
+	}
+}
+
+// chatSender records digests per chat.
+type chatSender struct{ sent map[string]string }
+
+func (c *chatSender) SendToChat(_ context.Context, chatID string, msg richtext.Doc) error {
+	c.sent[chatID] = richtext.MarkdownV2(msg)
+	return nil
+}
+
+func TestFinanceDigest_previousMonth(t *testing.T) {
+	tana, err := time.LoadLocation("Indian/Antananarivo")
+	if err != nil {
+		t.Fatal(err)
+	}
+	at := &mockAirtable{Store: testutil.Store{Financial: []domain.FinancialEntry{
+		{Date: domain.NewFlexibleDate(time.Date(2025, 6, 5, 0, 0, 0, 0, time.UTC)), NeedLabel: "Med", NeedAmount: 20, AmountContributed: 5, Contributor: "Bob"},
+	}}}
+	sender := &chatSender{sent: map[string]string{}}
+	deps := di.Dependencies{DigestSvc: usecase.DigestService{
+		Finance:     usecase.FinancialReportService{Repo: at},
+		Sender:      sender,
+		DefaultChat: "1",
+		Location:    tana,
+	}}
+
+	// 01:00 on July 1 in Antananarivo is still June 30 in UTC.
+	if err := background.FinanceDigest(context.Background(), deps, time.Date(2025, 6, 30, 22, 0, 0, 0, time.UTC)); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if at.asked != "2025-06" {
+		t.Errorf("reported month %s, want 2025-06", at.asked)
+	}
+	if msg := sender.sent["1"]; !strings.Contains(msg, "*Financial Report 2025\\-06*") || !strings.Contains(msg, "Total shortfall: 15\u202fMGA") {
+		t.Errorf("sent %q", sender.sent)
+	}
+}
+
+func TestForecastSync(t *testing.T) {
+	start := domain.NewFlexibleDate(time.Date(2025, 6, 1, 0, 0, 0, 0, time.UTC))
+	saved := domain.NewFlexibleDate(time.Date(2025, 6, 11, 0, 0, 0, 0, time.UTC))
+	at := &mockAirtable{Store: testutil.Store{Meds: []domain.Medicine{
+		{ID: "same", Name: "Same", StartDate: start, InitialStock: 10, DailyDose: 1, ForecastOutOfStockDate: &saved},
+		{ID: "moved", Name: "Moved", StartDate: start, InitialStock: 20, DailyDose: 1, ForecastOutOfStockDate: &saved},
+		{ID: "new", Name: "New", StartDate: start, InitialStock: 5, DailyDose: 1},
+		{ID: "archived", Name: "Archived", StartDate: start, InitialStock: 5, DailyDose: 1, Archived: true},
+	}}}
+	deps := di.Dependencies{ForecastSvc: usecase.OutOfStockService{Airtable: at}}
+
+	if err := background.ForecastSync(context.Background(), deps, time.Date(2025, 6, 3, 3, 0, 0, 0, time.UTC)); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	var got []string
+	for _, u := range at.Forecasts {
+		got = append(got, u.MedicineID+"="+u.Date.Format("2006-01-02"))
+	}
+	if want := "new=2025-06-06,moved=2025-06-21"; strings.Join(got, ",") != want {
+		t.Errorf("saved %v, want %s", got, want)
+	}
+}
+
+func TestMessageJobs_noSender(t *testing.T) {
+	for name, run := range map[string]func(context.Context, di.Dependencies, time.Time) error{
+		"stock digest":   background.StockDigest,
+		"finance digest": background.FinanceDigest,
+		"dose reminders": background.DoseReminders,
+		"stock alerts":   background.StockAlerts,
+	} {
+		if err := run(context.Background(), di.Dependencies{}, time.Now()); err == nil {
+			t.Errorf("%s ran without a sender", name)
+		}
+	}
+}
+
+func TestStartScheduler(t *testing.T) {
+	schedules := map[string]string{}
+	for _, j := range background.Jobs {
+		schedules[j.Name] = "off"
+	}
+	schedules["stock-alerts"] = "@every 5ms"
+
+	now := time.Now()
+	at := &mockAirtable{Store: testutil.Store{Meds: []domain.Medicine{
+		{ID: "m1", Name: "Med1", StartDate: domain.NewFlexibleDate(now.AddDate(0, 0, -3)), InitialStock: 20, DailyDose: 2, UnitPerBox: 10},
+	}}}
+	tg := &mockTelegram{}
+	lg := &captureLogger{}
+	jobs := scheduler.New(time.UTC, lg)
+	deps := di.Dependencies{Airtable: at, Telegram: tg, Logger: lg, Scheduler: jobs,
+		StockChecker: &usecase.StockChecker{Airtable: at, Telegram: tg, Logger: lg}}
+	deps.Config.Scheduler.Schedules = schedules
+
+	stop, err := background.StartScheduler(context.Background(), deps)
+	if err != nil {
+		t.Fatal(err)
+	}
+	time.Sleep(30 * time.Millisecond)
+	stop()
+
+	status := jobs.Status()
+	if len(status) != 1 || status[0].Name != "stock-alerts" || status[0].Runs == 0 || !status[0].LastOK {
+		t.Fatalf("status = %+v", status)
+	}
+	if len(tg.msgs) == 0 {
+		t.Error("stock-alerts job sent nothing")
+	}
+	if !strings.Contains(lg.String(), "job completed job=stock-alerts") {
+		t.Errorf("missing completion log:\n%s", lg)
+	}
+}
+
+func TestJobs_matchConfig(t *testing.T) {
+	var names []string
+	for _, j := range background.Jobs {
+		names = append(names, j.Name)
+	}
+	if !slices.Equal(names, config.JobNames) {
+		t.Errorf("jobs %v, config.JobNames %v", names, config.JobNames)
+	}
+}
+
+func TestStartScheduler_invalidSchedule(t *testing.T) {
+	var deps di.Dependencies
+	deps.Logger = &captureLogger{}
+	deps.Config.Scheduler.Schedules = map[string]string{"refill-chek": "@every 1m"}
+	if stop, err := background.StartScheduler(context.Background(), deps); err == nil {
+		stop()
+		t.Fatal("StartScheduler accepted a schedule for an unknown job")
+	}
+}
+
+func TestRegister_invalidSchedule(t *testing.T) {
+	tests := []struct {
+		name      string
+		schedules map[string]string
+	}{
+		{"bad_spec", map[string]string{"refill-check": "every day"}},
+		{"unknown_job", map[string]string{"refill-chek": "@every 1m"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var deps di.Dependencies
+			deps.Config.Scheduler.Schedules = tt.schedules
+			err := background.Register(scheduler.New(nil, &captureLogger{}), deps)
+			if err == nil || !strings.Contains(err.Error(), "refill-ch") {
+				t.Errorf("err = %v", err)
+			}
+		})
+	}
+}
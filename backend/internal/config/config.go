@@ -0,0 +1,516 @@
+// Package config loads the server configuration once at startup, from an
+// optional YAML file and the environment, and validates it before anything
+// is wired.
+package config
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"maps"
+	"net/url"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/nomenarkt/vitaltrack/backend/internal/infra/cache"
+	"github.com/nomenarkt/vitaltrack/backend/internal/logger"
+	"github.com/nomenarkt/vitaltrack/backend/internal/logic/calendar"
+	"github.com/nomenarkt/vitaltrack/backend/internal/scheduler"
+	"github.com/nomenarkt/vitaltrack/backend/internal/tracing"
+	"github.com/nomenarkt/vitaltrack/backend/internal/util/i18n"
+)
+
+// Config is the full server configuration.
+type Config struct {
+	Server    Server    `yaml:"server"`
+	Airtable  Airtable  `yaml:"airtable"`
+	Cache     Cache     `yaml:"cache"`
+	Telegram  Telegram  `yaml:"telegram"`
+	Scheduler Scheduler `yaml:"scheduler"`
+	Adherence Adherence `yaml:"adherence"`
+	Log       Log       `yaml:"log"`
+	Tracing   Tracing   `yaml:"tracing"`
+
+	// Timezone is the household IANA timezone that days are counted in.
+	Timezone string         `yaml:"timezone"`
+	Location *time.Location `yaml:"-"` // Timezone, loaded by Load; UTC when empty
+}
+
+// Server configures the HTTP server. ShutdownTimeout bounds the drain of
+// in-flight requests; BackgroundShutdownTimeout then bounds the stop of the
+// jobs, the poller and the outbox dispatcher.
+type Server struct {
+	Addr                      string        `yaml:"addr"`
+	ShutdownTimeout           time.Duration `yaml:"shutdown_timeout"`
+	BackgroundShutdownTimeout time.Duration `yaml:"background_shutdown_timeout"`
+	EnableEntryPost           bool          `yaml:"enable_entry_post"`
+	EnableAPIWrites           bool          `yaml:"enable_api_writes"`
+	// ReadinessTTL is how long /readyz reuses a check result.
+	ReadinessTTL time.Duration `yaml:"readiness_ttl"`
+}
+
+// Airtable configures the Airtable base and its tables. AlertsTable and
+// AdherenceTable are optional: the logs are kept in memory without them.
+type Airtable struct {
+	Token          string `yaml:"token"`
+	BaseID         string `yaml:"base_id"`
+	APIBaseURL     string `yaml:"api_base_url"`
+	MedicinesTable string `yaml:"medicines_table"`
+	EntriesTable   string `yaml:"entries_table"`
+	FinancialTable string `yaml:"financial_table"`
+	AlertsTable    string `yaml:"alerts_table"`
+	AdherenceTable string `yaml:"adherence_table"`
+
+	// Timeout bounds each request attempt; MaxRetries is how many times a
+	// rate limited or failed request is retried.
+	Timeout    time.Duration `yaml:"timeout"`
+	MaxRetries int           `yaml:"max_retries"`
+
+	// Fields maps the fields of each table to their column, when the column
+	// is not named as the field: Fields["medicines"]["daily_dose"] = "Dose".
+	// Tables are medicines, entries, financial, alerts and adherence.
+	Fields map[string]map[string]string `yaml:"fields"`
+	// CheckSchema compares the columns with the base schema at startup.
+	CheckSchema bool `yaml:"check_schema"`
+	// WebhookURL is the public URL of /webhooks/airtable. When set, Airtable
+	// notifies it of changes to the entries table. WebhookStatePath is
+	// optional: the webhook is saved there to be reused after a restart.
+	WebhookURL       string `yaml:"webhook_url"`
+	WebhookStatePath string `yaml:"webhook_state_path"`
+}
+
+// Cache configures how long Airtable tables are kept in memory. A zero TTL
+// fetches the table on every read.
+type Cache struct {
+	MedicinesTTL  time.Duration `yaml:"medicines_ttl"`
+	EntriesTTL    time.Duration `yaml:"entries_ttl"`
+	DoseEventsTTL time.Duration `yaml:"dose_events_ttl"`
+}
+
+// TTLs returns the cache TTLs for c.
+func (c Cache) TTLs() cache.TTLs {
+	return cache.TTLs{Medicines: c.MedicinesTTL, Entries: c.EntriesTTL, DoseEvents: c.DoseEventsTTL}
+}
+
+// Telegram configures the bot. OutboxPath and PrefsPath are optional: the
+// outbox and chat preferences are kept in memory without them. DigestChats
+// lists the chats besides ChatID allowed to receive digests and to use
+// /validate, /alerts, /adherence and /lang; its variable separates them by
+// commas.
+type Telegram struct {
+	BotToken    string    `yaml:"bot_token"`
+	ChatID      string    `yaml:"chat_id"`
+	APIBaseURL  string    `yaml:"api_base_url"`
+	Language    i18n.Lang `yaml:"language"`
+	OutboxPath  string    `yaml:"outbox_path"`
+	PrefsPath   string    `yaml:"prefs_path"`
+	DigestChats []string  `yaml:"digest_chats"`
+	Polling     bool      `yaml:"polling"`
+}
+
+// Scheduler configures the background jobs.
+type Scheduler struct {
+	Enabled bool          `yaml:"enabled"`
+	Jitter  time.Duration `yaml:"jitter"`
+	// Schedules overrides job schedules by job name, e.g. "stock-alerts".
+	// A cron spec replaces the default schedule and "off" disables the job.
+	Schedules map[string]string `yaml:"schedules"`
+}
+
+// FieldsChecker reports the problems of an Airtable.Fields mapping. The
+// fields are known by package airtable, whose CheckFields is passed to Load
+// and Validate.
+type FieldsChecker func(mapping map[string]map[string]string) error
+
+// JobNames lists the background jobs whose schedules can be set. It must
+// match background.Jobs.
+var JobNames = []string{"stock-alerts", "refill-check", "dose-reminders", "stock-digest", "finance-digest", "forecast-sync"}
+
+// Adherence configures dose tracking.
+type Adherence struct {
+	// StockCredit adds the pills of skipped doses back to the stock.
+	StockCredit bool `yaml:"stock_credit"`
+}
+
+// Log configures the server log.
+type Log struct {
+	Level  string `yaml:"level"`  // debug, info, warn or error
+	Format string `yaml:"format"` // text or json
+}
+
+// Tracing configures OpenTelemetry tracing.
+type Tracing struct {
+	// Exporter is none, otlp or stdout. stdout writes spans as JSON lines to
+	// File, or to standard output when File is empty.
+	Exporter string `yaml:"exporter"`
+	Endpoint string `yaml:"endpoint"` // OTLP/HTTP collector URL
+	File     string `yaml:"file"`
+}
+
+// Options returns the tracing options for t.
+func (t Tracing) Options() tracing.Options {
+	return tracing.Options{Exporter: t.Exporter, Endpoint: t.Endpoint, File: t.File}
+}
+
+// Options returns the logger options for l, which must have been
+// validated.
+func (l Log) Options() logger.Options {
+	level, _ := logger.ParseLevel(l.Level)
+	return logger.Options{Level: level, JSON: l.Format == "json"}
+}
+
+// Default returns the configuration used for everything that is not set.
+func Default() Config {
+	return Config{
+		Server: Server{
+			Addr:                      ":8787",
+			ShutdownTimeout:           15 * time.Second,
+			BackgroundShutdownTimeout: 15 * time.Second,
+			ReadinessTTL:              30 * time.Second,
+		},
+		Airtable: Airtable{
+			APIBaseURL:  "https://api.airtable.com",
+			Timeout:     15 * time.Second,
+			MaxRetries:  3,
+			CheckSchema: true,
+		},
+		Cache: Cache{MedicinesTTL: 5 * time.Minute, EntriesTTL: time.Minute, DoseEventsTTL: time.Minute},
+		Telegram: Telegram{
+			APIBaseURL: "https://api.telegram.org",
+			Language:   i18n.Default,
+		},
+		Scheduler: Scheduler{Jitter: 30 * time.Second},
+		Log:       Log{Level: "info", Format: "text"},
+		Tracing:   Tracing{Exporter: tracing.ExporterNone},
+	}
+}
+
+// Load reads the configuration: defaults, then the YAML file at path when
+// path is not empty, then environment variables, which win over the file.
+// The result is validated, checking the field mappings with checkFields, and
+// every problem found is reported in the returned error.
+func Load(path string, checkFields FieldsChecker) (Config, error) {
+	cfg := Default()
+	if path != "" {
+		b, err := os.ReadFile(path)
+		if err != nil {
+			return Config{}, fmt.Errorf("read config file: %w", err)
+		}
+		if err := yaml.Unmarshal(b, &cfg); err != nil {
+			return Config{}, fmt.Errorf("parse config file %s: %w", path, err)
+		}
+	}
+	errs := []error{cfg.applyEnv(os.Environ())}
+	errs = append(errs, cfg.Validate(checkFields))
+	if err := errors.Join(errs...); err != nil {
+		return Config{}, err
+	}
+	return cfg, nil
+}
+
+// setting binds one scalar field to its environment variable and YAML key.
+type setting struct {
+	env    string
+	key    string
+	field  any // *string, *[]string, *bool, *int, *time.Duration or *i18n.Lang
+	secret bool
+}
+
+func (c *Config) settings() []setting {
+	return []setting{
+		{env: "SERVER_ADDR", key: "server.addr", field: &c.Server.Addr},
+		{env: "SHUTDOWN_TIMEOUT", key: "server.shutdown_timeout", field: &c.Server.ShutdownTimeout},
+		{env: "BACKGROUND_SHUTDOWN_TIMEOUT", key: "server.background_shutdown_timeout", field: &c.Server.BackgroundShutdownTimeout},
+		{env: "ENABLE_ENTRY_POST", key: "server.enable_entry_post", field: &c.Server.EnableEntryPost},
+		{env: "ENABLE_API_WRITES", key: "server.enable_api_writes", field: &c.Server.EnableAPIWrites},
+		{env: "READINESS_CACHE_TTL", key: "server.readiness_ttl", field: &c.Server.ReadinessTTL},
+		{env: "AIRTABLE_TOKEN", key: "airtable.token", field: &c.Airtable.Token, secret: true},
+		{env: "AIRTABLE_BASE_ID", key: "airtable.base_id", field: &c.Airtable.BaseID},
+		{env: "AIRTABLE_API_BASE_URL", key: "airtable.api_base_url", field: &c.Airtable.APIBaseURL},
+		{env: "AIRTABLE_MEDICINES_TABLE", key: "airtable.medicines_table", field: &c.Airtable.MedicinesTable},
+		{env: "AIRTABLE_ENTRIES_TABLE", key: "airtable.entries_table", field: &c.Airtable.EntriesTable},
+		{env: "AIRTABLE_FINANCIAL_TABLE", key: "airtable.financial_table", field: &c.Airtable.FinancialTable},
+		{env: "AIRTABLE_ALERTS_TABLE", key: "airtable.alerts_table", field: &c.Airtable.AlertsTable},
+		{env: "AIRTABLE_ADHERENCE_TABLE", key: "airtable.adherence_table", field: &c.Airtable.AdherenceTable},
+		{env: "AIRTABLE_TIMEOUT", key: "airtable.timeout", field: &c.Airtable.Timeout},
+		{env: "AIRTABLE_MAX_RETRIES", key: "airtable.max_retries", field: &c.Airtable.MaxRetries},
+		{env: "AIRTABLE_CHECK_SCHEMA", key: "airtable.check_schema", field: &c.Airtable.CheckSchema},
+		{env: "AIRTABLE_WEBHOOK_URL", key: "airtable.webhook_url", field: &c.Airtable.WebhookURL},
+		{env: "AIRTABLE_WEBHOOK_STATE_PATH", key: "airtable.webhook_state_path", field: &c.Airtable.WebhookStatePath},
+		{env: "CACHE_MEDICINES_TTL", key: "cache.medicines_ttl", field: &c.Cache.MedicinesTTL},
+		{env: "CACHE_ENTRIES_TTL", key: "cache.entries_ttl", field: &c.Cache.EntriesTTL},
+		{env: "CACHE_DOSE_EVENTS_TTL", key: "cache.dose_events_ttl", field: &c.Cache.DoseEventsTTL},
+		{env: "TELEGRAM_BOT_TOKEN", key: "telegram.bot_token", field: &c.Telegram.BotToken, secret: true},
+		{env: "TELEGRAM_CHAT_ID", key: "telegram.chat_id", field: &c.Telegram.ChatID},
+		{env: "TELEGRAM_API_BASE_URL", key: "telegram.api_base_url", field: &c.Telegram.APIBaseURL},
+		{env: "TELEGRAM_LANGUAGE", key: "telegram.language", field: &c.Telegram.Language},
+		{env: "TELEGRAM_OUTBOX_PATH", key: "telegram.outbox_path", field: &c.Telegram.OutboxPath},
+		{env: "TELEGRAM_PREFS_PATH", key: "telegram.prefs_path", field: &c.Telegram.PrefsPath},
+		{env: "TELEGRAM_DIGEST_CHATS", key: "telegram.digest_chats", field: &c.Telegram.DigestChats},
+		{env: "ENABLE_TELEGRAM_POLLING", key: "telegram.polling", field: &c.Telegram.Polling},
+		{env: "ENABLE_SCHEDULER", key: "scheduler.enabled", field: &c.Scheduler.Enabled},
+		{env: "SCHEDULER_JITTER", key: "scheduler.jitter", field: &c.Scheduler.Jitter},
+		{env: "ADHERENCE_STOCK_CREDIT", key: "adherence.stock_credit", field: &c.Adherence.StockCredit},
+		{env: "LOG_LEVEL", key: "log.level", field: &c.Log.Level},
+		{env: "LOG_FORMAT", key: "log.format", field: &c.Log.Format},
+		{env: "TRACING_EXPORTER", key: "tracing.exporter", field: &c.Tracing.Exporter},
+		{env: "OTEL_EXPORTER_OTLP_ENDPOINT", key: "tracing.endpoint", field: &c.Tracing.Endpoint},
+		{env: "TRACING_FILE", key: "tracing.file", field: &c.Tracing.File},
+		{env: "HOUSEHOLD_TIMEZONE", key: "timezone", field: &c.Timezone},
+	}
+}
+
+// schedulePrefix starts the variables that set job schedules:
+// SCHEDULE_STOCK_ALERTS sets the "stock-alerts" job. Only the variables of
+// JobNames are read.
+const schedulePrefix = "SCHEDULE_"
+
+// scheduleEnv returns the variable that sets the schedule of job.
+func scheduleEnv(job string) string {
+	return schedulePrefix + strings.ToUpper(strings.ReplaceAll(job, "-", "_"))
+}
+
+// fieldPrefix starts the variables that map Airtable fields to columns:
+// AIRTABLE_FIELD_MEDICINES_DAILY_DOSE sets the column of the daily_dose
+// field of the medicines table.
+const fieldPrefix = "AIRTABLE_FIELD_"
+
+// applyEnv overrides c with the set, non-empty variables of environ.
+func (c *Config) applyEnv(environ []string) error {
+	env := map[string]string{}
+	for _, kv := range environ {
+		if k, v, ok := strings.Cut(kv, "="); ok && strings.TrimSpace(v) != "" {
+			env[k] = strings.TrimSpace(v)
+		}
+	}
+
+	var errs []error
+	for _, s := range c.settings() {
+		v, ok := env[s.env]
+		if !ok {
+			continue
+		}
+		if err := s.set(v); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", s.env, err))
+		}
+	}
+
+	if v, ok := env["ENABLE_ALERT_TICKER"]; ok {
+		slog.Warn("ENABLE_ALERT_TICKER is deprecated, use ENABLE_SCHEDULER")
+		on, err := strconv.ParseBool(v)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("ENABLE_ALERT_TICKER: %w", err))
+		}
+		c.Scheduler.Enabled = c.Scheduler.Enabled || on
+	}
+
+	for _, name := range JobNames {
+		v, ok := env[scheduleEnv(name)]
+		if !ok {
+			continue
+		}
+		if c.Scheduler.Schedules == nil {
+			c.Scheduler.Schedules = map[string]string{}
+		}
+		c.Scheduler.Schedules[name] = v
+	}
+	for _, k := range slices.Sorted(maps.Keys(env)) {
+		name, ok := strings.CutPrefix(k, fieldPrefix)
+		if !ok {
+			continue
+		}
+		table, field, ok := strings.Cut(strings.ToLower(name), "_")
+		if !ok || field == "" {
+			errs = append(errs, fmt.Errorf("%s: want %s<TABLE>_<FIELD>", k, fieldPrefix))
+			continue
+		}
+		if c.Airtable.Fields == nil {
+			c.Airtable.Fields = map[string]map[string]string{}
+		}
+		if c.Airtable.Fields[table] == nil {
+			c.Airtable.Fields[table] = map[string]string{}
+		}
+		c.Airtable.Fields[table][field] = env[k]
+	}
+	return errors.Join(errs...)
+}
+
+func (s setting) set(v string) error {
+	switch f := s.field.(type) {
+	case *string:
+		*f = v
+	case *[]string:
+		*f = nil
+		for _, item := range strings.Split(v, ",") {
+			if item = strings.TrimSpace(item); item != "" {
+				*f = append(*f, item)
+			}
+		}
+	case *i18n.Lang:
+		*f = i18n.Lang(v)
+	case *bool:
+		b, err := strconv.ParseBool(v)
+		if err != nil {
+			return fmt.Errorf("%q is not a boolean", v)
+		}
+		*f = b
+	case *int:
+		n, err := strconv.Atoi(v)
+		if err != nil {
+			return fmt.Errorf("%q is not an integer", v)
+		}
+		*f = n
+	case *time.Duration:
+		d, err := time.ParseDuration(v)
+		if err != nil {
+			return fmt.Errorf("%q is not a duration", v)
+		}
+		*f = d
+	}
+	return nil
+}
+
+func (s setting) String() string {
+	switch f := s.field.(type) {
+	case *string:
+		return *f
+	case *[]string:
+		return strings.Join(*f, ",")
+	case *i18n.Lang:
+		return string(*f)
+	case *bool:
+		return strconv.FormatBool(*f)
+	case *int:
+		return strconv.Itoa(*f)
+	case *time.Duration:
+		return f.String()
+	}
+	return ""
+}
+
+// Validate checks that required values are set and that every value is
+// usable, checking the field mappings with checkFields, and loads Location.
+// It reports all problems at once.
+func (c *Config) Validate(checkFields FieldsChecker) error {
+	var errs []error
+	for _, s := range c.settings() {
+		switch s.env {
+		case "AIRTABLE_TOKEN", "AIRTABLE_BASE_ID", "AIRTABLE_MEDICINES_TABLE", "AIRTABLE_ENTRIES_TABLE",
+			"TELEGRAM_BOT_TOKEN", "TELEGRAM_CHAT_ID":
+			if s.String() == "" {
+				errs = append(errs, fmt.Errorf("%s (%s) is required", s.env, s.key))
+			}
+		}
+	}
+
+	if c.Server.ShutdownTimeout <= 0 {
+		errs = append(errs, fmt.Errorf("SHUTDOWN_TIMEOUT (server.shutdown_timeout) must be positive, got %s", c.Server.ShutdownTimeout))
+	}
+	if c.Server.BackgroundShutdownTimeout <= 0 {
+		errs = append(errs, fmt.Errorf("BACKGROUND_SHUTDOWN_TIMEOUT (server.background_shutdown_timeout) must be positive, got %s", c.Server.BackgroundShutdownTimeout))
+	}
+	if c.Server.ReadinessTTL < 0 {
+		errs = append(errs, fmt.Errorf("READINESS_CACHE_TTL (server.readiness_ttl) must not be negative, got %s", c.Server.ReadinessTTL))
+	}
+	if c.Airtable.Timeout <= 0 {
+		errs = append(errs, fmt.Errorf("AIRTABLE_TIMEOUT (airtable.timeout) must be positive, got %s", c.Airtable.Timeout))
+	}
+	if c.Airtable.MaxRetries < 0 {
+		errs = append(errs, fmt.Errorf("AIRTABLE_MAX_RETRIES (airtable.max_retries) must not be negative, got %d", c.Airtable.MaxRetries))
+	}
+	if err := checkFields(c.Airtable.Fields); err != nil {
+		errs = append(errs, fmt.Errorf("AIRTABLE_FIELD_* (airtable.fields): %w", err))
+	}
+	if c.Airtable.WebhookURL != "" {
+		if u, err := url.Parse(c.Airtable.WebhookURL); err != nil || u.Scheme == "" || u.Host == "" {
+			errs = append(errs, fmt.Errorf("AIRTABLE_WEBHOOK_URL (airtable.webhook_url) must be an absolute URL, got %q", c.Airtable.WebhookURL))
+		}
+	}
+	if c.Cache.MedicinesTTL < 0 {
+		errs = append(errs, fmt.Errorf("CACHE_MEDICINES_TTL (cache.medicines_ttl) must not be negative, got %s", c.Cache.MedicinesTTL))
+	}
+	if c.Cache.EntriesTTL < 0 {
+		errs = append(errs, fmt.Errorf("CACHE_ENTRIES_TTL (cache.entries_ttl) must not be negative, got %s", c.Cache.EntriesTTL))
+	}
+	if c.Cache.DoseEventsTTL < 0 {
+		errs = append(errs, fmt.Errorf("CACHE_DOSE_EVENTS_TTL (cache.dose_events_ttl) must not be negative, got %s", c.Cache.DoseEventsTTL))
+	}
+	if c.Scheduler.Jitter < 0 {
+		errs = append(errs, fmt.Errorf("SCHEDULER_JITTER (scheduler.jitter) must not be negative, got %s", c.Scheduler.Jitter))
+	}
+	if c.Telegram.Language == "" {
+		c.Telegram.Language = i18n.Default
+	}
+	if lang, ok := i18n.Parse(string(c.Telegram.Language)); ok {
+		c.Telegram.Language = lang
+	} else {
+		errs = append(errs, fmt.Errorf("TELEGRAM_LANGUAGE (telegram.language): unsupported language %q", c.Telegram.Language))
+	}
+
+	if _, err := logger.ParseLevel(c.Log.Level); err != nil {
+		errs = append(errs, fmt.Errorf("LOG_LEVEL (log.level): unknown level %q", c.Log.Level))
+	}
+	if c.Log.Format != "text" && c.Log.Format != "json" {
+		errs = append(errs, fmt.Errorf("LOG_FORMAT (log.format) must be text or json, got %q", c.Log.Format))
+	}
+
+	switch c.Tracing.Exporter {
+	case tracing.ExporterNone, tracing.ExporterOTLP, tracing.ExporterStdout:
+	default:
+		errs = append(errs, fmt.Errorf("TRACING_EXPORTER (tracing.exporter) must be none, otlp or stdout, got %q", c.Tracing.Exporter))
+	}
+	if c.Tracing.Endpoint != "" {
+		if u, err := url.Parse(c.Tracing.Endpoint); err != nil || u.Scheme == "" || u.Host == "" {
+			errs = append(errs, fmt.Errorf("OTEL_EXPORTER_OTLP_ENDPOINT (tracing.endpoint) must be an absolute URL, got %q", c.Tracing.Endpoint))
+		}
+	}
+
+	loc, err := calendar.LoadLocation(c.Timezone)
+	if err != nil {
+		errs = append(errs, fmt.Errorf("HOUSEHOLD_TIMEZONE (timezone): %w", err))
+	}
+	c.Location = loc
+
+	for _, name := range slices.Sorted(maps.Keys(c.Scheduler.Schedules)) {
+		spec := c.Scheduler.Schedules[name]
+		if !slices.Contains(JobNames, name) {
+			errs = append(errs, fmt.Errorf("schedule set for unknown job %q, want one of %s", name, strings.Join(JobNames, ", ")))
+			continue
+		}
+		if spec == "off" {
+			continue
+		}
+		if _, err := scheduler.Parse(spec); err != nil {
+			errs = append(errs, fmt.Errorf("schedule of job %s: %w", name, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// String lists the configuration as environment variables, one per line,
+// with secrets redacted. It is safe to log.
+func (c Config) String() string {
+	var b strings.Builder
+	for _, s := range c.settings() {
+		v := s.String()
+		if s.secret && v != "" {
+			v = "[redacted]"
+		}
+		fmt.Fprintf(&b, "%s=%s\n", s.env, v)
+	}
+	for _, name := range slices.Sorted(maps.Keys(c.Scheduler.Schedules)) {
+		fmt.Fprintf(&b, "%s=%s\n", scheduleEnv(name), c.Scheduler.Schedules[name])
+	}
+	for _, table := range slices.Sorted(maps.Keys(c.Airtable.Fields)) {
+		for _, field := range slices.Sorted(maps.Keys(c.Airtable.Fields[table])) {
+			env := fieldPrefix + strings.ToUpper(table+"_"+field)
+			fmt.Fprintf(&b, "%s=%s\n", env, c.Airtable.Fields[table][field])
+		}
+	}
+	return b.String()
+}
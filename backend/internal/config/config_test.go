@@ -0,0 +1,221 @@
+package config_test
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/nomenarkt/vitaltrack/backend/internal/config"
+	"github.com/nomenarkt/vitaltrack/backend/internal/infra/airtable"
+)
+
+// required sets the values Load cannot do without.
+func required(t *testing.T) {
+	t.Helper()
+	for k, v := range map[string]string{
+		"AIRTABLE_TOKEN":           "patSecret",
+		"AIRTABLE_BASE_ID":         "app1",
+		"AIRTABLE_MEDICINES_TABLE": "Medicines",
+		"AIRTABLE_ENTRIES_TABLE":   "Entries",
+		"TELEGRAM_BOT_TOKEN":       "123:botSecret",
+		"TELEGRAM_CHAT_ID":         "42",
+	} {
+		t.Setenv(k, v)
+	}
+}
+
+func writeFile(t *testing.T, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestLoad_env(t *testing.T) {
+	required(t)
+	t.Setenv("ENABLE_API_WRITES", "true")
+	t.Setenv("SCHEDULER_JITTER", "5s")
+	t.Setenv("SCHEDULE_STOCK_ALERTS", "0 7 * * *")
+	t.Setenv("SCHEDULE_BACKUP", "0 2 * * *")
+	t.Setenv("TELEGRAM_DIGEST_CHATS", "7, 8,")
+	t.Setenv("HOUSEHOLD_TIMEZONE", "UTC")
+	t.Setenv("AIRTABLE_FIELD_MEDICINES_DAILY_DOSE", "Daily dose")
+
+	cfg, err := config.Load("", airtable.CheckFields)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.Airtable.BaseID != "app1" || cfg.Telegram.ChatID != "42" || !cfg.Server.EnableAPIWrites {
+		t.Errorf("cfg = %+v", cfg)
+	}
+	if cfg.Scheduler.Jitter != 5*time.Second || cfg.Scheduler.Schedules["stock-alerts"] != "0 7 * * *" {
+		t.Errorf("scheduler = %+v", cfg.Scheduler)
+	}
+	if _, ok := cfg.Scheduler.Schedules["backup"]; ok {
+		t.Error("SCHEDULE_BACKUP read although backup is not a job")
+	}
+	if got := fmt.Sprint(cfg.Telegram.DigestChats); got != "[7 8]" {
+		t.Errorf("DigestChats = %s", got)
+	}
+	if got := cfg.Airtable.Fields["medicines"]["daily_dose"]; got != "Daily dose" {
+		t.Errorf("daily_dose column = %q", got)
+	}
+	if cfg.Server.Addr != ":8787" || cfg.Server.ShutdownTimeout != 15*time.Second || cfg.Server.BackgroundShutdownTimeout != 15*time.Second {
+		t.Errorf("defaults not applied: %+v", cfg.Server)
+	}
+	if cfg.Location != time.UTC {
+		t.Errorf("Location = %v", cfg.Location)
+	}
+}
+
+func TestLoad_fileThenEnv(t *testing.T) {
+	path := writeFile(t, `
+airtable:
+  token: fileToken
+  base_id: appFile
+  medicines_table: Meds
+  entries_table: Entries
+  fields:
+    financial:
+      need_label: Besoin
+telegram:
+  bot_token: fileBot
+  chat_id: "7"
+  language: fr
+  digest_chats: ["8", "9"]
+scheduler:
+  enabled: true
+  jitter: 1m
+  schedules:
+    refill-check: "off"
+`)
+	t.Setenv("AIRTABLE_BASE_ID", "appEnv")
+
+	cfg, err := config.Load(path, airtable.CheckFields)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.Airtable.BaseID != "appEnv" {
+		t.Errorf("BaseID = %q, want the environment to win", cfg.Airtable.BaseID)
+	}
+	if cfg.Airtable.Token != "fileToken" || cfg.Telegram.Language != "fr" || !cfg.Scheduler.Enabled {
+		t.Errorf("cfg = %+v", cfg)
+	}
+	if cfg.Scheduler.Jitter != time.Minute || cfg.Scheduler.Schedules["refill-check"] != "off" {
+		t.Errorf("scheduler = %+v", cfg.Scheduler)
+	}
+	if got := fmt.Sprint(cfg.Telegram.DigestChats); got != "[8 9]" {
+		t.Errorf("DigestChats = %s", got)
+	}
+	if cfg.Airtable.Fields["financial"]["need_label"] != "Besoin" {
+		t.Errorf("fields = %v", cfg.Airtable.Fields)
+	}
+	if cfg.Airtable.APIBaseURL != "https://api.airtable.com" {
+		t.Errorf("default API URL lost: %q", cfg.Airtable.APIBaseURL)
+	}
+}
+
+func TestLoad_reportsEveryProblem(t *testing.T) {
+	for _, k := range []string{"AIRTABLE_TOKEN", "AIRTABLE_BASE_ID", "AIRTABLE_MEDICINES_TABLE", "AIRTABLE_ENTRIES_TABLE", "TELEGRAM_BOT_TOKEN", "TELEGRAM_CHAT_ID"} {
+		t.Setenv(k, "")
+	}
+	t.Setenv("ENABLE_SCHEDULER", "yes please")
+	t.Setenv("SHUTDOWN_TIMEOUT", "soon")
+	t.Setenv("BACKGROUND_SHUTDOWN_TIMEOUT", "0s")
+	t.Setenv("TELEGRAM_LANGUAGE", "de")
+	t.Setenv("HOUSEHOLD_TIMEZONE", "Mars/Olympus")
+	t.Setenv("SCHEDULE_REFILL_CHECK", "every day")
+	t.Setenv("AIRTABLE_MAX_RETRIES", "many")
+	t.Setenv("AIRTABLE_WEBHOOK_URL", "/webhooks/airtable")
+	t.Setenv("CACHE_ENTRIES_TTL", "-1m")
+	t.Setenv("LOG_LEVEL", "chatty")
+	t.Setenv("LOG_FORMAT", "xml")
+	t.Setenv("TRACING_EXPORTER", "jaeger")
+	t.Setenv("OTEL_EXPORTER_OTLP_ENDPOINT", "localhost:4318")
+	t.Setenv("AIRTABLE_FIELD_MEDICINES_DOSE", "Dose")
+
+	path := writeFile(t, `
+scheduler:
+  schedules:
+    stock-alert: "0 7 * * *"
+`)
+
+	_, err := config.Load(path, airtable.CheckFields)
+	if err == nil {
+		t.Fatal("Load() succeeded")
+	}
+	for _, want := range []string{
+		"AIRTABLE_TOKEN (airtable.token) is required",
+		"AIRTABLE_ENTRIES_TABLE",
+		"TELEGRAM_CHAT_ID",
+		"ENABLE_SCHEDULER",
+		"SHUTDOWN_TIMEOUT",
+		"BACKGROUND_SHUTDOWN_TIMEOUT",
+		"TELEGRAM_LANGUAGE",
+		"HOUSEHOLD_TIMEZONE",
+		"refill-check",
+		`unknown job "stock-alert"`,
+		"AIRTABLE_MAX_RETRIES",
+		"AIRTABLE_WEBHOOK_URL",
+		"CACHE_ENTRIES_TTL",
+		"LOG_LEVEL",
+		"LOG_FORMAT",
+		"TRACING_EXPORTER",
+		"OTEL_EXPORTER_OTLP_ENDPOINT",
+		"unknown field medicines.dose",
+	} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("error does not mention %s:\n%v", want, err)
+		}
+	}
+}
+
+func TestLoad_badFile(t *testing.T) {
+	required(t)
+	if _, err := config.Load(writeFile(t, "server: ["), airtable.CheckFields); err == nil || !strings.Contains(err.Error(), "parse config file") {
+		t.Errorf("err = %v", err)
+	}
+	if _, err := config.Load(filepath.Join(t.TempDir(), "missing.yaml"), airtable.CheckFields); err == nil {
+		t.Error("missing file accepted")
+	}
+}
+
+func TestLoad_legacyTickerFlag(t *testing.T) {
+	required(t)
+	t.Setenv("ENABLE_SCHEDULER", "")
+	t.Setenv("ENABLE_ALERT_TICKER", "true")
+
+	cfg, err := config.Load("", airtable.CheckFields)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !cfg.Scheduler.Enabled {
+		t.Error("ENABLE_ALERT_TICKER did not enable the scheduler")
+	}
+}
+
+func TestConfig_StringRedactsSecrets(t *testing.T) {
+	required(t)
+	t.Setenv("SCHEDULE_STOCK_DIGEST", "off")
+	cfg, err := config.Load("", airtable.CheckFields)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	out := cfg.String()
+	for _, secret := range []string{"patSecret", "botSecret"} {
+		if strings.Contains(out, secret) {
+			t.Errorf("String() leaks %s:\n%s", secret, out)
+		}
+	}
+	for _, want := range []string{"AIRTABLE_TOKEN=[redacted]", "AIRTABLE_BASE_ID=app1", "SHUTDOWN_TIMEOUT=15s", "SCHEDULE_STOCK_DIGEST=off"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("String() missing %s:\n%s", want, out)
+		}
+	}
+}
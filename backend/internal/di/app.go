@@ -2,61 +2,115 @@ package di
 
 import (
 	"context"
-	"os"
-	"time"
+	"fmt"
 
 	fiber "github.com/gofiber/fiber/v2"
+	"github.com/nomenarkt/vitaltrack/backend/internal/config"
+	"github.com/nomenarkt/vitaltrack/backend/internal/logger"
 	"github.com/nomenarkt/vitaltrack/backend/internal/server"
 )
 
 var (
-	// StartTickerFunc points to the ticker starter implementation.
-	// Tests or callers should assign it to background.StartStockAlertTicker.
-	StartTickerFunc func(context.Context, Dependencies, time.Duration, func() time.Time) func()
+	// StartSchedulerFunc points to the job scheduler starter implementation.
+	// Tests or callers should assign it to background.StartScheduler.
+	StartSchedulerFunc func(context.Context, Dependencies) (stop func(), err error)
 
 	// PollingFunc points to the Telegram polling starter implementation.
 	// Tests or callers should assign it to StartTelegramPolling.
-	PollingFunc func(context.Context, Dependencies)
+	PollingFunc func(context.Context, Dependencies) (stop func())
 )
 
-// StartFromEnv starts optional background processes based on environment flags.
-func StartFromEnv(ctx context.Context, deps Dependencies) {
-	tickerInterval := 24 * time.Hour
-	if val := os.Getenv("ALERT_TICKER_INTERVAL"); val != "" {
-		if d, err := time.ParseDuration(val); err == nil {
-			tickerInterval = d
+// StartBackground starts the background processes enabled in deps.Config,
+// the Airtable schema check and the Airtable webhook when set. They stop
+// taking new work when ctx is cancelled. The returned
+// function stops them and waits for the work in progress: the scheduler and
+// the poller first, then the outbox dispatcher, so that their last messages
+// are still delivered. If the scheduler cannot start, what was started is
+// stopped and the error returned.
+func StartBackground(ctx context.Context, deps Dependencies) (stop func(), err error) {
+	var stops []func()
+	stopAll := func() {
+		for _, stop := range stops {
+			stop()
 		}
 	}
-	if os.Getenv("ENABLE_ALERT_TICKER") == "true" && StartTickerFunc != nil {
-		StartTickerFunc(ctx, deps, tickerInterval, time.Now)
+	if deps.SchemaCheck != nil {
+		stops = append(stops, run(ctx, deps.SchemaCheck))
 	}
-	if os.Getenv("ENABLE_TELEGRAM_POLLING") == "true" && PollingFunc != nil {
-		PollingFunc(ctx, deps)
+	if deps.Webhook != nil {
+		stops = append(stops, run(ctx, deps.Webhook.KeepAlive))
+	}
+	if deps.StockChanges != nil {
+		stops = append(stops, run(ctx, deps.StockChanges.Run))
+	}
+	if deps.Config.Scheduler.Enabled && StartSchedulerFunc != nil {
+		stopScheduler, err := StartSchedulerFunc(ctx, deps)
+		if err != nil {
+			stopAll()
+			return nil, fmt.Errorf("start scheduler: %w", err)
+		}
+		stops = append(stops, stopScheduler)
+	}
+	if deps.Config.Telegram.Polling && PollingFunc != nil {
+		stops = append(stops, PollingFunc(ctx, deps))
+	}
+	if deps.Dispatcher != nil {
+		// Not cancelled with ctx: the dispatcher stops after the others.
+		stops = append(stops, run(context.WithoutCancel(ctx), deps.Dispatcher.Run))
+	}
+	return stopAll, nil
+}
+
+// run calls f in a goroutine with a context derived from ctx. The returned
+// function cancels that context and waits for f to return.
+func run(ctx context.Context, f func(context.Context)) (stop func()) {
+	ctx, cancel := context.WithCancel(ctx)
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		f(ctx)
+	}()
+	return func() {
+		cancel()
+		<-done
 	}
 }
 
-// NewApp initializes the Fiber application with all routes and optional
-// background processes. It resolves dependencies via Init() and returns the
-// configured *fiber.App instance.
-func NewApp() *fiber.App {
+// NewApp initializes the Fiber application with all routes and starts the
+// optional background processes under ctx. It resolves dependencies from cfg
+// via Init and returns the configured *fiber.App instance with the function
+// that stops the background processes. Requests are traced, and logged to lg
+// with their correlation IDs.
+func NewApp(ctx context.Context, cfg config.Config, lg logger.Logger) (*fiber.App, func(), error) {
 	app := fiber.New()
+	app.Use(server.Trace(), server.Correlate(lg))
 
-	deps := Init()
+	deps, err := Init(cfg, lg)
+	if err != nil {
+		return nil, nil, err
+	}
 
-	server.SetupRoutes(app, deps.StockChecker, deps.ForecastSvc, deps.MedicineSvc, deps.Airtable, deps.Telegram)
+	server.SetupRoutes(app, cfg.Server, deps.StockChecker, deps.ForecastSvc, deps.MedicineSvc, deps.EntrySvc, deps.FinEntrySvc, deps.AlertLogSvc, deps.AdherenceSvc, deps.ValidationSvc, deps.Airtable, deps.Telegram, deps.Scheduler, deps.Readiness)
+	if deps.Webhook != nil {
+		server.RegisterAirtableWebhook(app, deps.Webhook.Verify, deps.StockChanges.Notify)
+	}
 
 	if PollingFunc == nil {
 		PollingFunc = StartTelegramPolling
 	}
 
-	StartFromEnv(context.Background(), deps)
-
-	return app
+	stop, err := StartBackground(ctx, deps)
+	if err != nil {
+		return nil, nil, err
+	}
+	return app, stop, nil
 }
 
 // Build initializes the application and returns the Fiber app and its dependencies.
-func Build() (*fiber.App, Dependencies) {
-	app := fiber.New()
-	deps := Init()
-	return app, deps
+func Build(cfg config.Config, lg logger.Logger) (*fiber.App, Dependencies, error) {
+	deps, err := Init(cfg, lg)
+	if err != nil {
+		return nil, Dependencies{}, err
+	}
+	return fiber.New(), deps, nil
 }
@@ -2,213 +2,206 @@ package di_test
 
 import (
 	"context"
+	"errors"
+	"net/http/httptest"
 	"os"
+	"path/filepath"
+	"strings"
 	"testing"
-	"time"
 
+	"github.com/nomenarkt/vitaltrack/backend/internal/config"
 	"github.com/nomenarkt/vitaltrack/backend/internal/di"
 	"github.com/nomenarkt/vitaltrack/backend/internal/domain"
+	"github.com/nomenarkt/vitaltrack/backend/internal/infra/airtable"
 	"github.com/nomenarkt/vitaltrack/backend/internal/logger"
+	"github.com/nomenarkt/vitaltrack/backend/internal/server"
+	"github.com/nomenarkt/vitaltrack/backend/internal/testutil"
+	"github.com/nomenarkt/vitaltrack/backend/internal/util/richtext"
 )
 
-type envMockAirtable struct{}
-
-func (m *envMockAirtable) FetchMedicines() ([]domain.Medicine, error)      { return nil, nil }
-func (m *envMockAirtable) FetchStockEntries() ([]domain.StockEntry, error) { return nil, nil }
-func (m *envMockAirtable) FetchFinancialEntries(int, time.Month) ([]domain.FinancialEntry, error) {
-	return nil, nil
-}
-func (m *envMockAirtable) CreateStockEntry(domain.StockEntry) error              { return nil }
-func (m *envMockAirtable) UpdateForecastDate(string, time.Time, time.Time) error { return nil }
-func (m *envMockAirtable) UpdateMedicineLastAlertedDate(string, time.Time) error { return nil }
-
 type envMockTelegram struct{}
 
-func (m *envMockTelegram) SendTelegramMessage(string) error { return nil }
-func (m *envMockTelegram) PollForCommands(func() ([]domain.Medicine, []domain.StockEntry, error), func(int, int) (domain.MonthlyFinancialReport, error)) {
+func (m *envMockTelegram) SendTelegramMessage(context.Context, richtext.Doc) error { return nil }
+func (m *envMockTelegram) PollForCommands(context.Context, func(context.Context) ([]domain.Medicine, []domain.StockEntry, error), func(context.Context, int, int) (domain.MonthlyFinancialReport, error), func(context.Context) ([]domain.AlertRecord, error)) {
 }
 
-func TestStartFromEnv(t *testing.T) {
+func TestStartBackground(t *testing.T) {
 	tests := []struct {
-		name           string
-		tickerEnabled  bool
-		pollingEnabled bool
-		expectTicker   bool
-		expectPolling  bool
+		name             string
+		schedulerEnabled bool
+		pollingEnabled   bool
 	}{
 		{name: "none"},
-		{name: "ticker_only", tickerEnabled: true, expectTicker: true},
-		{name: "polling_only", pollingEnabled: true, expectPolling: true},
-		{name: "both", tickerEnabled: true, pollingEnabled: true, expectTicker: true, expectPolling: true},
+		{name: "scheduler_only", schedulerEnabled: true},
+		{name: "polling_only", pollingEnabled: true},
+		{name: "both", schedulerEnabled: true, pollingEnabled: true},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			if tt.tickerEnabled {
-				if err := os.Setenv("ENABLE_ALERT_TICKER", "true"); err != nil {
-					t.Fatal(err)
-				}
-			} else {
-				if err := os.Unsetenv("ENABLE_ALERT_TICKER"); err != nil {
-					t.Fatal(err)
-				}
-			}
-			if tt.pollingEnabled {
-				if err := os.Setenv("ENABLE_TELEGRAM_POLLING", "true"); err != nil {
-					t.Fatal(err)
-				}
-			} else {
-				if err := os.Unsetenv("ENABLE_TELEGRAM_POLLING"); err != nil {
-					t.Fatal(err)
-				}
-			}
-			defer func() {
-				if err := os.Unsetenv("ENABLE_ALERT_TICKER"); err != nil {
-					t.Fatal(err)
-				}
-			}()
-			defer func() {
-				if err := os.Unsetenv("ENABLE_TELEGRAM_POLLING"); err != nil {
-					t.Fatal(err)
-				}
-			}()
-
-			tickerCalled := false
-			pollingCalled := false
-			origTicker := di.StartTickerFunc
-			origPolling := di.PollingFunc
-			di.StartTickerFunc = func(_ context.Context, _ di.Dependencies, _ time.Duration, _ func() time.Time) func() {
-				tickerCalled = true
-				return func() {}
+			schedulerCalled, pollingCalled := stubStarters(t)
+
+			deps := di.Dependencies{Airtable: &testutil.Store{}, Telegram: &envMockTelegram{}, Logger: logger.NewStdLogger()}
+			deps.Config.Scheduler.Enabled = tt.schedulerEnabled
+			deps.Config.Telegram.Polling = tt.pollingEnabled
+			stop, err := di.StartBackground(context.Background(), deps)
+			if err != nil {
+				t.Fatal(err)
 			}
-			di.PollingFunc = func(_ context.Context, _ di.Dependencies) { pollingCalled = true }
-			defer func() {
-				di.StartTickerFunc = origTicker
-				di.PollingFunc = origPolling
-			}()
+			stop()
 
-			deps := di.Dependencies{Airtable: &envMockAirtable{}, Telegram: &envMockTelegram{}, Logger: logger.NewStdLogger()}
-			di.StartFromEnv(context.Background(), deps)
-
-			if tt.expectTicker != tickerCalled {
-				t.Errorf("ticker call = %v, want %v", tickerCalled, tt.expectTicker)
+			if *schedulerCalled != tt.schedulerEnabled {
+				t.Errorf("scheduler call = %v, want %v", *schedulerCalled, tt.schedulerEnabled)
 			}
-			if tt.expectPolling != pollingCalled {
-				t.Errorf("polling call = %v, want %v", pollingCalled, tt.expectPolling)
+			if *pollingCalled != tt.pollingEnabled {
+				t.Errorf("polling call = %v, want %v", *pollingCalled, tt.pollingEnabled)
 			}
 		})
 	}
 }
 
-func TestNewApp(t *testing.T) {
-	if err := os.Setenv("AIRTABLE_BASE_ID", "a"); err != nil {
-		t.Fatal(err)
+func TestStartBackground_schedulerError(t *testing.T) {
+	stubStarters(t)
+	di.StartSchedulerFunc = func(context.Context, di.Dependencies) (func(), error) {
+		return nil, errors.New("schedule set for unknown job")
 	}
-	if err := os.Setenv("AIRTABLE_MEDICINES_TABLE", "b"); err != nil {
-		t.Fatal(err)
+	stopped := make(chan struct{})
+	deps := di.Dependencies{Airtable: &testutil.Store{}, Telegram: &envMockTelegram{}, Logger: logger.NewStdLogger()}
+	deps.Config.Scheduler.Enabled = true
+	deps.SchemaCheck = func(ctx context.Context) {
+		<-ctx.Done()
+		close(stopped)
 	}
-	if err := os.Setenv("AIRTABLE_ENTRIES_TABLE", "c"); err != nil {
-		t.Fatal(err)
+
+	if _, err := di.StartBackground(context.Background(), deps); err == nil || !strings.Contains(err.Error(), "unknown job") {
+		t.Fatalf("err = %v", err)
 	}
-	if err := os.Setenv("AIRTABLE_TOKEN", "d"); err != nil {
-		t.Fatal(err)
+	<-stopped // what started before the scheduler was stopped
+}
+
+func TestStartBackground_schemaCheck(t *testing.T) {
+	stubStarters(t)
+	checked := make(chan struct{})
+	deps := di.Dependencies{Airtable: &testutil.Store{}, Telegram: &envMockTelegram{}, Logger: logger.NewStdLogger()}
+	deps.SchemaCheck = func(ctx context.Context) {
+		close(checked)
+		<-ctx.Done()
 	}
-	if err := os.Setenv("TELEGRAM_BOT_TOKEN", "e"); err != nil {
+
+	stop, err := di.StartBackground(context.Background(), deps)
+	if err != nil {
 		t.Fatal(err)
 	}
-	if err := os.Setenv("TELEGRAM_CHAT_ID", "f"); err != nil {
+	<-checked
+	stop() // returns once the check has seen its context cancelled
+}
+
+func TestInit_unreadableOutbox(t *testing.T) {
+	cfg := config.Default()
+	cfg.Telegram.OutboxPath = filepath.Join(t.TempDir(), "outbox.json")
+	if err := os.WriteFile(cfg.Telegram.OutboxPath, []byte("not json"), 0o600); err != nil {
 		t.Fatal(err)
 	}
-	defer func() {
-		if err := os.Unsetenv("AIRTABLE_BASE_ID"); err != nil {
-			t.Fatal(err)
-		}
-		if err := os.Unsetenv("AIRTABLE_MEDICINES_TABLE"); err != nil {
-			t.Fatal(err)
-		}
-		if err := os.Unsetenv("AIRTABLE_ENTRIES_TABLE"); err != nil {
-			t.Fatal(err)
-		}
-		if err := os.Unsetenv("AIRTABLE_TOKEN"); err != nil {
-			t.Fatal(err)
-		}
-		if err := os.Unsetenv("TELEGRAM_BOT_TOKEN"); err != nil {
-			t.Fatal(err)
-		}
-		if err := os.Unsetenv("TELEGRAM_CHAT_ID"); err != nil {
-			t.Fatal(err)
-		}
-	}()
+	if _, err := di.Init(cfg, logger.NewStdLogger()); err == nil || !strings.Contains(err.Error(), "outbox") {
+		t.Errorf("err = %v", err)
+	}
+}
 
+func TestNewApp(t *testing.T) {
 	tests := []struct {
-		name           string
-		tickerEnabled  bool
-		pollingEnabled bool
-		expectTicker   bool
-		expectPolling  bool
+		name             string
+		schedulerEnabled bool
+		pollingEnabled   bool
 	}{
 		{name: "none"},
-		{name: "ticker_only", tickerEnabled: true, expectTicker: true},
-		{name: "polling_only", pollingEnabled: true, expectPolling: true},
-		{name: "both", tickerEnabled: true, pollingEnabled: true, expectTicker: true, expectPolling: true},
+		{name: "scheduler_only", schedulerEnabled: true},
+		{name: "polling_only", pollingEnabled: true},
+		{name: "both", schedulerEnabled: true, pollingEnabled: true},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			if tt.tickerEnabled {
-				if err := os.Setenv("ENABLE_ALERT_TICKER", "true"); err != nil {
-					t.Fatal(err)
-				}
-			} else {
-				if err := os.Unsetenv("ENABLE_ALERT_TICKER"); err != nil {
-					t.Fatal(err)
-				}
-			}
-			if tt.pollingEnabled {
-				if err := os.Setenv("ENABLE_TELEGRAM_POLLING", "true"); err != nil {
-					t.Fatal(err)
-				}
-			} else {
-				if err := os.Unsetenv("ENABLE_TELEGRAM_POLLING"); err != nil {
-					t.Fatal(err)
-				}
-			}
-			defer func() {
-				if err := os.Unsetenv("ENABLE_ALERT_TICKER"); err != nil {
-					t.Fatal(err)
-				}
-			}()
-			defer func() {
-				if err := os.Unsetenv("ENABLE_TELEGRAM_POLLING"); err != nil {
-					t.Fatal(err)
-				}
-			}()
-
-			tickerCalled := false
-			pollingCalled := false
-			origTicker := di.StartTickerFunc
-			origPolling := di.PollingFunc
-			di.StartTickerFunc = func(_ context.Context, _ di.Dependencies, _ time.Duration, _ func() time.Time) func() {
-				tickerCalled = true
-				return func() {}
+			schedulerCalled, pollingCalled := stubStarters(t)
+
+			cfg := config.Default()
+			cfg.Airtable.Token, cfg.Airtable.BaseID = "d", "a"
+			cfg.Airtable.MedicinesTable, cfg.Airtable.EntriesTable = "b", "c"
+			cfg.Telegram.BotToken, cfg.Telegram.ChatID = "e", "f"
+			cfg.Scheduler.Enabled = tt.schedulerEnabled
+			cfg.Telegram.Polling = tt.pollingEnabled
+			cfg.Airtable.CheckSchema = false
+			if err := cfg.Validate(airtable.CheckFields); err != nil {
+				t.Fatal(err)
 			}
-			di.PollingFunc = func(_ context.Context, _ di.Dependencies) { pollingCalled = true }
-			defer func() {
-				di.StartTickerFunc = origTicker
-				di.PollingFunc = origPolling
-			}()
 
-			app := di.NewApp()
+			app, stop, err := di.NewApp(context.Background(), cfg, logger.NewStdLogger())
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer stop()
 			if app == nil {
 				t.Fatal("app is nil")
 			}
 
-			if tt.expectTicker != tickerCalled {
-				t.Errorf("ticker call = %v, want %v", tickerCalled, tt.expectTicker)
+			if *schedulerCalled != tt.schedulerEnabled {
+				t.Errorf("scheduler call = %v, want %v", *schedulerCalled, tt.schedulerEnabled)
 			}
-			if tt.expectPolling != pollingCalled {
-				t.Errorf("polling call = %v, want %v", pollingCalled, tt.expectPolling)
+			if *pollingCalled != tt.pollingEnabled {
+				t.Errorf("polling call = %v, want %v", *pollingCalled, tt.pollingEnabled)
 			}
 		})
 	}
 }
+
+func TestNewApp_airtableWebhook(t *testing.T) {
+	stubStarters(t)
+	cfg := config.Default()
+	cfg.Airtable.Token, cfg.Airtable.BaseID = "d", "a"
+	cfg.Airtable.MedicinesTable, cfg.Airtable.EntriesTable = "b", "c"
+	cfg.Airtable.APIBaseURL = "http://127.0.0.1:1" // registration fails at once
+	cfg.Airtable.CheckSchema = false
+	cfg.Airtable.WebhookURL = "https://vitaltrack.example/webhooks/airtable"
+	cfg.Telegram.BotToken, cfg.Telegram.ChatID = "e", "f"
+	if err := cfg.Validate(airtable.CheckFields); err != nil {
+		t.Fatal(err)
+	}
+
+	app, stop, err := di.NewApp(context.Background(), cfg, logger.NewStdLogger())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer stop()
+
+	req := httptest.NewRequest("POST", server.AirtableWebhookPath, strings.NewReader("{}"))
+	req.Header.Set("X-Airtable-Content-MAC", "hmac-sha256=00")
+	res, err := app.Test(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_ = res.Body.Close()
+	if res.StatusCode != 401 {
+		t.Errorf("status = %d for a notification of an unregistered webhook, want 401", res.StatusCode)
+	}
+}
+
+// stubStarters replaces the scheduler and polling starters for the test and
+// reports whether each was called.
+func stubStarters(t *testing.T) (schedulerCalled, pollingCalled *bool) {
+	t.Helper()
+	schedulerCalled, pollingCalled = new(bool), new(bool)
+	origScheduler := di.StartSchedulerFunc
+	origPolling := di.PollingFunc
+	di.StartSchedulerFunc = func(_ context.Context, _ di.Dependencies) (func(), error) {
+		*schedulerCalled = true
+		return func() {}, nil
+	}
+	di.PollingFunc = func(_ context.Context, _ di.Dependencies) func() {
+		*pollingCalled = true
+		return func() {}
+	}
+	t.Cleanup(func() {
+		di.StartSchedulerFunc = origScheduler
+		di.PollingFunc = origPolling
+	})
+	return schedulerCalled, pollingCalled
+}
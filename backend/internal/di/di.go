@@ -2,42 +2,213 @@
 package di
 
 import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/nomenarkt/vitaltrack/backend/internal/config"
 	"github.com/nomenarkt/vitaltrack/backend/internal/domain/ports"
+	"github.com/nomenarkt/vitaltrack/backend/internal/health"
 	"github.com/nomenarkt/vitaltrack/backend/internal/infra/airtable"
+	"github.com/nomenarkt/vitaltrack/backend/internal/infra/cache"
+	"github.com/nomenarkt/vitaltrack/backend/internal/infra/filestore"
+	"github.com/nomenarkt/vitaltrack/backend/internal/infra/memstore"
 	"github.com/nomenarkt/vitaltrack/backend/internal/infra/telegram"
 	"github.com/nomenarkt/vitaltrack/backend/internal/logger"
+	"github.com/nomenarkt/vitaltrack/backend/internal/scheduler"
 	"github.com/nomenarkt/vitaltrack/backend/internal/usecase"
 )
 
 // Dependencies groups runtime service implementations.
 type Dependencies struct {
-	Airtable     ports.StockDataPort // satisfies AirtableService + StockDataPort
-	Telegram     ports.TelegramService
-	Logger       logger.Logger
-	StockChecker *usecase.StockChecker
-	ForecastSvc  usecase.OutOfStockService
-	FinancialSvc usecase.FinancialReportService
-	MedicineSvc  usecase.MedicineService
+	Airtable      ports.StockDataPort // satisfies AirtableService + StockDataPort
+	Telegram      ports.TelegramService
+	Logger        logger.Logger
+	StockChecker  *usecase.StockChecker
+	ForecastSvc   usecase.OutOfStockService
+	FinancialSvc  usecase.FinancialReportService
+	MedicineSvc   usecase.MedicineService
+	EntrySvc      usecase.StockEntryService
+	FinEntrySvc   usecase.FinancialEntryService
+	DigestSvc     usecase.DigestService
+	AdherenceSvc  usecase.AdherenceService
+	ValidationSvc usecase.ValidationService
+	AlertLog      ports.AlertLogPort
+	AlertLogSvc   usecase.AlertLogService
+	Dispatcher    *telegram.Dispatcher  // delivers queued Telegram messages, nil when not queueing
+	Location      *time.Location        // household timezone for day boundaries, UTC when nil
+	Scheduler     *scheduler.Scheduler  // background jobs, registered by background.StartScheduler
+	Readiness     *health.Checker       // checks behind /readyz
+	SchemaCheck   func(context.Context) // logs Airtable columns the base lacks, nil when disabled
+	Webhook       *airtable.Webhook     // notifies StockChanges of entry changes, nil when not configured
+	StockChanges  *usecase.StockChanges // handles the changes Webhook reports, nil with it
+	Config        config.Config         // settings the dependencies were built from
 }
 
-// Init initializes all production dependencies.
-func Init() Dependencies {
-	at := airtable.NewClient()
-	tg := telegram.NewClient()
-	lg := logger.NewStdLogger()
+// Init initializes all production dependencies from cfg, which must have
+// been validated by config.Load. Everything logs to lg. It fails when the
+// Telegram outbox, chat preferences or Airtable webhook state cannot be
+// loaded.
+func Init(cfg config.Config, lg logger.Logger) (Dependencies, error) {
+	ctx := context.Background()
+	at := airtable.NewClient(cfg.Airtable, lg)
+	tg := telegram.NewClient(cfg.Telegram, lg)
 
-	return Dependencies{
-		Airtable: at,
+	loc := cfg.Location
+	tg.Location = loc
+
+	if cfg.Telegram.OutboxPath == "" {
+		lg.Warn(ctx, "TELEGRAM_OUTBOX_PATH not set, outbox kept in memory")
+	}
+	outbox, err := filestore.OpenOutbox(cfg.Telegram.OutboxPath)
+	if err != nil {
+		return Dependencies{}, fmt.Errorf("open telegram outbox: %w", err)
+	}
+	tg.UseOutbox(outbox)
+
+	if cfg.Telegram.PrefsPath == "" {
+		lg.Warn(ctx, "TELEGRAM_PREFS_PATH not set, chat preferences kept in memory")
+	}
+	prefs, err := filestore.OpenPrefs(cfg.Telegram.PrefsPath)
+	if err != nil {
+		return Dependencies{}, fmt.Errorf("open telegram preferences: %w", err)
+	}
+	tg.UsePrefs(prefs)
+
+	jobs := scheduler.New(loc, lg)
+	jobs.Jitter = cfg.Scheduler.Jitter
+
+	var alertLog ports.AlertLogPort = at
+	if cfg.Airtable.AlertsTable == "" {
+		lg.Warn(ctx, "AIRTABLE_ALERTS_TABLE not set, alert log kept in memory")
+		alertLog = memstore.NewAlertLog()
+	}
+
+	var doseStore ports.AdherencePort = at
+	if cfg.Airtable.AdherenceTable == "" {
+		lg.Warn(ctx, "AIRTABLE_ADHERENCE_TABLE not set, adherence log kept in memory")
+		doseStore = memstore.NewDoseLog()
+	}
+	cached := cache.NewStock(at, cfg.Cache.TTLs(), lg)
+	doseLog := cached.DoseLog(doseStore)
+	var stock usecase.StockStore = cached
+	if cfg.Adherence.StockCredit {
+		stock = usecase.SkippedDoseStock{StockStore: cached, Events: doseLog}
+	}
+	dispatcher := telegram.NewDispatcher(tg, outbox)
+	dispatcher.Alerts = usecase.AlertNotifier{Log: alertLog, Logger: lg}
+
+	ready := health.New(cfg.Server.ReadinessTTL)
+	ready.Add("airtable", at.Ping)
+	ready.Add("outbox", func(context.Context) error {
+		_, err := outbox.Pending()
+		return err
+	})
+	ready.Add("telegram", tg.Ping)
+
+	var schemaCheck func(context.Context)
+	if cfg.Airtable.CheckSchema {
+		schemaCheck = func(ctx context.Context) { checkSchema(ctx, at, lg) }
+	}
+
+	adherenceSvc := usecase.AdherenceService{
+		Airtable: cached,
+		Events:   doseLog,
+		Sender:   tg,
+		Chat:     tg.ChatID,
+		Locale:   tg.Printer,
+		Location: loc,
+		Logger:   lg,
+	}
+	tg.UseAdherence(adherenceSvc)
+
+	validationSvc := usecase.ValidationService{Records: at, Location: loc}
+	tg.UseValidation(validationSvc)
+
+	checker := &usecase.StockChecker{
+		Airtable: stock,
 		Telegram: tg,
+		Alerts:   alertLog,
+		Locale:   tg.Printer,
+		Location: loc,
 		Logger:   lg,
-		StockChecker: &usecase.StockChecker{
-			Airtable: at,
-			Telegram: tg,
-		},
-		ForecastSvc: usecase.OutOfStockService{
-			Airtable: at,
+	}
+	forecastSvc := usecase.OutOfStockService{
+		Airtable: stock,
+		Locale:   tg.Printer,
+		Location: loc,
+		Logger:   lg,
+	}
+
+	var webhook *airtable.Webhook
+	var changes *usecase.StockChanges
+	if cfg.Airtable.WebhookURL != "" {
+		webhook = at.EntriesWebhook(cfg.Airtable.WebhookURL)
+		if cfg.Airtable.WebhookStatePath == "" {
+			lg.Warn(ctx, "AIRTABLE_WEBHOOK_STATE_PATH not set, airtable webhook kept in memory")
+		}
+		state, err := filestore.OpenWebhookState(cfg.Airtable.WebhookStatePath)
+		if err != nil {
+			return Dependencies{}, fmt.Errorf("open airtable webhook state: %w", err)
+		}
+		webhook.UseStore(state)
+		changes = &usecase.StockChanges{
+			Feed:       webhook,
+			Store:      stock,
+			Invalidate: cached.InvalidateEntries,
+			Refills:    checker,
+			Forecasts:  forecastSvc,
+			Logger:     lg,
+		}
+	}
+
+	return Dependencies{
+		Airtable:      stock,
+		Telegram:      tg,
+		Logger:        lg,
+		StockChecker:  checker,
+		ForecastSvc:   forecastSvc,
+		FinancialSvc:  usecase.FinancialReportService{Repo: at},
+		MedicineSvc:   usecase.MedicineService{Repo: stock, Location: loc},
+		EntrySvc:      usecase.StockEntryService{Repo: cached},
+		FinEntrySvc:   usecase.FinancialEntryService{Repo: at, Location: loc},
+		ValidationSvc: validationSvc,
+		DigestSvc: usecase.DigestService{
+			Airtable:    stock,
+			Finance:     usecase.FinancialReportService{Repo: at},
+			Prefs:       prefs,
+			Sender:      tg,
+			DefaultChat: tg.ChatID,
+			OtherChats:  tg.DigestChats,
+			Locale:      tg.PrinterFor,
+			Location:    loc,
+			Logger:      lg,
 		},
-		FinancialSvc: usecase.FinancialReportService{Repo: at},
-		MedicineSvc:  usecase.MedicineService{Repo: at},
+		AlertLog:     alertLog,
+		AlertLogSvc:  usecase.AlertLogService{Log: alertLog},
+		Dispatcher:   dispatcher,
+		Location:     loc,
+		Scheduler:    jobs,
+		Readiness:    ready,
+		SchemaCheck:  schemaCheck,
+		Webhook:      webhook,
+		StockChanges: changes,
+		Config:       cfg,
+	}, nil
+}
+
+// checkSchema logs every column the client uses that the Airtable base
+// lacks or holds with another type. Such columns read as zero values.
+func checkSchema(ctx context.Context, at *airtable.Client, lg logger.Logger) {
+	problems, err := at.CheckSchema(ctx)
+	if err != nil {
+		lg.Warn(ctx, "airtable schema check failed", "error", err)
+		return
+	}
+	for _, p := range problems {
+		lg.Error(ctx, "airtable schema mismatch", "table", p.Table, "field", p.Field, "column", p.Column, "problem", p.String())
+	}
+	if len(problems) == 0 {
+		lg.Info(ctx, "airtable schema matches the field mapping")
 	}
 }
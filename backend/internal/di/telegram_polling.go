@@ -6,23 +6,41 @@ import (
 	"github.com/nomenarkt/vitaltrack/backend/internal/domain"
 )
 
-// StartTelegramPolling launches polling for Telegram bot commands.
-func StartTelegramPolling(ctx context.Context, deps Dependencies) {
+// recentAlertsLimit is how many alert log entries /alerts shows.
+const recentAlertsLimit = 10
+
+// StartTelegramPolling launches polling for Telegram bot commands until ctx
+// is cancelled. The returned function stops polling and waits for the
+// commands in progress to finish.
+func StartTelegramPolling(ctx context.Context, deps Dependencies) (stop func()) {
+	ctx, cancel := context.WithCancel(ctx)
+	done := make(chan struct{})
 	deps.Logger.Info(ctx, "telegram polling started")
-	go deps.Telegram.PollForCommands(
-		func() ([]domain.Medicine, []domain.StockEntry, error) {
-			meds, err := deps.Airtable.FetchMedicines()
-			if err != nil {
-				return nil, nil, err
-			}
-			entries, err := deps.Airtable.FetchStockEntries()
-			if err != nil {
-				return nil, nil, err
-			}
-			return meds, entries, nil
-		},
-		func(y, m int) (domain.MonthlyFinancialReport, error) {
-			return deps.FinancialSvc.GenerateFinancialReport(y, m)
-		},
-	)
+	go func() {
+		defer close(done)
+		deps.Telegram.PollForCommands(ctx,
+			func(ctx context.Context) ([]domain.Medicine, []domain.StockEntry, error) {
+				meds, err := deps.Airtable.FetchMedicines(ctx)
+				if err != nil {
+					return nil, nil, err
+				}
+				entries, err := deps.Airtable.FetchStockEntries(ctx)
+				if err != nil {
+					return nil, nil, err
+				}
+				return meds, entries, nil
+			},
+			func(ctx context.Context, y, m int) (domain.MonthlyFinancialReport, error) {
+				return deps.FinancialSvc.GenerateFinancialReport(ctx, y, m)
+			},
+			func(ctx context.Context) ([]domain.AlertRecord, error) {
+				res, err := deps.AlertLogSvc.ListAlerts(ctx, domain.AlertFilter{Page: domain.Page{Limit: recentAlertsLimit}})
+				return res.Items, err
+			},
+		)
+	}()
+	return func() {
+		cancel()
+		<-done
+	}
 }
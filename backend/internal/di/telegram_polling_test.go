@@ -8,41 +8,48 @@ import (
 	"github.com/nomenarkt/vitaltrack/backend/internal/di"
 	"github.com/nomenarkt/vitaltrack/backend/internal/domain"
 	"github.com/nomenarkt/vitaltrack/backend/internal/logger"
+	"github.com/nomenarkt/vitaltrack/backend/internal/testutil"
 	"github.com/nomenarkt/vitaltrack/backend/internal/usecase"
+	"github.com/nomenarkt/vitaltrack/backend/internal/util/richtext"
 )
 
 type mockAirtable struct {
+	testutil.Store
 	medsCalled    bool
 	entriesCalled bool
 }
 
-func (m *mockAirtable) FetchMedicines() ([]domain.Medicine, error) {
+func (m *mockAirtable) FetchMedicines(_ context.Context) ([]domain.Medicine, error) {
 	m.medsCalled = true
 	return []domain.Medicine{{ID: "1"}}, nil
 }
-func (m *mockAirtable) FetchStockEntries() ([]domain.StockEntry, error) {
+func (m *mockAirtable) FetchStockEntries(_ context.Context) ([]domain.StockEntry, error) {
 	m.entriesCalled = true
 	return []domain.StockEntry{}, nil
 }
-func (m *mockAirtable) CreateStockEntry(domain.StockEntry) error              { return nil }
-func (m *mockAirtable) UpdateForecastDate(string, time.Time, time.Time) error { return nil }
-func (m *mockAirtable) FetchFinancialEntries(int, time.Month) ([]domain.FinancialEntry, error) {
-	return nil, nil
-}
-func (m *mockAirtable) UpdateMedicineLastAlertedDate(string, time.Time) error { return nil }
 
 type mockFinanceRepo struct{ called bool }
 
-func (m *mockFinanceRepo) FetchFinancialEntries(int, time.Month) ([]domain.FinancialEntry, error) {
+func (m *mockFinanceRepo) FetchFinancialEntries(context.Context, int, time.Month) ([]domain.FinancialEntry, error) {
 	m.called = true
 	return nil, nil
 }
 
+func (m *mockFinanceRepo) GetFinancialEntry(context.Context, string) (domain.FinancialEntry, error) {
+	return domain.FinancialEntry{}, nil
+}
+func (m *mockFinanceRepo) CreateFinancialEntry(context.Context, domain.FinancialEntry) (domain.FinancialEntry, error) {
+	return domain.FinancialEntry{}, nil
+}
+func (m *mockFinanceRepo) UpdateFinancialEntry(context.Context, string, domain.FinancialEntryPatch) (domain.FinancialEntry, error) {
+	return domain.FinancialEntry{}, nil
+}
+
 type mockTelegram struct{ done chan struct{} }
 
-func (m *mockTelegram) SendTelegramMessage(string) error { return nil }
-func (m *mockTelegram) PollForCommands(fetch func() ([]domain.Medicine, []domain.StockEntry, error), report func(int, int) (domain.MonthlyFinancialReport, error)) {
-	meds, entries, err := fetch()
+func (m *mockTelegram) SendTelegramMessage(context.Context, richtext.Doc) error { return nil }
+func (m *mockTelegram) PollForCommands(ctx context.Context, fetch func(context.Context) ([]domain.Medicine, []domain.StockEntry, error), report func(context.Context, int, int) (domain.MonthlyFinancialReport, error), alerts func(context.Context) ([]domain.AlertRecord, error)) {
+	meds, entries, err := fetch(ctx)
 	if err != nil {
 		panic(err)
 	}
@@ -52,7 +59,7 @@ func (m *mockTelegram) PollForCommands(fetch func() ([]domain.Medicine, []domain
 		// Intentionally left blank: required to trigger fallback behavior
 	}
 
-	rep, err := report(2024, 6)
+	rep, err := report(ctx, 2024, 6)
 	if err != nil {
 		panic(err)
 	}
@@ -61,6 +68,9 @@ func (m *mockTelegram) PollForCommands(fetch func() ([]domain.Medicine, []domain
 		_ = rep.Needs
 		// ignore content
 	}
+	if _, err := alerts(ctx); err != nil {
+		panic(err)
+	}
 	close(m.done)
 }
 
@@ -75,7 +85,8 @@ func TestStartTelegramPolling(t *testing.T) {
 		Logger:       logger.NewStdLogger(),
 	}
 
-	di.StartTelegramPolling(context.Background(), deps)
+	stop := di.StartTelegramPolling(context.Background(), deps)
+	defer stop()
 
 	select {
 	case <-tg.done:
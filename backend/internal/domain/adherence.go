@@ -0,0 +1,114 @@
+package domain
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+// DoseTimes are the local times of day ("08:00") at which a medicine is
+// taken. Airtable stores them as one comma-separated text field, so JSON
+// input may be either that string or an array.
+type DoseTimes []string
+
+// ParseDoseTimes validates and normalizes times such as "8:00" or "20:30",
+// returning them sorted as "HH:MM" without duplicates.
+func ParseDoseTimes(times []string) (DoseTimes, error) {
+	seen := map[string]bool{}
+	out := DoseTimes{}
+	for _, s := range times {
+		s = strings.TrimSpace(s)
+		if s == "" {
+			continue
+		}
+		t, err := time.Parse("15:04", s)
+		if err != nil {
+			return nil, fmt.Errorf("invalid dose time %q: expected HH:MM", s)
+		}
+		s = t.Format("15:04")
+		if !seen[s] {
+			seen[s] = true
+			out = append(out, s)
+		}
+	}
+	sort.Strings(out)
+	return out, nil
+}
+
+// UnmarshalJSON accepts "08:00, 20:00" as well as ["08:00", "20:00"].
+func (d *DoseTimes) UnmarshalJSON(b []byte) error {
+	var list []string
+	if err := json.Unmarshal(b, &list); err != nil {
+		var s string
+		if err := json.Unmarshal(b, &s); err != nil {
+			return fmt.Errorf("dose times: expected a string or an array of strings")
+		}
+		list = strings.Split(s, ",")
+	}
+	times, err := ParseDoseTimes(list)
+	if err != nil {
+		return err
+	}
+	*d = times
+	return nil
+}
+
+// String joins the times the way Airtable stores them.
+func (d DoseTimes) String() string {
+	return strings.Join(d, ", ")
+}
+
+// DoseStatus is the answer to a dose reminder.
+type DoseStatus string
+
+// Dose statuses recorded in the adherence log.
+const (
+	DosePending DoseStatus = "pending" // reminder sent, no answer yet
+	DoseTaken   DoseStatus = "taken"
+	DoseSkipped DoseStatus = "skipped"
+)
+
+// DoseEvent is one scheduled dose in the adherence log. Key identifies the
+// dose ("dose:rec1:2025-06-05T08:00") so that a reminder is sent once.
+type DoseEvent struct {
+	ID           string     `json:"id"`
+	Key          string     `json:"key"`
+	MedicineID   string     `json:"medicine_id"`
+	MedicineName string     `json:"medicine_name"`
+	Patient      string     `json:"patient,omitempty"`
+	Scheduled    time.Time  `json:"scheduled"`
+	Pills        float64    `json:"pills"`
+	Status       DoseStatus `json:"status"`
+	ChatID       string     `json:"chat_id"` // chat the reminder was sent to, empty until it is
+	RespondedAt  time.Time  `json:"responded_at,omitzero"`
+	CreatedAt    time.Time  `json:"created_at"`
+}
+
+// DoseEventPatch records the delivery of a reminder or the answer to it.
+// Zero fields are left unchanged.
+type DoseEventPatch struct {
+	Status      DoseStatus
+	RespondedAt time.Time
+	ChatID      string
+}
+
+// AdherenceWeek summarizes one patient's doses over a week starting on
+// Monday in the household timezone.
+type AdherenceWeek struct {
+	Patient    string    `json:"patient"`
+	WeekStart  time.Time `json:"week_start"`
+	Scheduled  int       `json:"scheduled"`
+	Taken      int       `json:"taken"`
+	Skipped    int       `json:"skipped"`
+	Unanswered int       `json:"unanswered"`
+	Percent    float64   `json:"adherence_percent"` // taken out of scheduled
+}
+
+// ReplyButton is an inline button under a bot message. Data comes back to
+// the bot when it is pressed.
+type ReplyButton struct {
+	Label string
+	Data  string
+}
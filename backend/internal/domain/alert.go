@@ -0,0 +1,41 @@
+package domain
+
+import "time"
+
+// DeliveryStatus tracks an alert through the delivery log.
+type DeliveryStatus string
+
+// Delivery statuses recorded in the alert log.
+const (
+	DeliveryPending   DeliveryStatus = "pending"   // claimed, send in progress
+	DeliveryQueued    DeliveryStatus = "queued"    // handed to the outbox, outcome reported by its dispatcher
+	DeliverySent      DeliveryStatus = "sent"      // delivered to the channel
+	DeliveryFailed    DeliveryStatus = "failed"    // last attempt failed, retried on the next run
+	DeliveryDuplicate DeliveryStatus = "duplicate" // lost the claim to another replica
+)
+
+// AlertRecord is one entry in the alert delivery log. Key identifies the
+// event being notified (e.g. "low_stock:rec1:2025-06-10") and is what makes
+// delivery idempotent across runs, restarts and replicas.
+type AlertRecord struct {
+	ID           string         `json:"id"`
+	Key          string         `json:"key"`
+	Kind         string         `json:"kind"`
+	MedicineID   string         `json:"medicine_id"`
+	MedicineName string         `json:"medicine_name"`
+	Payload      string         `json:"payload"`
+	Channel      string         `json:"channel"`
+	Status       DeliveryStatus `json:"status"`
+	Attempts     int            `json:"attempts"`
+	LastError    string         `json:"last_error,omitempty"`
+	CreatedAt    time.Time      `json:"created_at"`
+	UpdatedAt    time.Time      `json:"updated_at"`
+}
+
+// AlertRecordPatch holds the delivery fields that change after an attempt.
+type AlertRecordPatch struct {
+	Status    *DeliveryStatus
+	Attempts  *int
+	LastError *string
+	UpdatedAt time.Time
+}
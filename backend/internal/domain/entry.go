@@ -2,7 +2,69 @@ package domain
 
 // CreateStockEntryRequest defines the payload for creating a stock entry.
 type CreateStockEntryRequest struct {
-	Quantity float64 `json:"quantity"`
-	Unit     string  `json:"unit"` // "pill" or "box"
-	Date     string  `json:"date"` // "2025-06-02"
+	MedicineID string    `json:"medicine_id,omitempty"`
+	Kind       EntryKind `json:"kind,omitempty"` // refill when empty
+	Quantity   float64   `json:"quantity"`       // negative only for corrections
+	Unit       string    `json:"unit"`           // "pill", "box" or a unit of the medicine
+	Date       string    `json:"date"`           // "2025-06-02"
+	Reason     string    `json:"reason,omitempty"`
+}
+
+// CreateMedicineRequest defines the payload for registering a medicine.
+type CreateMedicineRequest struct {
+	Name         string   `json:"name"`
+	UnitType     string   `json:"unit_type"`
+	UnitPerBox   float64  `json:"unit_per_box"`
+	Units        string   `json:"units,omitempty"` // "box = 3 strip, strip = 10 tablet"
+	DailyDose    float64  `json:"daily_dose"`
+	StartDate    string   `json:"start_date"` // "2025-06-02"
+	InitialStock float64  `json:"initial_stock"`
+	Patient      string   `json:"patient,omitempty"`
+	DoseTimes    []string `json:"dose_times,omitempty"` // "08:00", "20:00"
+}
+
+// CreateFinancialEntryRequest defines the payload for recording a contribution.
+type CreateFinancialEntryRequest struct {
+	Date              string  `json:"Date"`
+	NeedLabel         string  `json:"NeedLabel"`
+	NeedAmount        float64 `json:"NeedAmount"`
+	AmountContributed float64 `json:"AmountContributed"`
+	MonthTag          string  `json:"MonthTag,omitempty"` // derived from Date when empty
+	Contributor       string  `json:"Contributor"`
+}
+
+// MedicinePatch lists medicine fields to change. Nil fields are left untouched.
+type MedicinePatch struct {
+	Name         *string       `json:"name,omitempty"`
+	UnitType     *string       `json:"unit_type,omitempty"`
+	UnitPerBox   *float64      `json:"unit_per_box,omitempty"`
+	Units        *string       `json:"units,omitempty"`
+	DailyDose    *float64      `json:"daily_dose,omitempty"`
+	StartDate    *FlexibleDate `json:"start_date,omitempty"`
+	InitialStock *float64      `json:"initial_stock,omitempty"`
+	Patient      *string       `json:"patient,omitempty"`
+	DoseTimes    *[]string     `json:"dose_times,omitempty"`
+	Archived     *bool         `json:"archived,omitempty"`
+}
+
+// StockEntryPatch lists stock entry fields to change. Nil fields are left untouched.
+type StockEntryPatch struct {
+	MedicineID *string       `json:"medicine_id,omitempty"`
+	Kind       *EntryKind    `json:"kind,omitempty"`
+	Quantity   *float64      `json:"quantity,omitempty"`
+	Unit       *string       `json:"unit,omitempty"`
+	Date       *FlexibleDate `json:"date,omitempty"`
+	Reason     *string       `json:"reason,omitempty"`
+	Archived   *bool         `json:"archived,omitempty"`
+}
+
+// FinancialEntryPatch lists financial entry fields to change. Nil fields are left untouched.
+type FinancialEntryPatch struct {
+	Date              *FlexibleDate `json:"Date,omitempty"`
+	NeedLabel         *string       `json:"NeedLabel,omitempty"`
+	NeedAmount        *float64      `json:"NeedAmount,omitempty"`
+	AmountContributed *float64      `json:"AmountContributed,omitempty"`
+	MonthTag          *string       `json:"MonthTag,omitempty"`
+	Contributor       *string       `json:"Contributor,omitempty"`
+	Archived          *bool         `json:"Archived,omitempty"`
 }
@@ -0,0 +1,6 @@
+package domain
+
+import "errors"
+
+// ErrNotFound is returned by storage adapters when a record does not exist.
+var ErrNotFound = errors.New("record not found")
@@ -0,0 +1,46 @@
+package domain
+
+import "time"
+
+// Page selects a window of a list result.
+type Page struct {
+	Limit  int
+	Offset int
+}
+
+// MedicineFilter narrows medicine listings.
+type MedicineFilter struct {
+	Name            string // case-insensitive substring match
+	IncludeArchived bool
+	Page            Page
+}
+
+// StockEntryFilter narrows stock entry listings.
+type StockEntryFilter struct {
+	MedicineID      string
+	Kind            EntryKind // any kind when empty
+	From            time.Time // inclusive, zero means unbounded
+	To              time.Time // inclusive, zero means unbounded
+	IncludeArchived bool
+	Page            Page
+}
+
+// FinancialEntryFilter narrows financial entry listings to one month. A zero
+// Year means the current month.
+type FinancialEntryFilter struct {
+	Year            int
+	Month           time.Month
+	Contributor     string
+	NeedLabel       string
+	IncludeArchived bool
+	Page            Page
+}
+
+// AlertFilter narrows alert log listings.
+type AlertFilter struct {
+	MedicineID string
+	Kind       string
+	Status     DeliveryStatus
+	Since      time.Time // inclusive, zero means unbounded
+	Page       Page
+}
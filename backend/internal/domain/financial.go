@@ -11,6 +11,7 @@ type FinancialEntry struct {
 	AmountContributed float64      `json:"AmountContributed"`
 	MonthTag          string       `json:"MonthTag"`
 	Contributor       string       `json:"Contributor"`
+	Archived          bool         `json:"Archived,omitempty"`
 }
 
 // ContributorAmount represents the amount contributed by a single contributor.
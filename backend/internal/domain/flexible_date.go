@@ -16,22 +16,38 @@ func NewFlexibleDate(t time.Time) FlexibleDate {
 	return FlexibleDate{Time: t}
 }
 
-// UnmarshalJSON handles both "2006-01-02" and RFC3339 formats.
-func (fd *FlexibleDate) UnmarshalJSON(b []byte) error {
-	str := string(b)
-	str = str[1 : len(str)-1] // remove surrounding quotes
+// ParseDate parses a "2006-01-02" or RFC3339 date string.
+func ParseDate(s string) (time.Time, error) {
+	if t, err := time.Parse("2006-01-02", s); err == nil {
+		return t, nil
+	}
+	if t, err := time.Parse(time.RFC3339, s); err == nil {
+		return t, nil
+	}
+	return time.Time{}, fmt.Errorf("invalid date format: %s", s)
+}
 
-	if t, err := time.Parse("2006-01-02", str); err == nil {
-		fd.Time = t
+// UnmarshalJSON handles both "2006-01-02" and RFC3339 formats. null and
+// the empty string leave the date zero; other JSON values are an error.
+func (fd *FlexibleDate) UnmarshalJSON(b []byte) error {
+	if string(b) == "null" {
 		return nil
 	}
-
-	if t, err := time.Parse(time.RFC3339, str); err == nil {
-		fd.Time = t
+	var str string
+	if err := json.Unmarshal(b, &str); err != nil {
+		return fmt.Errorf("invalid date %s: want a string", b)
+	}
+	if str == "" {
+		fd.Time = time.Time{}
 		return nil
 	}
 
-	return fmt.Errorf("invalid date format: %s", str)
+	t, err := ParseDate(str)
+	if err != nil {
+		return err
+	}
+	fd.Time = t
+	return nil
 }
 
 // MarshalJSON always serializes in "2006-01-02" format.
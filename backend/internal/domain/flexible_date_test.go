@@ -0,0 +1,38 @@
+package domain_test
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/nomenarkt/vitaltrack/backend/internal/domain"
+)
+
+func TestFlexibleDate_UnmarshalJSON(t *testing.T) {
+	tests := []struct {
+		name    string
+		in      string
+		want    time.Time
+		wantErr bool
+	}{
+		{"date", `"2025-06-02"`, time.Date(2025, 6, 2, 0, 0, 0, 0, time.UTC), false},
+		{"rfc3339", `"2025-06-02T08:30:00Z"`, time.Date(2025, 6, 2, 8, 30, 0, 0, time.UTC), false},
+		{"null", `null`, time.Time{}, false},
+		{"empty", `""`, time.Time{}, false},
+		{"number", `5`, time.Time{}, true},
+		{"object", `{}`, time.Time{}, true},
+		{"bad_format", `"02/06/2025"`, time.Time{}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var e domain.StockEntry
+			err := json.Unmarshal([]byte(`{"date":`+tt.in+`}`), &e)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("err = %v, want error %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && !e.Date.Equal(tt.want) {
+				t.Errorf("date = %v, want %v", e.Date.Time, tt.want)
+			}
+		})
+	}
+}
@@ -1,25 +1,103 @@
 // Package domain contains core business models.
 package domain
 
+import "time"
+
 // Medicine represents a medicine tracked for stock levels.
 type Medicine struct {
 	ID                     string        `json:"id"`
 	Name                   string        `json:"name"`
-	UnitType               string        `json:"unit_type"`
+	UnitType               string        `json:"unit_type"` // unit of the stock and DailyDose, "pill" when empty
 	UnitPerBox             float64       `json:"unit_per_box"`
+	Units                  string        `json:"units,omitempty"` // conversions such as "box = 3 strip, strip = 10 tablet"
 	DailyDose              float64       `json:"daily_dose"`
 	StartDate              FlexibleDate  `json:"start_date"` // supports RFC3339 + YYYY-MM-DD
 	InitialStock           float64       `json:"initial_stock"`
 	ForecastOutOfStockDate *FlexibleDate `json:"forecast_out_of_stock_date,omitempty"`
 	ForecastLastUpdated    *FlexibleDate `json:"forecast_last_updated,omitempty"`
 	LastAlertedDate        *FlexibleDate `json:"last_alerted_date,omitempty"`
+	Patient                string        `json:"patient,omitempty"`    // who takes it, for adherence reports
+	DoseTimes              DoseTimes     `json:"dose_times,omitempty"` // local reminder times, DailyDose split evenly
+	Archived               bool          `json:"archived,omitempty"`
+
+	// SkippedPills are pills from doses confirmed skipped. They are not
+	// stored; see usecase.SkippedDoseStock.
+	SkippedPills float64 `json:"-"`
+}
+
+// ForecastUpdate is a newly computed out-of-stock date for a medicine.
+type ForecastUpdate struct {
+	MedicineID string
+	Date       time.Time
+}
+
+// EntryChanges lists, by record ID, the stock entries changed in the store
+// by someone other than the server.
+type EntryChanges struct {
+	Upserted  []string // created or updated
+	Destroyed []string
+}
+
+// Empty reports whether c holds no change.
+func (c EntryChanges) Empty() bool {
+	return len(c.Upserted) == 0 && len(c.Destroyed) == 0
+}
+
+// WebhookState is what a registered change webhook needs to be reused after
+// a restart: the store only reveals its Secret when it is created, and
+// Cursor is the first change payload not read yet.
+type WebhookState struct {
+	ID     string `json:"id"`
+	URL    string `json:"url"`
+	Secret []byte `json:"secret"`
+	Cursor int    `json:"cursor"`
 }
 
 // StockEntry records a consumption or purchase event for a medicine.
 type StockEntry struct {
 	ID         string       `json:"id"`
 	MedicineID []string     `json:"medicine_id"`
+	Kind       EntryKind    `json:"kind,omitempty"` // refill when empty
 	Quantity   float64      `json:"quantity"`
-	Unit       string       `json:"unit"` // "box" or "pill"
+	Unit       string       `json:"unit"` // "box", "pill" or a unit of the medicine
 	Date       FlexibleDate `json:"date"`
+	Reason     string       `json:"reason,omitempty"`
+	Archived   bool         `json:"archived,omitempty"`
+}
+
+// EntryKind says how a stock entry changes the stock.
+type EntryKind string
+
+// Kinds of stock entries.
+const (
+	EntryRefill      EntryKind = "refill"       // bought or received, added
+	EntryWastage     EntryKind = "wastage"      // lost, expired or discarded, removed
+	EntryTransferOut EntryKind = "transfer_out" // given to someone else, removed
+	EntryCorrection  EntryKind = "correction"   // signed adjustment after a count
+)
+
+// EntryKinds lists every stock entry kind.
+var EntryKinds = []EntryKind{EntryRefill, EntryWastage, EntryTransferOut, EntryCorrection}
+
+// EffectiveKind returns the kind of e. Entries recorded before kinds
+// existed are refills.
+func (e StockEntry) EffectiveKind() EntryKind {
+	if e.Kind == "" {
+		return EntryRefill
+	}
+	return e.Kind
+}
+
+// ValidQuantity reports whether the quantity of e suits its kind: positive,
+// or non-zero for corrections.
+func (e StockEntry) ValidQuantity() bool {
+	if e.EffectiveKind() == EntryCorrection {
+		return e.Quantity != 0
+	}
+	return e.Quantity > 0
+}
+
+// IsRefill reports whether e adds bought or received stock.
+func (e StockEntry) IsRefill() bool {
+	return e.EffectiveKind() == EntryRefill
 }
@@ -0,0 +1,30 @@
+package domain
+
+import "time"
+
+// OutboxStatus tracks a queued outbound message.
+type OutboxStatus string
+
+// Outbox statuses. Delivered messages are removed from the outbox.
+const (
+	OutboxQueued OutboxStatus = "queued" // waiting for its next delivery attempt
+	OutboxDead   OutboxStatus = "dead"   // gave up, the latest kept for inspection
+)
+
+// OutboxMessage is an outbound chat message waiting to be delivered. Text is
+// the final wire text, already escaped for ParseMode. A zero NextAttemptAt
+// means the message is due immediately. AlertKey is set on the parts of an
+// alert so its delivery can be reported to the alert log.
+type OutboxMessage struct {
+	ID            string       `json:"id"`
+	ChatID        string       `json:"chat_id"`
+	Text          string       `json:"text"`
+	ParseMode     string       `json:"parse_mode,omitempty"`
+	ReplyMarkup   string       `json:"reply_markup,omitempty"` // JSON inline keyboard, if any
+	AlertKey      string       `json:"alert_key,omitempty"`
+	Status        OutboxStatus `json:"status"`
+	Attempts      int          `json:"attempts"`
+	NextAttemptAt time.Time    `json:"next_attempt_at"`
+	LastError     string       `json:"last_error,omitempty"`
+	CreatedAt     time.Time    `json:"created_at"`
+}
@@ -2,38 +2,146 @@
 package ports
 
 import (
+	"context"
 	"time"
 
 	"github.com/nomenarkt/vitaltrack/backend/internal/domain"
+	"github.com/nomenarkt/vitaltrack/backend/internal/util/richtext"
 )
 
 // AirtableService defines operations required from the Airtable API client.
 type AirtableService interface {
-	FetchMedicines() ([]domain.Medicine, error)
-	FetchStockEntries() ([]domain.StockEntry, error)
-	FetchFinancialEntries(year int, month time.Month) ([]domain.FinancialEntry, error)
-	UpdateMedicineLastAlertedDate(medicineID string, date time.Time) error
+	FetchMedicines(ctx context.Context) ([]domain.Medicine, error)
+	FetchStockEntries(ctx context.Context) ([]domain.StockEntry, error)
+	FetchFinancialEntries(ctx context.Context, year int, month time.Month) ([]domain.FinancialEntry, error)
+	UpdateMedicineLastAlertedDate(ctx context.Context, medicineID string, date time.Time) error
+	// UpdateLastAlertedDates saves date as the last alert date of every
+	// medicine in medicineIDs, in as few requests as possible.
+	UpdateLastAlertedDates(ctx context.Context, medicineIDs []string, date time.Time) error
 }
 
 // TelegramService defines methods for interacting with Telegram.
 type TelegramService interface {
-	SendTelegramMessage(text string) error
+	SendTelegramMessage(ctx context.Context, msg richtext.Doc) error
 	PollForCommands(
-		fetch func() ([]domain.Medicine, []domain.StockEntry, error),
-		reportFn func(year, month int) (domain.MonthlyFinancialReport, error),
+		ctx context.Context,
+		fetch func(ctx context.Context) ([]domain.Medicine, []domain.StockEntry, error),
+		reportFn func(ctx context.Context, year, month int) (domain.MonthlyFinancialReport, error),
+		alertsFn func(ctx context.Context) ([]domain.AlertRecord, error),
 	)
 }
 
+// AlertSender sends alerts whose delivery may be deferred to an outbox.
+type AlertSender interface {
+	// SendAlert sends msg to the alert chat, tagged with the alert key. It
+	// reports whether msg was queued, its outcome then being reported to an
+	// AlertReporter once known.
+	SendAlert(ctx context.Context, key string, msg richtext.Doc) (queued bool, err error)
+}
+
+// AlertReporter records the outcome of a queued alert: delivered when
+// sendErr is nil, given up otherwise.
+type AlertReporter interface {
+	ReportAlert(ctx context.Context, key string, sendErr error, at time.Time) error
+}
+
+// ChatMessenger sends messages to a chosen Telegram chat.
+type ChatMessenger interface {
+	SendToChat(ctx context.Context, chatID string, msg richtext.Doc) error
+}
+
+// PromptSender sends a message with inline reply buttons to a chat.
+type PromptSender interface {
+	SendWithButtons(ctx context.Context, chatID string, msg richtext.Doc, buttons []domain.ReplyButton) error
+}
+
 // StockDataPort is used by use cases to persist and retrieve stock data.
 type StockDataPort interface {
-	FetchMedicines() ([]domain.Medicine, error)
-	FetchStockEntries() ([]domain.StockEntry, error)
-	FetchFinancialEntries(year int, month time.Month) ([]domain.FinancialEntry, error)
-	CreateStockEntry(domain.StockEntry) error
-	UpdateForecastDate(medicineID string, forecastDate, updatedAt time.Time) error
+	FetchMedicines(ctx context.Context) ([]domain.Medicine, error)
+	FetchStockEntries(ctx context.Context) ([]domain.StockEntry, error)
+	FetchFinancialEntries(ctx context.Context, year int, month time.Month) ([]domain.FinancialEntry, error)
+	CreateStockEntry(ctx context.Context, e domain.StockEntry) (domain.StockEntry, error)
+	UpdateForecastDate(ctx context.Context, medicineID string, forecastDate, updatedAt time.Time) error
+	// UpdateForecastDates saves every forecast in updates, stamped with
+	// updatedAt, in as few requests as possible.
+	UpdateForecastDates(ctx context.Context, updates []domain.ForecastUpdate, updatedAt time.Time) error
+
+	GetMedicine(ctx context.Context, id string) (domain.Medicine, error)
+	CreateMedicine(ctx context.Context, m domain.Medicine) (domain.Medicine, error)
+	UpdateMedicine(ctx context.Context, id string, patch domain.MedicinePatch) (domain.Medicine, error)
+	GetStockEntry(ctx context.Context, id string) (domain.StockEntry, error)
+	UpdateStockEntry(ctx context.Context, id string, patch domain.StockEntryPatch) (domain.StockEntry, error)
 }
 
-// FinancialDataPort fetches financial entries for reporting.
+// EntryChangesPort reports the stock entries changed in the store by
+// someone other than the server.
+type EntryChangesPort interface {
+	// EntryChanges returns the changes made since the previous call.
+	EntryChanges(ctx context.Context) (domain.EntryChanges, error)
+}
+
+// FinancialDataPort reads and writes financial entries.
 type FinancialDataPort interface {
-	FetchFinancialEntries(year int, month time.Month) ([]domain.FinancialEntry, error)
+	FetchFinancialEntries(ctx context.Context, year int, month time.Month) ([]domain.FinancialEntry, error)
+	GetFinancialEntry(ctx context.Context, id string) (domain.FinancialEntry, error)
+	CreateFinancialEntry(ctx context.Context, e domain.FinancialEntry) (domain.FinancialEntry, error)
+	UpdateFinancialEntry(ctx context.Context, id string, patch domain.FinancialEntryPatch) (domain.FinancialEntry, error)
+}
+
+// RecordScanPort reads every record the data validation checks. Unlike
+// StockDataPort and FinancialDataPort, it does not fail on a date that does
+// not parse: the date is left zero and reported in the scan's issues.
+type RecordScanPort interface {
+	// ScanRecords reads the medicines, the stock entries and the financial
+	// entries of the month.
+	ScanRecords(ctx context.Context, year int, month time.Month) (domain.RecordScan, error)
+}
+
+// AlertLogPort persists the alert delivery log used for dedupe and review.
+type AlertLogPort interface {
+	// FindAlert returns the oldest record for key, or domain.ErrNotFound.
+	FindAlert(ctx context.Context, key string) (domain.AlertRecord, error)
+	CreateAlert(ctx context.Context, r domain.AlertRecord) (domain.AlertRecord, error)
+	UpdateAlert(ctx context.Context, id string, patch domain.AlertRecordPatch) (domain.AlertRecord, error)
+	// FetchAlerts returns records created at or after since (all when zero).
+	FetchAlerts(ctx context.Context, since time.Time) ([]domain.AlertRecord, error)
+}
+
+// AdherencePort persists the dose reminder log.
+type AdherencePort interface {
+	// FindDoseEvent returns the event for key, or domain.ErrNotFound.
+	FindDoseEvent(ctx context.Context, key string) (domain.DoseEvent, error)
+	// GetDoseEvent returns the event with id, or domain.ErrNotFound.
+	GetDoseEvent(ctx context.Context, id string) (domain.DoseEvent, error)
+	CreateDoseEvent(ctx context.Context, e domain.DoseEvent) (domain.DoseEvent, error)
+	UpdateDoseEvent(ctx context.Context, id string, patch domain.DoseEventPatch) (domain.DoseEvent, error)
+	// FetchDoseEvents returns events scheduled at or after since (all when zero).
+	FetchDoseEvents(ctx context.Context, since time.Time) ([]domain.DoseEvent, error)
+}
+
+// OutboxPort persists outbound messages until they are delivered.
+type OutboxPort interface {
+	Enqueue(domain.OutboxMessage) (domain.OutboxMessage, error)
+	// Pending returns queued (not dead) messages in enqueue order.
+	Pending() ([]domain.OutboxMessage, error)
+	UpdateMessage(domain.OutboxMessage) error
+	// Remove drops a delivered message.
+	Remove(id string) error
+}
+
+// WebhookStatePort stores the state of the registered change webhook.
+type WebhookStatePort interface {
+	// LoadWebhook returns the saved state, or domain.ErrNotFound.
+	LoadWebhook() (domain.WebhookState, error)
+	SaveWebhook(domain.WebhookState) error
+}
+
+// ChatPrefsPort stores per-chat bot preferences.
+type ChatPrefsPort interface {
+	// GetChatPrefs returns the preferences saved for chatID, or
+	// domain.ErrNotFound.
+	GetChatPrefs(chatID string) (domain.ChatPrefs, error)
+	SaveChatPrefs(domain.ChatPrefs) error
+	// ListChatPrefs returns the preferences of every known chat.
+	ListChatPrefs() ([]domain.ChatPrefs, error)
 }
@@ -0,0 +1,32 @@
+package domain
+
+import "time"
+
+// ChatPrefs holds the bot preferences of one Telegram chat.
+type ChatPrefs struct {
+	ChatID    string              `json:"chat_id"`
+	Language  string              `json:"language,omitempty"`
+	Digests   map[DigestKind]bool `json:"digests,omitempty"` // explicit digest choices; see Subscribed
+	UpdatedAt time.Time           `json:"updated_at"`
+}
+
+// DigestKind names a scheduled summary a chat can subscribe to.
+type DigestKind string
+
+// Supported digests.
+const (
+	DigestStock   DigestKind = "stock"   // weekly out-of-stock forecast
+	DigestFinance DigestKind = "finance" // last month's report on the 1st
+)
+
+// DigestKinds lists the digests in display order.
+var DigestKinds = []DigestKind{DigestStock, DigestFinance}
+
+// Subscribed reports whether the chat receives kind. A chat that never chose
+// gets fallback, which is true for the alert chat only.
+func (p ChatPrefs) Subscribed(kind DigestKind, fallback bool) bool {
+	if on, ok := p.Digests[kind]; ok {
+		return on
+	}
+	return fallback
+}
@@ -0,0 +1,67 @@
+package domain
+
+// IssueKind names a problem found in the stored records.
+type IssueKind string
+
+// Issues reported by the data validation.
+const (
+	IssueOrphanEntry       IssueKind = "orphan_entry"         // entry without a known medicine, ignored
+	IssueMissingDate       IssueKind = "missing_date"         // entry or contribution without a date, ignored
+	IssueInvalidDate       IssueKind = "invalid_date"         // date that does not parse, read as missing
+	IssueUnknownUnit       IssueKind = "unknown_unit"         // entry unit its medicine does not define, counted as base units
+	IssueUnknownKind       IssueKind = "unknown_kind"         // entry kind none of EntryKinds, ignored
+	IssueInvalidUnits      IssueKind = "invalid_units"        // medicine conversions that do not parse, only pill and box used
+	IssueMissingUnitPerBox IssueKind = "missing_unit_per_box" // medicine without pills per box nor units, boxes count as 0
+	IssueFutureEntry       IssueKind = "future_entry"         // entry of any kind dated after today, not counted yet
+	IssueDuplicateEntry    IssueKind = "duplicate_entry"      // entry identical to an earlier one
+	IssueNegativeAmount    IssueKind = "negative_amount"      // negative quantity, stock, dose or amount
+	IssueOverContribution  IssueKind = "over_contribution"    // contributions to a need above its amount
+)
+
+// IssueKinds lists every issue kind in report order.
+var IssueKinds = []IssueKind{
+	IssueOrphanEntry, IssueMissingDate, IssueInvalidDate, IssueUnknownUnit, IssueUnknownKind, IssueInvalidUnits, IssueMissingUnitPerBox,
+	IssueFutureEntry, IssueDuplicateEntry, IssueNegativeAmount, IssueOverContribution,
+}
+
+// ValidationIssue is one problem found in a record, or in a group of records
+// for duplicates and contributions.
+type ValidationIssue struct {
+	Kind     IssueKind `json:"kind"`
+	Table    string    `json:"table"`               // medicines, entries or financial
+	RecordID string    `json:"record_id,omitempty"` // empty for a need, see Related
+	Label    string    `json:"label,omitempty"`     // medicine name or need label
+	Field    string    `json:"field,omitempty"`
+	Value    string    `json:"value,omitempty"`   // offending value as stored
+	Related  []string  `json:"related,omitempty"` // other records involved
+}
+
+// RecordScan holds the records read for validation. Dates that do not parse
+// are left zero in the records and listed in Issues as IssueInvalidDate.
+type RecordScan struct {
+	Medicines []Medicine
+	Entries   []StockEntry
+	Financial []FinancialEntry
+	Issues    []ValidationIssue
+}
+
+// ValidationReport lists the issues found in the medicines, the stock
+// entries and a month of financial entries.
+type ValidationReport struct {
+	Month            string            `json:"month"` // YYYY-MM of the financial entries
+	Medicines        int               `json:"medicines"`
+	Entries          int               `json:"entries"`
+	FinancialEntries int               `json:"financial_entries"`
+	Issues           []ValidationIssue `json:"issues"`
+}
+
+// Count returns the number of issues of kind in r.
+func (r ValidationReport) Count(kind IssueKind) int {
+	n := 0
+	for _, i := range r.Issues {
+		if i.Kind == kind {
+			n++
+		}
+	}
+	return n
+}
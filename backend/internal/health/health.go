@@ -0,0 +1,91 @@
+// Package health runs the readiness checks behind /readyz and caches their
+// results, so that frequent probes do not hammer Airtable or Telegram.
+package health
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// Check reports whether one dependency is usable.
+type Check func(ctx context.Context) error
+
+// Result is the outcome of one check.
+type Result struct {
+	Name      string    `json:"name"`
+	OK        bool      `json:"ok"`
+	Error     string    `json:"error,omitempty"`
+	CheckedAt time.Time `json:"checked_at"`
+}
+
+// Checker runs named checks. A result is reused for TTL before the check
+// runs again, and each run is bounded by Timeout.
+type Checker struct {
+	TTL     time.Duration
+	Timeout time.Duration
+	now     func() time.Time
+
+	mu     sync.Mutex
+	names  []string
+	checks map[string]Check
+	cache  map[string]Result
+}
+
+// New returns a Checker that caches results for ttl.
+func New(ttl time.Duration) *Checker {
+	return &Checker{
+		TTL:     ttl,
+		Timeout: 5 * time.Second,
+		now:     time.Now,
+		checks:  map[string]Check{},
+		cache:   map[string]Result{},
+	}
+}
+
+// Add registers check under name.
+func (c *Checker) Add(name string, check Check) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if _, ok := c.checks[name]; !ok {
+		c.names = append(c.names, name)
+	}
+	c.checks[name] = check
+	delete(c.cache, name)
+}
+
+// Check returns the result of every check, in the order they were added,
+// running those whose cached result has expired. ready is true when all
+// pass. A nil Checker has no checks and is always ready.
+func (c *Checker) Check(ctx context.Context) (ready bool, results []Result) {
+	if c == nil {
+		return true, []Result{}
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	ready = true
+	results = make([]Result, 0, len(c.names))
+	for _, name := range c.names {
+		res, ok := c.cache[name]
+		if !ok || c.now().Sub(res.CheckedAt) >= c.TTL {
+			res = c.run(ctx, name)
+			c.cache[name] = res
+		}
+		ready = ready && res.OK
+		results = append(results, res)
+	}
+	return ready, results
+}
+
+func (c *Checker) run(ctx context.Context, name string) Result {
+	ctx, cancel := context.WithTimeout(ctx, c.Timeout)
+	defer cancel()
+	res := Result{Name: name, OK: true}
+	if err := c.checks[name](ctx); err != nil {
+		res.OK = false
+		res.Error = err.Error()
+	}
+	res.CheckedAt = c.now().UTC()
+	return res
+}
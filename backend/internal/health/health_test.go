@@ -0,0 +1,68 @@
+package health
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestChecker_cachesResults(t *testing.T) {
+	now := time.Date(2025, 6, 5, 8, 0, 0, 0, time.UTC)
+	c := New(30 * time.Second)
+	c.now = func() time.Time { return now }
+
+	var storeCalls, botCalls int
+	botErr := errors.New("telegram unreachable")
+	c.Add("airtable", func(context.Context) error { storeCalls++; return nil })
+	c.Add("telegram", func(context.Context) error { botCalls++; return botErr })
+
+	steps := []struct {
+		advance   time.Duration
+		wantCalls int
+	}{
+		{0, 1},
+		{10 * time.Second, 1}, // cached
+		{20 * time.Second, 2}, // expired
+	}
+	for i, s := range steps {
+		now = now.Add(s.advance)
+		ready, results := c.Check(context.Background())
+		if ready {
+			t.Errorf("step %d: ready with a failing check", i)
+		}
+		if storeCalls != s.wantCalls || botCalls != s.wantCalls {
+			t.Errorf("step %d: calls = %d, %d; want %d", i, storeCalls, botCalls, s.wantCalls)
+		}
+		if len(results) != 2 || results[0].Name != "airtable" || !results[0].OK || results[1].Error != botErr.Error() {
+			t.Errorf("step %d: results = %+v", i, results)
+		}
+	}
+
+	botErr = nil
+	now = now.Add(time.Minute)
+	if ready, _ := c.Check(context.Background()); !ready {
+		t.Error("not ready once every check passes")
+	}
+}
+
+func TestChecker_timeout(t *testing.T) {
+	c := New(time.Minute)
+	c.Timeout = 10 * time.Millisecond
+	c.Add("slow", func(ctx context.Context) error {
+		<-ctx.Done()
+		return ctx.Err()
+	})
+
+	ready, results := c.Check(context.Background())
+	if ready || results[0].OK {
+		t.Errorf("slow check passed: %+v", results)
+	}
+}
+
+func TestChecker_nil(t *testing.T) {
+	var c *Checker
+	if ready, results := c.Check(context.Background()); !ready || len(results) != 0 {
+		t.Errorf("nil Checker = %v, %+v", ready, results)
+	}
+}
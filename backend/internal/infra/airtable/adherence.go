@@ -0,0 +1,135 @@
+package airtable
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/nomenarkt/vitaltrack/backend/internal/domain"
+)
+
+// airtableDoseFields mirrors the fields of the AIRTABLE_ADHERENCE_TABLE.
+type airtableDoseFields struct {
+	Key          string    `json:"key"`
+	MedicineID   string    `json:"medicine_id"`
+	MedicineName string    `json:"medicine_name"`
+	Patient      string    `json:"patient"`
+	Scheduled    time.Time `json:"scheduled"`
+	Pills        float64   `json:"pills"`
+	Status       string    `json:"status"`
+	ChatID       string    `json:"chat_id"`
+	RespondedAt  time.Time `json:"responded_at"`
+	CreatedAt    time.Time `json:"created_at"`
+}
+
+func toDoseEvent(rec airtableRecord[airtableDoseFields]) domain.DoseEvent {
+	f := rec.Fields
+	return domain.DoseEvent{
+		ID:           rec.ID,
+		Key:          f.Key,
+		MedicineID:   f.MedicineID,
+		MedicineName: f.MedicineName,
+		Patient:      f.Patient,
+		Scheduled:    f.Scheduled,
+		Pills:        f.Pills,
+		Status:       domain.DoseStatus(f.Status),
+		ChatID:       f.ChatID,
+		RespondedAt:  f.RespondedAt,
+		CreatedAt:    f.CreatedAt,
+	}
+}
+
+func doseFields(e domain.DoseEvent) map[string]any {
+	fields := map[string]any{
+		"key":           e.Key,
+		"medicine_id":   e.MedicineID,
+		"medicine_name": e.MedicineName,
+		"patient":       e.Patient,
+		"scheduled":     e.Scheduled.UTC().Format(time.RFC3339),
+		"pills":         e.Pills,
+		"status":        string(e.Status),
+		"chat_id":       e.ChatID,
+		"created_at":    e.CreatedAt.UTC().Format(time.RFC3339),
+	}
+	if !e.RespondedAt.IsZero() {
+		fields["responded_at"] = e.RespondedAt.UTC().Format(time.RFC3339)
+	}
+	return fields
+}
+
+// FindDoseEvent returns the adherence log record for key.
+func (c *Client) FindDoseEvent(ctx context.Context, key string) (domain.DoseEvent, error) {
+	q := url.Values{}
+	q.Set("filterByFormula", c.formulaField(adherenceTable, "key")+"="+formulaString(key))
+	q.Set("maxRecords", "1")
+
+	var page airtablePage[airtableDoseFields]
+	if err := c.doJSON(ctx, http.MethodGet, adherenceTable, c.tableURL(adherenceTable, "")+"?"+q.Encode(), nil, &page); err != nil {
+		return domain.DoseEvent{}, err
+	}
+	if len(page.Records) == 0 {
+		return domain.DoseEvent{}, fmt.Errorf("dose %s: %w", key, domain.ErrNotFound)
+	}
+	return toDoseEvent(page.Records[0]), nil
+}
+
+// GetDoseEvent retrieves a single adherence log record.
+func (c *Client) GetDoseEvent(ctx context.Context, id string) (domain.DoseEvent, error) {
+	var rec airtableRecord[airtableDoseFields]
+	if err := c.doJSON(ctx, http.MethodGet, adherenceTable, c.tableURL(adherenceTable, id), nil, &rec); err != nil {
+		return domain.DoseEvent{}, err
+	}
+	return toDoseEvent(rec), nil
+}
+
+// CreateDoseEvent appends a record to the adherence log.
+func (c *Client) CreateDoseEvent(ctx context.Context, e domain.DoseEvent) (domain.DoseEvent, error) {
+	payload := map[string]any{"fields": doseFields(e)}
+
+	var rec airtableRecord[airtableDoseFields]
+	if err := c.doJSON(ctx, http.MethodPost, adherenceTable, c.tableURL(adherenceTable, ""), payload, &rec); err != nil {
+		return domain.DoseEvent{}, err
+	}
+	return toDoseEvent(rec), nil
+}
+
+// UpdateDoseEvent records the delivery of a reminder or the answer to it.
+func (c *Client) UpdateDoseEvent(ctx context.Context, id string, patch domain.DoseEventPatch) (domain.DoseEvent, error) {
+	fields := map[string]any{}
+	if patch.Status != "" {
+		fields["status"] = string(patch.Status)
+	}
+	if !patch.RespondedAt.IsZero() {
+		fields["responded_at"] = patch.RespondedAt.UTC().Format(time.RFC3339)
+	}
+	if patch.ChatID != "" {
+		fields["chat_id"] = patch.ChatID
+	}
+	payload := map[string]any{"fields": fields}
+
+	var rec airtableRecord[airtableDoseFields]
+	if err := c.doJSON(ctx, http.MethodPatch, adherenceTable, c.tableURL(adherenceTable, id), payload, &rec); err != nil {
+		return domain.DoseEvent{}, err
+	}
+	return toDoseEvent(rec), nil
+}
+
+// FetchDoseEvents returns adherence log records scheduled at or after since,
+// following Airtable's pagination.
+func (c *Client) FetchDoseEvents(ctx context.Context, since time.Time) ([]domain.DoseEvent, error) {
+	q := url.Values{}
+	if !since.IsZero() {
+		q.Set("filterByFormula", fmt.Sprintf("NOT(IS_BEFORE(%s, %s))", c.formulaField(adherenceTable, "scheduled"), formulaString(since.UTC().Format(time.RFC3339))))
+	}
+	recs, err := fetchAll[airtableDoseFields](ctx, c, adherenceTable, q)
+	if err != nil {
+		return nil, err
+	}
+	var out []domain.DoseEvent
+	for _, rec := range recs {
+		out = append(out, toDoseEvent(rec))
+	}
+	return out, nil
+}
@@ -0,0 +1,75 @@
+package airtable
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/nomenarkt/vitaltrack/backend/internal/domain"
+)
+
+func TestCreateDoseEvent(t *testing.T) {
+	var body struct {
+		Fields map[string]any `json:"fields"`
+	}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v0/base/adherence" {
+			t.Errorf("path = %s", r.URL.Path)
+		}
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			t.Fatalf("decode body: %v", err)
+		}
+		if _, err := fmt.Fprint(w, `{"id":"recD","fields":{"key":"dose:m1:2025-06-05T08:00","status":"pending","scheduled":"2025-06-05T05:00:00.000Z","pills":1}}`); err != nil {
+			t.Fatalf("write response: %v", err)
+		}
+	}))
+	defer srv.Close()
+
+	tana := time.FixedZone("EAT", 3*3600)
+	c := &Client{cfg: testConfig, baseURL: srv.URL}
+	ev, err := c.CreateDoseEvent(context.Background(), domain.DoseEvent{
+		Key:       "dose:m1:2025-06-05T08:00",
+		Scheduled: time.Date(2025, 6, 5, 8, 0, 0, 0, tana),
+		Pills:     1,
+		Status:    domain.DosePending,
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if body.Fields["scheduled"] != "2025-06-05T05:00:00Z" || body.Fields["status"] != "pending" {
+		t.Errorf("fields = %v", body.Fields)
+	}
+	if _, ok := body.Fields["responded_at"]; ok {
+		t.Error("unanswered dose sent a responded_at")
+	}
+	if ev.ID != "recD" || ev.Status != domain.DosePending || !ev.Scheduled.Equal(time.Date(2025, 6, 5, 5, 0, 0, 0, time.UTC)) {
+		t.Errorf("unexpected event: %+v", ev)
+	}
+}
+
+func TestFetchDoseEvents_since(t *testing.T) {
+	var formula string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		formula = r.URL.Query().Get("filterByFormula")
+		if _, err := fmt.Fprint(w, `{"records":[{"id":"rec1","fields":{"key":"a","status":"skipped"}}]}`); err != nil {
+			t.Fatalf("write response: %v", err)
+		}
+	}))
+	defer srv.Close()
+
+	c := &Client{cfg: testConfig, baseURL: srv.URL}
+	events, err := c.FetchDoseEvents(context.Background(), time.Date(2025, 6, 2, 0, 0, 0, 0, time.UTC))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if formula != `NOT(IS_BEFORE({scheduled}, "2025-06-02T00:00:00Z"))` {
+		t.Errorf("filterByFormula = %s", formula)
+	}
+	if len(events) != 1 || events[0].Status != domain.DoseSkipped {
+		t.Errorf("events = %+v", events)
+	}
+}
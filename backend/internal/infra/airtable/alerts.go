@@ -0,0 +1,141 @@
+package airtable
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/nomenarkt/vitaltrack/backend/internal/domain"
+)
+
+// airtableAlertFields mirrors the fields of the AIRTABLE_ALERTS_TABLE.
+type airtableAlertFields struct {
+	Key          string    `json:"key"`
+	Kind         string    `json:"kind"`
+	MedicineID   string    `json:"medicine_id"`
+	MedicineName string    `json:"medicine_name"`
+	Payload      string    `json:"payload"`
+	Channel      string    `json:"channel"`
+	Status       string    `json:"status"`
+	Attempts     int       `json:"attempts"`
+	LastError    string    `json:"last_error"`
+	CreatedAt    time.Time `json:"created_at"`
+	UpdatedAt    time.Time `json:"updated_at"`
+}
+
+func toAlertRecord(rec airtableRecord[airtableAlertFields]) domain.AlertRecord {
+	f := rec.Fields
+	return domain.AlertRecord{
+		ID:           rec.ID,
+		Key:          f.Key,
+		Kind:         f.Kind,
+		MedicineID:   f.MedicineID,
+		MedicineName: f.MedicineName,
+		Payload:      f.Payload,
+		Channel:      f.Channel,
+		Status:       domain.DeliveryStatus(f.Status),
+		Attempts:     f.Attempts,
+		LastError:    f.LastError,
+		CreatedAt:    f.CreatedAt,
+		UpdatedAt:    f.UpdatedAt,
+	}
+}
+
+func alertFields(r domain.AlertRecord) map[string]any {
+	return map[string]any{
+		"key":           r.Key,
+		"kind":          r.Kind,
+		"medicine_id":   r.MedicineID,
+		"medicine_name": r.MedicineName,
+		"payload":       r.Payload,
+		"channel":       r.Channel,
+		"status":        string(r.Status),
+		"attempts":      r.Attempts,
+		"last_error":    r.LastError,
+		"created_at":    r.CreatedAt.UTC().Format(time.RFC3339),
+		"updated_at":    r.UpdatedAt.UTC().Format(time.RFC3339),
+	}
+}
+
+func alertPatchFields(p domain.AlertRecordPatch) map[string]any {
+	fields := map[string]any{}
+	if p.Status != nil {
+		fields["status"] = string(*p.Status)
+	}
+	if p.Attempts != nil {
+		fields["attempts"] = *p.Attempts
+	}
+	if p.LastError != nil {
+		fields["last_error"] = *p.LastError
+	}
+	if !p.UpdatedAt.IsZero() {
+		fields["updated_at"] = p.UpdatedAt.UTC().Format(time.RFC3339)
+	}
+	return fields
+}
+
+// formulaString quotes s as an Airtable formula string literal.
+func formulaString(s string) string {
+	return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(s) + `"`
+}
+
+// FindAlert returns the oldest alert log record for key.
+func (c *Client) FindAlert(ctx context.Context, key string) (domain.AlertRecord, error) {
+	q := url.Values{}
+	q.Set("filterByFormula", c.formulaField(alertsTable, "key")+"="+formulaString(key))
+	q.Set("sort[0][field]", c.column(alertsTable, "created_at"))
+	q.Set("sort[0][direction]", "asc")
+	q.Set("maxRecords", "1")
+
+	var page airtablePage[airtableAlertFields]
+	if err := c.doJSON(ctx, http.MethodGet, alertsTable, c.tableURL(alertsTable, "")+"?"+q.Encode(), nil, &page); err != nil {
+		return domain.AlertRecord{}, err
+	}
+	if len(page.Records) == 0 {
+		return domain.AlertRecord{}, fmt.Errorf("alert %s: %w", key, domain.ErrNotFound)
+	}
+	return toAlertRecord(page.Records[0]), nil
+}
+
+// CreateAlert appends a record to the alert log.
+func (c *Client) CreateAlert(ctx context.Context, r domain.AlertRecord) (domain.AlertRecord, error) {
+	payload := map[string]any{"fields": alertFields(r)}
+
+	var rec airtableRecord[airtableAlertFields]
+	if err := c.doJSON(ctx, http.MethodPost, alertsTable, c.tableURL(alertsTable, ""), payload, &rec); err != nil {
+		return domain.AlertRecord{}, err
+	}
+	return toAlertRecord(rec), nil
+}
+
+// UpdateAlert records the outcome of a delivery attempt.
+func (c *Client) UpdateAlert(ctx context.Context, id string, patch domain.AlertRecordPatch) (domain.AlertRecord, error) {
+	payload := map[string]any{"fields": alertPatchFields(patch)}
+
+	var rec airtableRecord[airtableAlertFields]
+	if err := c.doJSON(ctx, http.MethodPatch, alertsTable, c.tableURL(alertsTable, id), payload, &rec); err != nil {
+		return domain.AlertRecord{}, err
+	}
+	return toAlertRecord(rec), nil
+}
+
+// FetchAlerts returns alert log records created at or after since, following
+// Airtable's pagination.
+func (c *Client) FetchAlerts(ctx context.Context, since time.Time) ([]domain.AlertRecord, error) {
+	q := url.Values{}
+	if !since.IsZero() {
+		q.Set("filterByFormula", fmt.Sprintf("NOT(IS_BEFORE(%s, %s))", c.formulaField(alertsTable, "created_at"), formulaString(since.UTC().Format(time.RFC3339))))
+	}
+	recs, err := fetchAll[airtableAlertFields](ctx, c, alertsTable, q)
+	if err != nil {
+		return nil, err
+	}
+	var out []domain.AlertRecord
+	for _, rec := range recs {
+		out = append(out, toAlertRecord(rec))
+	}
+	return out, nil
+}
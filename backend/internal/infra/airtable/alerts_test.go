@@ -0,0 +1,77 @@
+package airtable
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/nomenarkt/vitaltrack/backend/internal/domain"
+)
+
+func TestFindAlert(t *testing.T) {
+	var query map[string][]string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		query = r.URL.Query()
+		if _, err := fmt.Fprint(w, `{"records":[{"id":"recA","fields":{"key":"low_stock:m1:2025-06-10","status":"sent","attempts":1,"created_at":"2025-06-10T09:00:00.000Z"}}]}`); err != nil {
+			t.Fatalf("write response: %v", err)
+		}
+	}))
+	defer srv.Close()
+
+	c := &Client{cfg: testConfig, baseURL: srv.URL}
+	rec, err := c.FindAlert(context.Background(), "low_stock:m1:2025-06-10")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got := query["filterByFormula"]; len(got) != 1 || got[0] != `{key}="low_stock:m1:2025-06-10"` {
+		t.Errorf("filterByFormula = %v", got)
+	}
+	if query["sort[0][field]"][0] != "created_at" || query["maxRecords"][0] != "1" {
+		t.Errorf("expected oldest-first single record query, got %v", query)
+	}
+	if rec.ID != "recA" || rec.Status != domain.DeliverySent || !rec.CreatedAt.Equal(time.Date(2025, 6, 10, 9, 0, 0, 0, time.UTC)) {
+		t.Errorf("unexpected record: %+v", rec)
+	}
+}
+
+func TestFindAlert_notFound(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		if _, err := fmt.Fprint(w, `{"records":[]}`); err != nil {
+			t.Fatalf("write response: %v", err)
+		}
+	}))
+	defer srv.Close()
+
+	c := &Client{cfg: testConfig, baseURL: srv.URL}
+	if _, err := c.FindAlert(context.Background(), "refill:e1"); !errors.Is(err, domain.ErrNotFound) {
+		t.Fatalf("err = %v, want domain.ErrNotFound", err)
+	}
+}
+
+func TestFetchAlerts_followsPagination(t *testing.T) {
+	calls := 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		body := `{"records":[{"id":"rec1","fields":{"key":"a"}}],"offset":"next"}`
+		if r.URL.Query().Get("offset") == "next" {
+			body = `{"records":[{"id":"rec2","fields":{"key":"b"}}]}`
+		}
+		if _, err := fmt.Fprint(w, body); err != nil {
+			t.Fatalf("write response: %v", err)
+		}
+	}))
+	defer srv.Close()
+
+	c := &Client{cfg: testConfig, baseURL: srv.URL}
+	recs, err := c.FetchAlerts(context.Background(), time.Time{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if calls != 2 || len(recs) != 2 || recs[1].Key != "b" {
+		t.Errorf("calls=%d records=%+v", calls, recs)
+	}
+}
@@ -0,0 +1,60 @@
+package airtable
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/nomenarkt/vitaltrack/backend/internal/domain"
+)
+
+// MaxBatchSize is the number of records Airtable accepts in one request.
+const MaxBatchSize = 10
+
+type recordPatch struct {
+	ID     string         `json:"id"`
+	Fields map[string]any `json:"fields"`
+}
+
+// patchRecords updates recs in table, MaxBatchSize records per request. A
+// failed request does not stop the others; their errors are joined.
+func (c *Client) patchRecords(ctx context.Context, t table, recs []recordPatch) error {
+	var errs []error
+	for start := 0; start < len(recs); start += MaxBatchSize {
+		batch := recs[start:min(start+MaxBatchSize, len(recs))]
+		payload := map[string]any{"records": batch}
+		if err := c.doJSON(ctx, http.MethodPatch, t, c.tableURL(t, ""), payload, nil); err != nil {
+			errs = append(errs, fmt.Errorf("update records %d-%d of %d: %w", start+1, start+len(batch), len(recs), err))
+			continue
+		}
+		c.log().Debug(ctx, "airtable batch patch", "table", c.tableName(t), "records", len(batch))
+	}
+	return errors.Join(errs...)
+}
+
+// UpdateForecastDates records the forecast dates in updates, stamped with
+// updatedAt.
+func (c *Client) UpdateForecastDates(ctx context.Context, updates []domain.ForecastUpdate, updatedAt time.Time) error {
+	recs := make([]recordPatch, 0, len(updates))
+	for _, u := range updates {
+		recs = append(recs, recordPatch{ID: u.MedicineID, Fields: map[string]any{
+			"forecast_out_of_stock_date": u.Date.Format("2006-01-02"),
+			"forecast_last_updated":      updatedAt.Format("2006-01-02"),
+		}})
+	}
+	return c.patchRecords(ctx, medicinesTable, recs)
+}
+
+// UpdateLastAlertedDates saves date as the last alert date of the medicines
+// in medicineIDs.
+func (c *Client) UpdateLastAlertedDates(ctx context.Context, medicineIDs []string, date time.Time) error {
+	recs := make([]recordPatch, 0, len(medicineIDs))
+	for _, id := range medicineIDs {
+		recs = append(recs, recordPatch{ID: id, Fields: map[string]any{
+			"last_alerted_date": date.Format("2006-01-02"),
+		}})
+	}
+	return c.patchRecords(ctx, medicinesTable, recs)
+}
@@ -0,0 +1,84 @@
+package airtable
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/nomenarkt/vitaltrack/backend/internal/domain"
+)
+
+func TestUpdateForecastDates_batches(t *testing.T) {
+	type request struct {
+		method, path string
+		records      []recordPatch
+	}
+	var requests []request
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body struct {
+			Records []recordPatch `json:"records"`
+		}
+		b, _ := io.ReadAll(r.Body)
+		if err := json.Unmarshal(b, &body); err != nil {
+			t.Errorf("decode body: %v", err)
+		}
+		requests = append(requests, request{r.Method, r.URL.Path, body.Records})
+		_, _ = fmt.Fprint(w, `{"records":[]}`)
+	}))
+	defer srv.Close()
+
+	var updates []domain.ForecastUpdate
+	for i := range 23 {
+		updates = append(updates, domain.ForecastUpdate{MedicineID: fmt.Sprintf("rec%d", i), Date: time.Date(2025, 7, 1, 0, 0, 0, 0, time.UTC)})
+	}
+	c := &Client{cfg: testConfig, baseURL: srv.URL}
+	if err := c.UpdateForecastDates(context.Background(), updates, time.Date(2025, 6, 3, 0, 0, 0, 0, time.UTC)); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if len(requests) != 3 {
+		t.Fatalf("%d requests, want 3", len(requests))
+	}
+	for i, want := range []int{10, 10, 3} {
+		r := requests[i]
+		if r.method != http.MethodPatch || r.path != "/v0/base/meds" || len(r.records) != want {
+			t.Errorf("request %d: %s %s with %d records, want %d", i, r.method, r.path, len(r.records), want)
+		}
+	}
+	first := requests[0].records[0]
+	if first.ID != "rec0" || first.Fields["forecast_out_of_stock_date"] != "2025-07-01" || first.Fields["forecast_last_updated"] != "2025-06-03" {
+		t.Errorf("first record = %+v", first)
+	}
+}
+
+func TestUpdateLastAlertedDates_partialFailure(t *testing.T) {
+	calls := 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		calls++
+		if calls == 1 {
+			w.WriteHeader(http.StatusUnprocessableEntity)
+			_, _ = fmt.Fprint(w, `{"error":{"type":"ROW_DOES_NOT_EXIST","message":"Record not found"}}`)
+			return
+		}
+		_, _ = fmt.Fprint(w, `{"records":[]}`)
+	}))
+	defer srv.Close()
+
+	ids := make([]string, 12)
+	for i := range ids {
+		ids[i] = fmt.Sprintf("rec%d", i)
+	}
+	c := &Client{cfg: testConfig, baseURL: srv.URL}
+	err := c.UpdateLastAlertedDates(context.Background(), ids, time.Date(2025, 6, 3, 0, 0, 0, 0, time.UTC))
+	if err == nil {
+		t.Fatal("expected error for the failed batch")
+	}
+	if calls != 2 {
+		t.Errorf("%d requests, want 2: a failed batch must not stop the next", calls)
+	}
+}
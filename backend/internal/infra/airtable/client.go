@@ -3,44 +3,57 @@ package airtable
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
 	"io"
-	"log"
 	"net/http"
 	"net/url"
-	"os"
 	"time"
 
-	"github.com/joho/godotenv"
+	"github.com/nomenarkt/vitaltrack/backend/internal/config"
 	"github.com/nomenarkt/vitaltrack/backend/internal/domain"
+	"github.com/nomenarkt/vitaltrack/backend/internal/logger"
 )
 
-// Client talks to the Airtable REST API.
+// Client talks to the Airtable REST API. Requests that Airtable rejects
+// fail with an *Error.
 type Client struct {
+	cfg     config.Airtable
 	baseURL string
+	lg      logger.Logger
+	http    *http.Client
 }
 
-// NewClient returns a Client configured from environment variables.
-func NewClient() *Client {
-	if err := godotenv.Load(); err != nil {
-		log.Printf("godotenv load: %v", err)
+// NewClient returns a Client for the base and tables in cfg, logging to lg.
+// Its requests are rate limited and retried as described at NewHTTPClient.
+func NewClient(cfg config.Airtable, lg logger.Logger) *Client {
+	return &Client{
+		cfg:     cfg,
+		baseURL: cfg.APIBaseURL,
+		lg:      lg,
+		http:    NewHTTPClient(HTTPOptions{Timeout: cfg.Timeout, MaxRetries: cfg.MaxRetries, Logger: lg}),
 	}
+}
 
-	// Validate required environment variables to avoid runtime errors
-	if os.Getenv("AIRTABLE_BASE_ID") == "" ||
-		os.Getenv("AIRTABLE_MEDICINES_TABLE") == "" ||
-		os.Getenv("AIRTABLE_ENTRIES_TABLE") == "" ||
-		os.Getenv("AIRTABLE_TOKEN") == "" {
-		panic("missing Airtable configuration: ensure AIRTABLE_BASE_ID, AIRTABLE_MEDICINES_TABLE, AIRTABLE_ENTRIES_TABLE and AIRTABLE_TOKEN are set")
-	}
+// UseHTTPClient makes c send its requests with hc.
+func (c *Client) UseHTTPClient(hc *http.Client) {
+	c.http = hc
+}
+
+func (c *Client) log() logger.Logger { return logger.OrNop(c.lg) }
 
-	baseURL := os.Getenv("AIRTABLE_API_BASE_URL")
-	if baseURL == "" {
-		baseURL = "https://api.airtable.com"
+func (c *Client) do(req *http.Request) (*http.Response, error) {
+	if c.http == nil {
+		return httpClient.Do(req)
 	}
+	return c.http.Do(req)
+}
 
-	return &Client{baseURL: baseURL}
+// Ping checks that Airtable answers and that the token can read the
+// medicines table.
+func (c *Client) Ping(ctx context.Context) error {
+	return c.doJSON(ctx, http.MethodGet, medicinesTable, c.tableURL(medicinesTable, "")+"?maxRecords=1", nil, nil)
 }
 
 type airtableRecord[T any] struct {
@@ -48,52 +61,42 @@ type airtableRecord[T any] struct {
 	Fields T      `json:"fields"`
 }
 
-type airtableResponse[T any] struct {
+// airtablePage is one page of a list response. Offset is set while more
+// pages follow.
+type airtablePage[T any] struct {
 	Records []airtableRecord[T] `json:"records"`
+	Offset  string              `json:"offset"`
 }
 
-// FetchMedicines retrieves all medicines from Airtable.
-func (c *Client) FetchMedicines() ([]domain.Medicine, error) {
-	url := fmt.Sprintf("%s/v0/%s/%s",
-		c.baseURL,
-		os.Getenv("AIRTABLE_BASE_ID"),
-		os.Getenv("AIRTABLE_MEDICINES_TABLE"))
-
-	req, err := http.NewRequest("GET", url, nil)
-	if err != nil {
-		return nil, err
-	}
-	req.Header.Set("Authorization", "Bearer "+os.Getenv("AIRTABLE_TOKEN"))
-
-	res, err := http.DefaultClient.Do(req)
-	if err != nil {
-		return nil, err
-	}
-	defer func() {
-		if cerr := res.Body.Close(); cerr != nil {
-			log.Printf("airtable response close error: %v", cerr)
+// fetchAll lists the records of t matching q, following Airtable's
+// pagination.
+func fetchAll[T any](ctx context.Context, c *Client, t table, q url.Values) ([]airtableRecord[T], error) {
+	var out []airtableRecord[T]
+	for {
+		endpoint := c.tableURL(t, "")
+		if len(q) > 0 {
+			endpoint += "?" + q.Encode()
 		}
-	}()
-
-	body, err := io.ReadAll(res.Body)
-	if err != nil {
-		return nil, err
-	}
-
-	var errCheck map[string]interface{}
-	if json.Unmarshal(body, &errCheck) == nil {
-		if errVal, exists := errCheck["error"]; exists {
-			return nil, fmt.Errorf("airtable error: %v", errVal)
+		var page airtablePage[T]
+		if err := c.doJSON(ctx, http.MethodGet, t, endpoint, nil, &page); err != nil {
+			return nil, err
+		}
+		out = append(out, page.Records...)
+		if page.Offset == "" {
+			return out, nil
 		}
+		q.Set("offset", page.Offset)
 	}
+}
 
-	var response airtableResponse[domain.Medicine]
-	if err := json.Unmarshal(body, &response); err != nil {
+// FetchMedicines retrieves all medicines from Airtable.
+func (c *Client) FetchMedicines(ctx context.Context) ([]domain.Medicine, error) {
+	recs, err := fetchAll[domain.Medicine](ctx, c, medicinesTable, url.Values{})
+	if err != nil {
 		return nil, err
 	}
-
 	var meds []domain.Medicine
-	for _, rec := range response.Records {
+	for _, rec := range recs {
 		m := rec.Fields
 		m.ID = rec.ID
 		meds = append(meds, m)
@@ -102,47 +105,13 @@ func (c *Client) FetchMedicines() ([]domain.Medicine, error) {
 }
 
 // FetchStockEntries retrieves all stock entry records from Airtable.
-func (c *Client) FetchStockEntries() ([]domain.StockEntry, error) {
-	url := fmt.Sprintf("%s/v0/%s/%s",
-		c.baseURL,
-		os.Getenv("AIRTABLE_BASE_ID"),
-		os.Getenv("AIRTABLE_ENTRIES_TABLE"))
-
-	req, err := http.NewRequest("GET", url, nil)
-	if err != nil {
-		return nil, err
-	}
-	req.Header.Set("Authorization", "Bearer "+os.Getenv("AIRTABLE_TOKEN"))
-
-	res, err := http.DefaultClient.Do(req)
+func (c *Client) FetchStockEntries(ctx context.Context) ([]domain.StockEntry, error) {
+	recs, err := fetchAll[domain.StockEntry](ctx, c, entriesTable, url.Values{})
 	if err != nil {
 		return nil, err
 	}
-	defer func() {
-		if cerr := res.Body.Close(); cerr != nil {
-			log.Printf("airtable response close error: %v", cerr)
-		}
-	}()
-
-	body, err := io.ReadAll(res.Body)
-	if err != nil {
-		return nil, err
-	}
-
-	var errCheck map[string]interface{}
-	if json.Unmarshal(body, &errCheck) == nil {
-		if errVal, exists := errCheck["error"]; exists {
-			return nil, fmt.Errorf("airtable error: %v", errVal)
-		}
-	}
-
-	var response airtableResponse[domain.StockEntry]
-	if err := json.Unmarshal(body, &response); err != nil {
-		return nil, err
-	}
-
 	var entries []domain.StockEntry
-	for _, rec := range response.Records {
+	for _, rec := range recs {
 		e := rec.Fields
 		e.ID = rec.ID
 		entries = append(entries, e)
@@ -150,61 +119,25 @@ func (c *Client) FetchStockEntries() ([]domain.StockEntry, error) {
 	return entries, nil
 }
 
-// CreateStockEntry adds a new stock entry record in Airtable.
-func (c *Client) CreateStockEntry(entry domain.StockEntry) error {
-	url := fmt.Sprintf("%s/v0/%s/%s",
-		c.baseURL,
-		os.Getenv("AIRTABLE_BASE_ID"),
-		os.Getenv("AIRTABLE_ENTRIES_TABLE"))
+// CreateStockEntry adds a new stock entry record in Airtable and returns it.
+func (c *Client) CreateStockEntry(ctx context.Context, entry domain.StockEntry) (domain.StockEntry, error) {
+	payload := map[string]any{"fields": stockEntryFields(entry)}
 
-	payload := map[string]any{
-		"fields": map[string]any{
-			"medicine_id": entry.MedicineID,
-			"quantity":    entry.Quantity,
-			"unit":        entry.Unit,
-			"date":        entry.Date.Format("2006-01-02"),
-		},
+	var rec airtableRecord[domain.StockEntry]
+	if err := c.doJSON(ctx, http.MethodPost, entriesTable, c.tableURL(entriesTable, ""), payload, &rec); err != nil {
+		return domain.StockEntry{}, err
 	}
-
-	body, err := json.Marshal(payload)
-	if err != nil {
-		return err
-	}
-
-	req, err := http.NewRequest("POST", url, bytes.NewReader(body))
-	if err != nil {
-		return err
-	}
-	req.Header.Set("Authorization", "Bearer "+os.Getenv("AIRTABLE_TOKEN"))
-	req.Header.Set("Content-Type", "application/json")
-
-	res, err := http.DefaultClient.Do(req)
-	if err != nil {
-		return err
-	}
-	defer func() {
-		if cerr := res.Body.Close(); cerr != nil {
-			log.Printf("airtable response close error: %v", cerr)
-		}
-	}()
-
-	if res.StatusCode >= 300 {
-		b, readErr := io.ReadAll(res.Body)
-		if readErr != nil {
-			return fmt.Errorf("airtable status %d read body error: %w", res.StatusCode, readErr)
-		}
-		return fmt.Errorf("airtable error: %s", string(b))
-	}
-
-	return nil
+	created := rec.Fields
+	created.ID = rec.ID
+	return created, nil
 }
 
 // UpdateForecastDate records the latest forecast date for a medicine in Airtable.
-func (c *Client) UpdateForecastDate(medicineID string, forecastDate, updatedAt time.Time) error {
+func (c *Client) UpdateForecastDate(ctx context.Context, medicineID string, forecastDate, updatedAt time.Time) error {
 	url := fmt.Sprintf("%s/v0/%s/%s/%s",
 		c.baseURL,
-		os.Getenv("AIRTABLE_BASE_ID"),
-		os.Getenv("AIRTABLE_MEDICINES_TABLE"),
+		c.cfg.BaseID,
+		c.cfg.MedicinesTable,
 		medicineID)
 
 	payload := map[string]any{
@@ -218,42 +151,41 @@ func (c *Client) UpdateForecastDate(medicineID string, forecastDate, updatedAt t
 	if err != nil {
 		return err
 	}
-	log.Printf("🧪 PATCH Airtable: recordID=%s body=%s", medicineID, string(body))
+	if body, err = c.toColumns(medicinesTable, body); err != nil {
+		return err
+	}
+	c.log().Debug(ctx, "airtable patch", "table", c.cfg.MedicinesTable, "record_id", medicineID, "body", string(body))
 
-	req, err := http.NewRequest("PATCH", url, bytes.NewReader(body))
+	req, err := http.NewRequestWithContext(ctx, "PATCH", url, bytes.NewReader(body))
 	if err != nil {
 		return err
 	}
-	req.Header.Set("Authorization", "Bearer "+os.Getenv("AIRTABLE_TOKEN"))
+	req.Header.Set("Authorization", "Bearer "+c.cfg.Token)
 	req.Header.Set("Content-Type", "application/json")
 
-	res, err := http.DefaultClient.Do(req)
+	res, err := c.do(req)
 	if err != nil {
 		return err
 	}
 	defer func() {
 		if cerr := res.Body.Close(); cerr != nil {
-			log.Printf("airtable response close error: %v", cerr)
+			c.log().Warn(ctx, "airtable response close failed", "error", cerr)
 		}
 	}()
 
-	if res.StatusCode >= 300 {
-		b, readErr := io.ReadAll(res.Body)
-		if readErr != nil {
-			return fmt.Errorf("airtable status %d read body error: %w", res.StatusCode, readErr)
-		}
-		return fmt.Errorf("airtable error: %s", string(b))
+	b, err := io.ReadAll(res.Body)
+	if err != nil {
+		return err
 	}
-
-	return nil
+	return responseError(req, res, b)
 }
 
 // UpdateMedicineLastAlertedDate saves the last alert date for a medicine.
-func (c *Client) UpdateMedicineLastAlertedDate(medicineID string, date time.Time) error {
+func (c *Client) UpdateMedicineLastAlertedDate(ctx context.Context, medicineID string, date time.Time) error {
 	url := fmt.Sprintf("%s/v0/%s/%s/%s",
 		c.baseURL,
-		os.Getenv("AIRTABLE_BASE_ID"),
-		os.Getenv("AIRTABLE_MEDICINES_TABLE"),
+		c.cfg.BaseID,
+		c.cfg.MedicinesTable,
 		medicineID)
 
 	payload := map[string]any{
@@ -266,22 +198,25 @@ func (c *Client) UpdateMedicineLastAlertedDate(medicineID string, date time.Time
 	if err != nil {
 		return err
 	}
-	log.Printf("🧪 PATCH Airtable: recordID=%s body=%s", medicineID, string(body))
+	if body, err = c.toColumns(medicinesTable, body); err != nil {
+		return err
+	}
+	c.log().Debug(ctx, "airtable patch", "table", c.cfg.MedicinesTable, "record_id", medicineID, "body", string(body))
 
-	req, err := http.NewRequest("PATCH", url, bytes.NewReader(body))
+	req, err := http.NewRequestWithContext(ctx, "PATCH", url, bytes.NewReader(body))
 	if err != nil {
 		return err
 	}
-	req.Header.Set("Authorization", "Bearer "+os.Getenv("AIRTABLE_TOKEN"))
+	req.Header.Set("Authorization", "Bearer "+c.cfg.Token)
 	req.Header.Set("Content-Type", "application/json")
 
-	res, err := http.DefaultClient.Do(req)
+	res, err := c.do(req)
 	if err != nil {
 		return err
 	}
 	defer func() {
 		if cerr := res.Body.Close(); cerr != nil {
-			log.Printf("airtable response close error: %v", cerr)
+			c.log().Warn(ctx, "airtable response close failed", "error", cerr)
 		}
 	}()
 
@@ -289,84 +224,55 @@ func (c *Client) UpdateMedicineLastAlertedDate(medicineID string, date time.Time
 	if readErr != nil {
 		return readErr
 	}
-	if res.StatusCode != http.StatusOK {
-		log.Printf("airtable update failed: status=%d body=%s", res.StatusCode, string(b))
-		return fmt.Errorf("airtable error: %s", string(b))
+	if err := responseError(req, res, b); err != nil {
+		c.log().Error(ctx, "airtable update failed", "record_id", medicineID, "error", err)
+		return err
 	}
 
-	log.Printf("🆗 updated last_alerted_date response=%s", string(b))
+	c.log().Debug(ctx, "last_alerted_date updated", "record_id", medicineID, "response", string(b))
 	return nil
 }
 
 type airtableFinancialFields struct {
-	Date              domain.FlexibleDate `json:"Date"`
-	NeedLabel         string              `json:"NeedLabel"`
-	NeedAmount        float64             `json:"NeedAmount"`
-	AmountContributed float64             `json:"AmountContributed"`
-	MonthTag          string              `json:"MonthTag"`
-	Contributor       string              `json:"Contributor"`
+	Date              domain.FlexibleDate `json:"date"`
+	NeedLabel         string              `json:"need_label"`
+	NeedAmount        float64             `json:"need_amount"`
+	AmountContributed float64             `json:"amount_contributed"`
+	MonthTag          string              `json:"month_tag"`
+	Contributor       string              `json:"contributor"`
+	Archived          bool                `json:"archived"`
 }
 
-// FetchFinancialEntries retrieves all financial entries for the given month.
-func (c *Client) FetchFinancialEntries(year int, month time.Month) ([]domain.FinancialEntry, error) {
-	query := url.QueryEscape(fmt.Sprintf("MonthTag=\"%04d-%02d\"", year, month))
-	url := fmt.Sprintf("%s/v0/%s/%s?filterByFormula=%s",
-		c.baseURL,
-		os.Getenv("AIRTABLE_BASE_ID"),
-		os.Getenv("AIRTABLE_FINANCIAL_TABLE"),
-		query)
-
-	req, err := http.NewRequest("GET", url, nil)
-	if err != nil {
-		return nil, err
-	}
-	req.Header.Set("Authorization", "Bearer "+os.Getenv("AIRTABLE_TOKEN"))
-
-	res, err := http.DefaultClient.Do(req)
-	if err != nil {
-		return nil, err
+func toFinancialEntry(rec airtableRecord[airtableFinancialFields]) domain.FinancialEntry {
+	f := rec.Fields
+	return domain.FinancialEntry{
+		ID:                rec.ID,
+		Date:              f.Date,
+		NeedLabel:         f.NeedLabel,
+		NeedAmount:        f.NeedAmount,
+		AmountContributed: f.AmountContributed,
+		MonthTag:          f.MonthTag,
+		Contributor:       f.Contributor,
+		Archived:          f.Archived,
 	}
-	defer func() {
-		if cerr := res.Body.Close(); cerr != nil {
-			log.Printf("airtable response close error: %v", cerr)
-		}
-	}()
+}
 
-	body, err := io.ReadAll(res.Body)
+// FetchFinancialEntries retrieves all financial entries for the given month.
+func (c *Client) FetchFinancialEntries(ctx context.Context, year int, month time.Month) ([]domain.FinancialEntry, error) {
+	q := url.Values{}
+	q.Set("filterByFormula", fmt.Sprintf("%s=\"%04d-%02d\"", c.formulaField(financialTable, "month_tag"), year, month))
+	recs, err := fetchAll[airtableFinancialFields](ctx, c, financialTable, q)
 	if err != nil {
 		return nil, err
 	}
-	log.Printf("🧾 Raw Airtable response: %s", string(body))
-
-	var errCheck map[string]interface{}
-	if json.Unmarshal(body, &errCheck) == nil {
-		if errVal, exists := errCheck["error"]; exists {
-			return nil, fmt.Errorf("airtable error: %v", errVal)
-		}
-	}
-
-	// ✅ Use intermediate field struct
-	var response airtableResponse[airtableFinancialFields]
-	if err := json.Unmarshal(body, &response); err != nil {
-		return nil, err
-	}
 
 	var entries []domain.FinancialEntry
-	for _, rec := range response.Records {
-		f := rec.Fields
+	for _, rec := range recs {
 		// ✅ Defensive filter for test stability
-		if f.MonthTag != fmt.Sprintf("%04d-%02d", year, month) {
+		if rec.Fields.MonthTag != fmt.Sprintf("%04d-%02d", year, month) {
 			continue
 		}
-		entries = append(entries, domain.FinancialEntry{
-			ID:                rec.ID,
-			Date:              f.Date,
-			NeedLabel:         f.NeedLabel,
-			NeedAmount:        f.NeedAmount,
-			AmountContributed: f.AmountContributed,
-			MonthTag:          f.MonthTag,
-			Contributor:       f.Contributor,
-		})
+		entries = append(entries, toFinancialEntry(rec))
 	}
 
 	return entries, nil
@@ -2,16 +2,20 @@ package airtable
 
 import (
 	"bytes"
+	"context"
+	"errors"
 	"fmt"
 	"io"
-	"log"
+	"log/slog"
 	"net/http"
 	"net/http/httptest"
 	"net/url"
-	"os"
 	"strings"
 	"testing"
 	"time"
+
+	"github.com/nomenarkt/vitaltrack/backend/internal/config"
+	"github.com/nomenarkt/vitaltrack/backend/internal/logger"
 )
 
 func TestUpdateMedicineLastAlertedDate(t *testing.T) {
@@ -36,24 +40,12 @@ func TestUpdateMedicineLastAlertedDate(t *testing.T) {
 	}))
 	defer srv.Close()
 
-	if err := os.Setenv("AIRTABLE_BASE_ID", baseID); err != nil {
-		t.Fatal(err)
-	}
-	if err := os.Setenv("AIRTABLE_MEDICINES_TABLE", table); err != nil {
-		t.Fatal(err)
-	}
-	if err := os.Setenv("AIRTABLE_TOKEN", "tok"); err != nil {
-		t.Fatal(err)
-	}
-
-	c := &Client{baseURL: srv.URL}
+	c := &Client{cfg: config.Airtable{BaseID: baseID, MedicinesTable: table, Token: "tok"}, baseURL: srv.URL}
 
 	var buf bytes.Buffer
-	orig := log.Writer()
-	log.SetOutput(&buf)
-	defer log.SetOutput(orig)
+	c.lg = logger.New(&buf, logger.Options{Level: slog.LevelDebug})
 
-	if err := c.UpdateMedicineLastAlertedDate(recID, date); err != nil {
+	if err := c.UpdateMedicineLastAlertedDate(context.Background(), recID, date); err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
 
@@ -65,7 +57,7 @@ func TestUpdateMedicineLastAlertedDate(t *testing.T) {
 		t.Errorf("request body missing date: %s", string(body))
 	}
 	logs := buf.String()
-	if !strings.Contains(logs, "PATCH Airtable") {
+	if !strings.Contains(logs, "airtable patch") {
 		t.Errorf("expected debug log, got %s", logs)
 	}
 	if !strings.Contains(logs, "response=") {
@@ -82,24 +74,12 @@ func TestUpdateMedicineLastAlertedDate_ignoresMismatch(t *testing.T) {
 	}))
 	defer srv.Close()
 
-	if err := os.Setenv("AIRTABLE_BASE_ID", "base"); err != nil {
-		t.Fatal(err)
-	}
-	if err := os.Setenv("AIRTABLE_MEDICINES_TABLE", "table"); err != nil {
-		t.Fatal(err)
-	}
-	if err := os.Setenv("AIRTABLE_TOKEN", "tok"); err != nil {
-		t.Fatal(err)
-	}
-
-	c := &Client{baseURL: srv.URL}
+	c := &Client{cfg: config.Airtable{BaseID: "base", MedicinesTable: "table", Token: "tok"}, baseURL: srv.URL}
 
 	var buf bytes.Buffer
-	orig := log.Writer()
-	log.SetOutput(&buf)
-	defer log.SetOutput(orig)
+	c.lg = logger.New(&buf, logger.Options{Level: slog.LevelDebug})
 
-	if err := c.UpdateMedicineLastAlertedDate("rec", date); err != nil {
+	if err := c.UpdateMedicineLastAlertedDate(context.Background(), "rec", date); err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
 
@@ -118,24 +98,12 @@ func TestUpdateMedicineLastAlertedDate_errorStatus(t *testing.T) {
 	}))
 	defer srv.Close()
 
-	if err := os.Setenv("AIRTABLE_BASE_ID", "bid"); err != nil {
-		t.Fatal(err)
-	}
-	if err := os.Setenv("AIRTABLE_MEDICINES_TABLE", "tab"); err != nil {
-		t.Fatal(err)
-	}
-	if err := os.Setenv("AIRTABLE_TOKEN", "tok"); err != nil {
-		t.Fatal(err)
-	}
-
-	c := &Client{baseURL: srv.URL}
+	c := &Client{cfg: config.Airtable{BaseID: "bid", MedicinesTable: "tab", Token: "tok"}, baseURL: srv.URL}
 
 	var buf bytes.Buffer
-	orig := log.Writer()
-	log.SetOutput(&buf)
-	defer log.SetOutput(orig)
+	c.lg = logger.New(&buf, logger.Options{Level: slog.LevelDebug})
 
-	err := c.UpdateMedicineLastAlertedDate("rec", date)
+	err := c.UpdateMedicineLastAlertedDate(context.Background(), "rec", date)
 	if err == nil {
 		t.Fatal("expected error")
 	}
@@ -166,24 +134,12 @@ func TestUpdateForecastDate(t *testing.T) {
 	}))
 	defer srv.Close()
 
-	if err := os.Setenv("AIRTABLE_BASE_ID", baseID); err != nil {
-		t.Fatal(err)
-	}
-	if err := os.Setenv("AIRTABLE_MEDICINES_TABLE", table); err != nil {
-		t.Fatal(err)
-	}
-	if err := os.Setenv("AIRTABLE_TOKEN", "tok"); err != nil {
-		t.Fatal(err)
-	}
-
-	c := &Client{baseURL: srv.URL}
+	c := &Client{cfg: config.Airtable{BaseID: baseID, MedicinesTable: table, Token: "tok"}, baseURL: srv.URL}
 
 	var buf bytes.Buffer
-	orig := log.Writer()
-	log.SetOutput(&buf)
-	defer log.SetOutput(orig)
+	c.lg = logger.New(&buf, logger.Options{Level: slog.LevelDebug})
 
-	if err := c.UpdateForecastDate(recID, forecast, updated); err != nil {
+	if err := c.UpdateForecastDate(context.Background(), recID, forecast, updated); err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
 
@@ -192,7 +148,7 @@ func TestUpdateForecastDate(t *testing.T) {
 		t.Errorf("path = %s, want %s", path, expPath)
 	}
 	logs := buf.String()
-	if !strings.Contains(logs, "PATCH Airtable") {
+	if !strings.Contains(logs, "airtable patch") {
 		t.Errorf("expected debug log, got %s", logs)
 	}
 	if !bytes.Contains(body, []byte(forecast.Format("2006-01-02"))) {
@@ -211,18 +167,8 @@ func TestFetchMedicines_AssignsID(t *testing.T) {
 	}))
 	defer srv.Close()
 
-	if err := os.Setenv("AIRTABLE_BASE_ID", "base"); err != nil {
-		t.Fatal(err)
-	}
-	if err := os.Setenv("AIRTABLE_MEDICINES_TABLE", "table"); err != nil {
-		t.Fatal(err)
-	}
-	if err := os.Setenv("AIRTABLE_TOKEN", "tok"); err != nil {
-		t.Fatal(err)
-	}
-
-	c := &Client{baseURL: srv.URL}
-	meds, err := c.FetchMedicines()
+	c := &Client{cfg: config.Airtable{BaseID: "base", MedicinesTable: "table", Token: "tok"}, baseURL: srv.URL}
+	meds, err := c.FetchMedicines(context.Background())
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
@@ -231,6 +177,42 @@ func TestFetchMedicines_AssignsID(t *testing.T) {
 	}
 }
 
+func TestFetch_followsPagination(t *testing.T) {
+	var queries []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		queries = append(queries, r.URL.RawQuery)
+		body := `{"records":[{"id":"rec1","fields":{"name":"A","MonthTag":"2025-06"}}],"offset":"next"}`
+		if r.URL.Query().Get("offset") == "next" {
+			body = `{"records":[{"id":"rec2","fields":{"name":"B","MonthTag":"2025-06"}}]}`
+		}
+		if _, err := fmt.Fprint(w, body); err != nil {
+			t.Fatalf("write response: %v", err)
+		}
+	}))
+	defer srv.Close()
+
+	c := &Client{cfg: config.Airtable{BaseID: "base", MedicinesTable: "med", EntriesTable: "ent", FinancialTable: "fin", Token: "tok"}, baseURL: srv.URL}
+	ctx := context.Background()
+	counts := map[string]func() (int, error){
+		"medicines": func() (int, error) { r, err := c.FetchMedicines(ctx); return len(r), err },
+		"entries":   func() (int, error) { r, err := c.FetchStockEntries(ctx); return len(r), err },
+		"financial": func() (int, error) { r, err := c.FetchFinancialEntries(ctx, 2025, time.June); return len(r), err },
+	}
+	for name, fetch := range counts {
+		queries = nil
+		n, err := fetch()
+		if err != nil {
+			t.Fatalf("%s: unexpected error: %v", name, err)
+		}
+		if n != 2 || len(queries) != 2 {
+			t.Errorf("%s: %d records in %d requests, want 2 in 2", name, n, len(queries))
+		}
+		if name == "financial" && !strings.Contains(queries[1], "filterByFormula") {
+			t.Errorf("second page dropped the filter: %s", queries[1])
+		}
+	}
+}
+
 func TestFetchFinancialEntries(t *testing.T) {
 	var query string
 	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
@@ -241,23 +223,13 @@ func TestFetchFinancialEntries(t *testing.T) {
 	}))
 	defer srv.Close()
 
-	if err := os.Setenv("AIRTABLE_BASE_ID", "base"); err != nil {
-		t.Fatal(err)
-	}
-	if err := os.Setenv("AIRTABLE_FINANCIAL_TABLE", "fin"); err != nil {
-		t.Fatal(err)
-	}
-	if err := os.Setenv("AIRTABLE_TOKEN", "tok"); err != nil {
-		t.Fatal(err)
-	}
-
-	c := &Client{baseURL: srv.URL}
-	entries, err := c.FetchFinancialEntries(2025, time.June)
+	c := &Client{cfg: config.Airtable{BaseID: "base", FinancialTable: "fin", Token: "tok"}, baseURL: srv.URL}
+	entries, err := c.FetchFinancialEntries(context.Background(), 2025, time.June)
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
 
-	expectedQuery := "filterByFormula=" + url.QueryEscape(`MonthTag="2025-06"`)
+	expectedQuery := "filterByFormula=" + url.QueryEscape(`{MonthTag}="2025-06"`)
 	if query != expectedQuery {
 		t.Errorf("query = %s, want %s", query, expectedQuery)
 	}
@@ -274,21 +246,16 @@ func TestFetchFinancialEntries_fields(t *testing.T) {
 	}))
 	defer srv.Close()
 
-	if err := os.Setenv("AIRTABLE_BASE_ID", "base"); err != nil {
-		t.Fatal(err)
-	}
-	if err := os.Setenv("AIRTABLE_FINANCIAL_TABLE", "fin"); err != nil {
-		t.Fatal(err)
-	}
-	if err := os.Setenv("AIRTABLE_TOKEN", "tok"); err != nil {
-		t.Fatal(err)
-	}
-
-	c := &Client{baseURL: srv.URL}
-	entries, err := c.FetchFinancialEntries(2025, time.August)
+	var buf bytes.Buffer
+	c := &Client{cfg: config.Airtable{BaseID: "base", FinancialTable: "fin", Token: "tok"}, baseURL: srv.URL}
+	c.lg = logger.New(&buf, logger.Options{Level: slog.LevelDebug})
+	entries, err := c.FetchFinancialEntries(context.Background(), 2025, time.August)
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
+	if strings.Contains(buf.String(), "Bob") {
+		t.Errorf("contributor logged: %s", buf.String())
+	}
 	if len(entries) != 1 {
 		t.Fatalf("expected 1 entry, got %d", len(entries))
 	}
@@ -309,18 +276,8 @@ func TestFetchFinancialEntries_zeroContribution(t *testing.T) {
 	}))
 	defer srv.Close()
 
-	if err := os.Setenv("AIRTABLE_BASE_ID", "base"); err != nil {
-		t.Fatal(err)
-	}
-	if err := os.Setenv("AIRTABLE_FINANCIAL_TABLE", "fin"); err != nil {
-		t.Fatal(err)
-	}
-	if err := os.Setenv("AIRTABLE_TOKEN", "tok"); err != nil {
-		t.Fatal(err)
-	}
-
-	c := &Client{baseURL: srv.URL}
-	entries, err := c.FetchFinancialEntries(2025, time.September)
+	c := &Client{cfg: config.Airtable{BaseID: "base", FinancialTable: "fin", Token: "tok"}, baseURL: srv.URL}
+	entries, err := c.FetchFinancialEntries(context.Background(), 2025, time.September)
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
@@ -331,3 +288,22 @@ func TestFetchFinancialEntries_zeroContribution(t *testing.T) {
 		t.Fatalf("expected zero contribution, got %v", entries[0].AmountContributed)
 	}
 }
+
+func TestFetchMedicines_cancelledContext(t *testing.T) {
+	var hits int
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		hits++
+		_, _ = fmt.Fprint(w, `{"records":[]}`)
+	}))
+	defer srv.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	c := &Client{cfg: config.Airtable{BaseID: "bid", MedicinesTable: "tab", Token: "tok"}, baseURL: srv.URL}
+	if _, err := c.FetchMedicines(ctx); !errors.Is(err, context.Canceled) {
+		t.Errorf("err = %v, want context.Canceled", err)
+	}
+	if hits != 0 {
+		t.Errorf("%d requests reached Airtable after cancel", hits)
+	}
+}
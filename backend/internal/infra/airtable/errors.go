@@ -0,0 +1,80 @@
+package airtable
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/nomenarkt/vitaltrack/backend/internal/domain"
+)
+
+// Errors an *Error wraps, by status code, so that callers can branch with
+// errors.Is.
+var (
+	// ErrNotFound is domain.ErrNotFound: the record or table does not exist.
+	ErrNotFound = domain.ErrNotFound
+	// ErrUnauthorized means the token is invalid or may not access the base.
+	ErrUnauthorized = errors.New("airtable: unauthorized")
+	// ErrRateLimited means Airtable still answered 429 after the retries.
+	ErrRateLimited = errors.New("airtable: rate limited")
+)
+
+// Error is a non-2xx answer from the Airtable API.
+type Error struct {
+	Method  string
+	Table   string
+	Status  int
+	Type    string // Airtable error type, e.g. INVALID_PERMISSIONS_OR_MODEL_NOT_FOUND
+	Message string
+}
+
+func (e *Error) Error() string {
+	msg := e.Type
+	if e.Message != "" {
+		msg += ": " + e.Message
+	}
+	return fmt.Sprintf("airtable %s %s: status %d: %s", e.Method, e.Table, e.Status, msg)
+}
+
+// Unwrap returns ErrNotFound, ErrUnauthorized or ErrRateLimited according
+// to the status code, or nil.
+func (e *Error) Unwrap() error {
+	switch e.Status {
+	case http.StatusNotFound:
+		return ErrNotFound
+	case http.StatusUnauthorized, http.StatusForbidden:
+		return ErrUnauthorized
+	case http.StatusTooManyRequests:
+		return ErrRateLimited
+	}
+	return nil
+}
+
+// responseError returns the *Error for a response to req with a non-2xx
+// status, or nil.
+func responseError(req *http.Request, res *http.Response, body []byte) error {
+	if res.StatusCode >= 200 && res.StatusCode < 300 {
+		return nil
+	}
+	e := &Error{Method: req.Method, Table: tableOf(req.URL.Path), Status: res.StatusCode}
+
+	// Airtable sends {"error": "NOT_FOUND"} or
+	// {"error": {"type": "...", "message": "..."}}.
+	var envelope struct {
+		Error json.RawMessage `json:"error"`
+	}
+	if json.Unmarshal(body, &envelope) == nil && len(envelope.Error) > 0 {
+		var detail struct {
+			Type    string `json:"type"`
+			Message string `json:"message"`
+		}
+		if json.Unmarshal(envelope.Error, &e.Type) != nil && json.Unmarshal(envelope.Error, &detail) == nil {
+			e.Type, e.Message = detail.Type, detail.Message
+		}
+	}
+	if e.Type == "" {
+		e.Type = http.StatusText(res.StatusCode)
+	}
+	return e
+}
@@ -0,0 +1,50 @@
+package airtable
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestResponseError(t *testing.T) {
+	tests := []struct {
+		name    string
+		status  int
+		body    string
+		wantMsg string
+		wantIs  error
+	}{
+		{"ok", 200, `{"records":[]}`, "", nil},
+		{"not_found", 404, `{"error":"NOT_FOUND"}`, "airtable GET meds: status 404: NOT_FOUND", ErrNotFound},
+		{"forbidden", 403, `{"error":{"type":"INVALID_PERMISSIONS_OR_MODEL_NOT_FOUND","message":"Invalid permissions"}}`,
+			"airtable GET meds: status 403: INVALID_PERMISSIONS_OR_MODEL_NOT_FOUND: Invalid permissions", ErrUnauthorized},
+		{"rate_limited", 429, ``, "airtable GET meds: status 429: Too Many Requests", ErrRateLimited},
+		{"invalid", 422, `{"error":{"type":"INVALID_VALUE_FOR_COLUMN","message":"bad"}}`,
+			"airtable GET meds: status 422: INVALID_VALUE_FOR_COLUMN: bad", nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/v0/base/meds/rec1", nil)
+			err := responseError(req, &http.Response{StatusCode: tt.status}, []byte(tt.body))
+			if tt.wantMsg == "" {
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				return
+			}
+			if err == nil || err.Error() != tt.wantMsg {
+				t.Fatalf("err = %v, want %s", err, tt.wantMsg)
+			}
+			var ae *Error
+			if !errors.As(err, &ae) || ae.Status != tt.status {
+				t.Errorf("err is not an *Error with status %d", tt.status)
+			}
+			for _, sentinel := range []error{ErrNotFound, ErrUnauthorized, ErrRateLimited} {
+				if got := errors.Is(err, sentinel); got != (sentinel == tt.wantIs) {
+					t.Errorf("errors.Is(err, %v) = %v", sentinel, got)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,247 @@
+package airtable
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+)
+
+// table identifies one of the tables set in config.Airtable. Its value is
+// the key of the table in config.Airtable.Fields.
+type table string
+
+const (
+	medicinesTable table = "medicines"
+	entriesTable   table = "entries"
+	financialTable table = "financial"
+	alertsTable    table = "alerts"
+	adherenceTable table = "adherence"
+)
+
+// field is a column the client reads or writes. The code refers to it by
+// name; the column it maps to defaults to column and can be changed in
+// config.Airtable.Fields.
+type field struct {
+	name   string
+	column string
+	types  []string // Airtable field types the column may have
+}
+
+// Airtable field types accepted for each kind of value.
+var (
+	textTypes     = []string{"singleLineText", "multilineText", "richText", "singleSelect", "email", "phoneNumber", "url"}
+	numberTypes   = []string{"number", "currency", "percent", "decimal"}
+	dateTypes     = []string{"date", "dateTime"}
+	dateTimeTypes = []string{"dateTime", "createdTime", "lastModifiedTime"}
+	checkbox      = []string{"checkbox"}
+	recordLinks   = []string{"multipleRecordLinks"}
+)
+
+// fields lists the columns of each table.
+var fields = map[table][]field{
+	medicinesTable: {
+		{"name", "name", textTypes},
+		{"unit_type", "unit_type", textTypes},
+		{"unit_per_box", "unit_per_box", numberTypes},
+		{"units", "units", textTypes},
+		{"daily_dose", "daily_dose", numberTypes},
+		{"start_date", "start_date", dateTypes},
+		{"initial_stock", "initial_stock", numberTypes},
+		{"forecast_out_of_stock_date", "forecast_out_of_stock_date", dateTypes},
+		{"forecast_last_updated", "forecast_last_updated", dateTypes},
+		{"last_alerted_date", "last_alerted_date", dateTypes},
+		{"patient", "patient", textTypes},
+		{"dose_times", "dose_times", textTypes},
+		{"archived", "archived", checkbox},
+	},
+	entriesTable: {
+		{"medicine_id", "medicine_id", recordLinks},
+		{"kind", "kind", textTypes},
+		{"quantity", "quantity", numberTypes},
+		{"unit", "unit", textTypes},
+		{"date", "date", dateTypes},
+		{"reason", "reason", textTypes},
+		{"archived", "archived", checkbox},
+	},
+	financialTable: {
+		{"date", "Date", dateTypes},
+		{"need_label", "NeedLabel", textTypes},
+		{"need_amount", "NeedAmount", numberTypes},
+		{"amount_contributed", "AmountContributed", numberTypes},
+		{"month_tag", "MonthTag", append([]string{"formula"}, textTypes...)},
+		{"contributor", "Contributor", textTypes},
+		{"archived", "Archived", checkbox},
+	},
+	alertsTable: {
+		{"key", "key", textTypes},
+		{"kind", "kind", textTypes},
+		{"medicine_id", "medicine_id", textTypes},
+		{"medicine_name", "medicine_name", textTypes},
+		{"payload", "payload", textTypes},
+		{"channel", "channel", textTypes},
+		{"status", "status", textTypes},
+		{"attempts", "attempts", numberTypes},
+		{"last_error", "last_error", textTypes},
+		{"created_at", "created_at", dateTimeTypes},
+		{"updated_at", "updated_at", dateTimeTypes},
+	},
+	adherenceTable: {
+		{"key", "key", textTypes},
+		{"medicine_id", "medicine_id", textTypes},
+		{"medicine_name", "medicine_name", textTypes},
+		{"patient", "patient", textTypes},
+		{"scheduled", "scheduled", dateTimeTypes},
+		{"pills", "pills", numberTypes},
+		{"status", "status", textTypes},
+		{"chat_id", "chat_id", textTypes},
+		{"responded_at", "responded_at", dateTimeTypes},
+		{"created_at", "created_at", dateTimeTypes},
+	},
+}
+
+// tableOrder is the order tables are checked and reported in.
+var tableOrder = []table{medicinesTable, entriesTable, financialTable, alertsTable, adherenceTable}
+
+// CheckFields reports the entries of a config.Airtable.Fields mapping that
+// name an unknown table or field, and columns mapped to two fields.
+func CheckFields(mapping map[string]map[string]string) error {
+	var errs []error
+	for _, t := range slices.Sorted(maps.Keys(mapping)) {
+		known, ok := fields[table(t)]
+		if !ok {
+			errs = append(errs, fmt.Errorf("unknown table %q, want one of %s", t, joinTables()))
+			continue
+		}
+		for _, f := range slices.Sorted(maps.Keys(mapping[t])) {
+			if !slices.ContainsFunc(known, func(k field) bool { return k.name == f }) {
+				errs = append(errs, fmt.Errorf("unknown field %s.%s", t, f))
+			} else if mapping[t][f] == "" {
+				errs = append(errs, fmt.Errorf("field %s.%s is mapped to an empty column", t, f))
+			}
+		}
+		owner := map[string]string{}
+		for _, f := range known {
+			col, ok := mapping[t][f.name]
+			if !ok {
+				col = f.column
+			}
+			if other, taken := owner[col]; taken && col != "" {
+				errs = append(errs, fmt.Errorf("column %q of table %s is mapped to both %s and %s", col, t, other, f.name))
+				continue
+			}
+			owner[col] = f.name
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func joinTables() string {
+	names := make([]string, len(tableOrder))
+	for i, t := range tableOrder {
+		names[i] = string(t)
+	}
+	return strings.Join(names, ", ")
+}
+
+// column returns the column that holds the field name of t.
+func (c *Client) column(t table, name string) string {
+	if col := c.cfg.Fields[string(t)][name]; col != "" {
+		return col
+	}
+	for _, f := range fields[t] {
+		if f.name == name {
+			return f.column
+		}
+	}
+	return name
+}
+
+// columns returns the columns of the fields of t by field name, and the
+// field names by column.
+func (c *Client) columns(t table) (byField, byColumn map[string]string) {
+	byField = make(map[string]string, len(fields[t]))
+	byColumn = make(map[string]string, len(fields[t]))
+	for _, f := range fields[t] {
+		col := c.column(t, f.name)
+		byField[f.name] = col
+		byColumn[col] = f.name
+	}
+	return byField, byColumn
+}
+
+// toColumns renames the fields of the request body b, which holds a record
+// or a list of records, to the columns of t.
+func (c *Client) toColumns(t table, b []byte) ([]byte, error) {
+	byField, _ := c.columns(t)
+	return renameFields(b, byField)
+}
+
+// toFields renames the columns of the response body b, which holds a record
+// or a list of records, to the fields of t. Columns the client does not use
+// are dropped.
+func (c *Client) toFields(t table, b []byte) ([]byte, error) {
+	_, byColumn := c.columns(t)
+	return renameFields(b, byColumn)
+}
+
+// formulaField returns the reference to the field name of t in a formula.
+func (c *Client) formulaField(t table, name string) string {
+	return "{" + c.column(t, name) + "}"
+}
+
+// renameFields renames the keys of the "fields" object of b, or of each
+// record in its "records" list, with names. Keys missing from names are
+// dropped; the rest of b is kept.
+func renameFields(b []byte, names map[string]string) ([]byte, error) {
+	var msg map[string]json.RawMessage
+	if err := json.Unmarshal(b, &msg); err != nil {
+		return nil, err
+	}
+	if f, ok := msg["fields"]; ok {
+		renamed, err := renameKeys(f, names)
+		if err != nil {
+			return nil, err
+		}
+		msg["fields"] = renamed
+	}
+	if r, ok := msg["records"]; ok {
+		var recs []map[string]json.RawMessage
+		if err := json.Unmarshal(r, &recs); err != nil {
+			return nil, err
+		}
+		for _, rec := range recs {
+			f, ok := rec["fields"]
+			if !ok {
+				continue
+			}
+			renamed, err := renameKeys(f, names)
+			if err != nil {
+				return nil, err
+			}
+			rec["fields"] = renamed
+		}
+		renamed, err := json.Marshal(recs)
+		if err != nil {
+			return nil, err
+		}
+		msg["records"] = renamed
+	}
+	return json.Marshal(msg)
+}
+
+func renameKeys(obj json.RawMessage, names map[string]string) (json.RawMessage, error) {
+	var in map[string]json.RawMessage
+	if err := json.Unmarshal(obj, &in); err != nil {
+		return nil, err
+	}
+	out := make(map[string]json.RawMessage, len(in))
+	for k, v := range in {
+		if name, ok := names[k]; ok {
+			out[name] = v
+		}
+	}
+	return json.Marshal(out)
+}
@@ -0,0 +1,101 @@
+package airtable
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/nomenarkt/vitaltrack/backend/internal/domain"
+)
+
+func TestCheckFields(t *testing.T) {
+	tests := []struct {
+		name    string
+		mapping map[string]map[string]string
+		wantErr []string
+	}{
+		{name: "empty"},
+		{name: "renamed", mapping: map[string]map[string]string{"medicines": {"daily_dose": "Daily dose"}, "financial": {"need_label": "Besoin"}}},
+		{name: "swapped", mapping: map[string]map[string]string{"entries": {"unit": "date", "date": "unit"}}},
+		{name: "unknown_table", mapping: map[string]map[string]string{"stock": {"name": "Name"}}, wantErr: []string{`unknown table "stock"`}},
+		{name: "unknown_field", mapping: map[string]map[string]string{"medicines": {"dose": "Dose"}}, wantErr: []string{"unknown field medicines.dose"}},
+		{name: "empty_column", mapping: map[string]map[string]string{"alerts": {"key": ""}}, wantErr: []string{"alerts.key is mapped to an empty column"}},
+		{name: "shared_column", mapping: map[string]map[string]string{"medicines": {"patient": "name"}}, wantErr: []string{`column "name" of table medicines is mapped to both name and patient`}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := CheckFields(tt.mapping)
+			if len(tt.wantErr) == 0 {
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				return
+			}
+			if err == nil {
+				t.Fatal("expected error")
+			}
+			for _, want := range tt.wantErr {
+				if !strings.Contains(err.Error(), want) {
+					t.Errorf("error %q lacks %q", err, want)
+				}
+			}
+		})
+	}
+}
+
+func TestClient_mapsFields(t *testing.T) {
+	var sent map[string]any
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodPost {
+			var body struct {
+				Fields map[string]any `json:"fields"`
+			}
+			b, _ := io.ReadAll(r.Body)
+			if err := json.Unmarshal(b, &body); err != nil {
+				t.Errorf("decode body: %v", err)
+			}
+			sent = body.Fields
+		}
+		_, _ = fmt.Fprint(w, `{"records":[{"id":"rec1","fields":{"Nom":"MedA","Dose":2,"daily_dose":9}}],"id":"rec1","fields":{"Nom":"MedA","Dose":2}}`)
+	}))
+	defer srv.Close()
+
+	cfg := testConfig
+	cfg.Fields = map[string]map[string]string{"medicines": {"name": "Nom", "daily_dose": "Dose"}}
+	c := &Client{cfg: cfg, baseURL: srv.URL}
+
+	meds, err := c.FetchMedicines(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(meds) != 1 || meds[0].Name != "MedA" || meds[0].DailyDose != 2 {
+		t.Errorf("medicines = %+v, want the mapped columns read", meds)
+	}
+
+	if _, err := c.CreateMedicine(context.Background(), domain.Medicine{Name: "MedB", DailyDose: 1}); err != nil {
+		t.Fatal(err)
+	}
+	if sent["Nom"] != "MedB" || sent["Dose"] != float64(1) {
+		t.Errorf("sent fields = %v, want the mapped columns", sent)
+	}
+	if _, ok := sent["name"]; ok {
+		t.Errorf("field name sent under its default column: %v", sent)
+	}
+}
+
+func TestRenameFields(t *testing.T) {
+	names := map[string]string{"A": "a"}
+	got, err := renameFields([]byte(`{"records":[{"id":"r1","fields":{"A":1,"B":2}},{"id":"r2"}],"offset":"next"}`), names)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `{"offset":"next","records":[{"fields":{"a":1},"id":"r1"},{"id":"r2"}]}`
+	if string(got) != want {
+		t.Errorf("renameFields = %s, want %s", got, want)
+	}
+}
@@ -0,0 +1,341 @@
+package airtable
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+
+	"github.com/nomenarkt/vitaltrack/backend/internal/domain"
+)
+
+// tableName returns the name of t in the base.
+func (c *Client) tableName(t table) string {
+	switch t {
+	case medicinesTable:
+		return c.cfg.MedicinesTable
+	case entriesTable:
+		return c.cfg.EntriesTable
+	case financialTable:
+		return c.cfg.FinancialTable
+	case alertsTable:
+		return c.cfg.AlertsTable
+	case adherenceTable:
+		return c.cfg.AdherenceTable
+	}
+	return ""
+}
+
+// tableURL builds the REST endpoint for a table, or for one of its records
+// when recordID is not empty.
+func (c *Client) tableURL(t table, recordID string) string {
+	u := fmt.Sprintf("%s/v0/%s/%s", c.baseURL, c.cfg.BaseID, url.PathEscape(c.tableName(t)))
+	if recordID != "" {
+		u += "/" + url.PathEscape(recordID)
+	}
+	return u
+}
+
+// doJSON sends payload (if any) as JSON and decodes the response into out (if
+// any). The record fields in both are renamed between the field names of t
+// and its columns; nothing is renamed when t is empty. A non-2xx answer is
+// returned as an *Error.
+func (c *Client) doJSON(ctx context.Context, method string, t table, endpoint string, payload, out any) error {
+	var reqBody io.Reader
+	if payload != nil {
+		b, err := json.Marshal(payload)
+		if err != nil {
+			return err
+		}
+		if t != "" {
+			if b, err = c.toColumns(t, b); err != nil {
+				return err
+			}
+		}
+		reqBody = bytes.NewReader(b)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, endpoint, reqBody)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", "Bearer "+c.cfg.Token)
+	if payload != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	res, err := c.do(req)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if cerr := res.Body.Close(); cerr != nil {
+			c.log().Warn(ctx, "airtable response close failed", "error", cerr)
+		}
+	}()
+
+	body, err := io.ReadAll(res.Body)
+	if err != nil {
+		return err
+	}
+	if err := responseError(req, res, body); err != nil {
+		return err
+	}
+	if out == nil {
+		return nil
+	}
+	if t != "" {
+		if body, err = c.toFields(t, body); err != nil {
+			return err
+		}
+	}
+	return json.Unmarshal(body, out)
+}
+
+// call is doJSON for the endpoints that carry no records, such as those of
+// the metadata and webhooks APIs.
+func (c *Client) call(ctx context.Context, method, endpoint string, payload, out any) error {
+	return c.doJSON(ctx, method, "", endpoint, payload, out)
+}
+
+func medicineFields(m domain.Medicine) map[string]any {
+	fields := map[string]any{
+		"name":          m.Name,
+		"unit_type":     m.UnitType,
+		"unit_per_box":  m.UnitPerBox,
+		"daily_dose":    m.DailyDose,
+		"initial_stock": m.InitialStock,
+	}
+	if !m.StartDate.IsZero() {
+		fields["start_date"] = m.StartDate.Format("2006-01-02")
+	}
+	if m.Units != "" {
+		fields["units"] = m.Units
+	}
+	if m.Patient != "" {
+		fields["patient"] = m.Patient
+	}
+	if len(m.DoseTimes) > 0 {
+		fields["dose_times"] = m.DoseTimes.String()
+	}
+	if m.Archived {
+		fields["archived"] = true
+	}
+	return fields
+}
+
+func medicinePatchFields(p domain.MedicinePatch) map[string]any {
+	fields := map[string]any{}
+	if p.Name != nil {
+		fields["name"] = *p.Name
+	}
+	if p.UnitType != nil {
+		fields["unit_type"] = *p.UnitType
+	}
+	if p.UnitPerBox != nil {
+		fields["unit_per_box"] = *p.UnitPerBox
+	}
+	if p.Units != nil {
+		fields["units"] = *p.Units
+	}
+	if p.DailyDose != nil {
+		fields["daily_dose"] = *p.DailyDose
+	}
+	if p.StartDate != nil {
+		fields["start_date"] = p.StartDate.Format("2006-01-02")
+	}
+	if p.InitialStock != nil {
+		fields["initial_stock"] = *p.InitialStock
+	}
+	if p.Patient != nil {
+		fields["patient"] = *p.Patient
+	}
+	if p.DoseTimes != nil {
+		fields["dose_times"] = domain.DoseTimes(*p.DoseTimes).String()
+	}
+	if p.Archived != nil {
+		fields["archived"] = *p.Archived
+	}
+	return fields
+}
+
+func stockEntryFields(e domain.StockEntry) map[string]any {
+	fields := map[string]any{
+		"medicine_id": e.MedicineID,
+		"quantity":    e.Quantity,
+		"unit":        e.Unit,
+		"date":        e.Date.Format("2006-01-02"),
+	}
+	if !e.IsRefill() {
+		fields["kind"] = e.Kind
+	}
+	if e.Reason != "" {
+		fields["reason"] = e.Reason
+	}
+	if e.Archived {
+		fields["archived"] = true
+	}
+	return fields
+}
+
+func stockEntryPatchFields(p domain.StockEntryPatch) map[string]any {
+	fields := map[string]any{}
+	if p.MedicineID != nil {
+		fields["medicine_id"] = []string{*p.MedicineID}
+	}
+	if p.Kind != nil {
+		fields["kind"] = *p.Kind
+	}
+	if p.Quantity != nil {
+		fields["quantity"] = *p.Quantity
+	}
+	if p.Unit != nil {
+		fields["unit"] = *p.Unit
+	}
+	if p.Reason != nil {
+		fields["reason"] = *p.Reason
+	}
+	if p.Date != nil {
+		fields["date"] = p.Date.Format("2006-01-02")
+	}
+	if p.Archived != nil {
+		fields["archived"] = *p.Archived
+	}
+	return fields
+}
+
+func financialEntryFields(e domain.FinancialEntry) map[string]any {
+	fields := map[string]any{
+		"date":               e.Date.Format("2006-01-02"),
+		"need_label":         e.NeedLabel,
+		"need_amount":        e.NeedAmount,
+		"amount_contributed": e.AmountContributed,
+		"month_tag":          e.MonthTag,
+		"contributor":        e.Contributor,
+	}
+	if e.Archived {
+		fields["archived"] = true
+	}
+	return fields
+}
+
+func financialEntryPatchFields(p domain.FinancialEntryPatch) map[string]any {
+	fields := map[string]any{}
+	if p.Date != nil {
+		fields["date"] = p.Date.Format("2006-01-02")
+	}
+	if p.NeedLabel != nil {
+		fields["need_label"] = *p.NeedLabel
+	}
+	if p.NeedAmount != nil {
+		fields["need_amount"] = *p.NeedAmount
+	}
+	if p.AmountContributed != nil {
+		fields["amount_contributed"] = *p.AmountContributed
+	}
+	if p.MonthTag != nil {
+		fields["month_tag"] = *p.MonthTag
+	}
+	if p.Contributor != nil {
+		fields["contributor"] = *p.Contributor
+	}
+	if p.Archived != nil {
+		fields["archived"] = *p.Archived
+	}
+	return fields
+}
+
+// GetMedicine retrieves a single medicine record.
+func (c *Client) GetMedicine(ctx context.Context, id string) (domain.Medicine, error) {
+	var rec airtableRecord[domain.Medicine]
+	if err := c.doJSON(ctx, http.MethodGet, medicinesTable, c.tableURL(medicinesTable, id), nil, &rec); err != nil {
+		return domain.Medicine{}, err
+	}
+	m := rec.Fields
+	m.ID = rec.ID
+	return m, nil
+}
+
+// CreateMedicine adds a medicine record and returns it with its Airtable ID.
+func (c *Client) CreateMedicine(ctx context.Context, m domain.Medicine) (domain.Medicine, error) {
+	payload := map[string]any{"fields": medicineFields(m)}
+
+	var rec airtableRecord[domain.Medicine]
+	if err := c.doJSON(ctx, http.MethodPost, medicinesTable, c.tableURL(medicinesTable, ""), payload, &rec); err != nil {
+		return domain.Medicine{}, err
+	}
+	created := rec.Fields
+	created.ID = rec.ID
+	return created, nil
+}
+
+// UpdateMedicine applies patch to a medicine record and returns the result.
+func (c *Client) UpdateMedicine(ctx context.Context, id string, patch domain.MedicinePatch) (domain.Medicine, error) {
+	payload := map[string]any{"fields": medicinePatchFields(patch)}
+
+	var rec airtableRecord[domain.Medicine]
+	if err := c.doJSON(ctx, http.MethodPatch, medicinesTable, c.tableURL(medicinesTable, id), payload, &rec); err != nil {
+		return domain.Medicine{}, err
+	}
+	updated := rec.Fields
+	updated.ID = rec.ID
+	return updated, nil
+}
+
+// GetStockEntry retrieves a single stock entry record.
+func (c *Client) GetStockEntry(ctx context.Context, id string) (domain.StockEntry, error) {
+	var rec airtableRecord[domain.StockEntry]
+	if err := c.doJSON(ctx, http.MethodGet, entriesTable, c.tableURL(entriesTable, id), nil, &rec); err != nil {
+		return domain.StockEntry{}, err
+	}
+	e := rec.Fields
+	e.ID = rec.ID
+	return e, nil
+}
+
+// UpdateStockEntry applies patch to a stock entry record and returns the result.
+func (c *Client) UpdateStockEntry(ctx context.Context, id string, patch domain.StockEntryPatch) (domain.StockEntry, error) {
+	payload := map[string]any{"fields": stockEntryPatchFields(patch)}
+
+	var rec airtableRecord[domain.StockEntry]
+	if err := c.doJSON(ctx, http.MethodPatch, entriesTable, c.tableURL(entriesTable, id), payload, &rec); err != nil {
+		return domain.StockEntry{}, err
+	}
+	updated := rec.Fields
+	updated.ID = rec.ID
+	return updated, nil
+}
+
+// GetFinancialEntry retrieves a single financial entry record.
+func (c *Client) GetFinancialEntry(ctx context.Context, id string) (domain.FinancialEntry, error) {
+	var rec airtableRecord[airtableFinancialFields]
+	if err := c.doJSON(ctx, http.MethodGet, financialTable, c.tableURL(financialTable, id), nil, &rec); err != nil {
+		return domain.FinancialEntry{}, err
+	}
+	return toFinancialEntry(rec), nil
+}
+
+// CreateFinancialEntry adds a financial entry record and returns it.
+func (c *Client) CreateFinancialEntry(ctx context.Context, e domain.FinancialEntry) (domain.FinancialEntry, error) {
+	payload := map[string]any{"fields": financialEntryFields(e)}
+
+	var rec airtableRecord[airtableFinancialFields]
+	if err := c.doJSON(ctx, http.MethodPost, financialTable, c.tableURL(financialTable, ""), payload, &rec); err != nil {
+		return domain.FinancialEntry{}, err
+	}
+	return toFinancialEntry(rec), nil
+}
+
+// UpdateFinancialEntry applies patch to a financial entry record and returns the result.
+func (c *Client) UpdateFinancialEntry(ctx context.Context, id string, patch domain.FinancialEntryPatch) (domain.FinancialEntry, error) {
+	payload := map[string]any{"fields": financialEntryPatchFields(patch)}
+
+	var rec airtableRecord[airtableFinancialFields]
+	if err := c.doJSON(ctx, http.MethodPatch, financialTable, c.tableURL(financialTable, id), payload, &rec); err != nil {
+		return domain.FinancialEntry{}, err
+	}
+	return toFinancialEntry(rec), nil
+}
@@ -0,0 +1,165 @@
+package airtable
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/nomenarkt/vitaltrack/backend/internal/config"
+	"github.com/nomenarkt/vitaltrack/backend/internal/domain"
+)
+
+// testConfig names the base and tables the record tests expect.
+var testConfig = config.Airtable{
+	Token:          "tok",
+	BaseID:         "base",
+	MedicinesTable: "meds",
+	EntriesTable:   "entries",
+	FinancialTable: "fin",
+	AlertsTable:    "alerts",
+	AdherenceTable: "adherence",
+}
+
+func TestGetMedicine_notFound(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+		if _, err := fmt.Fprint(w, `{"error":"NOT_FOUND"}`); err != nil {
+			t.Fatalf("write response: %v", err)
+		}
+	}))
+	defer srv.Close()
+
+	c := &Client{cfg: testConfig, baseURL: srv.URL}
+	if _, err := c.GetMedicine(context.Background(), "recX"); !errors.Is(err, domain.ErrNotFound) {
+		t.Fatalf("err = %v, want domain.ErrNotFound", err)
+	}
+}
+
+func TestCreateMedicine(t *testing.T) {
+	var method, path string
+	var payload struct {
+		Fields map[string]any `json:"fields"`
+	}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		method, path = r.Method, r.URL.Path
+		body, err := io.ReadAll(r.Body)
+		if err != nil {
+			t.Fatalf("read body: %v", err)
+		}
+		if err := json.Unmarshal(body, &payload); err != nil {
+			t.Fatalf("decode body: %v", err)
+		}
+		if _, err := fmt.Fprint(w, `{"id":"recNew","fields":{"name":"MedA","daily_dose":2,"start_date":"2025-06-01","patient":"Mum","dose_times":"20:00, 8:00"}}`); err != nil {
+			t.Fatalf("write response: %v", err)
+		}
+	}))
+	defer srv.Close()
+
+	c := &Client{cfg: testConfig, baseURL: srv.URL}
+	m, err := c.CreateMedicine(context.Background(), domain.Medicine{
+		Name:      "MedA",
+		DailyDose: 2,
+		StartDate: domain.NewFlexibleDate(time.Date(2025, 6, 1, 0, 0, 0, 0, time.UTC)),
+		Patient:   "Mum",
+		DoseTimes: domain.DoseTimes{"08:00", "20:00"},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if method != http.MethodPost || path != "/v0/base/meds" {
+		t.Errorf("request = %s %s, want POST /v0/base/meds", method, path)
+	}
+	if payload.Fields["start_date"] != "2025-06-01" || payload.Fields["name"] != "MedA" || payload.Fields["dose_times"] != "08:00, 20:00" {
+		t.Errorf("unexpected fields: %v", payload.Fields)
+	}
+	if _, ok := payload.Fields["id"]; ok {
+		t.Errorf("record ID must not be sent as a field: %v", payload.Fields)
+	}
+	if m.ID != "recNew" || m.DailyDose != 2 || m.Patient != "Mum" || !reflect.DeepEqual(m.DoseTimes, domain.DoseTimes{"08:00", "20:00"}) {
+		t.Errorf("unexpected medicine: %+v", m)
+	}
+}
+
+func TestUpdateStockEntry_sendsOnlyPatchedFields(t *testing.T) {
+	var method, path string
+	var payload struct {
+		Fields map[string]any `json:"fields"`
+	}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		method, path = r.Method, r.URL.Path
+		body, err := io.ReadAll(r.Body)
+		if err != nil {
+			t.Fatalf("read body: %v", err)
+		}
+		if err := json.Unmarshal(body, &payload); err != nil {
+			t.Fatalf("decode body: %v", err)
+		}
+		if _, err := fmt.Fprint(w, `{"id":"recE","fields":{"medicine_id":["m1"],"quantity":1,"unit":"box","date":"2025-06-02","archived":true}}`); err != nil {
+			t.Fatalf("write response: %v", err)
+		}
+	}))
+	defer srv.Close()
+
+	archived := true
+	c := &Client{cfg: testConfig, baseURL: srv.URL}
+	e, err := c.UpdateStockEntry(context.Background(), "recE", domain.StockEntryPatch{Archived: &archived})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if method != http.MethodPatch || path != "/v0/base/entries/recE" {
+		t.Errorf("request = %s %s, want PATCH /v0/base/entries/recE", method, path)
+	}
+	if len(payload.Fields) != 1 || payload.Fields["archived"] != true {
+		t.Errorf("expected only archived field, got %v", payload.Fields)
+	}
+	if !e.Archived || e.ID != "recE" {
+		t.Errorf("unexpected entry: %+v", e)
+	}
+}
+
+func TestCreateFinancialEntry(t *testing.T) {
+	var payload struct {
+		Fields map[string]any `json:"fields"`
+	}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, err := io.ReadAll(r.Body)
+		if err != nil {
+			t.Fatalf("read body: %v", err)
+		}
+		if err := json.Unmarshal(body, &payload); err != nil {
+			t.Fatalf("decode body: %v", err)
+		}
+		if _, err := fmt.Fprint(w, `{"id":"recF","fields":{"Date":"2025-06-05","NeedLabel":"Med","NeedAmount":20,"AmountContributed":5,"MonthTag":"2025-06","Contributor":"Bob"}}`); err != nil {
+			t.Fatalf("write response: %v", err)
+		}
+	}))
+	defer srv.Close()
+
+	c := &Client{cfg: testConfig, baseURL: srv.URL}
+	e, err := c.CreateFinancialEntry(context.Background(), domain.FinancialEntry{
+		Date:              domain.NewFlexibleDate(time.Date(2025, 6, 5, 0, 0, 0, 0, time.UTC)),
+		NeedLabel:         "Med",
+		NeedAmount:        20,
+		AmountContributed: 5,
+		MonthTag:          "2025-06",
+		Contributor:       "Bob",
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if payload.Fields["MonthTag"] != "2025-06" || payload.Fields["Date"] != "2025-06-05" {
+		t.Errorf("unexpected fields: %v", payload.Fields)
+	}
+	if e.ID != "recF" || e.Contributor != "Bob" {
+		t.Errorf("unexpected entry: %+v", e)
+	}
+}
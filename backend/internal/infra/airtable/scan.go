@@ -0,0 +1,94 @@
+package airtable
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/nomenarkt/vitaltrack/backend/internal/domain"
+)
+
+// ScanRecords reads the medicines, the stock entries and the financial
+// entries of the month like the Fetch methods, except that a date that does
+// not parse is left zero and reported as a domain.IssueInvalidDate instead
+// of failing the read.
+func (c *Client) ScanRecords(ctx context.Context, year int, month time.Month) (domain.RecordScan, error) {
+	var scan domain.RecordScan
+
+	meds, err := scanAll[domain.Medicine](ctx, c, medicinesTable, url.Values{}, &scan.Issues)
+	if err != nil {
+		return domain.RecordScan{}, fmt.Errorf("fetch medicines: %w", err)
+	}
+	for _, rec := range meds {
+		m := rec.Fields
+		m.ID = rec.ID
+		scan.Medicines = append(scan.Medicines, m)
+	}
+
+	entries, err := scanAll[domain.StockEntry](ctx, c, entriesTable, url.Values{}, &scan.Issues)
+	if err != nil {
+		return domain.RecordScan{}, fmt.Errorf("fetch stock entries: %w", err)
+	}
+	for _, rec := range entries {
+		e := rec.Fields
+		e.ID = rec.ID
+		scan.Entries = append(scan.Entries, e)
+	}
+
+	tag := fmt.Sprintf("%04d-%02d", year, month)
+	q := url.Values{}
+	q.Set("filterByFormula", c.formulaField(financialTable, "month_tag")+"="+formulaString(tag))
+	financial, err := scanAll[airtableFinancialFields](ctx, c, financialTable, q, &scan.Issues)
+	if err != nil {
+		return domain.RecordScan{}, fmt.Errorf("fetch financial entries: %w", err)
+	}
+	for _, rec := range financial {
+		if rec.Fields.MonthTag == tag {
+			scan.Financial = append(scan.Financial, toFinancialEntry(rec))
+		}
+	}
+	return scan, nil
+}
+
+// scanAll is fetchAll for ScanRecords: the date fields of t that do not
+// parse are dropped from their record and appended to issues.
+func scanAll[T any](ctx context.Context, c *Client, t table, q url.Values, issues *[]domain.ValidationIssue) ([]airtableRecord[T], error) {
+	raw, err := fetchAll[map[string]json.RawMessage](ctx, c, t, q)
+	if err != nil {
+		return nil, err
+	}
+	out := make([]airtableRecord[T], 0, len(raw))
+	for _, rec := range raw {
+		for _, f := range fields[t] {
+			v, ok := rec.Fields[f.name]
+			if !ok || !slices.Equal(f.types, dateTypes) {
+				continue
+			}
+			var d domain.FlexibleDate
+			if err := json.Unmarshal(v, &d); err != nil {
+				delete(rec.Fields, f.name)
+				*issues = append(*issues, domain.ValidationIssue{
+					Kind:     domain.IssueInvalidDate,
+					Table:    string(t),
+					RecordID: rec.ID,
+					Field:    f.name,
+					Value:    strings.Trim(string(v), `"`),
+				})
+			}
+		}
+		b, err := json.Marshal(rec.Fields)
+		if err != nil {
+			return nil, err
+		}
+		parsed := airtableRecord[T]{ID: rec.ID}
+		if err := json.Unmarshal(b, &parsed.Fields); err != nil {
+			return nil, fmt.Errorf("record %s: %w", rec.ID, err)
+		}
+		out = append(out, parsed)
+	}
+	return out, nil
+}
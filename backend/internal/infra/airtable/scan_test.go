@@ -0,0 +1,49 @@
+package airtable
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/nomenarkt/vitaltrack/backend/internal/domain"
+)
+
+func TestScanRecords_invalidDates(t *testing.T) {
+	pages := map[string]string{
+		"/v0/base/meds":    `{"records":[{"id":"m1","fields":{"name":"Aspirin","start_date":"soon"}}]}`,
+		"/v0/base/entries": `{"records":[{"id":"e1","fields":{"medicine_id":["m1"],"quantity":1,"date":"02/06/2025"}},{"id":"e2","fields":{"medicine_id":["m1"],"quantity":2,"date":"2025-06-02"}}]}`,
+		"/v0/base/fin":     `{"records":[{"id":"f1","fields":{"Date":7,"NeedLabel":"Rent","MonthTag":"2025-06"}}]}`,
+	}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if _, err := fmt.Fprint(w, pages[r.URL.Path]); err != nil {
+			t.Fatalf("write response: %v", err)
+		}
+	}))
+	defer srv.Close()
+
+	c := &Client{cfg: testConfig, baseURL: srv.URL}
+	if _, err := c.FetchStockEntries(context.Background()); err == nil {
+		t.Fatal("FetchStockEntries accepted an invalid date")
+	}
+	scan, err := c.ScanRecords(context.Background(), 2025, time.June)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(scan.Medicines) != 1 || scan.Medicines[0].Name != "Aspirin" || len(scan.Entries) != 2 || len(scan.Financial) != 1 {
+		t.Fatalf("records = %+v", scan)
+	}
+	if !scan.Entries[0].Date.IsZero() || scan.Entries[1].Date.IsZero() || scan.Financial[0].NeedLabel != "Rent" {
+		t.Errorf("records = %+v", scan)
+	}
+	want := []domain.ValidationIssue{
+		{Kind: domain.IssueInvalidDate, Table: "medicines", RecordID: "m1", Field: "start_date", Value: "soon"},
+		{Kind: domain.IssueInvalidDate, Table: "entries", RecordID: "e1", Field: "date", Value: "02/06/2025"},
+		{Kind: domain.IssueInvalidDate, Table: "financial", RecordID: "f1", Field: "date", Value: "7"},
+	}
+	if fmt.Sprint(scan.Issues) != fmt.Sprint(want) {
+		t.Errorf("issues = %+v, want %+v", scan.Issues, want)
+	}
+}
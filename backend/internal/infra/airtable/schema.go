@@ -0,0 +1,109 @@
+package airtable
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+)
+
+// SchemaProblem is a column the client uses that the base lacks or that
+// has an unexpected type.
+type SchemaProblem struct {
+	Table     string // table key, e.g. medicines
+	TableName string // name of the table in the base
+	Field     string // empty when the table itself is missing
+	Column    string
+	Type      string   // type of the column, empty when it is missing
+	Want      []string // types the column may have
+}
+
+func (p SchemaProblem) String() string {
+	switch {
+	case p.Field == "":
+		return fmt.Sprintf("table %q (%s) not found in the base", p.TableName, p.Table)
+	case p.Type == "":
+		return fmt.Sprintf("%s.%s: column %q not found in table %q", p.Table, p.Field, p.Column, p.TableName)
+	}
+	return fmt.Sprintf("%s.%s: column %q is %s, want one of %s", p.Table, p.Field, p.Column, p.Type, strings.Join(p.Want, ", "))
+}
+
+// metaTable is a table in the answer of the metadata API.
+type metaTable struct {
+	ID     string `json:"id"`
+	Name   string `json:"name"`
+	Fields []struct {
+		Name string `json:"name"`
+		Type string `json:"type"`
+	} `json:"fields"`
+}
+
+// CheckSchema compares the columns of the configured tables with the schema
+// of the base, read from the metadata API, and returns the columns that are
+// missing or have the wrong type. The token needs the schema.bases:read
+// scope.
+func (c *Client) CheckSchema(ctx context.Context) ([]SchemaProblem, error) {
+	tables, err := c.metaTables(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var problems []SchemaProblem
+	for _, t := range tableOrder {
+		name := c.tableName(t)
+		if name == "" {
+			continue // optional table not in use
+		}
+		i := slices.IndexFunc(tables, func(mt metaTable) bool { return mt.Name == name || mt.ID == name })
+		if i < 0 {
+			problems = append(problems, SchemaProblem{Table: string(t), TableName: name})
+			continue
+		}
+		types := map[string]string{}
+		for _, f := range tables[i].Fields {
+			types[f.Name] = f.Type
+		}
+		for _, f := range fields[t] {
+			col := c.column(t, f.name)
+			typ, ok := types[col]
+			if ok && slices.Contains(f.types, typ) {
+				continue
+			}
+			problems = append(problems, SchemaProblem{Table: string(t), TableName: name, Field: f.name, Column: col, Type: typ, Want: f.types})
+		}
+	}
+	return problems, nil
+}
+
+// metaTables returns the tables of the base from the metadata API.
+func (c *Client) metaTables(ctx context.Context) ([]metaTable, error) {
+	var meta struct {
+		Tables []metaTable `json:"tables"`
+	}
+	endpoint := fmt.Sprintf("%s/v0/meta/bases/%s/tables", c.baseURL, url.PathEscape(c.cfg.BaseID))
+	if err := c.call(ctx, http.MethodGet, endpoint, nil, &meta); err != nil {
+		return nil, err
+	}
+	return meta.Tables, nil
+}
+
+// tableID returns the ID of t, looking its name up in the metadata API
+// unless it is configured by ID.
+func (c *Client) tableID(ctx context.Context, t table) (string, error) {
+	name := c.tableName(t)
+	if strings.HasPrefix(name, "tbl") {
+		return name, nil
+	}
+	tables, err := c.metaTables(ctx)
+	if err != nil {
+		return "", err
+	}
+	for _, mt := range tables {
+		if mt.Name == name {
+			return mt.ID, nil
+		}
+	}
+	return "", fmt.Errorf("table %q: %w", name, ErrNotFound)
+}
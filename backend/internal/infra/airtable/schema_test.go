@@ -0,0 +1,84 @@
+package airtable
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// fakeMeta serves the metadata API of a base holding the medicines table
+// with the columns in meds, and an empty entries table.
+func fakeMeta(t *testing.T, meds string) *httptest.Server {
+	t.Helper()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v0/meta/bases/base/tables" || r.Header.Get("Authorization") != "Bearer tok" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		_, _ = fmt.Fprintf(w, `{"tables":[{"id":"tblM","name":"meds","fields":[%s]},{"id":"tblE","name":"entries","fields":[]}]}`, meds)
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func TestCheckSchema(t *testing.T) {
+	srv := fakeMeta(t, `
+		{"name":"Nom","type":"singleLineText"},
+		{"name":"unit_type","type":"singleSelect"},
+		{"name":"unit_per_box","type":"number"},
+		{"name":"units","type":"multilineText"},
+		{"name":"daily_dose","type":"singleLineText"},
+		{"name":"start_date","type":"date"},
+		{"name":"initial_stock","type":"number"},
+		{"name":"forecast_out_of_stock_date","type":"date"},
+		{"name":"forecast_last_updated","type":"date"},
+		{"name":"last_alerted_date","type":"date"},
+		{"name":"patient","type":"singleLineText"},
+		{"name":"dose_times","type":"singleLineText"},
+		{"name":"name","type":"singleLineText"}`)
+
+	cfg := testConfig
+	cfg.FinancialTable, cfg.AlertsTable, cfg.AdherenceTable = "", "", "tblMissing"
+	cfg.Fields = map[string]map[string]string{"medicines": {"name": "Nom"}, "entries": {"quantity": "Qty"}}
+	c := &Client{cfg: cfg, baseURL: srv.URL}
+
+	problems, err := c.CheckSchema(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	for _, p := range problems {
+		got = append(got, p.String())
+	}
+	want := []string{
+		`medicines.daily_dose: column "daily_dose" is singleLineText, want one of number, currency, percent, decimal`,
+		`medicines.archived: column "archived" not found in table "meds"`,
+		`entries.medicine_id: column "medicine_id" not found in table "entries"`,
+		`entries.kind: column "kind" not found in table "entries"`,
+		`entries.quantity: column "Qty" not found in table "entries"`,
+		`entries.unit: column "unit" not found in table "entries"`,
+		`entries.date: column "date" not found in table "entries"`,
+		`entries.reason: column "reason" not found in table "entries"`,
+		`entries.archived: column "archived" not found in table "entries"`,
+		`table "tblMissing" (adherence) not found in the base`,
+	}
+	if fmt.Sprint(got) != fmt.Sprint(want) {
+		t.Errorf("problems:\n%q\nwant:\n%q", got, want)
+	}
+}
+
+func TestCheckSchema_unauthorized(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusForbidden)
+		_, _ = fmt.Fprint(w, `{"error":{"type":"INVALID_PERMISSIONS_OR_MODEL_NOT_FOUND","message":"Invalid permissions"}}`)
+	}))
+	defer srv.Close()
+
+	c := &Client{cfg: testConfig, baseURL: srv.URL}
+	if _, err := c.CheckSchema(context.Background()); !errors.Is(err, ErrUnauthorized) {
+		t.Errorf("err = %v, want ErrUnauthorized", err)
+	}
+}
@@ -0,0 +1,281 @@
+package airtable
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/nomenarkt/vitaltrack/backend/internal/logger"
+	"github.com/nomenarkt/vitaltrack/backend/internal/metrics"
+	"github.com/nomenarkt/vitaltrack/backend/internal/tracing"
+)
+
+// RequestsPerSecond is the rate Airtable allows per base.
+const RequestsPerSecond = 5
+
+// RateLimitPenalty is how long Airtable refuses the requests of a base that
+// went over RequestsPerSecond.
+const RateLimitPenalty = 30 * time.Second
+
+// HTTPOptions configure the client returned by NewHTTPClient.
+type HTTPOptions struct {
+	Timeout    time.Duration // per attempt, including reading the body
+	MaxRetries int           // retries after a 429, a 5xx or a failed attempt
+	Logger     logger.Logger // logs retries, discards when nil
+}
+
+// NewHTTPClient returns a client that sends at most RequestsPerSecond
+// requests per second, bounds each attempt by opts.Timeout and retries
+// rate limited, failed and 5xx requests with exponential backoff, waiting
+// as long as Retry-After asks for when Airtable sends it and at least
+// RateLimitPenalty when it does not. POST requests,
+// which are not idempotent, are only retried after a 429. Every attempt is
+// measured and traced.
+func NewHTTPClient(opts HTTPOptions) *http.Client {
+	return &http.Client{Transport: &retrying{
+		next:    instrumented{next: http.DefaultTransport},
+		limit:   &limiter{interval: time.Second / RequestsPerSecond},
+		timeout: opts.Timeout,
+		retries: opts.MaxRetries,
+		backoff: 500 * time.Millisecond,
+		penalty: RateLimitPenalty,
+		lg:      logger.OrNop(opts.Logger),
+	}}
+}
+
+// httpClient sends the requests of a Client built without NewClient: once
+// each, measured and traced.
+var httpClient = &http.Client{Transport: instrumented{next: http.DefaultTransport}}
+
+// maxBackoff caps the wait between two attempts. A longer Retry-After is
+// not waited for: the 429 is returned.
+const maxBackoff = 30 * time.Second
+
+type retrying struct {
+	next    http.RoundTripper
+	limit   *limiter
+	timeout time.Duration
+	retries int
+	backoff time.Duration // first wait, doubled for each retry
+	penalty time.Duration // least wait after a 429 without Retry-After
+	lg      logger.Logger
+}
+
+func (t *retrying) RoundTrip(req *http.Request) (*http.Response, error) {
+	ctx := req.Context()
+	for attempt := 0; ; attempt++ {
+		if err := t.limit.wait(ctx); err != nil {
+			return nil, err
+		}
+		r := req
+		if attempt > 0 && req.Body != nil {
+			body, err := req.GetBody()
+			if err != nil {
+				return nil, err
+			}
+			r = req.Clone(ctx)
+			r.Body = body
+		}
+		res, err := t.send(r)
+
+		delay, retry := t.retryAfter(req, res, err, attempt)
+		if !retry || attempt >= t.retries || delay > maxBackoff {
+			return res, err
+		}
+		status := "error"
+		if res != nil {
+			status = strconv.Itoa(res.StatusCode)
+			_, _ = io.Copy(io.Discard, res.Body)
+			_ = res.Body.Close()
+		}
+		t.lg.Warn(ctx, "airtable request retried", "method", req.Method, "table", tableOf(req.URL.Path), "status", status, "attempt", attempt+1, "delay", delay)
+		if err := sleep(ctx, delay); err != nil {
+			return nil, err
+		}
+	}
+}
+
+// send runs one attempt, bounded by t.timeout until its body is closed.
+func (t *retrying) send(req *http.Request) (*http.Response, error) {
+	if t.timeout <= 0 {
+		return t.next.RoundTrip(req)
+	}
+	ctx, cancel := context.WithTimeout(req.Context(), t.timeout)
+	res, err := t.next.RoundTrip(req.WithContext(ctx))
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+	res.Body = cancelBody{ReadCloser: res.Body, cancel: cancel}
+	return res, nil
+}
+
+// retryAfter reports whether the attempt given res and err is retried, and
+// after how long.
+func (t *retrying) retryAfter(req *http.Request, res *http.Response, err error, attempt int) (time.Duration, bool) {
+	if req.Context().Err() != nil || (req.Body != nil && req.GetBody == nil) {
+		return 0, false
+	}
+	backoff := t.backoff << attempt
+	switch {
+	case err != nil:
+		return backoff, req.Method != http.MethodPost
+	case res.StatusCode == http.StatusTooManyRequests:
+		if d, ok := parseRetryAfter(res.Header.Get("Retry-After"), time.Now()); ok {
+			return d, true
+		}
+		return max(backoff, t.penalty), true
+	case res.StatusCode >= http.StatusInternalServerError:
+		return backoff, req.Method != http.MethodPost
+	}
+	return 0, false
+}
+
+// parseRetryAfter reads a Retry-After header: a number of seconds or an
+// HTTP date.
+func parseRetryAfter(v string, now time.Time) (time.Duration, bool) {
+	if v == "" {
+		return 0, false
+	}
+	if s, err := strconv.Atoi(v); err == nil && s >= 0 {
+		return time.Duration(s) * time.Second, true
+	}
+	if at, err := http.ParseTime(v); err == nil {
+		return max(at.Sub(now), 0), true
+	}
+	return 0, false
+}
+
+func sleep(ctx context.Context, d time.Duration) error {
+	if d <= 0 {
+		return ctx.Err()
+	}
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+type cancelBody struct {
+	io.ReadCloser
+	cancel context.CancelFunc
+}
+
+func (b cancelBody) Close() error {
+	defer b.cancel()
+	return b.ReadCloser.Close()
+}
+
+// limiter spaces requests interval apart.
+type limiter struct {
+	interval time.Duration
+
+	mu   sync.Mutex
+	next time.Time   // first slot not taken
+	free []time.Time // slots before next given back by cancelled waits
+}
+
+// wait blocks until the caller may send a request, or ctx is done. A wait
+// ended by ctx gives its slot back for the next caller.
+func (l *limiter) wait(ctx context.Context) error {
+	now := time.Now()
+	at := l.take(now)
+	if err := sleep(ctx, at.Sub(now)); err != nil {
+		l.release(at)
+		return err
+	}
+	return nil
+}
+
+// take reserves the earliest slot not before now.
+func (l *limiter) take(now time.Time) time.Time {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.free = slices.DeleteFunc(l.free, func(at time.Time) bool { return at.Before(now) })
+	if len(l.free) > 0 {
+		at := l.free[0]
+		l.free = l.free[1:]
+		return at
+	}
+	at := l.next
+	if at.Before(now) {
+		at = now
+	}
+	l.next = at.Add(l.interval)
+	return at
+}
+
+// release gives back the slot at, taken and not used.
+func (l *limiter) release(at time.Time) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if at.Add(l.interval).Equal(l.next) {
+		l.next = at
+		return
+	}
+	i, _ := slices.BinarySearchFunc(l.free, at, time.Time.Compare)
+	l.free = slices.Insert(l.free, i, at)
+}
+
+type instrumented struct {
+	next http.RoundTripper
+}
+
+func (t instrumented) RoundTrip(req *http.Request) (*http.Response, error) {
+	table := tableOf(req.URL.Path)
+	ctx, span := tracing.Start(req.Context(), "airtable "+req.Method+" "+table,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(
+			attribute.String("http.request.method", req.Method),
+			attribute.String("airtable.table", table),
+		))
+	defer span.End()
+
+	start := time.Now()
+	res, err := t.next.RoundTrip(req.WithContext(ctx))
+
+	code := "error"
+	if err == nil {
+		code = strconv.Itoa(res.StatusCode)
+		span.SetAttributes(attribute.Int("http.response.status_code", res.StatusCode))
+	}
+	metrics.AirtableRequestDuration.WithLabelValues(req.Method, table, code).Observe(time.Since(start).Seconds())
+	switch {
+	case err != nil:
+		tracing.Fail(span, err)
+		metrics.AirtableRequestErrors.WithLabelValues(req.Method, table).Inc()
+	case res.StatusCode >= http.StatusMultipleChoices:
+		span.SetStatus(codes.Error, res.Status)
+		metrics.AirtableRequestErrors.WithLabelValues(req.Method, table).Inc()
+	}
+	return res, err
+}
+
+// tableOf returns the table in an API path: /v0/{base}/{table}[/{record}],
+// or "meta" for the metadata API and "webhooks" for the webhooks API.
+func tableOf(path string) string {
+	parts := strings.Split(strings.TrimPrefix(path, "/"), "/")
+	if len(parts) < 3 {
+		return ""
+	}
+	switch parts[1] {
+	case "meta":
+		return "meta"
+	case "bases":
+		return "webhooks"
+	}
+	return parts[2]
+}
@@ -0,0 +1,248 @@
+package airtable
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+
+	"github.com/nomenarkt/vitaltrack/backend/internal/domain"
+	"github.com/nomenarkt/vitaltrack/backend/internal/logger"
+	"github.com/nomenarkt/vitaltrack/backend/internal/tracing"
+)
+
+func TestTransport_traces(t *testing.T) {
+	rec := tracetest.NewSpanRecorder()
+	prev := otel.GetTracerProvider()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(rec)))
+	t.Cleanup(func() { otel.SetTracerProvider(prev) })
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer srv.Close()
+
+	c := &Client{cfg: testConfig, baseURL: srv.URL}
+	ctx, parent := tracing.Start(context.Background(), "finance report")
+	if _, err := c.FetchFinancialEntries(ctx, 2025, 6); err == nil {
+		t.Fatal("expected error for a 503")
+	}
+	parent.End()
+
+	spans := rec.Ended()
+	if len(spans) != 2 {
+		t.Fatalf("%d spans recorded", len(spans))
+	}
+	s := spans[0]
+	if s.Name() != "airtable GET fin" || s.Parent().SpanID() != parent.SpanContext().SpanID() {
+		t.Errorf("span %q with parent %s", s.Name(), s.Parent().SpanID())
+	}
+	if s.Status().Code != codes.Error {
+		t.Errorf("status = %v for a 503", s.Status())
+	}
+	want := attribute.String("airtable.table", "fin")
+	found := false
+	for _, a := range s.Attributes() {
+		found = found || a == want
+	}
+	if !found {
+		t.Errorf("attributes %v lack %v", s.Attributes(), want)
+	}
+}
+
+// retryingClient returns a Client for srv that retries like NewClient,
+// without waiting between attempts.
+func retryingClient(srv *httptest.Server, timeout time.Duration) *Client {
+	c := &Client{cfg: testConfig, baseURL: srv.URL}
+	c.UseHTTPClient(&http.Client{Transport: &retrying{
+		next:    instrumented{next: http.DefaultTransport},
+		limit:   &limiter{},
+		timeout: timeout,
+		retries: 2,
+		backoff: time.Millisecond,
+		lg:      logger.OrNop(nil),
+	}})
+	return c
+}
+
+func TestRetrying(t *testing.T) {
+	tests := []struct {
+		name     string
+		statuses []int // answered in turn, the last one repeated
+		create   bool
+		wantHits int
+		fails    bool
+		wantErr  error
+	}{
+		{name: "ok", statuses: []int{200}, wantHits: 1},
+		{name: "rate_limited_once", statuses: []int{429, 200}, wantHits: 2},
+		{name: "server_error_once", statuses: []int{503, 200}, wantHits: 2},
+		{name: "rate_limited", statuses: []int{429}, wantHits: 3, fails: true, wantErr: ErrRateLimited},
+		{name: "unauthorized", statuses: []int{401}, wantHits: 1, fails: true, wantErr: ErrUnauthorized},
+		{name: "not_found", statuses: []int{404}, wantHits: 1, fails: true, wantErr: ErrNotFound},
+		{name: "post_rate_limited_once", statuses: []int{429, 200}, create: true, wantHits: 2},
+		{name: "post_server_error", statuses: []int{500, 200}, create: true, wantHits: 1, fails: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var hits atomic.Int32
+			var bodies []string
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				n := int(hits.Add(1))
+				b, _ := io.ReadAll(r.Body)
+				bodies = append(bodies, string(b))
+				status := tt.statuses[min(n, len(tt.statuses))-1]
+				if status == http.StatusTooManyRequests {
+					w.Header().Set("Retry-After", "0")
+				}
+				w.WriteHeader(status)
+				_, _ = fmt.Fprint(w, `{"id":"rec1","fields":{}}`)
+			}))
+			defer srv.Close()
+
+			c := retryingClient(srv, time.Second)
+			var err error
+			if tt.create {
+				_, err = c.CreateStockEntry(context.Background(), domain.StockEntry{Quantity: 2})
+			} else {
+				_, err = c.GetMedicine(context.Background(), "rec1")
+			}
+
+			if int(hits.Load()) != tt.wantHits {
+				t.Errorf("%d requests, want %d", hits.Load(), tt.wantHits)
+			}
+			if (err != nil) != tt.fails {
+				t.Fatalf("err = %v", err)
+			}
+			if tt.wantErr != nil && !errors.Is(err, tt.wantErr) {
+				t.Fatalf("err = %v, want %v", err, tt.wantErr)
+			}
+			for _, b := range bodies[1:] {
+				if b != bodies[0] {
+					t.Errorf("retried with body %q, first sent %q", b, bodies[0])
+				}
+			}
+		})
+	}
+}
+
+func TestRetrying_timeout(t *testing.T) {
+	release := make(chan struct{})
+	srv := httptest.NewServer(http.HandlerFunc(func(http.ResponseWriter, *http.Request) {
+		<-release
+	}))
+	defer srv.Close()
+	defer close(release)
+
+	c := retryingClient(srv, 20*time.Millisecond)
+	start := time.Now()
+	if _, err := c.FetchMedicines(context.Background()); err == nil {
+		t.Fatal("hung request succeeded")
+	}
+	if d := time.Since(start); d > time.Second {
+		t.Errorf("gave up after %s", d)
+	}
+}
+
+func TestLimiter(t *testing.T) {
+	l := &limiter{interval: 10 * time.Millisecond}
+	start := time.Now()
+	for range 4 {
+		if err := l.wait(context.Background()); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if d := time.Since(start); d < 30*time.Millisecond {
+		t.Errorf("4 requests within %s", d)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	l.next = time.Now().Add(time.Hour)
+	if err := l.wait(ctx); !errors.Is(err, context.Canceled) {
+		t.Errorf("wait = %v after cancel", err)
+	}
+}
+
+func TestLimiter_releasesCancelledSlot(t *testing.T) {
+	l := &limiter{interval: time.Hour}
+	now := time.Now()
+	first := l.take(now)
+	second := l.take(now)
+	third := l.take(now)
+
+	// The last slot taken moves next back; an earlier one is kept for reuse.
+	l.release(third)
+	l.release(second)
+	if got := l.take(now); !got.Equal(second) {
+		t.Errorf("took %s, want the released %s", got.Sub(first), second.Sub(first))
+	}
+	if got := l.take(now); !got.Equal(third) {
+		t.Errorf("took %s, want %s", got.Sub(first), third.Sub(first))
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if err := l.wait(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("wait = %v", err)
+	}
+	if got := l.take(now); !got.Equal(third.Add(time.Hour)) {
+		t.Errorf("took %s after a cancelled wait, want %s", got.Sub(first), third.Add(time.Hour).Sub(first))
+	}
+}
+
+func TestRetrying_rateLimitPenalty(t *testing.T) {
+	tr := &retrying{backoff: time.Millisecond, penalty: RateLimitPenalty}
+	req, err := http.NewRequest(http.MethodGet, "/v0/app1/Medicines", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		retryAfter string
+		want       time.Duration
+	}{
+		{"", RateLimitPenalty},
+		{"2", 2 * time.Second},
+	}
+	for _, tt := range tests {
+		res := &http.Response{StatusCode: http.StatusTooManyRequests, Header: http.Header{}}
+		if tt.retryAfter != "" {
+			res.Header.Set("Retry-After", tt.retryAfter)
+		}
+		if got, ok := tr.retryAfter(req, res, nil, 0); got != tt.want || !ok {
+			t.Errorf("Retry-After %q: wait %s, %v; want %s", tt.retryAfter, got, ok, tt.want)
+		}
+	}
+}
+
+func TestParseRetryAfter(t *testing.T) {
+	now := time.Date(2025, 6, 1, 8, 0, 0, 0, time.UTC)
+	tests := []struct {
+		in     string
+		want   time.Duration
+		wantOK bool
+	}{
+		{"30", 30 * time.Second, true},
+		{now.Add(5 * time.Second).Format(http.TimeFormat), 5 * time.Second, true},
+		{now.Add(-time.Minute).Format(http.TimeFormat), 0, true},
+		{"", 0, false},
+		{"soon", 0, false},
+	}
+	for _, tt := range tests {
+		got, ok := parseRetryAfter(tt.in, now)
+		if got != tt.want || ok != tt.wantOK {
+			t.Errorf("parseRetryAfter(%q) = %s, %v", tt.in, got, ok)
+		}
+	}
+}
@@ -0,0 +1,316 @@
+package airtable
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"maps"
+	"net/http"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/nomenarkt/vitaltrack/backend/internal/domain"
+	"github.com/nomenarkt/vitaltrack/backend/internal/domain/ports"
+)
+
+// MACHeader is the header in which Airtable signs webhook notifications.
+const MACHeader = "X-Airtable-Content-MAC"
+
+// Airtable disables a webhook 7 days after its creation or last refresh.
+const (
+	webhookRefreshEvery = 24 * time.Hour
+	webhookRetryEvery   = time.Minute
+)
+
+// Webhook follows the changes made to the stock entries table through an
+// Airtable webhook, which notifies url of every change. Notifications only
+// say that something changed; EntryChanges reads what did.
+type Webhook struct {
+	client *Client
+	url    string
+	store  ports.WebhookStatePort // optional, see UseStore
+
+	mu     sync.Mutex
+	id     string
+	secret []byte
+	cursor int
+}
+
+// EntriesWebhook returns the Webhook of the entries table notifying
+// notificationURL. It must be registered before it receives anything.
+func (c *Client) EntriesWebhook(notificationURL string) *Webhook {
+	return &Webhook{client: c, url: notificationURL}
+}
+
+// UseStore keeps the ID, secret and cursor of the webhook in store, so that
+// a restart reuses the webhook instead of leaving it behind.
+func (w *Webhook) UseStore(store ports.WebhookStatePort) {
+	w.store = store
+}
+
+func (w *Webhook) endpoint(path string) string {
+	c := w.client
+	return fmt.Sprintf("%s/v0/bases/%s/webhooks%s", c.baseURL, url.PathEscape(c.cfg.BaseID), path)
+}
+
+// Register reuses the webhook registered earlier, by this Webhook or by a
+// previous run that saved it with UseStore, while Airtable still knows it.
+// Otherwise it creates one and deletes the earlier one: Airtable only
+// reveals the secret that signs notifications when a webhook is created.
+// Webhooks created by other deployments for the same URL are left alone.
+func (w *Webhook) Register(ctx context.Context) error {
+	c := w.client
+	prev, err := w.previous()
+	if err != nil {
+		return err
+	}
+	known, err := w.exists(ctx, prev.ID)
+	if err != nil {
+		return err
+	}
+	if known && prev.URL == w.url && len(prev.Secret) > 0 {
+		w.set(prev)
+		err := w.Refresh(ctx)
+		if err == nil {
+			c.log().Info(ctx, "airtable webhook reused", "webhook_id", prev.ID, "table", c.tableName(entriesTable))
+			return nil
+		}
+		if !errors.Is(err, ErrNotFound) {
+			return fmt.Errorf("refresh webhook %s: %w", prev.ID, err)
+		}
+		known = false
+	}
+	if known {
+		err := c.call(ctx, http.MethodDelete, w.endpoint("/"+url.PathEscape(prev.ID)), nil, nil)
+		if err != nil && !errors.Is(err, ErrNotFound) {
+			return fmt.Errorf("delete webhook %s: %w", prev.ID, err)
+		}
+	}
+
+	tableID, err := c.tableID(ctx, entriesTable)
+	if err != nil {
+		return fmt.Errorf("entries table: %w", err)
+	}
+	payload := map[string]any{
+		"notificationUrl": w.url,
+		"specification": map[string]any{
+			"options": map[string]any{
+				"filters": map[string]any{
+					"dataTypes":         []string{"tableData"},
+					"recordChangeScope": tableID,
+				},
+			},
+		},
+	}
+	var created struct {
+		ID              string `json:"id"`
+		MACSecretBase64 string `json:"macSecretBase64"`
+	}
+	if err := c.call(ctx, http.MethodPost, w.endpoint(""), payload, &created); err != nil {
+		return fmt.Errorf("create webhook: %w", err)
+	}
+	secret, err := base64.StdEncoding.DecodeString(created.MACSecretBase64)
+	if err != nil {
+		return fmt.Errorf("webhook secret: %w", err)
+	}
+
+	state := domain.WebhookState{ID: created.ID, URL: w.url, Secret: secret, Cursor: 1}
+	w.set(state)
+	w.save(ctx, state)
+	c.log().Info(ctx, "airtable webhook registered", "webhook_id", created.ID, "table", c.tableName(entriesTable))
+	return nil
+}
+
+// exists reports whether Airtable knows the webhook id.
+func (w *Webhook) exists(ctx context.Context, id string) (bool, error) {
+	if id == "" {
+		return false, nil
+	}
+	var list struct {
+		Webhooks []struct {
+			ID string `json:"id"`
+		} `json:"webhooks"`
+	}
+	if err := w.client.call(ctx, http.MethodGet, w.endpoint(""), nil, &list); err != nil {
+		return false, fmt.Errorf("list webhooks: %w", err)
+	}
+	for _, h := range list.Webhooks {
+		if h.ID == id {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// previous returns the webhook this Webhook registered, or else the one
+// saved in its store, if any.
+func (w *Webhook) previous() (domain.WebhookState, error) {
+	w.mu.Lock()
+	state := domain.WebhookState{ID: w.id, URL: w.url, Secret: w.secret, Cursor: w.cursor}
+	w.mu.Unlock()
+	if state.ID != "" || w.store == nil {
+		return state, nil
+	}
+	saved, err := w.store.LoadWebhook()
+	if errors.Is(err, domain.ErrNotFound) {
+		return domain.WebhookState{}, nil
+	}
+	if err != nil {
+		return domain.WebhookState{}, fmt.Errorf("load webhook state: %w", err)
+	}
+	return saved, nil
+}
+
+func (w *Webhook) set(state domain.WebhookState) {
+	w.mu.Lock()
+	w.id, w.secret, w.cursor = state.ID, state.Secret, state.Cursor
+	w.mu.Unlock()
+}
+
+// save writes state to the store. A failure is only logged: the webhook
+// works until the next restart, which registers another.
+func (w *Webhook) save(ctx context.Context, state domain.WebhookState) {
+	if w.store == nil {
+		return
+	}
+	if err := w.store.SaveWebhook(state); err != nil {
+		w.client.log().Warn(ctx, "failed to save airtable webhook state", "webhook_id", state.ID, "error", err)
+	}
+}
+
+// Refresh extends the life of the registered webhook.
+func (w *Webhook) Refresh(ctx context.Context) error {
+	w.mu.Lock()
+	id := w.id
+	w.mu.Unlock()
+	if id == "" {
+		return errors.New("webhook not registered")
+	}
+	return w.client.call(ctx, http.MethodPost, w.endpoint("/"+url.PathEscape(id)+"/refresh"), nil, nil)
+}
+
+// KeepAlive registers the webhook, then refreshes it every day until ctx is
+// cancelled. A webhook that Airtable no longer knows is registered again,
+// and failures are retried every minute.
+func (w *Webhook) KeepAlive(ctx context.Context) {
+	lg := w.client.log()
+	var wait time.Duration
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(wait):
+		}
+
+		w.mu.Lock()
+		registered := w.id != ""
+		w.mu.Unlock()
+		var err error
+		if registered {
+			err = w.Refresh(ctx)
+		}
+		if !registered || errors.Is(err, ErrNotFound) {
+			err = w.Register(ctx)
+		}
+		if err != nil && ctx.Err() == nil {
+			lg.Warn(ctx, "airtable webhook registration failed", "error", err, "retry_in", webhookRetryEvery)
+			wait = webhookRetryEvery
+			continue
+		}
+		wait = webhookRefreshEvery
+	}
+}
+
+// Verify reports whether mac, the MACHeader of a notification, signs body
+// with the secret of the registered webhook.
+func (w *Webhook) Verify(body []byte, mac string) bool {
+	w.mu.Lock()
+	secret := w.secret
+	w.mu.Unlock()
+	sum, ok := strings.CutPrefix(mac, "hmac-sha256=")
+	if len(secret) == 0 || !ok {
+		return false
+	}
+	got, err := hex.DecodeString(sum)
+	if err != nil {
+		return false
+	}
+	h := hmac.New(sha256.New, secret)
+	h.Write(body)
+	return hmac.Equal(got, h.Sum(nil))
+}
+
+type webhookPayloads struct {
+	Cursor        int  `json:"cursor"`
+	MightHaveMore bool `json:"mightHaveMore"`
+	Payloads      []struct {
+		ChangedTablesByID map[string]struct {
+			CreatedRecordsByID map[string]struct{} `json:"createdRecordsById"`
+			ChangedRecordsByID map[string]struct{} `json:"changedRecordsById"`
+			DestroyedRecordIDs []string            `json:"destroyedRecordIds"`
+		} `json:"changedTablesById"`
+	} `json:"payloads"`
+}
+
+// EntryChanges returns the stock entries created, updated or deleted since
+// the previous call, reading the webhook payloads that follow its cursor.
+func (w *Webhook) EntryChanges(ctx context.Context) (domain.EntryChanges, error) {
+	w.mu.Lock()
+	id, cursor := w.id, w.cursor
+	w.mu.Unlock()
+	if id == "" {
+		return domain.EntryChanges{}, errors.New("webhook not registered")
+	}
+
+	var changes domain.EntryChanges
+	upserted, destroyed := map[string]bool{}, map[string]bool{}
+	for {
+		q := url.Values{"cursor": {strconv.Itoa(cursor)}}
+		var page webhookPayloads
+		if err := w.client.call(ctx, http.MethodGet, w.endpoint("/"+url.PathEscape(id)+"/payloads?"+q.Encode()), nil, &page); err != nil {
+			return domain.EntryChanges{}, fmt.Errorf("webhook payloads: %w", err)
+		}
+		for _, p := range page.Payloads {
+			for _, t := range p.ChangedTablesByID {
+				for _, recs := range []map[string]struct{}{t.CreatedRecordsByID, t.ChangedRecordsByID} {
+					for _, recID := range slices.Sorted(maps.Keys(recs)) {
+						if !upserted[recID] {
+							upserted[recID] = true
+							changes.Upserted = append(changes.Upserted, recID)
+						}
+					}
+				}
+				for _, recID := range t.DestroyedRecordIDs {
+					if !destroyed[recID] {
+						destroyed[recID] = true
+						changes.Destroyed = append(changes.Destroyed, recID)
+					}
+				}
+			}
+		}
+		cursor = page.Cursor
+		if !page.MightHaveMore {
+			break
+		}
+	}
+
+	w.mu.Lock()
+	current := w.id == id
+	if current {
+		w.cursor = cursor
+	}
+	state := domain.WebhookState{ID: w.id, URL: w.url, Secret: w.secret, Cursor: w.cursor}
+	w.mu.Unlock()
+	if current {
+		w.save(ctx, state)
+	}
+	return changes, nil
+}
@@ -0,0 +1,203 @@
+package airtable
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	"github.com/nomenarkt/vitaltrack/backend/internal/domain"
+	"github.com/nomenarkt/vitaltrack/backend/internal/domain/ports"
+)
+
+const hookURL = "https://vitaltrack.example/webhooks/airtable"
+
+// fakeWebhooks serves the parts of the metadata and webhooks APIs a Webhook
+// uses. It holds one webhook left by an earlier run for hookURL and one for
+// another URL, and two pages of payloads.
+type fakeWebhooks struct {
+	t         *testing.T
+	deleted   []string
+	refreshed []string
+	created   map[string]any
+	cursors   []string
+}
+
+func (f *fakeWebhooks) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	switch r.Method + " " + r.URL.Path {
+	case "GET /v0/meta/bases/base/tables":
+		_, _ = fmt.Fprint(w, `{"tables":[{"id":"tblMeds","name":"meds"},{"id":"tblEntries","name":"entries"}]}`)
+	case "GET /v0/bases/base/webhooks":
+		_, _ = fmt.Fprintf(w, `{"webhooks":[{"id":"achOld","notificationUrl":%q},{"id":"achOther","notificationUrl":"https://elsewhere.example"}]}`, hookURL)
+	case "DELETE /v0/bases/base/webhooks/achOld", "DELETE /v0/bases/base/webhooks/achOther":
+		f.deleted = append(f.deleted, r.URL.Path)
+	case "POST /v0/bases/base/webhooks/achOld/refresh":
+		f.refreshed = append(f.refreshed, r.URL.Path)
+	case "POST /v0/bases/base/webhooks":
+		b, _ := io.ReadAll(r.Body)
+		if err := json.Unmarshal(b, &f.created); err != nil {
+			f.t.Errorf("decode body: %v", err)
+		}
+		_, _ = fmt.Fprintf(w, `{"id":"achNew","macSecretBase64":%q}`, base64.StdEncoding.EncodeToString([]byte("secret")))
+	case "GET /v0/bases/base/webhooks/achNew/payloads":
+		cursor := r.URL.Query().Get("cursor")
+		f.cursors = append(f.cursors, cursor)
+		switch cursor {
+		case "1":
+			_, _ = fmt.Fprint(w, `{"cursor":3,"mightHaveMore":true,"payloads":[
+				{"changedTablesById":{"tblEntries":{"createdRecordsById":{"recA":{"cellValuesByFieldId":{}}}}}},
+				{"changedTablesById":{"tblEntries":{"changedRecordsById":{"recA":{},"recB":{}}}}}]}`)
+		case "3":
+			_, _ = fmt.Fprint(w, `{"cursor":4,"mightHaveMore":false,"payloads":[
+				{"changedTablesById":{"tblEntries":{"destroyedRecordIds":["recC"]}}}]}`)
+		default:
+			_, _ = fmt.Fprintf(w, `{"cursor":%s,"mightHaveMore":false,"payloads":[]}`, cursor)
+		}
+	default:
+		f.t.Errorf("unexpected request %s %s", r.Method, r.URL.Path)
+		w.WriteHeader(http.StatusNotFound)
+	}
+}
+
+// memWebhookState is an in-memory ports.WebhookStatePort.
+type memWebhookState struct{ state *domain.WebhookState }
+
+func (m *memWebhookState) LoadWebhook() (domain.WebhookState, error) {
+	if m.state == nil {
+		return domain.WebhookState{}, domain.ErrNotFound
+	}
+	return *m.state, nil
+}
+
+func (m *memWebhookState) SaveWebhook(s domain.WebhookState) error {
+	m.state = &s
+	return nil
+}
+
+// newTestWebhook registers a Webhook, saving its state in store when it is
+// not nil.
+func newTestWebhook(t *testing.T, store ports.WebhookStatePort) (*Webhook, *fakeWebhooks) {
+	t.Helper()
+	fake := &fakeWebhooks{t: t}
+	srv := httptest.NewServer(fake)
+	t.Cleanup(srv.Close)
+	c := &Client{cfg: testConfig, baseURL: srv.URL}
+	w := c.EntriesWebhook(hookURL)
+	if store != nil {
+		w.UseStore(store)
+	}
+	if err := w.Register(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	return w, fake
+}
+
+func TestWebhook_Register(t *testing.T) {
+	tests := []struct {
+		name        string
+		saved       *domain.WebhookState
+		wantDeleted []string
+		wantReused  bool
+	}{
+		{name: "no_store"},
+		{name: "saved_reused", saved: &domain.WebhookState{ID: "achOld", URL: hookURL, Secret: []byte("old"), Cursor: 7}, wantReused: true},
+		{name: "saved_gone", saved: &domain.WebhookState{ID: "achGone", URL: hookURL, Secret: []byte("old"), Cursor: 7}},
+		{name: "url_changed", saved: &domain.WebhookState{ID: "achOld", URL: "https://old.example/webhooks/airtable", Secret: []byte("old"), Cursor: 7},
+			wantDeleted: []string{"/v0/bases/base/webhooks/achOld"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var store ports.WebhookStatePort
+			mem := &memWebhookState{state: tt.saved}
+			if tt.saved != nil {
+				store = mem
+			}
+			w, fake := newTestWebhook(t, store)
+
+			if !reflect.DeepEqual(fake.deleted, tt.wantDeleted) {
+				t.Errorf("deleted = %v, want %v", fake.deleted, tt.wantDeleted)
+			}
+			if tt.wantReused {
+				if fake.created != nil || len(fake.refreshed) != 1 || w.id != "achOld" || w.cursor != 7 || string(w.secret) != "old" {
+					t.Errorf("saved webhook not reused: created %v, refreshed %v, webhook %s at %d", fake.created, fake.refreshed, w.id, w.cursor)
+				}
+				return
+			}
+			spec, _ := json.Marshal(fake.created["specification"])
+			if fake.created["notificationUrl"] != hookURL || string(spec) != `{"options":{"filters":{"dataTypes":["tableData"],"recordChangeScope":"tblEntries"}}}` {
+				t.Errorf("created = %v", fake.created)
+			}
+			want := &domain.WebhookState{ID: "achNew", URL: hookURL, Secret: []byte("secret"), Cursor: 1}
+			if tt.saved != nil && !reflect.DeepEqual(mem.state, want) {
+				t.Errorf("saved state = %+v, want %+v", mem.state, want)
+			}
+		})
+	}
+}
+
+func TestWebhook_Verify(t *testing.T) {
+	w, _ := newTestWebhook(t, nil)
+	body := []byte(`{"base":{"id":"base"},"webhook":{"id":"achNew"},"timestamp":"2025-06-10T09:00:00.000Z"}`)
+	h := hmac.New(sha256.New, []byte("secret"))
+	h.Write(body)
+	mac := "hmac-sha256=" + hex.EncodeToString(h.Sum(nil))
+
+	tests := []struct {
+		name string
+		body []byte
+		mac  string
+		want bool
+	}{
+		{name: "signed", body: body, mac: mac, want: true},
+		{name: "tampered", body: append([]byte(" "), body...), mac: mac},
+		{name: "missing", body: body},
+		{name: "not_hex", body: body, mac: "hmac-sha256=zz"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := w.Verify(tt.body, tt.mac); got != tt.want {
+				t.Errorf("Verify = %v, want %v", got, tt.want)
+			}
+		})
+	}
+
+	unregistered := (&Client{cfg: testConfig}).EntriesWebhook(hookURL)
+	if unregistered.Verify(body, mac) {
+		t.Error("a webhook that is not registered accepted a notification")
+	}
+}
+
+func TestWebhook_EntryChanges(t *testing.T) {
+	store := &memWebhookState{}
+	w, fake := newTestWebhook(t, store)
+
+	changes, err := w.EntryChanges(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(changes.Upserted, []string{"recA", "recB"}) || !reflect.DeepEqual(changes.Destroyed, []string{"recC"}) {
+		t.Errorf("changes = %+v", changes)
+	}
+
+	changes, err = w.EntryChanges(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !changes.Empty() {
+		t.Errorf("changes read twice: %+v", changes)
+	}
+	if want := []string{"1", "3", "4"}; !reflect.DeepEqual(fake.cursors, want) {
+		t.Errorf("cursors = %v, want %v", fake.cursors, want)
+	}
+	if store.state == nil || store.state.Cursor != 4 {
+		t.Errorf("saved state = %+v, want cursor 4", store.state)
+	}
+}
@@ -0,0 +1,226 @@
+// Package cache keeps Airtable reads in memory, so that commands, jobs and
+// API requests arriving together do not each download the whole base.
+package cache
+
+import (
+	"context"
+	"slices"
+	"strconv"
+	"sync"
+	"time"
+
+	"golang.org/x/sync/singleflight"
+
+	"github.com/nomenarkt/vitaltrack/backend/internal/domain"
+	"github.com/nomenarkt/vitaltrack/backend/internal/domain/ports"
+	"github.com/nomenarkt/vitaltrack/backend/internal/logger"
+	"github.com/nomenarkt/vitaltrack/backend/internal/metrics"
+)
+
+// Store is the medicine and stock storage wrapped by Stock.
+type Store interface {
+	ports.StockDataPort
+	ports.AirtableService
+}
+
+// TTLs are how long each table is served from memory before it is fetched
+// again. A zero TTL disables caching of the table.
+type TTLs struct {
+	Medicines  time.Duration
+	Entries    time.Duration
+	DoseEvents time.Duration
+}
+
+// Stock is a read-through cache of the medicines and stock entries tables,
+// and of the dose history of the DoseLog it returns.
+// Concurrent misses share one fetch, and every write through Stock
+// invalidates the table it changes. When a fetch fails and an earlier
+// result is held, the earlier result is served and the failure logged as a
+// warning. Single records and financial entries are not cached.
+type Stock struct {
+	Store
+	ttl TTLs
+	lg  logger.Logger
+	now func() time.Time
+
+	mu        sync.Mutex
+	medicines table[domain.Medicine]
+	entries   table[domain.StockEntry]
+	doses     table[domain.DoseEvent]
+	group     singleflight.Group
+}
+
+// table holds the last fetched rows of one table.
+type table[T any] struct {
+	rows      []T
+	fetchedAt time.Time
+	held      bool   // rows were fetched at least once
+	stale     bool   // invalidated by a write
+	gen       uint64 // incremented by every invalidation
+}
+
+// NewStock returns a Stock reading through to store, logging to lg.
+func NewStock(store Store, ttl TTLs, lg logger.Logger) *Stock {
+	return &Stock{Store: store, ttl: ttl, lg: logger.OrNop(lg), now: time.Now}
+}
+
+// FetchMedicines returns the medicines table.
+func (s *Stock) FetchMedicines(ctx context.Context) ([]domain.Medicine, error) {
+	return read(ctx, s, "medicines", &s.medicines, s.ttl.Medicines, s.Store.FetchMedicines)
+}
+
+// FetchStockEntries returns the stock entries table.
+func (s *Stock) FetchStockEntries(ctx context.Context) ([]domain.StockEntry, error) {
+	return read(ctx, s, "entries", &s.entries, s.ttl.Entries, s.Store.FetchStockEntries)
+}
+
+// DoseLog is a dose log whose complete history, which stock calculations
+// read to credit skipped doses, is cached by a Stock. Events since a date
+// are read through, and every write invalidates the history.
+type DoseLog struct {
+	ports.AdherencePort
+	stock *Stock
+}
+
+// DoseLog returns log with its complete history cached in s for
+// TTLs.DoseEvents.
+func (s *Stock) DoseLog(log ports.AdherencePort) *DoseLog {
+	return &DoseLog{AdherencePort: log, stock: s}
+}
+
+// FetchDoseEvents returns the events scheduled at or after since, from the
+// cache when since is zero.
+func (d *DoseLog) FetchDoseEvents(ctx context.Context, since time.Time) ([]domain.DoseEvent, error) {
+	if !since.IsZero() {
+		return d.AdherencePort.FetchDoseEvents(ctx, since)
+	}
+	return read(ctx, d.stock, "dose_events", &d.stock.doses, d.stock.ttl.DoseEvents, func(ctx context.Context) ([]domain.DoseEvent, error) {
+		return d.AdherencePort.FetchDoseEvents(ctx, time.Time{})
+	})
+}
+
+// CreateDoseEvent stores e and invalidates the dose history.
+func (d *DoseLog) CreateDoseEvent(ctx context.Context, e domain.DoseEvent) (domain.DoseEvent, error) {
+	defer invalidate(d.stock, &d.stock.doses)
+	return d.AdherencePort.CreateDoseEvent(ctx, e)
+}
+
+// UpdateDoseEvent applies patch and invalidates the dose history.
+func (d *DoseLog) UpdateDoseEvent(ctx context.Context, id string, patch domain.DoseEventPatch) (domain.DoseEvent, error) {
+	defer invalidate(d.stock, &d.stock.doses)
+	return d.AdherencePort.UpdateDoseEvent(ctx, id, patch)
+}
+
+// read returns a copy of the rows of t, fetching them when they are missing,
+// expired or invalidated.
+func read[T any](ctx context.Context, s *Stock, name string, t *table[T], ttl time.Duration, fetch func(context.Context) ([]T, error)) ([]T, error) {
+	if ttl <= 0 {
+		return fetch(ctx)
+	}
+
+	s.mu.Lock()
+	if t.held && !t.stale && s.now().Sub(t.fetchedAt) < ttl {
+		rows := slices.Clone(t.rows)
+		s.mu.Unlock()
+		metrics.CacheReads.WithLabelValues(name, "hit").Inc()
+		return rows, nil
+	}
+	gen := t.gen
+	s.mu.Unlock()
+
+	// The key carries the generation, so that a read following a write does
+	// not share a fetch started before it.
+	v, err, _ := s.group.Do(name+"/"+strconv.FormatUint(gen, 10), func() (any, error) {
+		// Not cancelled with the caller that happens to run the fetch, since
+		// the others wait for it too.
+		rows, err := fetch(context.WithoutCancel(ctx))
+		if err != nil {
+			return nil, err
+		}
+		s.mu.Lock()
+		if t.gen == gen {
+			t.rows, t.fetchedAt, t.held, t.stale = rows, s.now(), true, false
+		}
+		s.mu.Unlock()
+		return rows, nil
+	})
+	if err != nil {
+		s.mu.Lock()
+		rows, held := slices.Clone(t.rows), t.held
+		s.mu.Unlock()
+		if !held {
+			metrics.CacheReads.WithLabelValues(name, "error").Inc()
+			return nil, err
+		}
+		metrics.CacheReads.WithLabelValues(name, "stale").Inc()
+		s.lg.Warn(ctx, "airtable unavailable, serving cached data", "table", name, "error", err)
+		return rows, nil
+	}
+	metrics.CacheReads.WithLabelValues(name, "miss").Inc()
+	return slices.Clone(v.([]T)), nil
+}
+
+// invalidate marks the rows of t as needing a fetch. They are still served
+// if that fetch fails.
+func invalidate[T any](s *Stock, t *table[T]) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	t.stale = true
+	t.gen++
+}
+
+// InvalidateEntries makes the next read fetch the stock entries, for when
+// they were changed outside the server.
+func (s *Stock) InvalidateEntries() {
+	invalidate(s, &s.entries)
+}
+
+// CreateStockEntry stores e and invalidates the stock entries.
+func (s *Stock) CreateStockEntry(ctx context.Context, e domain.StockEntry) (domain.StockEntry, error) {
+	defer invalidate(s, &s.entries)
+	return s.Store.CreateStockEntry(ctx, e)
+}
+
+// UpdateStockEntry applies patch and invalidates the stock entries.
+func (s *Stock) UpdateStockEntry(ctx context.Context, id string, patch domain.StockEntryPatch) (domain.StockEntry, error) {
+	defer invalidate(s, &s.entries)
+	return s.Store.UpdateStockEntry(ctx, id, patch)
+}
+
+// CreateMedicine stores m and invalidates the medicines.
+func (s *Stock) CreateMedicine(ctx context.Context, m domain.Medicine) (domain.Medicine, error) {
+	defer invalidate(s, &s.medicines)
+	return s.Store.CreateMedicine(ctx, m)
+}
+
+// UpdateMedicine applies patch and invalidates the medicines.
+func (s *Stock) UpdateMedicine(ctx context.Context, id string, patch domain.MedicinePatch) (domain.Medicine, error) {
+	defer invalidate(s, &s.medicines)
+	return s.Store.UpdateMedicine(ctx, id, patch)
+}
+
+// UpdateForecastDate stores the forecast and invalidates the medicines.
+func (s *Stock) UpdateForecastDate(ctx context.Context, medicineID string, forecastDate, updatedAt time.Time) error {
+	defer invalidate(s, &s.medicines)
+	return s.Store.UpdateForecastDate(ctx, medicineID, forecastDate, updatedAt)
+}
+
+// UpdateMedicineLastAlertedDate stores the alert date and invalidates the
+// medicines.
+func (s *Stock) UpdateMedicineLastAlertedDate(ctx context.Context, medicineID string, date time.Time) error {
+	defer invalidate(s, &s.medicines)
+	return s.Store.UpdateMedicineLastAlertedDate(ctx, medicineID, date)
+}
+
+// UpdateForecastDates stores the forecasts and invalidates the medicines.
+func (s *Stock) UpdateForecastDates(ctx context.Context, updates []domain.ForecastUpdate, updatedAt time.Time) error {
+	defer invalidate(s, &s.medicines)
+	return s.Store.UpdateForecastDates(ctx, updates, updatedAt)
+}
+
+// UpdateLastAlertedDates stores the alert dates and invalidates the
+// medicines.
+func (s *Stock) UpdateLastAlertedDates(ctx context.Context, medicineIDs []string, date time.Time) error {
+	defer invalidate(s, &s.medicines)
+	return s.Store.UpdateLastAlertedDates(ctx, medicineIDs, date)
+}
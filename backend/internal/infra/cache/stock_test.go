@@ -0,0 +1,284 @@
+package cache
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/nomenarkt/vitaltrack/backend/internal/domain"
+	"github.com/nomenarkt/vitaltrack/backend/internal/domain/ports"
+	"github.com/nomenarkt/vitaltrack/backend/internal/logger"
+)
+
+// fakeStore counts fetches. Its methods not overridden panic.
+type fakeStore struct {
+	Store
+	medFetches   atomic.Int32
+	entryFetches atomic.Int32
+	release      chan struct{} // blocks fetches until closed, when set
+	err          error
+}
+
+func (f *fakeStore) FetchMedicines(context.Context) ([]domain.Medicine, error) {
+	f.medFetches.Add(1)
+	if f.release != nil {
+		<-f.release
+	}
+	if f.err != nil {
+		return nil, f.err
+	}
+	return []domain.Medicine{{ID: "m1", Name: "Aspirin"}}, nil
+}
+
+func (f *fakeStore) FetchStockEntries(context.Context) ([]domain.StockEntry, error) {
+	f.entryFetches.Add(1)
+	return []domain.StockEntry{{ID: "e1", MedicineID: []string{"m1"}, Quantity: 10}}, nil
+}
+
+func (f *fakeStore) CreateStockEntry(_ context.Context, e domain.StockEntry) (domain.StockEntry, error) {
+	return e, nil
+}
+
+func (f *fakeStore) UpdateForecastDate(context.Context, string, time.Time, time.Time) error {
+	return nil
+}
+
+// fakeDoseLog counts fetches of the complete history. Its methods not
+// overridden panic.
+type fakeDoseLog struct {
+	ports.AdherencePort
+	fetches atomic.Int32
+}
+
+func (f *fakeDoseLog) FetchDoseEvents(_ context.Context, since time.Time) ([]domain.DoseEvent, error) {
+	if since.IsZero() {
+		f.fetches.Add(1)
+	}
+	return []domain.DoseEvent{{ID: "d1", MedicineID: "m1", Pills: 1, Status: domain.DoseSkipped}}, nil
+}
+
+func (f *fakeDoseLog) UpdateDoseEvent(_ context.Context, id string, _ domain.DoseEventPatch) (domain.DoseEvent, error) {
+	return domain.DoseEvent{ID: id}, nil
+}
+
+func newTestStock(store Store, ttl TTLs, lg logger.Logger) (*Stock, *time.Time) {
+	s := NewStock(store, ttl, lg)
+	now := time.Date(2025, 6, 1, 8, 0, 0, 0, time.UTC)
+	s.now = func() time.Time { return now }
+	return s, &now
+}
+
+func TestStock_ttl(t *testing.T) {
+	f := &fakeStore{}
+	s, now := newTestStock(f, TTLs{Medicines: time.Minute, Entries: time.Minute}, nil)
+	ctx := context.Background()
+
+	for range 3 {
+		if _, err := s.FetchMedicines(ctx); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if n := f.medFetches.Load(); n != 1 {
+		t.Fatalf("fetches within TTL = %d, want 1", n)
+	}
+
+	*now = now.Add(time.Minute)
+	if _, err := s.FetchMedicines(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if n := f.medFetches.Load(); n != 2 {
+		t.Errorf("fetches after TTL = %d, want 2", n)
+	}
+}
+
+func TestStock_zeroTTL(t *testing.T) {
+	f := &fakeStore{}
+	s, _ := newTestStock(f, TTLs{Medicines: time.Minute}, nil)
+	ctx := context.Background()
+
+	for range 2 {
+		if _, err := s.FetchStockEntries(ctx); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if n := f.entryFetches.Load(); n != 2 {
+		t.Errorf("uncached fetches = %d, want 2", n)
+	}
+}
+
+func TestStock_invalidatesOnWrite(t *testing.T) {
+	tests := []struct {
+		name    string
+		write   func(*Stock) error
+		fetches func(*fakeStore) int32
+		read    func(*Stock) error
+	}{
+		{
+			name: "entry_created",
+			write: func(s *Stock) error {
+				_, err := s.CreateStockEntry(context.Background(), domain.StockEntry{MedicineID: []string{"m1"}})
+				return err
+			},
+			fetches: func(f *fakeStore) int32 { return f.entryFetches.Load() },
+			read: func(s *Stock) error {
+				_, err := s.FetchStockEntries(context.Background())
+				return err
+			},
+		},
+		{
+			name: "entries_changed_elsewhere",
+			write: func(s *Stock) error {
+				s.InvalidateEntries()
+				return nil
+			},
+			fetches: func(f *fakeStore) int32 { return f.entryFetches.Load() },
+			read: func(s *Stock) error {
+				_, err := s.FetchStockEntries(context.Background())
+				return err
+			},
+		},
+		{
+			name: "forecast_updated",
+			write: func(s *Stock) error {
+				return s.UpdateForecastDate(context.Background(), "m1", time.Now(), time.Now())
+			},
+			fetches: func(f *fakeStore) int32 { return f.medFetches.Load() },
+			read: func(s *Stock) error {
+				_, err := s.FetchMedicines(context.Background())
+				return err
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := &fakeStore{}
+			s, _ := newTestStock(f, TTLs{Medicines: time.Hour, Entries: time.Hour}, nil)
+
+			if err := tt.read(s); err != nil {
+				t.Fatal(err)
+			}
+			if err := tt.write(s); err != nil {
+				t.Fatal(err)
+			}
+			if err := tt.read(s); err != nil {
+				t.Fatal(err)
+			}
+			if n := tt.fetches(f); n != 2 {
+				t.Errorf("fetches = %d, want 2", n)
+			}
+		})
+	}
+}
+
+func TestDoseLog(t *testing.T) {
+	f := &fakeDoseLog{}
+	s, now := newTestStock(&fakeStore{}, TTLs{DoseEvents: time.Minute}, nil)
+	log := s.DoseLog(f)
+	ctx := context.Background()
+	fetch := func(since time.Time) {
+		t.Helper()
+		if _, err := log.FetchDoseEvents(ctx, since); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	fetch(time.Time{})
+	fetch(time.Time{})
+	fetch(now.AddDate(0, 0, -7)) // not cached
+	if n := f.fetches.Load(); n != 1 {
+		t.Fatalf("history fetches within TTL = %d, want 1", n)
+	}
+
+	if _, err := log.UpdateDoseEvent(ctx, "d1", domain.DoseEventPatch{Status: domain.DoseSkipped}); err != nil {
+		t.Fatal(err)
+	}
+	fetch(time.Time{})
+	if n := f.fetches.Load(); n != 2 {
+		t.Fatalf("history fetches after a write = %d, want 2", n)
+	}
+
+	*now = now.Add(time.Minute)
+	fetch(time.Time{})
+	if n := f.fetches.Load(); n != 3 {
+		t.Errorf("history fetches after TTL = %d, want 3", n)
+	}
+}
+
+func TestStock_sharesConcurrentFetches(t *testing.T) {
+	f := &fakeStore{release: make(chan struct{})}
+	s, _ := newTestStock(f, TTLs{Medicines: time.Minute}, nil)
+
+	var wg sync.WaitGroup
+	for range 5 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, err := s.FetchMedicines(context.Background()); err != nil {
+				t.Error(err)
+			}
+		}()
+	}
+	for f.medFetches.Load() == 0 {
+		time.Sleep(time.Millisecond)
+	}
+	time.Sleep(10 * time.Millisecond) // let the other readers join
+	close(f.release)
+	wg.Wait()
+
+	if n := f.medFetches.Load(); n != 1 {
+		t.Errorf("fetches = %d, want 1", n)
+	}
+}
+
+func TestStock_servesStaleWhenDown(t *testing.T) {
+	var buf bytes.Buffer
+	f := &fakeStore{}
+	s, now := newTestStock(f, TTLs{Medicines: time.Minute}, logger.New(&buf, logger.Options{}))
+	ctx := context.Background()
+
+	if _, err := s.FetchMedicines(ctx); err != nil {
+		t.Fatal(err)
+	}
+	f.err = errors.New("airtable: 503")
+	*now = now.Add(time.Hour)
+
+	meds, err := s.FetchMedicines(ctx)
+	if err != nil {
+		t.Fatalf("stale read failed: %v", err)
+	}
+	if len(meds) != 1 || meds[0].ID != "m1" {
+		t.Errorf("stale medicines = %+v", meds)
+	}
+	if !strings.Contains(buf.String(), "level=WARN") || !strings.Contains(buf.String(), "airtable: 503") {
+		t.Errorf("missing warning:\n%s", buf.String())
+	}
+
+	cold, _ := newTestStock(f, TTLs{Medicines: time.Minute}, nil)
+	if _, err := cold.FetchMedicines(ctx); err == nil {
+		t.Error("read without cached data succeeded")
+	}
+}
+
+func TestStock_returnsCopies(t *testing.T) {
+	s, _ := newTestStock(&fakeStore{}, TTLs{Medicines: time.Minute}, nil)
+	ctx := context.Background()
+
+	meds, err := s.FetchMedicines(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	meds[0].SkippedPills = 4
+
+	again, err := s.FetchMedicines(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if again[0].SkippedPills != 0 {
+		t.Error("caller change leaked into the cache")
+	}
+}
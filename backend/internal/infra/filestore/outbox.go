@@ -0,0 +1,179 @@
+// Package filestore provides small JSON-file backed stores for state that
+// must survive restarts but does not belong in Airtable.
+package filestore
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"sync"
+
+	"github.com/nomenarkt/vitaltrack/backend/internal/domain"
+)
+
+// MaxDeadMessages is how many dead-lettered messages an Outbox keeps for
+// inspection. Older ones are dropped as new ones die.
+const MaxDeadMessages = 100
+
+// Outbox is a ports.OutboxPort persisted to a JSON file. Every change is
+// written through, so queued messages survive a crash or restart. With an
+// empty path the outbox is kept in memory only.
+type Outbox struct {
+	mu   sync.Mutex
+	path string
+	data outboxFile
+}
+
+type outboxFile struct {
+	NextID   int                    `json:"next_id"`
+	Messages []domain.OutboxMessage `json:"messages"`
+}
+
+// OpenOutbox loads the outbox at path, creating it on first write.
+func OpenOutbox(path string) (*Outbox, error) {
+	o := &Outbox{path: path}
+	if path == "" {
+		return o, nil
+	}
+	b, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return o, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("read outbox: %w", err)
+	}
+	if err := json.Unmarshal(b, &o.data); err != nil {
+		return nil, fmt.Errorf("decode outbox %s: %w", path, err)
+	}
+	return o, nil
+}
+
+// save writes the outbox atomically. Callers must hold o.mu.
+func (o *Outbox) save() error {
+	if o.path == "" {
+		return nil
+	}
+	b, err := json.MarshalIndent(o.data, "", "  ")
+	if err != nil {
+		return err
+	}
+	return writeFileAtomic(o.path, b)
+}
+
+// Enqueue appends m and returns it with a generated ID.
+func (o *Outbox) Enqueue(m domain.OutboxMessage) (domain.OutboxMessage, error) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	o.data.NextID++
+	m.ID = fmt.Sprintf("msg%d", o.data.NextID)
+	if m.Status == "" {
+		m.Status = domain.OutboxQueued
+	}
+	o.data.Messages = append(o.data.Messages, m)
+	if err := o.save(); err != nil {
+		o.data.Messages = o.data.Messages[:len(o.data.Messages)-1]
+		return domain.OutboxMessage{}, fmt.Errorf("persist outbox: %w", err)
+	}
+	return m, nil
+}
+
+// Pending returns queued messages in enqueue order.
+func (o *Outbox) Pending() ([]domain.OutboxMessage, error) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	var out []domain.OutboxMessage
+	for _, m := range o.data.Messages {
+		if m.Status == domain.OutboxQueued {
+			out = append(out, m)
+		}
+	}
+	return out, nil
+}
+
+// UpdateMessage replaces the stored message with the same ID. Past
+// MaxDeadMessages, the oldest dead messages are dropped.
+func (o *Outbox) UpdateMessage(m domain.OutboxMessage) error {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	for i := range o.data.Messages {
+		if o.data.Messages[i].ID == m.ID {
+			o.data.Messages[i] = m
+			if m.Status == domain.OutboxDead {
+				o.pruneDead()
+			}
+			return o.save()
+		}
+	}
+	return domain.ErrNotFound
+}
+
+// pruneDead drops the oldest dead messages past MaxDeadMessages. Callers must
+// hold o.mu.
+func (o *Outbox) pruneDead() {
+	extra := -MaxDeadMessages
+	for _, m := range o.data.Messages {
+		if m.Status == domain.OutboxDead {
+			extra++
+		}
+	}
+	if extra <= 0 {
+		return
+	}
+	o.data.Messages = slices.DeleteFunc(o.data.Messages, func(m domain.OutboxMessage) bool {
+		if m.Status != domain.OutboxDead || extra == 0 {
+			return false
+		}
+		extra--
+		return true
+	})
+}
+
+// Remove drops the message with the given ID.
+func (o *Outbox) Remove(id string) error {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	for i := range o.data.Messages {
+		if o.data.Messages[i].ID == id {
+			o.data.Messages = append(o.data.Messages[:i], o.data.Messages[i+1:]...)
+			return o.save()
+		}
+	}
+	return domain.ErrNotFound
+}
+
+// Dead returns dead-lettered messages in enqueue order.
+func (o *Outbox) Dead() []domain.OutboxMessage {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	var out []domain.OutboxMessage
+	for _, m := range o.data.Messages {
+		if m.Status == domain.OutboxDead {
+			out = append(out, m)
+		}
+	}
+	return out
+}
+
+// writeFileAtomic writes b next to path and renames it into place.
+func writeFileAtomic(path string, b []byte) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	if _, err := tmp.Write(b); err != nil {
+		_ = tmp.Close()
+		_ = os.Remove(tmp.Name())
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		_ = os.Remove(tmp.Name())
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
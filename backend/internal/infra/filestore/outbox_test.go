@@ -0,0 +1,108 @@
+package filestore_test
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/nomenarkt/vitaltrack/backend/internal/domain"
+	"github.com/nomenarkt/vitaltrack/backend/internal/infra/filestore"
+)
+
+func TestOutbox_survivesReopen(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "state", "outbox.json")
+
+	o, err := filestore.OpenOutbox(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	first, err := o.Enqueue(domain.OutboxMessage{ChatID: "1", Text: "one"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	second, err := o.Enqueue(domain.OutboxMessage{ChatID: "1", Text: "two"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	second.Status = domain.OutboxDead
+	second.Attempts = 3
+	if err := o.UpdateMessage(second); err != nil {
+		t.Fatal(err)
+	}
+
+	reopened, err := filestore.OpenOutbox(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pending, _ := reopened.Pending()
+	if len(pending) != 1 || pending[0].ID != first.ID || pending[0].Status != domain.OutboxQueued {
+		t.Fatalf("pending after reopen = %+v", pending)
+	}
+	if dead := reopened.Dead(); len(dead) != 1 || dead[0].Attempts != 3 {
+		t.Fatalf("dead after reopen = %+v", dead)
+	}
+
+	if err := reopened.Remove(first.ID); err != nil {
+		t.Fatal(err)
+	}
+	third, err := reopened.Enqueue(domain.OutboxMessage{ChatID: "2", Text: "three"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if third.ID == first.ID || third.ID == second.ID {
+		t.Errorf("ID %s reused after reopen", third.ID)
+	}
+	if err := reopened.Remove(first.ID); !errors.Is(err, domain.ErrNotFound) {
+		t.Errorf("second remove err = %v, want ErrNotFound", err)
+	}
+
+	entries, err := os.ReadDir(filepath.Dir(path))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 1 {
+		t.Errorf("temporary files left behind: %v", entries)
+	}
+}
+
+func TestOpenOutbox_corruptFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "outbox.json")
+	if err := os.WriteFile(path, []byte("{not json"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := filestore.OpenOutbox(path); err == nil {
+		t.Fatal("expected error for corrupt outbox file")
+	}
+}
+
+func TestOutbox_keepsLatestDead(t *testing.T) {
+	o, err := filestore.OpenOutbox("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	queued, err := o.Enqueue(domain.OutboxMessage{ChatID: "1", Text: "queued"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var last domain.OutboxMessage
+	for range filestore.MaxDeadMessages + 5 {
+		m, err := o.Enqueue(domain.OutboxMessage{ChatID: "1", Text: "dead"})
+		if err != nil {
+			t.Fatal(err)
+		}
+		m.Status = domain.OutboxDead
+		if err := o.UpdateMessage(m); err != nil {
+			t.Fatal(err)
+		}
+		last = m
+	}
+
+	dead := o.Dead()
+	if len(dead) != filestore.MaxDeadMessages || dead[len(dead)-1].ID != last.ID {
+		t.Fatalf("kept %d dead messages ending %s, want %d ending %s", len(dead), dead[len(dead)-1].ID, filestore.MaxDeadMessages, last.ID)
+	}
+	if pending, _ := o.Pending(); len(pending) != 1 || pending[0].ID != queued.ID {
+		t.Errorf("pending = %+v, want %s kept", pending, queued.ID)
+	}
+}
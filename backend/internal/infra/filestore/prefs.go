@@ -0,0 +1,91 @@
+package filestore
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"sync"
+
+	"github.com/nomenarkt/vitaltrack/backend/internal/domain"
+)
+
+// Prefs is a ports.ChatPrefsPort persisted to a JSON file. With an empty
+// path the preferences are kept in memory only.
+type Prefs struct {
+	mu    sync.Mutex
+	path  string
+	chats map[string]domain.ChatPrefs
+}
+
+// OpenPrefs loads the preferences at path, creating the file on first write.
+func OpenPrefs(path string) (*Prefs, error) {
+	p := &Prefs{path: path, chats: map[string]domain.ChatPrefs{}}
+	if path == "" {
+		return p, nil
+	}
+	b, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return p, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("read prefs: %w", err)
+	}
+	if err := json.Unmarshal(b, &p.chats); err != nil {
+		return nil, fmt.Errorf("decode prefs %s: %w", path, err)
+	}
+	return p, nil
+}
+
+// GetChatPrefs returns the preferences saved for chatID.
+func (p *Prefs) GetChatPrefs(chatID string) (domain.ChatPrefs, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	prefs, ok := p.chats[chatID]
+	if !ok {
+		return domain.ChatPrefs{}, domain.ErrNotFound
+	}
+	return prefs, nil
+}
+
+// ListChatPrefs returns the preferences of every chat, ordered by chat ID.
+func (p *Prefs) ListChatPrefs() ([]domain.ChatPrefs, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	out := make([]domain.ChatPrefs, 0, len(p.chats))
+	for _, prefs := range p.chats {
+		out = append(out, prefs)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].ChatID < out[j].ChatID })
+	return out, nil
+}
+
+// SaveChatPrefs stores prefs for prefs.ChatID, replacing earlier ones.
+func (p *Prefs) SaveChatPrefs(prefs domain.ChatPrefs) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	prev, had := p.chats[prefs.ChatID]
+	p.chats[prefs.ChatID] = prefs
+	if err := p.save(); err != nil {
+		if had {
+			p.chats[prefs.ChatID] = prev
+		} else {
+			delete(p.chats, prefs.ChatID)
+		}
+		return fmt.Errorf("persist prefs: %w", err)
+	}
+	return nil
+}
+
+// save writes the preferences atomically. Callers must hold p.mu.
+func (p *Prefs) save() error {
+	if p.path == "" {
+		return nil
+	}
+	b, err := json.MarshalIndent(p.chats, "", "  ")
+	if err != nil {
+		return err
+	}
+	return writeFileAtomic(p.path, b)
+}
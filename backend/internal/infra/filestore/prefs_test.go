@@ -0,0 +1,63 @@
+package filestore_test
+
+import (
+	"errors"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/nomenarkt/vitaltrack/backend/internal/domain"
+	"github.com/nomenarkt/vitaltrack/backend/internal/infra/filestore"
+)
+
+func TestPrefs_survivesReopen(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "prefs.json")
+
+	p, err := filestore.OpenPrefs(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := p.GetChatPrefs("42"); !errors.Is(err, domain.ErrNotFound) {
+		t.Fatalf("unknown chat err = %v, want ErrNotFound", err)
+	}
+	for _, lang := range []string{"fr", "mg"} {
+		if err := p.SaveChatPrefs(domain.ChatPrefs{ChatID: "42", Language: lang}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	reopened, err := filestore.OpenPrefs(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := reopened.GetChatPrefs("42")
+	if err != nil || got.Language != "mg" {
+		t.Fatalf("prefs after reopen = %+v, %v", got, err)
+	}
+}
+
+func TestPrefs_list(t *testing.T) {
+	p, err := filestore.OpenPrefs("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, id := range []string{"9", "-100", "42"} {
+		if err := p.SaveChatPrefs(domain.ChatPrefs{ChatID: id, Digests: map[domain.DigestKind]bool{domain.DigestStock: true}}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	got, err := p.ListChatPrefs()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var ids []string
+	for _, prefs := range got {
+		ids = append(ids, prefs.ChatID)
+		if !prefs.Subscribed(domain.DigestStock, false) {
+			t.Errorf("chat %s lost its digest choice", prefs.ChatID)
+		}
+	}
+	if want := []string{"-100", "42", "9"}; !reflect.DeepEqual(ids, want) {
+		t.Errorf("chats = %v, want %v", ids, want)
+	}
+}
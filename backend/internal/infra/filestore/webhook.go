@@ -0,0 +1,67 @@
+package filestore
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+
+	"github.com/nomenarkt/vitaltrack/backend/internal/domain"
+)
+
+// WebhookState is a ports.WebhookStatePort persisted to a JSON file, which
+// holds the webhook secret and is only readable by its owner. With an empty
+// path the state is kept in memory only.
+type WebhookState struct {
+	mu    sync.Mutex
+	path  string
+	state *domain.WebhookState
+}
+
+// OpenWebhookState loads the webhook state at path, creating the file on
+// first write.
+func OpenWebhookState(path string) (*WebhookState, error) {
+	w := &WebhookState{path: path}
+	if path == "" {
+		return w, nil
+	}
+	b, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return w, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("read webhook state: %w", err)
+	}
+	if err := json.Unmarshal(b, &w.state); err != nil {
+		return nil, fmt.Errorf("decode webhook state %s: %w", path, err)
+	}
+	return w, nil
+}
+
+// LoadWebhook returns the saved state, or domain.ErrNotFound.
+func (w *WebhookState) LoadWebhook() (domain.WebhookState, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.state == nil {
+		return domain.WebhookState{}, domain.ErrNotFound
+	}
+	return *w.state, nil
+}
+
+// SaveWebhook replaces the saved state with s.
+func (w *WebhookState) SaveWebhook(s domain.WebhookState) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.path != "" {
+		b, err := json.MarshalIndent(s, "", "  ")
+		if err != nil {
+			return err
+		}
+		if err := writeFileAtomic(w.path, b); err != nil {
+			return fmt.Errorf("persist webhook state: %w", err)
+		}
+	}
+	w.state = &s
+	return nil
+}
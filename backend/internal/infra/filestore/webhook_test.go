@@ -0,0 +1,43 @@
+package filestore_test
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/nomenarkt/vitaltrack/backend/internal/domain"
+	"github.com/nomenarkt/vitaltrack/backend/internal/infra/filestore"
+)
+
+func TestWebhookState_survivesReopen(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "webhook.json")
+
+	w, err := filestore.OpenWebhookState(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := w.LoadWebhook(); !errors.Is(err, domain.ErrNotFound) {
+		t.Fatalf("empty state: err = %v, want ErrNotFound", err)
+	}
+	saved := domain.WebhookState{ID: "ach1", URL: "https://example.com/hook", Secret: []byte("secret"), Cursor: 4}
+	if err := w.SaveWebhook(saved); err != nil {
+		t.Fatal(err)
+	}
+
+	reopened, err := filestore.OpenWebhookState(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, err := reopened.LoadWebhook(); err != nil || !reflect.DeepEqual(got, saved) {
+		t.Fatalf("state after reopen = %+v, %v; want %+v", got, err, saved)
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if perm := info.Mode().Perm(); perm&0o077 != 0 {
+		t.Errorf("state file mode = %v, want owner only", perm)
+	}
+}
@@ -0,0 +1,93 @@
+package memstore
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/nomenarkt/vitaltrack/backend/internal/domain"
+)
+
+// DoseLog is an in-memory ports.AdherencePort.
+type DoseLog struct {
+	mu     sync.Mutex
+	events []domain.DoseEvent
+	nextID int
+}
+
+// NewDoseLog returns an empty DoseLog.
+func NewDoseLog() *DoseLog {
+	return &DoseLog{}
+}
+
+// FindDoseEvent returns the event for key.
+func (l *DoseLog) FindDoseEvent(_ context.Context, key string) (domain.DoseEvent, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	for _, e := range l.events {
+		if e.Key == key {
+			return e, nil
+		}
+	}
+	return domain.DoseEvent{}, domain.ErrNotFound
+}
+
+// GetDoseEvent returns the event with the given ID.
+func (l *DoseLog) GetDoseEvent(_ context.Context, id string) (domain.DoseEvent, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	for _, e := range l.events {
+		if e.ID == id {
+			return e, nil
+		}
+	}
+	return domain.DoseEvent{}, domain.ErrNotFound
+}
+
+// CreateDoseEvent appends e to the log and returns it with a generated ID.
+func (l *DoseLog) CreateDoseEvent(_ context.Context, e domain.DoseEvent) (domain.DoseEvent, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.nextID++
+	e.ID = fmt.Sprintf("dose%d", l.nextID)
+	l.events = append(l.events, e)
+	return e, nil
+}
+
+// UpdateDoseEvent applies patch to the event with the given ID.
+func (l *DoseLog) UpdateDoseEvent(_ context.Context, id string, patch domain.DoseEventPatch) (domain.DoseEvent, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	for i := range l.events {
+		e := &l.events[i]
+		if e.ID != id {
+			continue
+		}
+		if patch.Status != "" {
+			e.Status = patch.Status
+		}
+		if !patch.RespondedAt.IsZero() {
+			e.RespondedAt = patch.RespondedAt
+		}
+		if patch.ChatID != "" {
+			e.ChatID = patch.ChatID
+		}
+		return *e, nil
+	}
+	return domain.DoseEvent{}, domain.ErrNotFound
+}
+
+// FetchDoseEvents returns a copy of the events scheduled at or after since.
+func (l *DoseLog) FetchDoseEvents(_ context.Context, since time.Time) ([]domain.DoseEvent, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	out := make([]domain.DoseEvent, 0, len(l.events))
+	for _, e := range l.events {
+		if !since.IsZero() && e.Scheduled.Before(since) {
+			continue
+		}
+		out = append(out, e)
+	}
+	return out, nil
+}
@@ -0,0 +1,86 @@
+// Package memstore provides in-process stores used when no durable backend
+// is configured. Their contents do not survive a restart.
+package memstore
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/nomenarkt/vitaltrack/backend/internal/domain"
+)
+
+// AlertLog is an in-memory ports.AlertLogPort.
+type AlertLog struct {
+	mu      sync.Mutex
+	records []domain.AlertRecord
+	nextID  int
+}
+
+// NewAlertLog returns an empty AlertLog.
+func NewAlertLog() *AlertLog {
+	return &AlertLog{}
+}
+
+// FindAlert returns the oldest record for key.
+func (l *AlertLog) FindAlert(_ context.Context, key string) (domain.AlertRecord, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	for _, r := range l.records {
+		if r.Key == key {
+			return r, nil
+		}
+	}
+	return domain.AlertRecord{}, domain.ErrNotFound
+}
+
+// CreateAlert appends r to the log and returns it with a generated ID.
+func (l *AlertLog) CreateAlert(_ context.Context, r domain.AlertRecord) (domain.AlertRecord, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.nextID++
+	r.ID = fmt.Sprintf("alert%d", l.nextID)
+	l.records = append(l.records, r)
+	return r, nil
+}
+
+// UpdateAlert applies patch to the record with the given ID.
+func (l *AlertLog) UpdateAlert(_ context.Context, id string, patch domain.AlertRecordPatch) (domain.AlertRecord, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	for i := range l.records {
+		r := &l.records[i]
+		if r.ID != id {
+			continue
+		}
+		if patch.Status != nil {
+			r.Status = *patch.Status
+		}
+		if patch.Attempts != nil {
+			r.Attempts = *patch.Attempts
+		}
+		if patch.LastError != nil {
+			r.LastError = *patch.LastError
+		}
+		if !patch.UpdatedAt.IsZero() {
+			r.UpdatedAt = patch.UpdatedAt
+		}
+		return *r, nil
+	}
+	return domain.AlertRecord{}, domain.ErrNotFound
+}
+
+// FetchAlerts returns a copy of the records created at or after since.
+func (l *AlertLog) FetchAlerts(_ context.Context, since time.Time) ([]domain.AlertRecord, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	out := make([]domain.AlertRecord, 0, len(l.records))
+	for _, r := range l.records {
+		if !since.IsZero() && r.CreatedAt.Before(since) {
+			continue
+		}
+		out = append(out, r)
+	}
+	return out, nil
+}
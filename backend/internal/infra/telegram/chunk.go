@@ -0,0 +1,244 @@
+package telegram
+
+import (
+	"fmt"
+	"strings"
+	"unicode/utf16"
+)
+
+// maxMessageLen is the per-message budget in UTF-16 code units, the unit
+// Telegram's 4096 character limit is counted in, kept below that limit.
+const maxMessageLen = 4000
+
+// partMarkerReserve is the room kept for the "\n\(12/34\)" part marker.
+const partMarkerReserve = 16
+
+const fence = "```"
+
+// splitMessage splits MarkdownV2 text into parts of at most limit UTF-16
+// code units. It
+// prefers blank-line block boundaries, then line boundaries, and only cuts
+// inside a line when the line alone is too long. Code fences cut in two are
+// closed at the end of one part and reopened at the start of the next, and a
+// cut never separates a backslash from the character it escapes. When more
+// than one part is produced, each ends with a "(i/n)" marker.
+func splitMessage(text string, limit int) []string {
+	if textLen(text) <= limit {
+		return []string{text}
+	}
+	budget := limit - partMarkerReserve
+
+	var parts []string
+	cur := ""
+	for _, block := range splitBlocks(text) {
+		joined := strings.Join(block, "\n")
+		if cur != "" && textLen(cur)+2+textLen(joined) <= budget {
+			cur += "\n\n" + joined
+			continue
+		}
+		if textLen(joined) <= budget {
+			if cur != "" {
+				parts = append(parts, cur)
+			}
+			cur = joined
+			continue
+		}
+		// An oversized block is split anyway, so let it fill the current part.
+		lines := block
+		if cur != "" {
+			lines = append(append(strings.Split(cur, "\n"), ""), block...)
+		}
+		pieces := splitLines(lines, budget)
+		parts = append(parts, pieces[:len(pieces)-1]...)
+		cur = pieces[len(pieces)-1]
+	}
+	if cur != "" {
+		parts = append(parts, cur)
+	}
+
+	if len(parts) > 1 {
+		for i := range parts {
+			parts[i] += fmt.Sprintf("\n\\(%d/%d\\)", i+1, len(parts))
+		}
+	}
+	return parts
+}
+
+// splitBlocks groups lines into blocks separated by blank lines. Blank lines
+// inside a code fence do not end a block.
+func splitBlocks(text string) [][]string {
+	var blocks [][]string
+	var cur []string
+	inFence := false
+	for _, line := range strings.Split(text, "\n") {
+		if strings.HasPrefix(line, fence) {
+			inFence = !inFence
+		}
+		if line == "" && !inFence {
+			if len(cur) > 0 {
+				blocks = append(blocks, cur)
+				cur = nil
+			}
+			continue
+		}
+		cur = append(cur, line)
+	}
+	if len(cur) > 0 {
+		blocks = append(blocks, cur)
+	}
+	return blocks
+}
+
+// splitLines packs lines into pieces of at most budget code units, closing and
+// reopening code fences across piece boundaries.
+func splitLines(lines []string, budget int) []string {
+	var pieces []string
+	var cur []string
+	curLen := 0
+	opener := "" // fence line of the currently open code block
+
+	flush := func() {
+		if opener != "" {
+			cur = append(cur, fence)
+		}
+		pieces = append(pieces, strings.Join(cur, "\n"))
+		cur, curLen = nil, 0
+		if opener != "" {
+			cur, curLen = []string{opener}, textLen(opener)
+		}
+	}
+	add := func(line string) {
+		if len(cur) > 0 {
+			curLen++
+		}
+		cur = append(cur, line)
+		curLen += textLen(line)
+	}
+
+	for _, line := range lines {
+		isFence := strings.HasPrefix(line, fence)
+		// Room for a closing fence must remain while a code block is open.
+		closing := 0
+		if (opener != "") != isFence {
+			closing = len(fence) + 1
+		}
+
+		segments := []string{line}
+		if maxLen := budget - textLen(opener) - len(fence) - 2; textLen(line) > maxLen {
+			segments = splitLongLine(line, maxLen, opener != "")
+		}
+		for _, seg := range segments {
+			if len(cur) > 0 && curLen+1+textLen(seg)+closing > budget {
+				flush()
+			}
+			add(seg)
+		}
+
+		if isFence {
+			if opener == "" {
+				opener = line
+			} else {
+				opener = ""
+			}
+		}
+	}
+	if len(cur) > 0 {
+		pieces = append(pieces, strings.Join(cur, "\n"))
+	}
+	return pieces
+}
+
+// splitLongLine cuts a single line into segments of at most maxLen code units,
+// preferring spaces. Outside code, formatting entities left open at a cut are
+// closed and reopened in the next segment.
+func splitLongLine(line string, maxLen int, inCode bool) []string {
+	var segments []string
+	rs := []rune(line)
+	reopen := ""
+	for {
+		rs = append([]rune(reopen), rs...)
+		if textLen(string(rs)) <= maxLen {
+			return append(segments, string(rs))
+		}
+
+		limit := fitting(rs, maxLen-3) // room to close up to three open entities
+		cut := max(limit, 1)
+		for i := limit; i > limit/2; i-- {
+			if rs[i-1] == ' ' {
+				cut = i
+				break
+			}
+		}
+		for cut > 1 && escapesNext(rs, cut) {
+			cut--
+		}
+
+		seg := string(rs[:cut])
+		reopen = ""
+		if !inCode {
+			open := openEntities(seg)
+			for i := len(open) - 1; i >= 0; i-- {
+				seg += string(open[i])
+			}
+			reopen = string(open)
+		}
+		segments = append(segments, seg)
+		rs = rs[cut:]
+	}
+}
+
+// escapesNext reports whether rs[cut-1] is a backslash escaping rs[cut].
+func escapesNext(rs []rune, cut int) bool {
+	n := 0
+	for i := cut - 1; i >= 0 && rs[i] == '\\'; i-- {
+		n++
+	}
+	return n%2 == 1
+}
+
+// openEntities returns the MarkdownV2 entity markers left open at the end of
+// s, in the order they were opened.
+func openEntities(s string) []rune {
+	var open []rune
+	rs := []rune(s)
+	for i := 0; i < len(rs); i++ {
+		r := rs[i]
+		if r == '\\' {
+			i++
+			continue
+		}
+		inCode := len(open) > 0 && open[len(open)-1] == '`'
+		if inCode && r != '`' {
+			continue
+		}
+		switch r {
+		case '*', '_', '~', '`':
+			if n := len(open); n > 0 && open[n-1] == r {
+				open = open[:n-1]
+			} else {
+				open = append(open, r)
+			}
+		}
+	}
+	return open
+}
+
+// fitting returns how many leading runes of rs fit in n UTF-16 code units.
+func fitting(rs []rune, n int) int {
+	for i, r := range rs {
+		if n -= utf16.RuneLen(r); n < 0 {
+			return i
+		}
+	}
+	return len(rs)
+}
+
+// textLen returns the length of s in UTF-16 code units, counting runes
+// outside the Basic Multilingual Plane, such as most emoji, as two.
+func textLen(s string) int {
+	n := 0
+	for _, r := range s {
+		n += utf16.RuneLen(r)
+	}
+	return n
+}
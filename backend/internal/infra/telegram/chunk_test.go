@@ -0,0 +1,153 @@
+package telegram
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+	"unicode/utf8"
+
+	"github.com/nomenarkt/vitaltrack/backend/internal/domain"
+)
+
+var partMarker = regexp.MustCompile(`\n\\\((\d+)/(\d+)\\\)$`)
+
+// checkParts asserts the invariants every split must keep.
+func checkParts(t *testing.T, parts []string, limit int) {
+	t.Helper()
+	for i, p := range parts {
+		if n := textLen(p); n > limit {
+			t.Errorf("part %d has %d UTF-16 code units, limit %d", i+1, n, limit)
+		}
+		if !utf8.ValidString(p) {
+			t.Errorf("part %d is not valid UTF-8", i+1)
+		}
+		if strings.Count(p, "```")%2 != 0 {
+			t.Errorf("part %d has an unbalanced code fence:\n%s", i+1, p)
+		}
+		body := partMarker.ReplaceAllString(p, "")
+		if escapesNext([]rune(body), utf8.RuneCountInString(body)) {
+			t.Errorf("part %d ends with a dangling escape", i+1)
+		}
+		if len(parts) > 1 {
+			m := partMarker.FindStringSubmatch(p)
+			if m == nil || m[1] != fmt.Sprint(i+1) || m[2] != fmt.Sprint(len(parts)) {
+				t.Errorf("part %d marker missing or wrong: %q", i+1, p[max(0, len(p)-20):])
+			}
+		}
+	}
+}
+
+func TestSplitMessage(t *testing.T) {
+	longTable := "*Report*\n\n```text\n" + strings.Repeat("| Alice        |      10 MGA |\n", 300) + "```\n\nTotal"
+	escapes := strings.Repeat(md("Ação ✅ 1.5-2 (ok)! "), 400)
+	bold := "*" + strings.Repeat("word ", 1000) + "*"
+	emoji := strings.Repeat("💊", 3000)
+
+	tests := []struct {
+		name      string
+		text      string
+		limit     int
+		wantParts int
+	}{
+		{name: "short_unchanged", text: "*hello*", limit: 100, wantParts: 1},
+		{name: "block_boundaries", text: strings.Repeat(strings.Repeat("a", 30)+"\n\n", 10), limit: 100, wantParts: 5},
+		{name: "fence_reopened", text: longTable, limit: maxMessageLen, wantParts: 3},
+		{name: "multibyte_and_escapes", text: escapes, limit: maxMessageLen},
+		{name: "entity_across_cut", text: bold, limit: maxMessageLen},
+		{name: "astral_plane", text: emoji, limit: maxMessageLen, wantParts: 2},
+		{name: "astral_plane_lines", text: strings.Repeat("*Aspirin* 💊💊💊💊\n", 250), limit: maxMessageLen, wantParts: 2},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			parts := splitMessage(tt.text, tt.limit)
+			if tt.wantParts > 0 && len(parts) != tt.wantParts {
+				t.Errorf("got %d parts, want %d", len(parts), tt.wantParts)
+			}
+			checkParts(t, parts, tt.limit)
+			if tt.wantParts == 1 && parts[0] != tt.text {
+				t.Errorf("short message altered: %q", parts[0])
+			}
+		})
+	}
+}
+
+func TestSplitMessage_preservesContent(t *testing.T) {
+	var lines []string
+	for i := 0; i < 400; i++ {
+		lines = append(lines, fmt.Sprintf("| Contributor%03d | %6d MGA |", i, i*10))
+	}
+	text := "```text\n" + strings.Join(lines, "\n") + "\n```"
+
+	parts := splitMessage(text, 1000)
+	checkParts(t, parts, 1000)
+
+	var got []string
+	for i, p := range parts {
+		p = partMarker.ReplaceAllString(p, "")
+		if !strings.HasPrefix(p, "```text\n") || !strings.HasSuffix(p, "\n```") {
+			t.Fatalf("part %d is not a self-contained code block: %q", i+1, p)
+		}
+		got = append(got, strings.TrimSuffix(strings.TrimPrefix(p, "```text\n"), "\n```"))
+	}
+	if strings.Join(got, "\n") != strings.Join(lines, "\n") {
+		t.Errorf("rows lost or reordered across parts")
+	}
+}
+
+func TestSplitLongLine_closesEntities(t *testing.T) {
+	segs := splitLongLine("*"+strings.Repeat("bold ", 50)+"*", 60, false)
+	if len(segs) < 2 {
+		t.Fatalf("expected the line to be split, got %d segment(s)", len(segs))
+	}
+	for i, s := range segs {
+		if open := openEntities(s); len(open) != 0 {
+			t.Errorf("segment %d leaves %q open: %q", i, string(open), s)
+		}
+		if !strings.HasPrefix(s, "*") || !strings.HasSuffix(s, "*") {
+			t.Errorf("segment %d does not carry the bold entity: %q", i, s)
+		}
+	}
+}
+
+func TestHandleFinanceCommand_longReportIsSplit(t *testing.T) {
+	srv, msgs := newTestServer(t)
+	defer srv.Close()
+
+	report := domain.MonthlyFinancialReport{Year: 2025, Month: 6}
+	for i := 0; i < 40; i++ {
+		block := domain.NeedReportBlock{Need: fmt.Sprintf("2025-06-%02d Need%d", i%28+1, i), NeedAmount: 1000}
+		for j := 0; j < 5; j++ {
+			block.Contributors = append(block.Contributors, domain.ContributorAmount{Name: fmt.Sprintf("Person%d", j), Amount: 200})
+		}
+		report.Needs = append(report.Needs, block)
+	}
+	fn := func(context.Context, int, int) (domain.MonthlyFinancialReport, error) { return report, nil }
+
+	c := &Client{Token: "test", ChatID: "1", baseURL: srv.URL}
+	c.handleFinanceCommand(context.Background(), 500, fn, 2025, time.June)
+
+	if len(*msgs) < 2 {
+		t.Fatalf("expected the report to be split, got %d message(s)", len(*msgs))
+	}
+	checkParts(t, *msgs, maxMessageLen)
+	if last := (*msgs)[len(*msgs)-1]; !strings.Contains(last, "Monthly Summary") {
+		t.Errorf("summary dropped from the last part: %q", last)
+	}
+	for i := 0; i < 40; i++ {
+		need := fmt.Sprintf("Need%d", i)
+		found := false
+		for _, m := range *msgs {
+			if strings.Contains(m, need+"\n") {
+				found = true
+				break
+			}
+		}
+		if !found {
+			t.Errorf("need %d missing from the split report", i)
+		}
+	}
+}
@@ -2,103 +2,196 @@
 package telegram
 
 import (
-	"bytes"
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
-	"log"
 	"net/http"
-	"os"
+	"strconv"
 	"strings"
+	"sync"
 
 	"io"
 	"net/url"
+	"slices"
 	"sort"
 	"time"
 
-	"github.com/joho/godotenv"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
 
+	"github.com/nomenarkt/vitaltrack/backend/internal/config"
 	"github.com/nomenarkt/vitaltrack/backend/internal/domain"
+	"github.com/nomenarkt/vitaltrack/backend/internal/domain/ports"
+	"github.com/nomenarkt/vitaltrack/backend/internal/logger"
+	"github.com/nomenarkt/vitaltrack/backend/internal/logic/adherence"
+	"github.com/nomenarkt/vitaltrack/backend/internal/logic/calendar"
+	"github.com/nomenarkt/vitaltrack/backend/internal/logic/finance"
 	"github.com/nomenarkt/vitaltrack/backend/internal/logic/stockcalc"
-	"github.com/nomenarkt/vitaltrack/backend/internal/util"
+	"github.com/nomenarkt/vitaltrack/backend/internal/logic/validation"
+	"github.com/nomenarkt/vitaltrack/backend/internal/metrics"
+	"github.com/nomenarkt/vitaltrack/backend/internal/tracing"
+	"github.com/nomenarkt/vitaltrack/backend/internal/util/i18n"
+	"github.com/nomenarkt/vitaltrack/backend/internal/util/richtext"
 )
 
 // Client interacts with the Telegram Bot API.
 type Client struct {
-	Token   string
-	ChatID  string
-	baseURL string
+	Token       string
+	ChatID      string
+	DigestChats []string       // chats besides ChatID allowed the household commands and digests
+	Lang        i18n.Lang      // language for chats without a saved preference
+	Location    *time.Location // household timezone for dates, UTC when nil
+	Logger      logger.Logger  // discards when nil
+	baseURL     string
+	outbox      ports.OutboxPort
+	prefs       ports.ChatPrefsPort
+	doses       AdherenceHandler
+	checks      Validator
 }
 
-// NewClient constructs a Client using environment variables for configuration.
-func NewClient() *Client {
-	if err := godotenv.Load(); err != nil {
-		log.Printf("godotenv load: %v", err)
-	}
+// AdherenceHandler records answers to dose reminders and reports adherence
+// for the bot. usecase.AdherenceService implements it.
+type AdherenceHandler interface {
+	Respond(ctx context.Context, eventID string, status domain.DoseStatus, at time.Time) (domain.DoseEvent, error)
+	WeeklyReport(ctx context.Context, weeks int, now time.Time) ([]domain.AdherenceWeek, error)
+}
 
-	token := os.Getenv("TELEGRAM_BOT_TOKEN")
-	chatID := os.Getenv("TELEGRAM_CHAT_ID")
-	baseURL := os.Getenv("TELEGRAM_API_BASE_URL")
-	if baseURL == "" {
-		baseURL = "https://api.telegram.org"
-	}
-	if token == "" || chatID == "" {
-		panic("missing Telegram configuration: TELEGRAM_BOT_TOKEN and TELEGRAM_CHAT_ID must be set")
-	}
+// Validator reports data issues for /validate. usecase.ValidationService
+// implements it.
+type Validator interface {
+	Validate(ctx context.Context, year int, month time.Month, now time.Time) (domain.ValidationReport, error)
+}
 
+// NewClient returns a Client for the bot and chat in cfg, logging to lg.
+func NewClient(cfg config.Telegram, lg logger.Logger) *Client {
 	return &Client{
-		Token:   token,
-		ChatID:  chatID,
-		baseURL: baseURL,
+		Token:       cfg.BotToken,
+		ChatID:      cfg.ChatID,
+		DigestChats: cfg.DigestChats,
+		Lang:        cfg.Language,
+		Logger:      lg,
+		baseURL:     cfg.APIBaseURL,
 	}
 }
 
-// SendTelegramMessage posts a Markdown-formatted message to the configured chat.
-func (c *Client) SendTelegramMessage(msg string) error {
-	log.Printf("📨 Sending Telegram: %s", msg)
+func (c *Client) log() logger.Logger { return logger.OrNop(c.Logger) }
 
-	escaped := util.EscapeMarkdown(msg)
+// UsePrefs makes the client read and save per-chat preferences, such as the
+// language chosen with /lang, in store.
+func (c *Client) UsePrefs(store ports.ChatPrefsPort) {
+	c.prefs = store
+}
 
-	payload := map[string]string{
-		"chat_id":    c.ChatID,
-		"text":       escaped,
-		"parse_mode": "MarkdownV2",
+// Printer returns the message printer for the configured chat.
+func (c *Client) Printer() i18n.Printer {
+	return c.PrinterFor(c.ChatID)
+}
+
+// PrinterFor returns the printer for chatID's saved language, falling back
+// to the client default.
+func (c *Client) PrinterFor(chatID string) i18n.Printer {
+	if c.prefs != nil {
+		prefs, err := c.prefs.GetChatPrefs(chatID)
+		if err != nil && !errors.Is(err, domain.ErrNotFound) {
+			c.log().Warn(context.Background(), "could not load chat preferences", "chat_id", chatID, "error", err)
+		}
+		if lang, ok := i18n.Parse(prefs.Language); ok {
+			return i18n.For(lang)
+		}
 	}
+	return i18n.For(c.Lang)
+}
 
-	body, err := json.Marshal(payload)
-	if err != nil {
-		return err
+// UseAdherence makes the client record the Taken and Skipped buttons of dose
+// reminders with h, and answer /adherence.
+func (c *Client) UseAdherence(h AdherenceHandler) {
+	c.doses = h
+}
+
+// UseValidation makes the client answer /validate with v's report.
+func (c *Client) UseValidation(v Validator) {
+	c.checks = v
+}
+
+// UseOutbox makes the client queue outgoing messages in store instead of
+// sending them inline. A Dispatcher must then be running to deliver them.
+func (c *Client) UseOutbox(store ports.OutboxPort) {
+	c.outbox = store
+}
+
+// SendTelegramMessage posts msg to the configured chat.
+func (c *Client) SendTelegramMessage(ctx context.Context, msg richtext.Doc) error {
+	c.log().Debug(ctx, "sending telegram message", "chat_id", c.ChatID)
+	return c.send(ctx, c.ChatID, richtext.MarkdownV2(msg), "")
+}
+
+// SendAlert sends msg to the configured chat like SendTelegramMessage. With
+// an outbox, msg is queued tagged with key and true is returned; the
+// Dispatcher reports its delivery.
+func (c *Client) SendAlert(ctx context.Context, key string, msg richtext.Doc) (bool, error) {
+	c.log().Debug(ctx, "sending telegram alert", "chat_id", c.ChatID, "key", key)
+	if err := c.sendTagged(ctx, c.ChatID, richtext.MarkdownV2(msg), "", key); err != nil {
+		return false, err
 	}
+	return c.outbox != nil, nil
+}
 
-	res, err := http.Post(
-		c.baseURL+"/bot"+c.Token+"/sendMessage",
-		"application/json",
-		bytes.NewReader(body),
-	)
+// SendToChat posts msg to chatID, which need not be the configured chat.
+func (c *Client) SendToChat(ctx context.Context, chatID string, msg richtext.Doc) error {
+	c.log().Debug(ctx, "sending telegram message", "chat_id", chatID)
+	return c.send(ctx, chatID, richtext.MarkdownV2(msg), "")
+}
+
+// SendWithButtons posts msg to chatID with one row of inline buttons.
+func (c *Client) SendWithButtons(ctx context.Context, chatID string, msg richtext.Doc, buttons []domain.ReplyButton) error {
+	c.log().Debug(ctx, "sending telegram prompt", "chat_id", chatID, "buttons", len(buttons))
+	markup, err := inlineKeyboard(buttons)
 	if err != nil {
 		return err
 	}
-	defer func() {
-		if cerr := res.Body.Close(); cerr != nil {
-			log.Printf("telegram response close error: %v", cerr)
-		}
-	}()
+	return c.send(ctx, chatID, richtext.MarkdownV2(msg), markup)
+}
 
-	if res.StatusCode >= 300 {
-		return fmt.Errorf("telegram error status: %d", res.StatusCode)
+// inlineKeyboard encodes buttons as a reply_markup with a single row.
+func inlineKeyboard(buttons []domain.ReplyButton) (string, error) {
+	type button struct {
+		Text         string `json:"text"`
+		CallbackData string `json:"callback_data"`
+	}
+	row := make([]button, 0, len(buttons))
+	for _, b := range buttons {
+		row = append(row, button{Text: b.Label, CallbackData: b.Data})
+	}
+	markup, err := json.Marshal(map[string][][]button{"inline_keyboard": {row}})
+	if err != nil {
+		return "", fmt.Errorf("encode buttons: %w", err)
 	}
+	return string(markup), nil
+}
 
-	return nil
+// Message is the part of a Telegram message the bot reads.
+type Message struct {
+	MessageID int    `json:"message_id"`
+	Text      string `json:"text"`
+	Chat      struct {
+		ID int64 `json:"id"`
+	} `json:"chat"`
+}
+
+// CallbackQuery is sent when someone presses an inline button.
+type CallbackQuery struct {
+	ID      string   `json:"id"`
+	Data    string   `json:"data"`
+	Message *Message `json:"message"`
 }
 
 // Update represents a single Telegram bot update.
 type Update struct {
-	UpdateID int `json:"update_id"`
-	Message  struct {
-		Text string `json:"text"`
-		Chat struct {
-			ID int64 `json:"id"`
-		} `json:"chat"`
-	} `json:"message"`
+	UpdateID      int            `json:"update_id"`
+	Message       Message        `json:"message"`
+	CallbackQuery *CallbackQuery `json:"callback_query"`
 }
 
 // GetUpdatesResponse is the Telegram API response for updates polling.
@@ -107,103 +200,173 @@ type GetUpdatesResponse struct {
 	Result []Update `json:"result"`
 }
 
-// PollForCommands continuously polls Telegram for bot commands and handles them.
+// PollForCommands polls Telegram for bot commands and handles them until ctx
+// is cancelled. Commands already received are allowed to finish: their
+// handlers are not cancelled with ctx, and PollForCommands returns once they
+// have. Each update is logged with the correlation ID "tg-<update_id>".
 func (c *Client) PollForCommands(
-	fetchData func() ([]domain.Medicine, []domain.StockEntry, error),
-	reportFn func(year, month int) (domain.MonthlyFinancialReport, error),
+	ctx context.Context,
+	fetchData func(ctx context.Context) ([]domain.Medicine, []domain.StockEntry, error),
+	reportFn func(ctx context.Context, year, month int) (domain.MonthlyFinancialReport, error),
+	alertsFn func(ctx context.Context) ([]domain.AlertRecord, error),
 ) {
 	var lastUpdateID int
+	var handlers sync.WaitGroup
+	handle := func(ctx context.Context, command string, fn func(ctx context.Context)) {
+		metrics.CommandsHandled.WithLabelValues(command).Inc()
+		c.log().Info(ctx, "telegram command received", "command", command)
+		handlers.Add(1)
+		go func() {
+			defer handlers.Done()
+			ctx, span := tracing.Start(context.WithoutCancel(ctx), "telegram "+command,
+				trace.WithSpanKind(trace.SpanKindServer),
+				trace.WithAttributes(attribute.String("telegram.command", command)))
+			defer span.End()
+			fn(ctx)
+		}()
+	}
+	defer handlers.Wait()
 
-	log.Printf("%s", "📨 Telegram polling started...")
+	c.log().Info(ctx, "telegram polling started")
 	for {
-		time.Sleep(2 * time.Second)
-
-		apiURL := fmt.Sprintf("%s/bot%s/getUpdates?timeout=10&offset=%d", c.baseURL, c.Token, lastUpdateID+1)
-		resp, err := http.Get(apiURL)
-		if err != nil {
-			log.Printf("Telegram polling error: %v", err)
-			continue
-		}
-		body, err := io.ReadAll(resp.Body)
-		if err != nil {
-			log.Printf("Telegram polling read body error: %v", err)
-			continue
-		}
-		if err := resp.Body.Close(); err != nil {
-			log.Printf("Telegram response close error: %v", err)
+		select {
+		case <-ctx.Done():
+			c.log().Info(ctx, "telegram polling stopped")
+			return
+		case <-time.After(2 * time.Second):
 		}
 
-		var updates GetUpdatesResponse
-		if err := json.Unmarshal(body, &updates); err != nil {
-			log.Printf("Failed to decode Telegram updates: %v", err)
-			continue
-		}
-		if !updates.OK {
-			log.Printf("Telegram API error status %d: %s", resp.StatusCode, string(body))
+		updates, err := c.getUpdates(ctx, lastUpdateID+1)
+		if err != nil {
+			if ctx.Err() == nil {
+				c.log().Warn(ctx, "telegram polling failed", "error", err)
+			}
 			continue
 		}
+		metrics.TelegramLastPoll.SetToCurrentTime()
 
-		for _, update := range updates.Result {
+		for _, update := range updates {
 			lastUpdateID = update.UpdateID
+			uctx := logger.WithCorrelationID(ctx, "tg-"+strconv.Itoa(update.UpdateID))
+			if q := update.CallbackQuery; q != nil {
+				handle(uctx, "callback", func(ctx context.Context) { c.handleCallback(ctx, *q) })
+				continue
+			}
 
+			fields := strings.Fields(update.Message.Text)
+			if len(fields) == 0 {
+				continue
+			}
 			// Extract command ignoring bot username (e.g. /stock@BotName)
-			cmd := strings.Split(update.Message.Text, "@")[0]
+			cmd := strings.Split(fields[0], "@")[0]
+			chatID := update.Message.Chat.ID
+			args := fields[1:]
 
 			switch cmd {
 			case "/stock":
-				log.Printf("%s", "🟡 /stock command triggered")
-				go c.handleStockCommand(update.Message.Chat.ID, fetchData)
+				handle(uctx, cmd, func(ctx context.Context) { c.handleStockCommand(ctx, chatID, fetchData) })
 			case "/finance":
-				log.Printf("%s", "🟡 /finance command triggered")
-				year, month := time.Now().Year(), time.Now().Month()
-				parts := strings.Fields(update.Message.Text)
-				if len(parts) > 1 {
-					if t, err := time.Parse("2006-01", parts[1]); err == nil {
-						year, month = t.Year(), t.Month()
-					}
-				}
-				go c.handleFinanceCommand(update.Message.Chat.ID, reportFn, year, month)
+				year, month := c.monthArg(args)
+				handle(uctx, cmd, func(ctx context.Context) { c.handleFinanceCommand(ctx, chatID, reportFn, year, month) })
+			case "/validate":
+				year, month := c.monthArg(args)
+				handle(uctx, cmd, func(ctx context.Context) { c.handleValidateCommand(ctx, chatID, year, month) })
+			case "/alerts":
+				handle(uctx, cmd, func(ctx context.Context) { c.handleAlertsCommand(ctx, chatID, alertsFn) })
+			case "/lang":
+				handle(uctx, cmd, func(ctx context.Context) { c.handleLangCommand(ctx, chatID, args) })
+			case "/adherence":
+				handle(uctx, cmd, func(ctx context.Context) { c.handleAdherenceCommand(ctx, chatID) })
+			case "/digest":
+				handle(uctx, cmd, func(ctx context.Context) { c.handleDigestCommand(ctx, chatID, args) })
 			}
 		}
 	}
 }
 
-func (c *Client) handleStockCommand(chatID int64, fetchData func() ([]domain.Medicine, []domain.StockEntry, error)) {
+// monthArg returns the month named "YYYY-MM" by the first argument of a
+// command, or the current month in the household timezone.
+func (c *Client) monthArg(args []string) (int, time.Month) {
+	if len(args) > 0 {
+		if t, err := time.Parse("2006-01", args[0]); err == nil {
+			return t.Year(), t.Month()
+		}
+	}
+	now := calendar.In(time.Now(), c.Location)
+	return now.Year(), now.Month()
+}
+
+// getUpdates long-polls for updates starting at offset.
+func (c *Client) getUpdates(ctx context.Context, offset int) ([]Update, error) {
+	apiURL := fmt.Sprintf("%s/bot%s/getUpdates?timeout=10&offset=%d", c.baseURL, c.Token, offset)
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, apiURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		if err := resp.Body.Close(); err != nil {
+			c.log().Warn(ctx, "telegram response close failed", "error", err)
+		}
+	}()
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("read body: %w", err)
+	}
+
+	var updates GetUpdatesResponse
+	if err := json.Unmarshal(body, &updates); err != nil {
+		return nil, fmt.Errorf("decode updates: %w", err)
+	}
+	if !updates.OK {
+		return nil, fmt.Errorf("telegram API error status %d: %s", resp.StatusCode, string(body))
+	}
+	return updates.Result, nil
+}
+
+func (c *Client) handleStockCommand(ctx context.Context, chatID int64, fetchData func(context.Context) ([]domain.Medicine, []domain.StockEntry, error)) {
 	defer func() {
 		if r := recover(); r != nil {
-			log.Printf("‼️ recovered from /stock crash: %v", r)
+			c.log().Error(ctx, "recovered from /stock panic", "panic", r)
 		}
 	}()
 
-	meds, entries, err := fetchData()
+	p := c.PrinterFor(strconv.FormatInt(chatID, 10))
+	meds, entries, err := fetchData(ctx)
 	if err != nil {
-		log.Printf("❌ /stock fetchData error: %v", err)
-		if err := c.sendTo(chatID, "\u26a0\ufe0f Failed to fetch stock data."); err != nil {
-			log.Printf("failed to send /stock response: %v", err)
+		c.log().Error(ctx, "/stock fetch failed", "error", err)
+		if err := c.sendTo(ctx, chatID, richtext.Text(p.T("stock.fetch_failed"))); err != nil {
+			c.log().Error(ctx, "failed to send /stock response", "error", err)
 		}
 		return
 	}
 
-	log.Printf("📦 meds: %d, entries: %d", len(meds), len(entries))
+	c.log().Debug(ctx, "stock data fetched", "medicines", len(meds), "entries", len(entries))
 
 	var validEntries []domain.StockEntry
 	skipped := 0
 	for _, e := range entries {
-		if e.Date.IsZero() || len(e.MedicineID) == 0 || e.Quantity <= 0 {
-			log.Printf("⚠️ skipping invalid stock entry: %+v", e)
+		if e.Archived {
+			continue
+		}
+		if e.Date.IsZero() || len(e.MedicineID) == 0 || !e.ValidQuantity() {
+			c.log().Warn(ctx, "skipping invalid stock entry", "entry_id", e.ID)
 			skipped++
 			continue
 		}
 		validEntries = append(validEntries, e)
 	}
 	if len(meds) == 0 {
-		if err := c.sendTo(chatID, "\u26a0\ufe0f No medicine or stock data found."); err != nil {
-			log.Printf("failed to send /stock response: %v", err)
+		if err := c.sendTo(ctx, chatID, richtext.Text(p.T("stock.no_data"))); err != nil {
+			c.log().Error(ctx, "failed to send /stock response", "error", err)
 		}
 		return
 	}
 
-	now := time.Now()
+	now := calendar.In(time.Now(), c.Location)
 	type Row struct {
 		Name  string
 		Date  time.Time
@@ -211,6 +374,9 @@ func (c *Client) handleStockCommand(chatID int64, fetchData func() ([]domain.Med
 	}
 	var rows []Row
 	for _, m := range meds {
+		if m.Archived {
+			continue
+		}
 		stock := stockcalc.CurrentStockAt(m, validEntries, now)
 		if m.DailyDose == 0 || stock <= 0 {
 			continue
@@ -220,8 +386,8 @@ func (c *Client) handleStockCommand(chatID int64, fetchData func() ([]domain.Med
 	}
 
 	if len(rows) == 0 {
-		if err := c.sendTo(chatID, "\u2705 All medicines are well stocked."); err != nil {
-			log.Printf("failed to send /stock response: %v", err)
+		if err := c.sendTo(ctx, chatID, richtext.Text(p.T("stock.all_good"))); err != nil {
+			c.log().Error(ctx, "failed to send /stock response", "error", err)
 		}
 		return
 	}
@@ -232,139 +398,462 @@ func (c *Client) handleStockCommand(chatID int64, fetchData func() ([]domain.Med
 
 	var lines []string
 	for _, r := range rows {
-		lines = append(lines, fmt.Sprintf("%-22s → %s (%.2f left)", r.Name, r.Date.Format("2006-01-02"), r.Pills))
+		lines = append(lines, p.T("stock.row", r.Name, p.Date(r.Date), p.Number(r.Pills, 2)))
 	}
 
-	msg := "*Out-of-Stock Forecast*\n\n```text\n" + strings.Join(lines, "\n") + "\n```"
+	msg := richtext.New(
+		richtext.P(richtext.B(p.T("stock.title"))),
+		richtext.CodeBlock{Lang: "text", Lines: lines},
+	)
 	if skipped > 0 {
-		msg += "\n\u26a0\ufe0f Some records were skipped due to data issues."
+		msg = append(msg, richtext.P(richtext.T(p.T("stock.skipped"))))
 	}
-	if err := c.sendTo(chatID, msg); err != nil {
-		log.Printf("failed to send /stock response: %v", err)
+	if err := c.sendTo(ctx, chatID, msg); err != nil {
+		c.log().Error(ctx, "failed to send /stock response", "error", err)
 	} else {
-		log.Printf("%s", "sent /stock forecast")
+		c.log().Info(ctx, "sent /stock forecast", "rows", len(rows))
 	}
 }
 
-func (c *Client) handleFinanceCommand(chatID int64, fn func(year, month int) (domain.MonthlyFinancialReport, error), year int, month time.Month) {
-	log.Printf("💸 Generating financial report for %d-%02d", year, month)
-	report, err := fn(year, int(month))
+func (c *Client) handleFinanceCommand(ctx context.Context, chatID int64, fn func(ctx context.Context, year, month int) (domain.MonthlyFinancialReport, error), year int, month time.Month) {
+	c.log().Info(ctx, "generating financial report", "month", fmt.Sprintf("%04d-%02d", year, month))
+	p := c.PrinterFor(strconv.FormatInt(chatID, 10))
+	report, err := fn(ctx, year, int(month))
 	if err != nil {
-		if err := c.sendTo(chatID, "\u26a0\ufe0f Failed to fetch financial data."); err != nil {
-			log.Printf("failed to send /finance response: %v", err)
+		if err := c.sendTo(ctx, chatID, richtext.Text(p.T("finance.fetch_failed"))); err != nil {
+			c.log().Error(ctx, "failed to send /finance response", "error", err)
 		}
 		return
 	}
 
-	var sections []string
-	totalNeed := 0.0
-	for _, n := range report.Needs {
-		sections = append(sections, renderNeedBlock(n))
-		totalNeed += n.NeedAmount
+	msg := finance.GenerateFinancialReportMessage(p, report)
+	if err := c.sendTo(ctx, chatID, msg); err != nil {
+		c.log().Error(ctx, "failed to send /finance response", "error", err)
 	}
+}
+
+// allowedChat reports whether chatID is ChatID or one of DigestChats, the
+// chats trusted with the household's data and settings.
+func (c *Client) allowedChat(chatID string) bool {
+	return chatID == c.ChatID || slices.Contains(c.DigestChats, chatID)
+}
 
-	var summary []string
-	summary = append(summary, "🧮 Monthly Summary")
-	summary = append(summary, fmt.Sprintf("💰 Total Needs: %s", formatMGA(totalNeed)))
-	summary = append(summary, fmt.Sprintf("💵 Total Contributed: %s", formatMGA(report.Total)))
-	summary = append(summary, "")
-	summary = append(summary, "👤 By Contributor:")
-	for _, ctb := range report.Contributors {
-		summary = append(summary, fmt.Sprintf("- %s \u2192 %s", ctb.Name, formatMGA(ctb.Amount)))
+// refuse answers command with a refusal when chatID is not an allowed chat,
+// and reports whether it did.
+func (c *Client) refuse(ctx context.Context, chatID int64, command string) bool {
+	id := strconv.FormatInt(chatID, 10)
+	if c.allowedChat(id) {
+		return false
+	}
+	c.log().Warn(ctx, "command refused for a chat not allowed", "command", command, "chat_id", id)
+	if err := c.sendTo(ctx, chatID, richtext.Text(c.PrinterFor(id).T("command.not_allowed"))); err != nil {
+		c.log().Error(ctx, "failed to send "+command+" response", "error", err)
 	}
+	return true
+}
 
-	msg := fmt.Sprintf("*Financial Report %d-%02d*\n\n%s\n\n%s",
-		report.Year, report.Month, strings.Join(sections, "\n\n"), strings.Join(summary, "\n"))
+func (c *Client) handleAlertsCommand(ctx context.Context, chatID int64, fn func(context.Context) ([]domain.AlertRecord, error)) {
+	if c.refuse(ctx, chatID, "/alerts") {
+		return
+	}
+	p := c.PrinterFor(strconv.FormatInt(chatID, 10))
+	records, err := fn(ctx)
+	if err != nil {
+		c.log().Error(ctx, "/alerts fetch failed", "error", err)
+		if err := c.sendTo(ctx, chatID, richtext.Text(p.T("alerts.fetch_failed"))); err != nil {
+			c.log().Error(ctx, "failed to send /alerts response", "error", err)
+		}
+		return
+	}
+	if err := c.sendTo(ctx, chatID, renderAlertHistory(p, c.Location, records)); err != nil {
+		c.log().Error(ctx, "failed to send /alerts response", "error", err)
+	}
+}
 
-	if err := c.sendTo(chatID, msg); err != nil {
-		log.Printf("failed to send /finance response: %v", err)
+// handleLangCommand shows the chat's language, or sets it when args names a
+// supported one. Only the configured chat and DigestChats may use it.
+func (c *Client) handleLangCommand(ctx context.Context, chatID int64, args []string) {
+	if c.refuse(ctx, chatID, "/lang") {
+		return
+	}
+	id := strconv.FormatInt(chatID, 10)
+	p := c.PrinterFor(id)
+
+	var msg richtext.Doc
+	switch {
+	case len(args) == 0:
+		msg = richtext.Text(p.T("lang.current", p.Lang().Name()) + "\n" + p.T("lang.usage"))
+	default:
+		lang, ok := i18n.Parse(args[0])
+		switch {
+		case !ok:
+			msg = richtext.Text(p.T("lang.unknown", args[0]) + "\n" + p.T("lang.usage"))
+		case c.prefs == nil:
+			msg = richtext.Text(p.T("lang.save_failed"))
+		default:
+			if err := c.updatePrefs(id, func(prefs *domain.ChatPrefs) { prefs.Language = string(lang) }); err != nil {
+				c.log().Error(ctx, "/lang save failed", "chat_id", id, "error", err)
+				msg = richtext.Text(p.T("lang.save_failed"))
+				break
+			}
+			msg = richtext.Text(i18n.For(lang).T("lang.set", lang.Name()))
+		}
+	}
+	if err := c.sendTo(ctx, chatID, msg); err != nil {
+		c.log().Error(ctx, "failed to send /lang response", "error", err)
 	}
 }
 
-func (c *Client) sendTo(chatID int64, msg string) error {
-	if msg == "" {
-		return fmt.Errorf("empty telegram message")
+// handleDigestCommand shows which digests the chat receives, or turns one
+// on or off with "/digest <kind> on|off". Only the configured chat and
+// DigestChats may use it.
+func (c *Client) handleDigestCommand(ctx context.Context, chatID int64, args []string) {
+	id := strconv.FormatInt(chatID, 10)
+	p := c.PrinterFor(id)
+
+	var msg richtext.Doc
+	switch {
+	case !c.allowedChat(id):
+		c.log().Warn(ctx, "/digest refused for a chat not allowed digests", "chat_id", id)
+		msg = richtext.Text(p.T("digest.not_allowed"))
+	case len(args) == 0:
+		msg = richtext.Text(p.T("digest.current", c.digestSummary(p, id)) + "\n" + p.T("digest.usage"))
+	case len(args) != 2 || !knownDigest(args[0]) || (args[1] != "on" && args[1] != "off"):
+		msg = richtext.Text(p.T("digest.unknown", strings.Join(args, " ")) + "\n" + p.T("digest.usage"))
+	case c.prefs == nil:
+		msg = richtext.Text(p.T("digest.save_failed"))
+	default:
+		kind, on := domain.DigestKind(strings.ToLower(args[0])), args[1] == "on"
+		err := c.updatePrefs(id, func(prefs *domain.ChatPrefs) {
+			if prefs.Digests == nil {
+				prefs.Digests = map[domain.DigestKind]bool{}
+			}
+			prefs.Digests[kind] = on
+		})
+		if err != nil {
+			c.log().Error(ctx, "/digest save failed", "chat_id", id, "error", err)
+			msg = richtext.Text(p.T("digest.save_failed"))
+			break
+		}
+		msg = richtext.Text(p.T("digest.set", p.T("digest.name_"+string(kind)), p.T("digest."+args[1])))
+	}
+	if err := c.sendTo(ctx, chatID, msg); err != nil {
+		c.log().Error(ctx, "failed to send /digest response", "error", err)
+	}
+}
+
+// digestSummary lists each digest and whether chatID receives it.
+func (c *Client) digestSummary(p i18n.Printer, chatID string) string {
+	var prefs domain.ChatPrefs
+	if c.prefs != nil {
+		prefs, _ = c.prefs.GetChatPrefs(chatID)
+	}
+	var parts []string
+	for _, kind := range domain.DigestKinds {
+		state := "digest.off"
+		if prefs.Subscribed(kind, chatID == c.ChatID) {
+			state = "digest.on"
+		}
+		parts = append(parts, p.T("digest.name_"+string(kind))+" "+p.T(state))
 	}
+	return strings.Join(parts, ", ")
+}
 
-	escaped := util.EscapeMarkdown(msg)
-	if len(escaped) > 4000 {
-		escaped = escaped[:4000]
+func knownDigest(name string) bool {
+	for _, kind := range domain.DigestKinds {
+		if strings.EqualFold(name, string(kind)) {
+			return true
+		}
+	}
+	return false
+}
+
+// updatePrefs applies change to chatID's saved preferences, keeping the
+// fields it does not touch.
+func (c *Client) updatePrefs(chatID string, change func(*domain.ChatPrefs)) error {
+	prefs, err := c.prefs.GetChatPrefs(chatID)
+	if err != nil && !errors.Is(err, domain.ErrNotFound) {
+		return err
+	}
+	prefs.ChatID = chatID
+	change(&prefs)
+	prefs.UpdatedAt = time.Now().UTC()
+	return c.prefs.SaveChatPrefs(prefs)
+}
+
+func (c *Client) handleValidateCommand(ctx context.Context, chatID int64, year int, month time.Month) {
+	if c.refuse(ctx, chatID, "/validate") {
+		return
 	}
+	p := c.PrinterFor(strconv.FormatInt(chatID, 10))
+	msg := richtext.Text(p.T("validation.fetch_failed"))
+	if c.checks != nil {
+		report, err := c.checks.Validate(ctx, year, month, time.Now())
+		if err != nil {
+			c.log().Error(ctx, "/validate fetch failed", "error", err)
+		} else {
+			msg = validation.ReportMessage(p, report)
+		}
+	}
+	if err := c.sendTo(ctx, chatID, msg); err != nil {
+		c.log().Error(ctx, "failed to send /validate response", "error", err)
+	}
+}
+
+// adherenceReportWeeks is how many weeks /adherence shows.
+const adherenceReportWeeks = 4
+
+func (c *Client) handleAdherenceCommand(ctx context.Context, chatID int64) {
+	if c.refuse(ctx, chatID, "/adherence") {
+		return
+	}
+	p := c.PrinterFor(strconv.FormatInt(chatID, 10))
+	msg := richtext.Text(p.T("adherence.fetch_failed"))
+	if c.doses != nil {
+		weeks, err := c.doses.WeeklyReport(ctx, adherenceReportWeeks, time.Now())
+		if err != nil {
+			c.log().Error(ctx, "/adherence fetch failed", "error", err)
+		} else {
+			msg = adherence.ReportMessage(p, weeks)
+		}
+	}
+	if err := c.sendTo(ctx, chatID, msg); err != nil {
+		c.log().Error(ctx, "failed to send /adherence response", "error", err)
+	}
+}
+
+// handleCallback records a Taken or Skipped answer to a dose reminder and
+// replaces the reminder's buttons with the answer.
+func (c *Client) handleCallback(ctx context.Context, q CallbackQuery) {
+	if q.Message == nil {
+		return
+	}
+	id := strconv.FormatInt(q.Message.Chat.ID, 10)
+	p := c.PrinterFor(id)
+
+	eventID, status, ok := parseDoseCallback(q.Data)
+	if !ok || c.doses == nil {
+		c.answerCallback(ctx, q.ID, "")
+		return
+	}
+	ev, err := c.doses.Respond(ctx, eventID, status, time.Now())
+	if err != nil {
+		c.log().Error(ctx, "dose answer failed", "data", q.Data, "error", err)
+		c.answerCallback(ctx, q.ID, p.T("adherence.record_failed"))
+		return
+	}
+	c.answerCallback(ctx, q.ID, p.T("adherence.recorded"))
 
 	payload := url.Values{}
-	payload.Set("chat_id", fmt.Sprintf("%d", chatID))
-	payload.Set("text", escaped)
+	payload.Set("chat_id", id)
+	payload.Set("message_id", strconv.Itoa(q.Message.MessageID))
+	payload.Set("text", richtext.MarkdownV2(adherence.ReminderMessage(p, ev, c.Location)))
 	payload.Set("parse_mode", "MarkdownV2")
+	if err := c.call(ctx, "editMessageText", payload); err != nil {
+		c.log().Warn(ctx, "failed to update dose reminder", "error", err)
+	}
+}
 
-	res, err := http.PostForm(
-		c.baseURL+"/bot"+c.Token+"/sendMessage",
-		payload,
+// parseDoseCallback reads button data made by adherence.CallbackData.
+func parseDoseCallback(data string) (string, domain.DoseStatus, bool) {
+	rest, ok := strings.CutPrefix(data, "dose:")
+	if !ok {
+		return "", "", false
+	}
+	i := strings.LastIndex(rest, ":")
+	if i <= 0 {
+		return "", "", false
+	}
+	return rest[:i], domain.DoseStatus(rest[i+1:]), true
+}
+
+func (c *Client) answerCallback(ctx context.Context, queryID, text string) {
+	payload := url.Values{}
+	payload.Set("callback_query_id", queryID)
+	if text != "" {
+		payload.Set("text", text)
+	}
+	if err := c.call(ctx, "answerCallbackQuery", payload); err != nil {
+		c.log().Warn(ctx, "failed to answer callback", "error", err)
+	}
+}
+
+// call invokes a Bot API method directly, bypassing the outbox; button
+// answers are only useful right away.
+func (c *Client) call(ctx context.Context, method string, payload url.Values) error {
+	res, err := c.postForm(ctx, method, payload)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if cerr := res.Body.Close(); cerr != nil {
+			c.log().Warn(ctx, "telegram response close failed", "error", cerr)
+		}
+	}()
+	if res.StatusCode >= http.StatusMultipleChoices {
+		body, _ := io.ReadAll(res.Body)
+		return parseAPIError(res.StatusCode, res.Header, body)
+	}
+	return nil
+}
+
+// renderAlertHistory lists recent alert deliveries, newest first.
+func renderAlertHistory(p i18n.Printer, loc *time.Location, records []domain.AlertRecord) richtext.Doc {
+	if len(records) == 0 {
+		return richtext.Text(p.T("alerts.none"))
+	}
+
+	icons := map[domain.DeliveryStatus]string{
+		domain.DeliverySent:      "✅",
+		domain.DeliveryFailed:    "❌",
+		domain.DeliveryPending:   "⏳",
+		domain.DeliveryQueued:    "📤",
+		domain.DeliveryDuplicate: "➖",
+	}
+	var lines []string
+	for _, r := range records {
+		line := fmt.Sprintf("%s %s %-9s %s", icons[r.Status], p.DateTime(calendar.In(r.CreatedAt, loc)), r.Kind, r.MedicineName)
+		if r.Attempts > 1 {
+			line += p.T("alerts.attempts", r.Attempts)
+		}
+		lines = append(lines, line)
+	}
+	return richtext.New(
+		richtext.P(richtext.B(p.T("alerts.title"))),
+		richtext.CodeBlock{Lang: "text", Lines: lines},
 	)
+}
+
+func (c *Client) sendTo(ctx context.Context, chatID int64, msg richtext.Doc) error {
+	text := richtext.MarkdownV2(msg)
+	if text == "" {
+		return fmt.Errorf("empty telegram message")
+	}
+
+	return c.send(ctx, strconv.FormatInt(chatID, 10), text, "")
+}
+
+// send delivers text to chatID, split into parts that fit Telegram's message
+// limit. text must already be escaped for MarkdownV2. markup, when set, is
+// attached to the last part.
+func (c *Client) send(ctx context.Context, chatID, text, markup string) error {
+	return c.sendTagged(ctx, chatID, text, markup, "")
+}
+
+// sendTagged sends text like send, tagging queued parts with alertKey.
+func (c *Client) sendTagged(ctx context.Context, chatID, text, markup, alertKey string) error {
+	parts := splitMessage(text, maxMessageLen)
+	for i, part := range parts {
+		partMarkup := ""
+		if i == len(parts)-1 {
+			partMarkup = markup
+		}
+		if err := c.sendPart(ctx, chatID, part, partMarkup, alertKey); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// sendPart queues text for chatID when an outbox is configured, and posts it
+// immediately otherwise.
+func (c *Client) sendPart(ctx context.Context, chatID, text, markup, alertKey string) error {
+	if c.outbox == nil {
+		return c.post(ctx, chatID, text, markup)
+	}
+	m, err := c.outbox.Enqueue(domain.OutboxMessage{
+		ChatID:      chatID,
+		Text:        text,
+		ParseMode:   "MarkdownV2",
+		ReplyMarkup: markup,
+		AlertKey:    alertKey,
+		Status:      domain.OutboxQueued,
+		CreatedAt:   time.Now().UTC(),
+	})
 	if err != nil {
+		return fmt.Errorf("queue telegram message: %w", err)
+	}
+	c.log().Debug(ctx, "telegram message queued", "message_id", m.ID, "chat_id", chatID)
+	return nil
+}
+
+// post makes a single sendMessage call. Non-2xx responses are returned as
+// *APIError.
+func (c *Client) post(ctx context.Context, chatID, text, markup string) error {
+	payload := url.Values{}
+	payload.Set("chat_id", chatID)
+	payload.Set("text", text)
+	payload.Set("parse_mode", "MarkdownV2")
+	if markup != "" {
+		payload.Set("reply_markup", markup)
+	}
+
+	res, err := c.postForm(ctx, "sendMessage", payload)
+	if err != nil {
+		metrics.TelegramSends.WithLabelValues("error").Inc()
 		return err
 	}
 	defer func() {
 		if cerr := res.Body.Close(); cerr != nil {
-			log.Printf("telegram response close error: %v", cerr)
+			c.log().Warn(ctx, "telegram response close failed", "error", cerr)
 		}
 	}()
+	metrics.TelegramSends.WithLabelValues(sendOutcome(res.StatusCode)).Inc()
 
 	if res.StatusCode >= http.StatusMultipleChoices {
 		body, err := io.ReadAll(res.Body)
 		if err != nil {
-			log.Printf("telegram read body error: %v", err)
-			return fmt.Errorf("telegram error status: %d", res.StatusCode)
+			c.log().Warn(ctx, "telegram read body failed", "error", err)
+			return &APIError{StatusCode: res.StatusCode}
 		}
-		log.Printf("telegram send failed: status=%d body=%s", res.StatusCode, string(body))
-		return fmt.Errorf("telegram error status: %d", res.StatusCode)
+		c.log().Warn(ctx, "telegram send failed", "chat_id", chatID, "status", res.StatusCode, "body", string(body))
+		return parseAPIError(res.StatusCode, res.Header, body)
 	}
 
 	return nil
 }
 
-// formatMGA formats numbers with comma separators and adds NARROW NO-BREAK SPACE before MGA.
-func formatMGA(v float64) string {
-	s := fmt.Sprintf("%.0f", v)
-	n := len(s)
-	if n <= 3 {
-		return s + "\u202fMGA"
+// sendOutcome labels a sendMessage response for metrics.TelegramSends.
+func sendOutcome(status int) string {
+	switch {
+	case status < http.StatusMultipleChoices:
+		return "sent"
+	case status == http.StatusTooManyRequests:
+		return "rate_limited"
+	case status < http.StatusInternalServerError:
+		return "rejected"
+	default:
+		return "failed"
 	}
+}
 
-	var out []rune
-	for i, c := range s {
-		if (n-i)%3 == 0 && i != 0 {
-			out = append(out, ',')
-		}
-		out = append(out, c)
-	}
-	return string(out) + "\u202fMGA"
+// Ping checks that the Bot API answers and accepts the token.
+func (c *Client) Ping(ctx context.Context) error {
+	return c.call(ctx, "getMe", url.Values{})
 }
 
-// renderNeedBlock formats a single need report block in monospaced layout.
-func renderNeedBlock(n domain.NeedReportBlock) string {
-	parts := strings.SplitN(n.Need, " ", 2)
-	dateStr := parts[0]
-	label := ""
-	if len(parts) > 1 {
-		label = parts[1]
+// postForm sends payload to a Bot API method as a form, in a span that ends
+// once the response headers are received. The request is abandoned when ctx
+// is cancelled.
+func (c *Client) postForm(ctx context.Context, method string, payload url.Values) (*http.Response, error) {
+	ctx, span := tracing.Start(ctx, "telegram "+method,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(attribute.String("telegram.method", method)))
+	defer span.End()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.baseURL+"/bot"+c.Token+"/"+method, strings.NewReader(payload.Encode()))
+	if err != nil {
+		return nil, err
 	}
-	d, err := time.Parse("2006-01-02", dateStr)
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	res, err := http.DefaultClient.Do(req)
 	if err != nil {
-		d = time.Time{}
+		tracing.Fail(span, err)
+		return nil, err
 	}
-
-	var lines []string
-	lines = append(lines, fmt.Sprintf("📅 %s – %s", d.Format("2006-01-02"), label))
-	lines = append(lines, fmt.Sprintf("Need:          %s", formatMGA(n.NeedAmount)))
-	lines = append(lines, fmt.Sprintf("Contributed:   %s", formatMGA(n.Total)))
-	lines = append(lines, "")
-	lines = append(lines, fmt.Sprintf("| %-12s | %-12s |", "Contributor", "Amount"))
-	lines = append(lines, fmt.Sprintf("|%s|%s|", strings.Repeat("-", 14), strings.Repeat("-", 14)))
-
-	// ❌ DO NOT re-sort here. Keep usecase-defined order.
-	for _, ctb := range n.Contributors {
-		lines = append(lines, fmt.Sprintf("| %-12s | %12s |", ctb.Name, formatMGA(ctb.Amount)))
+	span.SetAttributes(attribute.Int("http.response.status_code", res.StatusCode))
+	if res.StatusCode >= http.StatusMultipleChoices {
+		span.SetStatus(codes.Error, res.Status)
 	}
-
-	return "```text\n" + strings.Join(lines, "\n") + "\n```"
+	return res, nil
 }
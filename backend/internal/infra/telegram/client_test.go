@@ -2,20 +2,35 @@ package telegram
 
 import (
 	"bytes"
+	"context"
+	"errors"
 	"fmt"
-	"log"
+	"log/slog"
 	"math"
 	"net/http"
 	"net/http/httptest"
+	"net/url"
+	"path"
 	"strings"
 	"testing"
 	"time"
 
 	"github.com/nomenarkt/vitaltrack/backend/internal/domain"
+	"github.com/nomenarkt/vitaltrack/backend/internal/infra/filestore"
+	"github.com/nomenarkt/vitaltrack/backend/internal/infra/memstore"
+	"github.com/nomenarkt/vitaltrack/backend/internal/logger"
 	"github.com/nomenarkt/vitaltrack/backend/internal/usecase"
-	"github.com/nomenarkt/vitaltrack/backend/internal/util"
+	"github.com/nomenarkt/vitaltrack/backend/internal/util/i18n"
+	"github.com/nomenarkt/vitaltrack/backend/internal/util/richtext"
 )
 
+// md returns s as it appears in a MarkdownV2 message outside code blocks.
+func md(s string) string {
+	return richtext.MarkdownV2(richtext.Text(s))
+}
+
+const forecastTitle = "*Out\\-of\\-Stock Forecast*"
+
 func newTestServer(t *testing.T) (*httptest.Server, *[]string) {
 	msgs := &[]string{}
 	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
@@ -41,25 +56,25 @@ func TestHandleStockCommand(t *testing.T) {
 			name:    "no_data",
 			meds:    []domain.Medicine{},
 			entries: []domain.StockEntry{},
-			expect:  "\u26a0\ufe0f No medicine or stock data found.",
+			expect:  md("\u26a0\ufe0f No medicine or stock data found."),
 		},
 		{
 			name:    "no_entries",
 			meds:    []domain.Medicine{{ID: "m1", Name: "Med1", StartDate: domain.NewFlexibleDate(now), InitialStock: 10, DailyDose: 1, UnitPerBox: 10}},
 			entries: []domain.StockEntry{},
-			expect:  "*Out-of-Stock Forecast*",
+			expect:  forecastTitle,
 		},
 		{
 			name:    "all_good",
 			meds:    []domain.Medicine{{ID: "m2", Name: "Med2", StartDate: domain.NewFlexibleDate(now), InitialStock: 0, DailyDose: 1, UnitPerBox: 10}},
 			entries: []domain.StockEntry{{MedicineID: []string{"m2"}, Quantity: 1.0, Unit: "box", Date: domain.NewFlexibleDate(now)}},
-			expect:  "*Out-of-Stock Forecast*",
+			expect:  forecastTitle,
 		},
 		{
 			name:    "forecast",
 			meds:    []domain.Medicine{{ID: "m3", Name: "Med3", StartDate: domain.NewFlexibleDate(now), InitialStock: 10, DailyDose: 1, UnitPerBox: 10}},
 			entries: []domain.StockEntry{{MedicineID: []string{"m3"}, Quantity: 1.0, Unit: "box", Date: domain.NewFlexibleDate(now)}},
-			expect:  "*Out-of-Stock Forecast*",
+			expect:  forecastTitle,
 		},
 	}
 
@@ -69,26 +84,22 @@ func TestHandleStockCommand(t *testing.T) {
 			defer srv.Close()
 
 			var logBuf bytes.Buffer
-			orig := log.Writer()
-			log.SetOutput(&logBuf)
-			defer log.SetOutput(orig)
-
 			c := &Client{Token: "test", ChatID: "1", baseURL: srv.URL}
-			fetch := func() ([]domain.Medicine, []domain.StockEntry, error) {
+			c.Logger = logger.New(&logBuf, logger.Options{Level: slog.LevelDebug})
+			fetch := func(context.Context) ([]domain.Medicine, []domain.StockEntry, error) {
 				return tt.meds, tt.entries, nil
 			}
-			c.handleStockCommand(123, fetch)
+			c.handleStockCommand(context.Background(), 123, fetch)
 
 			if len(*msgs) == 0 {
 				t.Fatalf("no telegram message sent")
 			}
 			got := (*msgs)[0]
-			expected := util.EscapeMarkdown(tt.expect)
-			if !strings.Contains(got, expected) {
+			if !strings.Contains(got, tt.expect) {
 				t.Errorf("expected %q in message, got %q", tt.expect, got)
 			}
 
-			if !strings.Contains(logBuf.String(), "📦 meds:") {
+			if !strings.Contains(logBuf.String(), "stock data fetched") {
 				t.Errorf("expected log of data counts")
 			}
 		})
@@ -101,20 +112,19 @@ func TestHandleStockCommand_onlyInitialStock(t *testing.T) {
 
 	now := time.Now().AddDate(0, 0, -2)
 	meds := []domain.Medicine{{ID: "m4", Name: "InitOnly", StartDate: domain.NewFlexibleDate(now), InitialStock: 5, DailyDose: 1, UnitPerBox: 10}}
-	fetch := func() ([]domain.Medicine, []domain.StockEntry, error) {
+	fetch := func(context.Context) ([]domain.Medicine, []domain.StockEntry, error) {
 		return meds, []domain.StockEntry{}, nil
 	}
 
 	c := &Client{Token: "test", ChatID: "1", baseURL: srv.URL}
-	c.handleStockCommand(456, fetch)
+	c.handleStockCommand(context.Background(), 456, fetch)
 
 	if len(*msgs) == 0 {
 		t.Fatalf("no telegram message sent")
 	}
 
 	got := (*msgs)[0]
-	expected := util.EscapeMarkdown("*Out-of-Stock Forecast*")
-	if !strings.Contains(got, expected) {
+	if !strings.Contains(got, forecastTitle) {
 		t.Errorf("expected forecast message, got %q", got)
 	}
 }
@@ -126,20 +136,19 @@ func TestHandleStockCommand_withFloatEntries(t *testing.T) {
 	now := time.Now().AddDate(0, 0, -1)
 	meds := []domain.Medicine{{ID: "m5", Name: "FloatMed", StartDate: domain.NewFlexibleDate(now), InitialStock: 0, DailyDose: 1, UnitPerBox: 10}}
 	entries := []domain.StockEntry{{MedicineID: []string{"m5"}, Quantity: 0.75, Unit: "box", Date: domain.NewFlexibleDate(now)}}
-	fetch := func() ([]domain.Medicine, []domain.StockEntry, error) {
+	fetch := func(context.Context) ([]domain.Medicine, []domain.StockEntry, error) {
 		return meds, entries, nil
 	}
 
 	c := &Client{Token: "test", ChatID: "1", baseURL: srv.URL}
-	c.handleStockCommand(789, fetch)
+	c.handleStockCommand(context.Background(), 789, fetch)
 
 	if len(*msgs) == 0 {
 		t.Fatalf("no telegram message sent")
 	}
 
 	got := (*msgs)[0]
-	expected := util.EscapeMarkdown("*Out-of-Stock Forecast*")
-	if !strings.Contains(got, expected) {
+	if !strings.Contains(got, forecastTitle) {
 		t.Errorf("expected forecast message, got %q", got)
 	}
 }
@@ -151,19 +160,19 @@ func TestHandleStockCommand_zeroDose(t *testing.T) {
 	now := time.Now().AddDate(0, 0, -1)
 	meds := []domain.Medicine{{ID: "m6", Name: "ZeroDose", StartDate: domain.NewFlexibleDate(now), InitialStock: 10, DailyDose: 0, UnitPerBox: 10}}
 	entries := []domain.StockEntry{{MedicineID: []string{"m6"}, Quantity: 1.0, Unit: "box", Date: domain.NewFlexibleDate(now)}}
-	fetch := func() ([]domain.Medicine, []domain.StockEntry, error) {
+	fetch := func(context.Context) ([]domain.Medicine, []domain.StockEntry, error) {
 		return meds, entries, nil
 	}
 
 	c := &Client{Token: "test", ChatID: "1", baseURL: srv.URL}
-	c.handleStockCommand(999, fetch)
+	c.handleStockCommand(context.Background(), 999, fetch)
 
 	if len(*msgs) == 0 {
 		t.Fatalf("no telegram message sent")
 	}
 
 	got := (*msgs)[0]
-	expected := util.EscapeMarkdown("\u2705 All medicines are well stocked.")
+	expected := md("\u2705 All medicines are well stocked.")
 	if !strings.Contains(got, expected) {
 		t.Errorf("expected all-stocked message, got %q", got)
 	}
@@ -186,18 +195,18 @@ func TestHandleStockCommand_partialDose(t *testing.T) {
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			meds := []domain.Medicine{{ID: "mp" + tt.name, Name: "Partial" + tt.name, StartDate: domain.NewFlexibleDate(now), InitialStock: tt.initialStock, DailyDose: tt.daily, UnitPerBox: 1}}
-			fetch := func() ([]domain.Medicine, []domain.StockEntry, error) {
+			fetch := func(context.Context) ([]domain.Medicine, []domain.StockEntry, error) {
 				return meds, []domain.StockEntry{}, nil
 			}
 
 			c := &Client{Token: "test", ChatID: "1", baseURL: srv.URL}
-			c.handleStockCommand(100, fetch)
+			c.handleStockCommand(context.Background(), 100, fetch)
 
 			if len(*msgs) == 0 {
 				t.Fatalf("no telegram message sent")
 			}
 			got := (*msgs)[len(*msgs)-1]
-			expectedDate := util.EscapeMarkdown(now.AddDate(0, 0, int(math.Floor(tt.initialStock/tt.daily))).Format("2006-01-02"))
+			expectedDate := now.AddDate(0, 0, int(math.Floor(tt.initialStock/tt.daily))).Format("2006-01-02")
 			if !strings.Contains(got, expectedDate) {
 				t.Errorf("expected date %s in message, got %q", expectedDate, got)
 			}
@@ -217,10 +226,10 @@ func TestHandleStockCommand_refillAppliedCumulatively(t *testing.T) {
 		{MedicineID: []string{"mref"}, Quantity: 1, Unit: "box", Date: domain.NewFlexibleDate(start.AddDate(0, 0, 3))},
 	}
 	meds := []domain.Medicine{{ID: "mref", Name: "Refill", StartDate: domain.NewFlexibleDate(start), InitialStock: 0, DailyDose: 1, UnitPerBox: 10}}
-	fetch := func() ([]domain.Medicine, []domain.StockEntry, error) { return meds, entries, nil }
+	fetch := func(context.Context) ([]domain.Medicine, []domain.StockEntry, error) { return meds, entries, nil }
 
 	c := &Client{Token: "test", ChatID: "1", baseURL: srv.URL}
-	c.handleStockCommand(200, fetch)
+	c.handleStockCommand(context.Background(), 200, fetch)
 
 	if len(*msgs) == 0 {
 		t.Fatalf("no telegram message sent")
@@ -228,7 +237,7 @@ func TestHandleStockCommand_refillAppliedCumulatively(t *testing.T) {
 	got := (*msgs)[0]
 	stock := 30.0 - 5.0 // 3 boxes = 30, consumed 5
 	days := int(math.Floor(stock))
-	expectedDate := util.EscapeMarkdown(now.AddDate(0, 0, days).Format("2006-01-02"))
+	expectedDate := now.AddDate(0, 0, days).Format("2006-01-02")
 	if !strings.Contains(got, expectedDate) {
 		t.Errorf("expected cumulative forecast date %s, got %q", expectedDate, got)
 	}
@@ -238,26 +247,36 @@ func TestHandleStockCommand_fetchError(t *testing.T) {
 	srv, msgs := newTestServer(t)
 	defer srv.Close()
 
-	fetch := func() ([]domain.Medicine, []domain.StockEntry, error) {
+	fetch := func(context.Context) ([]domain.Medicine, []domain.StockEntry, error) {
 		return nil, nil, fmt.Errorf("boom")
 	}
 	c := &Client{Token: "test", ChatID: "1", baseURL: srv.URL}
-	c.handleStockCommand(300, fetch)
+	c.handleStockCommand(context.Background(), 300, fetch)
 
 	if len(*msgs) == 0 {
 		t.Fatalf("no telegram message sent")
 	}
-	if (*msgs)[0] != util.EscapeMarkdown("\u26a0\ufe0f Failed to fetch stock data.") {
+	if (*msgs)[0] != md("\u26a0\ufe0f Failed to fetch stock data.") {
 		t.Errorf("expected fetch error message, got %q", (*msgs)[0])
 	}
 }
 
 type mockFinanceRepo struct{ entries []domain.FinancialEntry }
 
-func (m mockFinanceRepo) FetchFinancialEntries(_ int, _ time.Month) ([]domain.FinancialEntry, error) {
+func (m mockFinanceRepo) FetchFinancialEntries(_ context.Context, _ int, _ time.Month) ([]domain.FinancialEntry, error) {
 	return m.entries, nil
 }
 
+func (m mockFinanceRepo) GetFinancialEntry(context.Context, string) (domain.FinancialEntry, error) {
+	return domain.FinancialEntry{}, nil
+}
+func (m mockFinanceRepo) CreateFinancialEntry(context.Context, domain.FinancialEntry) (domain.FinancialEntry, error) {
+	return domain.FinancialEntry{}, nil
+}
+func (m mockFinanceRepo) UpdateFinancialEntry(context.Context, string, domain.FinancialEntryPatch) (domain.FinancialEntry, error) {
+	return domain.FinancialEntry{}, nil
+}
+
 func TestHandleFinanceCommand(t *testing.T) {
 	srv, msgs := newTestServer(t)
 	defer srv.Close()
@@ -270,42 +289,43 @@ func TestHandleFinanceCommand(t *testing.T) {
 	}
 
 	svc := usecase.FinancialReportService{Repo: mockFinanceRepo{entries: entries}}
-	report, err := svc.GenerateFinancialReport(2025, int(time.June))
+	report, err := svc.GenerateFinancialReport(context.Background(), 2025, int(time.June))
 	if err != nil {
 		t.Fatalf("generate report error: %v", err)
 	}
 
-	fn := func(_, _ int) (domain.MonthlyFinancialReport, error) { return report, nil }
+	fn := func(context.Context, int, int) (domain.MonthlyFinancialReport, error) { return report, nil }
 	c := &Client{Token: "tok", ChatID: "1", baseURL: srv.URL}
-	c.handleFinanceCommand(55, fn, 2025, time.June)
+	c.handleFinanceCommand(context.Background(), 55, fn, 2025, time.June)
 
 	if len(*msgs) == 0 {
 		t.Fatalf("no telegram message sent")
 	}
 	msg := (*msgs)[0]
 
-	// Assertions matching the actual generated message
+	// Assertions matching the actual generated message; code block lines are
+	// sent unescaped.
 	expectedSubstrings := []string{
-		"*Financial Report 2025-06*",
-		"📅 2025-06-05 – Med",
+		"*Financial Report 2025\\-06*",
+		"```text\n📅 2025-06-05 – Med",
 		"Need:          20\u202fMGA",
 		"Contributed:   15\u202fMGA",
-		"| Contributor  | Amount       |",
-		"|--------------|--------------|",
-		"| Alice        |       10\u202fMGA |",
-		"| Bob          |        5\u202fMGA |",
-		"| Charlie      |        0\u202fMGA |",
-		"🧮 Monthly Summary",
-		"💰 Total Needs: 20\u202fMGA",
-		"💵 Total Contributed: 15\u202fMGA",
-		"👤 By Contributor:",
-		"- Alice → 10\u202fMGA",
-		"- Bob → 5\u202fMGA",
-		"- Charlie → 0\u202fMGA",
+		"| Contributor | Amount |",
+		"|-------------|--------|",
+		"| Alice       | 10\u202fMGA |",
+		"| Bob         |  5\u202fMGA |",
+		"| Charlie     |  0\u202fMGA |",
+		md("🧮 Monthly Summary"),
+		md("💰 Total Needs: 20\u202fMGA"),
+		md("💵 Total Contributed: 15\u202fMGA"),
+		md("👤 By Contributor:"),
+		"• Alice → 10\u202fMGA",
+		"• Bob → 5\u202fMGA",
+		"• Charlie → 0\u202fMGA",
 	}
 
 	for _, want := range expectedSubstrings {
-		if !strings.Contains(msg, util.EscapeMarkdown(want)) {
+		if !strings.Contains(msg, want) {
 			t.Errorf("expected to find substring:\n\t%s\nin message:\n\t%s", want, msg)
 		}
 	}
@@ -317,8 +337,8 @@ func TestSendTo_escapesMarkdown(t *testing.T) {
 
 	c := &Client{Token: "test", ChatID: "1", baseURL: srv.URL}
 
-	name := "NEBI-LOL 5mg (sample)"
-	if err := c.sendTo(111, name); err != nil {
+	name := "NEBI_LOL*5mg (sample)"
+	if err := c.sendTo(context.Background(), 111, richtext.New(richtext.P(richtext.B(name)))); err != nil {
 		t.Fatalf("sendTo error: %v", err)
 	}
 
@@ -326,7 +346,7 @@ func TestSendTo_escapesMarkdown(t *testing.T) {
 		t.Fatalf("no telegram message sent")
 	}
 
-	expected := util.EscapeMarkdown(name)
+	expected := "*NEBI\\_LOL\\*5mg \\(sample\\)*"
 	if (*msgs)[0] != expected {
 		t.Errorf("expected %q, got %q", expected, (*msgs)[0])
 	}
@@ -343,24 +363,21 @@ func TestHandleStockCommand_invalidEntriesSkipped(t *testing.T) {
 		{MedicineID: []string{"sk1"}, Quantity: 1, Unit: "box", Date: domain.NewFlexibleDate(now)},
 		{MedicineID: []string{"sk1"}, Quantity: -2, Unit: "pill", Date: domain.FlexibleDate{}},
 	}
-	fetch := func() ([]domain.Medicine, []domain.StockEntry, error) { return meds, entries, nil }
+	fetch := func(context.Context) ([]domain.Medicine, []domain.StockEntry, error) { return meds, entries, nil }
 
 	var logBuf bytes.Buffer
-	orig := log.Writer()
-	log.SetOutput(&logBuf)
-	defer log.SetOutput(orig)
-
 	c := &Client{Token: "tok", ChatID: "1", baseURL: srv.URL}
-	c.handleStockCommand(22, fetch)
+	c.Logger = logger.New(&logBuf, logger.Options{})
+	c.handleStockCommand(context.Background(), 22, fetch)
 
 	if len(*msgs) == 0 {
 		t.Fatalf("no telegram message sent")
 	}
 	msg := (*msgs)[0]
-	if !strings.Contains(msg, util.EscapeMarkdown("*Out-of-Stock Forecast*")) {
+	if !strings.Contains(msg, forecastTitle) {
 		t.Errorf("expected forecast message, got %q", msg)
 	}
-	warn := util.EscapeMarkdown("\u26a0\ufe0f Some records were skipped due to data issues.")
+	warn := md("\u26a0\ufe0f Some records were skipped due to data issues.")
 	if !strings.Contains(msg, warn) {
 		t.Errorf("expected warning, got %q", msg)
 	}
@@ -368,3 +385,293 @@ func TestHandleStockCommand_invalidEntriesSkipped(t *testing.T) {
 		t.Errorf("expected log of skipped entry")
 	}
 }
+
+func TestHandleAlertsCommand(t *testing.T) {
+	at := time.Date(2025, 6, 10, 9, 30, 0, 0, time.UTC)
+	tests := []struct {
+		name    string
+		records []domain.AlertRecord
+		err     error
+		expect  []string
+	}{
+		{name: "empty", expect: []string{"No alerts sent yet"}},
+		{name: "fetch_error", err: fmt.Errorf("boom"), expect: []string{"Failed to fetch alert history"}},
+		{
+			name: "history",
+			records: []domain.AlertRecord{
+				{Kind: "low_stock", MedicineName: "Med1", Status: domain.DeliverySent, Attempts: 1, CreatedAt: at},
+				{Kind: "refill", MedicineName: "Med2", Status: domain.DeliveryFailed, Attempts: 3, CreatedAt: at},
+			},
+			expect: []string{"*Recent Alerts*", "✅ 2025-06-10 09:30 low_stock Med1", "❌ 2025-06-10 09:30 refill    Med2 (3 attempts)"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv, msgs := newTestServer(t)
+			defer srv.Close()
+
+			c := &Client{Token: "test", ChatID: "400", baseURL: srv.URL}
+			c.handleAlertsCommand(context.Background(), 400, func(context.Context) ([]domain.AlertRecord, error) { return tt.records, tt.err })
+
+			if len(*msgs) != 1 {
+				t.Fatalf("sent %d messages, want 1", len(*msgs))
+			}
+			for _, want := range tt.expect {
+				if !strings.Contains((*msgs)[0], want) {
+					t.Errorf("message %q missing %q", (*msgs)[0], want)
+				}
+			}
+		})
+	}
+}
+
+type fakeValidator struct {
+	report domain.ValidationReport
+	err    error
+	year   int
+	month  time.Month
+}
+
+func (v *fakeValidator) Validate(_ context.Context, year int, month time.Month, _ time.Time) (domain.ValidationReport, error) {
+	v.year, v.month = year, month
+	return v.report, v.err
+}
+
+func TestHandleValidateCommand(t *testing.T) {
+	issues := domain.ValidationReport{Month: "2025-06", Issues: []domain.ValidationIssue{
+		{Kind: domain.IssueDuplicateEntry, Table: "entries", RecordID: "rec2", Label: "Aspirin"},
+	}}
+	tests := []struct {
+		name   string
+		checks *fakeValidator
+		expect []string
+	}{
+		{name: "not_configured", expect: []string{"Failed to fetch the records to check"}},
+		{name: "fetch_error", checks: &fakeValidator{err: fmt.Errorf("boom")}, expect: []string{"Failed to fetch the records to check"}},
+		{name: "clean", checks: &fakeValidator{report: domain.ValidationReport{Month: "2025-06"}}, expect: []string{"No data issues found"}},
+		{name: "issues", checks: &fakeValidator{report: issues}, expect: []string{"*Data issues*", "Duplicate entries", "Aspirin rec2"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv, msgs := newTestServer(t)
+			defer srv.Close()
+
+			c := &Client{Token: "test", ChatID: "400", baseURL: srv.URL}
+			if tt.checks != nil {
+				c.UseValidation(tt.checks)
+			}
+			c.handleValidateCommand(context.Background(), 400, 2025, time.June)
+
+			if len(*msgs) != 1 {
+				t.Fatalf("sent %d messages, want 1", len(*msgs))
+			}
+			for _, want := range tt.expect {
+				if !strings.Contains((*msgs)[0], want) {
+					t.Errorf("message %q missing %q", (*msgs)[0], want)
+				}
+			}
+			if tt.checks != nil && (tt.checks.year != 2025 || tt.checks.month != time.June) {
+				t.Errorf("validated %d-%02d, want 2025-06", tt.checks.year, tt.checks.month)
+			}
+		})
+	}
+}
+
+func TestHandleLangCommand(t *testing.T) {
+	srv, msgs := newTestServer(t)
+	defer srv.Close()
+
+	prefs, err := filestore.OpenPrefs("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	c := &Client{Token: "test", ChatID: "1", DigestChats: []string{"7", "8"}, Lang: i18n.English, baseURL: srv.URL}
+	c.UsePrefs(prefs)
+	report := func(context.Context, int, int) (domain.MonthlyFinancialReport, error) {
+		return domain.MonthlyFinancialReport{
+			Year: 2025, Month: 6, Total: 12500,
+			Needs: []domain.NeedReportBlock{{Need: "2025-06-05 Med", NeedAmount: 12500, Total: 12500,
+				Contributors: []domain.ContributorAmount{{Name: "Alice", Amount: 12500}}}},
+		}, nil
+	}
+
+	steps := []struct {
+		name   string
+		run    func()
+		expect []string
+	}{
+		{"show_default", func() { c.handleLangCommand(context.Background(), 7, nil) }, []string{md("🌐 Language: English"), "/lang fr"}},
+		{"unknown", func() { c.handleLangCommand(context.Background(), 7, []string{"de"}) }, []string{md(`⚠️ Unknown language "de".`)}},
+		{"set_french", func() { c.handleLangCommand(context.Background(), 7, []string{"FR"}) }, []string{md("✅ Langue choisie : Français.")}},
+		{"french_report", func() { c.handleFinanceCommand(context.Background(), 7, report, 2025, time.June) }, []string{
+			"*Rapport financier juin 2025*",
+			"📅 05/06/2025 – Med",
+			"Besoin :       12\u202f500\u202fMGA",
+			"| Contributeur |    Montant |",
+			md("💵 Total versé : 12\u202f500\u202fMGA"),
+		}},
+		{"other_chat_unaffected", func() { c.handleLangCommand(context.Background(), 8, nil) }, []string{md("🌐 Language: English")}},
+
+		{"unknown_chat_refused", func() { c.handleLangCommand(context.Background(), 666, []string{"fr"}) }, []string{md("⛔ This chat cannot use this command.")}},
+		{"validate_refused", func() { c.handleValidateCommand(context.Background(), 666, 2025, time.June) }, []string{md("⛔ This chat cannot use this command.")}},
+	}
+	for _, s := range steps {
+		before := len(*msgs)
+		s.run()
+		if len(*msgs) != before+1 {
+			t.Fatalf("%s: sent %d messages, want 1", s.name, len(*msgs)-before)
+		}
+		for _, want := range s.expect {
+			if got := (*msgs)[before]; !strings.Contains(got, want) {
+				t.Errorf("%s: message %q missing %q", s.name, got, want)
+			}
+		}
+	}
+
+	if got := c.Printer().Lang(); got != i18n.English {
+		t.Errorf("configured chat printer = %s, want the default", got)
+	}
+	if _, err := prefs.GetChatPrefs("666"); !errors.Is(err, domain.ErrNotFound) {
+		t.Errorf("refused chat prefs: err = %v, want ErrNotFound", err)
+	}
+}
+
+func TestHandleDigestCommand(t *testing.T) {
+	srv, msgs := newTestServer(t)
+	defer srv.Close()
+
+	prefs, err := filestore.OpenPrefs("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	c := &Client{Token: "test", ChatID: "1", DigestChats: []string{"7"}, Lang: i18n.English, baseURL: srv.URL}
+	c.UsePrefs(prefs)
+
+	steps := []struct {
+		name   string
+		run    func()
+		expect []string
+	}{
+		{"alert_chat_defaults_on", func() { c.handleDigestCommand(context.Background(), 1, nil) }, []string{md("Digests for this chat: stock on, finance on")}},
+		{"other_chat_defaults_off", func() { c.handleDigestCommand(context.Background(), 7, nil) }, []string{md("stock off, finance off"), "/digest stock on"}},
+		{"bad_args", func() { c.handleDigestCommand(context.Background(), 7, []string{"weather", "on"}) }, []string{md(`⚠️ Unknown digest "weather on".`)}},
+		{"set_french", func() { c.handleLangCommand(context.Background(), 7, []string{"fr"}) }, []string{md("Français")}},
+		{"subscribe", func() { c.handleDigestCommand(context.Background(), 7, []string{"stock", "on"}) }, []string{md("✅ Résumé stocks : activé.")}},
+		{"opt_out", func() { c.handleDigestCommand(context.Background(), 1, []string{"finance", "off"}) }, []string{md("✅ Digest finance turned off.")}},
+		{"unknown_chat_refused", func() { c.handleDigestCommand(context.Background(), 666, []string{"finance", "on"}) }, []string{md("⛔ This chat cannot receive digests.")}},
+	}
+	for _, s := range steps {
+		before := len(*msgs)
+		s.run()
+		if len(*msgs) != before+1 {
+			t.Fatalf("%s: sent %d messages, want 1", s.name, len(*msgs)-before)
+		}
+		for _, want := range s.expect {
+			if got := (*msgs)[before]; !strings.Contains(got, want) {
+				t.Errorf("%s: message %q missing %q", s.name, got, want)
+			}
+		}
+	}
+
+	got, err := prefs.GetChatPrefs("7")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.Language != "fr" || !got.Subscribed(domain.DigestStock, false) || got.Subscribed(domain.DigestFinance, false) {
+		t.Errorf("chat 7 prefs = %+v, want French with the stock digest only", got)
+	}
+	if _, err := prefs.GetChatPrefs("666"); !errors.Is(err, domain.ErrNotFound) {
+		t.Errorf("unknown chat prefs saved: %v", err)
+	}
+}
+
+func TestSendWithButtons(t *testing.T) {
+	var markup string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if err := r.ParseForm(); err != nil {
+			t.Fatalf("parse form: %v", err)
+		}
+		markup = r.Form.Get("reply_markup")
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer srv.Close()
+
+	c := &Client{Token: "test", ChatID: "1", baseURL: srv.URL}
+	buttons := []domain.ReplyButton{{Label: "✅ Taken", Data: "dose:dose1:taken"}, {Label: "⏭️ Skipped", Data: "dose:dose1:skipped"}}
+	if err := c.SendWithButtons(context.Background(), "1", richtext.Text("Aspirin"), buttons); err != nil {
+		t.Fatal(err)
+	}
+	want := `{"inline_keyboard":[[{"text":"✅ Taken","callback_data":"dose:dose1:taken"},{"text":"⏭️ Skipped","callback_data":"dose:dose1:skipped"}]]}`
+	if markup != want {
+		t.Errorf("reply_markup = %s, want %s", markup, want)
+	}
+}
+
+func TestHandleCallback(t *testing.T) {
+	calls := map[string]url.Values{}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if err := r.ParseForm(); err != nil {
+			t.Fatalf("parse form: %v", err)
+		}
+		calls[path.Base(r.URL.Path)] = r.Form
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer srv.Close()
+
+	doses := memstore.NewDoseLog()
+	ev, _ := doses.CreateDoseEvent(context.Background(), domain.DoseEvent{
+		Key: "dose:m1:2025-06-05T08:00", MedicineID: "m1", MedicineName: "Aspirin", Pills: 1,
+		Scheduled: time.Date(2025, 6, 5, 8, 0, 0, 0, time.UTC), Status: domain.DosePending,
+	})
+	c := &Client{Token: "test", ChatID: "1", Lang: i18n.English, baseURL: srv.URL}
+	c.UseAdherence(usecase.AdherenceService{Events: doses})
+
+	q := CallbackQuery{ID: "q1", Data: "dose:" + ev.ID + ":taken", Message: &Message{MessageID: 42}}
+	q.Message.Chat.ID = 1
+	c.handleCallback(context.Background(), q)
+
+	if got, _ := doses.GetDoseEvent(context.Background(), ev.ID); got.Status != domain.DoseTaken {
+		t.Errorf("status = %s, want taken", got.Status)
+	}
+	if a := calls["answerCallbackQuery"]; a.Get("callback_query_id") != "q1" || a.Get("text") != "Answer recorded" {
+		t.Errorf("answerCallbackQuery = %v", a)
+	}
+	edit := calls["editMessageText"]
+	if edit.Get("message_id") != "42" || !strings.Contains(edit.Get("text"), md("✅ Taken at")) {
+		t.Errorf("editMessageText = %v", edit)
+	}
+
+	calls = map[string]url.Values{}
+	q.Data = "dose:dose99:taken"
+	c.handleCallback(context.Background(), q)
+	if a := calls["answerCallbackQuery"]; a.Get("text") != "⚠️ Could not record your answer." {
+		t.Errorf("unknown dose answer = %v", a)
+	}
+	if _, edited := calls["editMessageText"]; edited {
+		t.Error("reminder edited after a failed answer")
+	}
+}
+
+func TestPollForCommands_stopsOnCancel(t *testing.T) {
+	server, msgs := newTestServer(t)
+	defer server.Close()
+	c := &Client{Token: "x", baseURL: server.URL}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	done := make(chan struct{})
+	go func() {
+		c.PollForCommands(ctx, nil, nil, nil)
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("PollForCommands did not return after cancel")
+	}
+	if len(*msgs) != 0 {
+		t.Errorf("polled Telegram after cancel: %v", *msgs)
+	}
+}
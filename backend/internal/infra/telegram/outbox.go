@@ -0,0 +1,232 @@
+package telegram
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/nomenarkt/vitaltrack/backend/internal/domain"
+	"github.com/nomenarkt/vitaltrack/backend/internal/domain/ports"
+)
+
+// APIError is a non-2xx response from the Bot API.
+type APIError struct {
+	StatusCode  int
+	Description string
+	RetryAfter  time.Duration // from a 429's parameters.retry_after
+}
+
+func (e *APIError) Error() string {
+	if e.Description == "" {
+		return fmt.Sprintf("telegram error status: %d", e.StatusCode)
+	}
+	return fmt.Sprintf("telegram error status: %d: %s", e.StatusCode, e.Description)
+}
+
+// Temporary reports whether the same request may succeed if retried.
+func (e *APIError) Temporary() bool {
+	return e.StatusCode == http.StatusTooManyRequests || e.StatusCode >= 500
+}
+
+func parseAPIError(status int, header http.Header, body []byte) *APIError {
+	apiErr := &APIError{StatusCode: status}
+	var resp struct {
+		Description string `json:"description"`
+		Parameters  struct {
+			RetryAfter int `json:"retry_after"`
+		} `json:"parameters"`
+	}
+	if json.Unmarshal(body, &resp) == nil {
+		apiErr.Description = resp.Description
+		apiErr.RetryAfter = time.Duration(resp.Parameters.RetryAfter) * time.Second
+	}
+	if apiErr.RetryAfter == 0 {
+		if secs, err := strconv.Atoi(header.Get("Retry-After")); err == nil {
+			apiErr.RetryAfter = time.Duration(secs) * time.Second
+		}
+	}
+	return apiErr
+}
+
+// Dispatcher delivers queued outbox messages. Messages for the same chat are
+// sent in order, at most one per ChatInterval. Failures are retried with
+// exponential backoff (or after Telegram's retry_after on a 429) and
+// dead-lettered after MaxAttempts or on a permanent error.
+//
+// The outcome of an alert is reported to Alerts once its last part is
+// delivered or any part is dead-lettered; the remaining parts of a
+// dead-lettered alert are dead-lettered with it.
+type Dispatcher struct {
+	MaxAttempts  int
+	BaseBackoff  time.Duration
+	MaxBackoff   time.Duration
+	ChatInterval time.Duration
+	PollInterval time.Duration
+	Alerts       ports.AlertReporter // optional
+
+	client *Client
+	store  ports.OutboxPort
+	now    func() time.Time
+
+	lastSent     map[string]time.Time
+	blockedUntil map[string]time.Time
+}
+
+// NewDispatcher returns a Dispatcher delivering store's messages through c,
+// with defaults suited to Telegram's per-chat limits.
+func NewDispatcher(c *Client, store ports.OutboxPort) *Dispatcher {
+	return &Dispatcher{
+		MaxAttempts:  8,
+		BaseBackoff:  2 * time.Second,
+		MaxBackoff:   5 * time.Minute,
+		ChatInterval: time.Second,
+		PollInterval: 500 * time.Millisecond,
+		client:       c,
+		store:        store,
+		now:          time.Now,
+		lastSent:     map[string]time.Time{},
+		blockedUntil: map[string]time.Time{},
+	}
+}
+
+// Run delivers due messages every PollInterval until ctx is cancelled.
+// Sends in progress are not interrupted by the cancellation, and one last
+// pass delivers what is ready before Run returns; anything still queued
+// stays in the outbox.
+func (d *Dispatcher) Run(ctx context.Context) {
+	d.client.log().Info(ctx, "outbox dispatcher started")
+	sendCtx := context.WithoutCancel(ctx)
+	ticker := time.NewTicker(d.PollInterval)
+	defer ticker.Stop()
+	for {
+		if _, err := d.DeliverDue(sendCtx); err != nil {
+			d.client.log().Error(ctx, "outbox delivery pass failed", "error", err)
+		}
+		select {
+		case <-ctx.Done():
+			if _, err := d.DeliverDue(sendCtx); err != nil {
+				d.client.log().Error(ctx, "outbox delivery pass failed", "error", err)
+			}
+			d.client.log().Info(ctx, "outbox dispatcher stopped")
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// DeliverDue makes one delivery pass: for each chat it attempts the oldest
+// queued message if that message and the chat are ready. It returns the
+// number of messages delivered.
+func (d *Dispatcher) DeliverDue(ctx context.Context) (int, error) {
+	pending, err := d.store.Pending()
+	if err != nil {
+		return 0, fmt.Errorf("load outbox: %w", err)
+	}
+
+	now := d.now().UTC()
+	delivered := 0
+	seen := map[string]bool{}
+	for _, m := range pending {
+		if seen[m.ChatID] {
+			continue // keep per-chat ordering: only the head message is eligible
+		}
+		seen[m.ChatID] = true
+
+		if m.NextAttemptAt.After(now) || d.blockedUntil[m.ChatID].After(now) {
+			continue
+		}
+		if last, ok := d.lastSent[m.ChatID]; ok && now.Sub(last) < d.ChatInterval {
+			continue
+		}
+
+		if err := d.client.post(ctx, m.ChatID, m.Text, m.ReplyMarkup); err != nil {
+			d.fail(ctx, m, pending, err, now)
+			continue
+		}
+		d.lastSent[m.ChatID] = now
+		if err := d.store.Remove(m.ID); err != nil {
+			d.client.log().Warn(ctx, "delivered message could not be removed from the outbox", "message_id", m.ID, "error", err)
+		}
+		delivered++
+		if m.AlertKey != "" && len(alertParts(pending, m)) == 0 {
+			d.report(ctx, m.AlertKey, nil, now)
+		}
+	}
+	return delivered, nil
+}
+
+// alertParts returns the other messages of pending carrying m's alert.
+func alertParts(pending []domain.OutboxMessage, m domain.OutboxMessage) []domain.OutboxMessage {
+	var out []domain.OutboxMessage
+	for _, o := range pending {
+		if o.AlertKey == m.AlertKey && o.ID != m.ID {
+			out = append(out, o)
+		}
+	}
+	return out
+}
+
+func (d *Dispatcher) report(ctx context.Context, key string, sendErr error, now time.Time) {
+	if d.Alerts == nil {
+		return
+	}
+	if err := d.Alerts.ReportAlert(ctx, key, sendErr, now); err != nil {
+		d.client.log().Error(ctx, "could not report alert delivery", "key", key, "error", err)
+	}
+}
+
+func (d *Dispatcher) fail(ctx context.Context, m domain.OutboxMessage, pending []domain.OutboxMessage, err error, now time.Time) {
+	m.Attempts++
+	m.LastError = err.Error()
+
+	var apiErr *APIError
+	isAPIErr := errors.As(err, &apiErr)
+	switch {
+	case isAPIErr && !apiErr.Temporary():
+		m.Status = domain.OutboxDead
+	case m.Attempts >= d.MaxAttempts:
+		m.Status = domain.OutboxDead
+	case isAPIErr && apiErr.RetryAfter > 0:
+		d.blockedUntil[m.ChatID] = now.Add(apiErr.RetryAfter)
+		m.NextAttemptAt = now.Add(apiErr.RetryAfter)
+	default:
+		m.NextAttemptAt = now.Add(d.backoff(m.Attempts))
+	}
+
+	if m.Status == domain.OutboxDead {
+		d.client.log().Error(ctx, "telegram message dead-lettered", "message_id", m.ID, "attempts", m.Attempts, "error", err)
+	} else {
+		d.client.log().Warn(ctx, "telegram message attempt failed", "message_id", m.ID, "attempts", m.Attempts, "retry_at", m.NextAttemptAt, "error", err)
+	}
+	if err := d.store.UpdateMessage(m); err != nil {
+		d.client.log().Error(ctx, "could not record outbox attempt", "message_id", m.ID, "error", err)
+	}
+	if m.Status != domain.OutboxDead || m.AlertKey == "" {
+		return
+	}
+	for _, part := range alertParts(pending, m) {
+		part.Status = domain.OutboxDead
+		part.LastError = "alert part " + m.ID + " dead-lettered"
+		if err := d.store.UpdateMessage(part); err != nil {
+			d.client.log().Error(ctx, "could not dead-letter alert part", "message_id", part.ID, "error", err)
+		}
+	}
+	d.report(ctx, m.AlertKey, err, now)
+}
+
+// backoff returns BaseBackoff doubled for each previous attempt, capped at
+// MaxBackoff.
+func (d *Dispatcher) backoff(attempts int) time.Duration {
+	delay := d.BaseBackoff
+	for i := 1; i < attempts && delay < d.MaxBackoff; i++ {
+		delay *= 2
+	}
+	if delay > d.MaxBackoff {
+		delay = d.MaxBackoff
+	}
+	return delay
+}
@@ -0,0 +1,297 @@
+package telegram
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/nomenarkt/vitaltrack/backend/internal/infra/filestore"
+	"github.com/nomenarkt/vitaltrack/backend/internal/util/richtext"
+)
+
+type botReply struct {
+	status int
+	body   string
+}
+
+// fakeBotAPI replies to sendMessage with scripted responses, then 200s.
+type fakeBotAPI struct {
+	mu      sync.Mutex
+	replies []botReply
+	got     []string // chat_id:text of every request
+}
+
+func (f *fakeBotAPI) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if err := r.ParseForm(); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.got = append(f.got, r.Form.Get("chat_id")+":"+r.Form.Get("text"))
+	reply := botReply{http.StatusOK, `{"ok":true}`}
+	if len(f.replies) > 0 {
+		reply, f.replies = f.replies[0], f.replies[1:]
+	}
+	w.WriteHeader(reply.status)
+	_, _ = w.Write([]byte(reply.body))
+}
+
+func (f *fakeBotAPI) requests() []string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([]string(nil), f.got...)
+}
+
+func newTestDispatcher(t *testing.T, api *fakeBotAPI) (*Client, *Dispatcher, *filestore.Outbox, *time.Time) {
+	t.Helper()
+	srv := httptest.NewServer(api)
+	t.Cleanup(srv.Close)
+
+	store, err := filestore.OpenOutbox("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	c := &Client{Token: "test", ChatID: "100", baseURL: srv.URL}
+	c.UseOutbox(store)
+
+	now := time.Now().UTC()
+	d := NewDispatcher(c, store)
+	d.BaseBackoff = time.Second
+	d.MaxBackoff = 10 * time.Second
+	d.now = func() time.Time { return now }
+	return c, d, store, &now
+}
+
+func deliver(t *testing.T, d *Dispatcher) int {
+	t.Helper()
+	n, err := d.DeliverDue(context.Background())
+	if err != nil {
+		t.Fatalf("deliver: %v", err)
+	}
+	return n
+}
+
+func TestDispatcher_retriesAfter429And5xx(t *testing.T) {
+	api := &fakeBotAPI{replies: []botReply{
+		{http.StatusTooManyRequests, `{"ok":false,"error_code":429,"description":"Too Many Requests: retry after 3","parameters":{"retry_after":3}}`},
+		{http.StatusBadGateway, `Bad Gateway`},
+	}}
+	c, d, store, now := newTestDispatcher(t, api)
+
+	if err := c.SendTelegramMessage(context.Background(), richtext.Text("Refill soon")); err != nil {
+		t.Fatalf("enqueue: %v", err)
+	}
+	if len(api.requests()) != 0 {
+		t.Fatalf("message sent inline instead of queued")
+	}
+
+	steps := []struct {
+		advance   time.Duration
+		requests  int
+		delivered int
+	}{
+		{0, 1, 0},                // 429, retry_after=3s
+		{2 * time.Second, 1, 0},  // still inside retry_after
+		{time.Second, 2, 0},      // 502, attempt 2 backs off 2s
+		{time.Second, 2, 0},      // backoff not over
+		{time.Second, 3, 1},      // delivered
+		{10 * time.Second, 3, 0}, // nothing left
+	}
+	for i, s := range steps {
+		*now = now.Add(s.advance)
+		if got := deliver(t, d); got != s.delivered {
+			t.Errorf("step %d: delivered %d, want %d", i, got, s.delivered)
+		}
+		if got := len(api.requests()); got != s.requests {
+			t.Fatalf("step %d: %d requests, want %d", i, got, s.requests)
+		}
+	}
+
+	if pending, _ := store.Pending(); len(pending) != 0 {
+		t.Errorf("outbox not drained: %+v", pending)
+	}
+}
+
+func TestDispatcher_deadLetters(t *testing.T) {
+	t.Run("permanent_error", func(t *testing.T) {
+		api := &fakeBotAPI{replies: []botReply{
+			{http.StatusBadRequest, `{"ok":false,"error_code":400,"description":"Bad Request: can't parse entities"}`},
+		}}
+		c, d, store, _ := newTestDispatcher(t, api)
+		if err := c.SendTelegramMessage(context.Background(), richtext.Text("broken")); err != nil {
+			t.Fatal(err)
+		}
+
+		deliver(t, d)
+
+		dead := store.Dead()
+		if len(dead) != 1 || dead[0].Attempts != 1 || !strings.Contains(dead[0].LastError, "can't parse entities") {
+			t.Fatalf("expected immediate dead letter, got %+v", dead)
+		}
+	})
+
+	t.Run("max_attempts", func(t *testing.T) {
+		api := &fakeBotAPI{}
+		for i := 0; i < 10; i++ {
+			api.replies = append(api.replies, botReply{http.StatusInternalServerError, `{"ok":false,"error_code":500}`})
+		}
+		c, d, store, now := newTestDispatcher(t, api)
+		d.MaxAttempts = 3
+		if err := c.SendTelegramMessage(context.Background(), richtext.Text("flaky")); err != nil {
+			t.Fatal(err)
+		}
+
+		for i := 0; i < 6; i++ {
+			deliver(t, d)
+			*now = now.Add(d.MaxBackoff)
+		}
+
+		if got := len(api.requests()); got != 3 {
+			t.Errorf("%d attempts, want 3", got)
+		}
+		if dead := store.Dead(); len(dead) != 1 || dead[0].Attempts != 3 {
+			t.Errorf("unexpected dead letters: %+v", dead)
+		}
+	})
+}
+
+func TestDispatcher_perChatOrderAndRateLimit(t *testing.T) {
+	api := &fakeBotAPI{}
+	c, d, _, now := newTestDispatcher(t, api)
+
+	for _, send := range []func() error{
+		func() error { return c.sendTo(context.Background(), 1, richtext.Text("a1")) },
+		func() error { return c.sendTo(context.Background(), 1, richtext.Text("a2")) },
+		func() error { return c.sendTo(context.Background(), 2, richtext.Text("b1")) },
+	} {
+		if err := send(); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if got := deliver(t, d); got != 2 {
+		t.Fatalf("first pass delivered %d, want one per chat", got)
+	}
+	*now = now.Add(d.ChatInterval / 2)
+	if got := deliver(t, d); got != 0 {
+		t.Fatalf("delivered %d inside the per-chat interval", got)
+	}
+	*now = now.Add(d.ChatInterval / 2)
+	if got := deliver(t, d); got != 1 {
+		t.Fatalf("third pass delivered %d, want 1", got)
+	}
+
+	want := []string{"1:a1", "2:b1", "1:a2"}
+	got := api.requests()
+	for i := range want {
+		if i >= len(got) || got[i] != want[i] {
+			t.Fatalf("requests = %v, want %v", got, want)
+		}
+	}
+}
+
+func TestDispatcher_headOfLineBlocksChat(t *testing.T) {
+	api := &fakeBotAPI{replies: []botReply{{http.StatusBadGateway, ``}}}
+	c, d, _, now := newTestDispatcher(t, api)
+	if err := c.sendTo(context.Background(), 1, richtext.Text("first")); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.sendTo(context.Background(), 1, richtext.Text("second")); err != nil {
+		t.Fatal(err)
+	}
+
+	for i := 0; i < 3; i++ {
+		deliver(t, d)
+		*now = now.Add(d.BaseBackoff)
+	}
+	want := []string{"1:first", "1:first", "1:second"}
+	if got := api.requests(); strings.Join(got, ",") != strings.Join(want, ",") {
+		t.Fatalf("requests = %v, want %v: second message must wait for the first", got, want)
+	}
+}
+
+func TestDispatcher_runDrainsOnCancel(t *testing.T) {
+	api := &fakeBotAPI{}
+	c, d, store, _ := newTestDispatcher(t, api)
+	d.PollInterval = time.Hour
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if err := c.SendTelegramMessage(context.Background(), richtext.Text("Refill soon")); err != nil {
+		t.Fatalf("enqueue: %v", err)
+	}
+
+	done := make(chan struct{})
+	go func() { d.Run(ctx); close(done) }()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("Run did not return after cancel")
+	}
+
+	if got := len(api.requests()); got != 1 {
+		t.Errorf("%d requests, want the queued message sent once", got)
+	}
+	if pending, _ := store.Pending(); len(pending) != 0 {
+		t.Errorf("outbox not drained: %+v", pending)
+	}
+}
+
+type alertReports struct{ got []string }
+
+func (r *alertReports) ReportAlert(_ context.Context, key string, sendErr error, _ time.Time) error {
+	r.got = append(r.got, fmt.Sprintf("%s:%v", key, sendErr != nil))
+	return nil
+}
+
+func TestDispatcher_reportsAlerts(t *testing.T) {
+	long := strings.Repeat("a", maxMessageLen) + " tail"
+
+	t.Run("delivered", func(t *testing.T) {
+		api := &fakeBotAPI{}
+		c, d, _, now := newTestDispatcher(t, api)
+		reports := &alertReports{}
+		d.Alerts = reports
+		queued, err := c.SendAlert(context.Background(), "low_stock:m1", richtext.Text(long))
+		if err != nil || !queued {
+			t.Fatalf("SendAlert = %v, %v", queued, err)
+		}
+
+		deliver(t, d)
+		if len(reports.got) != 0 {
+			t.Fatalf("reported %v before the last part", reports.got)
+		}
+		*now = now.Add(d.ChatInterval)
+		deliver(t, d)
+		if fmt.Sprint(reports.got) != "[low_stock:m1:false]" || len(api.requests()) != 2 {
+			t.Errorf("reports = %v, requests %d", reports.got, len(api.requests()))
+		}
+	})
+
+	t.Run("dead_lettered", func(t *testing.T) {
+		api := &fakeBotAPI{replies: []botReply{{http.StatusBadRequest, `{"ok":false,"description":"Bad Request"}`}}}
+		c, d, store, _ := newTestDispatcher(t, api)
+		reports := &alertReports{}
+		d.Alerts = reports
+		if _, err := c.SendAlert(context.Background(), "low_stock:m1", richtext.Text(long)); err != nil {
+			t.Fatal(err)
+		}
+		if err := c.SendTelegramMessage(context.Background(), richtext.Text("next")); err != nil {
+			t.Fatal(err)
+		}
+
+		deliver(t, d)
+		if fmt.Sprint(reports.got) != "[low_stock:m1:true]" || len(store.Dead()) != 2 {
+			t.Fatalf("reports = %v, dead %+v", reports.got, store.Dead())
+		}
+		if pending, _ := store.Pending(); len(pending) != 1 || pending[0].AlertKey != "" {
+			t.Errorf("pending = %+v, want only the unrelated message", pending)
+		}
+	})
+}
@@ -1,26 +1,29 @@
 package telegram
 
 import (
+	"context"
 	"time"
 
+	"github.com/nomenarkt/vitaltrack/backend/internal/config"
 	"github.com/nomenarkt/vitaltrack/backend/internal/infra/airtable"
+	"github.com/nomenarkt/vitaltrack/backend/internal/logger"
 	"github.com/nomenarkt/vitaltrack/backend/internal/logic/forecast"
 )
 
 // HandleOutOfStockCommand sends an out-of-stock forecast via Telegram.
-func HandleOutOfStockCommand() error {
-	at := airtable.NewClient()
-	tg := NewClient()
+func HandleOutOfStockCommand(ctx context.Context, cfg config.Config, lg logger.Logger) error {
+	at := airtable.NewClient(cfg.Airtable, lg)
+	tg := NewClient(cfg.Telegram, lg)
 
-	meds, err := at.FetchMedicines()
+	meds, err := at.FetchMedicines(ctx)
 	if err != nil {
 		return err
 	}
-	entries, err := at.FetchStockEntries()
+	entries, err := at.FetchStockEntries(ctx)
 	if err != nil {
 		return err
 	}
 
-	msg := forecast.GenerateOutOfStockForecastMessage(meds, entries, time.Now().UTC(), airtable.NewClient())
-	return tg.SendTelegramMessage(msg)
+	msg := forecast.GenerateOutOfStockForecastMessage(tg.Printer(), meds, entries, time.Now().UTC())
+	return tg.SendTelegramMessage(ctx, msg)
 }
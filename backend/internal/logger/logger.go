@@ -1,47 +1,157 @@
-// Package logger configures structured logging helpers.
+// Package logger provides the leveled, structured logger injected into the
+// rest of the server. It is backed by log/slog.
 package logger
 
 import (
 	"context"
-	"fmt"
-	"log"
+	"crypto/rand"
+	"encoding/hex"
+	"io"
+	"log/slog"
+	"os"
 	"strings"
+
+	"go.opentelemetry.io/otel/trace"
 )
 
-// Logger defines structured logging methods.
+// Logger defines structured logging methods. kv holds alternating keys and
+// values, as in log/slog.
 type Logger interface {
+	Debug(ctx context.Context, msg string, kv ...any)
 	Info(ctx context.Context, msg string, kv ...any)
+	Warn(ctx context.Context, msg string, kv ...any)
 	Error(ctx context.Context, msg string, kv ...any)
 }
 
-// StdLogger logs to the standard library logger.
-type StdLogger struct{}
+// Options configure a Logger.
+type Options struct {
+	Level slog.Level // minimum level written, Info by default
+	JSON  bool       // one JSON object per line instead of key=value text
+}
+
+// SlogLogger writes through a slog.Logger. Records carry the correlation ID
+// and trace ID of their context, and values of sensitive keys are redacted.
+type SlogLogger struct {
+	l *slog.Logger
+}
+
+// New returns a SlogLogger writing to w.
+func New(w io.Writer, opts Options) *SlogLogger {
+	ho := &slog.HandlerOptions{Level: opts.Level, ReplaceAttr: redact}
+	var h slog.Handler = slog.NewTextHandler(w, ho)
+	if opts.JSON {
+		h = slog.NewJSONHandler(w, ho)
+	}
+	return &SlogLogger{l: slog.New(correlationHandler{h})}
+}
+
+// NewStdLogger returns a text SlogLogger writing Info and above to stderr.
+func NewStdLogger() *SlogLogger { return New(os.Stderr, Options{}) }
+
+// ParseLevel reads a level name: debug, info, warn or error.
+func ParseLevel(s string) (slog.Level, error) {
+	var l slog.Level
+	err := l.UnmarshalText([]byte(s))
+	return l, err
+}
 
-// NewStdLogger creates a StdLogger.
-func NewStdLogger() *StdLogger { return &StdLogger{} }
+// Slog returns the underlying slog.Logger.
+func (l *SlogLogger) Slog() *slog.Logger { return l.l }
+
+// Debug logs a message useful when tracing a problem.
+func (l *SlogLogger) Debug(ctx context.Context, msg string, kv ...any) {
+	l.l.Log(ctx, slog.LevelDebug, msg, kv...)
+}
 
 // Info logs an informational message with optional key-value pairs.
-func (l *StdLogger) Info(_ context.Context, msg string, kv ...any) {
-	log.Printf("%s", format(msg, kv...))
+func (l *SlogLogger) Info(ctx context.Context, msg string, kv ...any) {
+	l.l.Log(ctx, slog.LevelInfo, msg, kv...)
+}
+
+// Warn logs a problem the server recovers from.
+func (l *SlogLogger) Warn(ctx context.Context, msg string, kv ...any) {
+	l.l.Log(ctx, slog.LevelWarn, msg, kv...)
 }
 
 // Error logs an error message with optional key-value pairs.
-func (l *StdLogger) Error(_ context.Context, msg string, kv ...any) {
-	log.Printf("%s", format(msg, kv...))
+func (l *SlogLogger) Error(ctx context.Context, msg string, kv ...any) {
+	l.l.Log(ctx, slog.LevelError, msg, kv...)
 }
 
-func format(msg string, kv ...any) string {
-	if len(kv) == 0 {
-		return msg
+type nop struct{}
+
+func (nop) Debug(context.Context, string, ...any) {}
+func (nop) Info(context.Context, string, ...any)  {}
+func (nop) Warn(context.Context, string, ...any)  {}
+func (nop) Error(context.Context, string, ...any) {}
+
+// OrNop returns l, or a Logger that discards everything when l is nil.
+func OrNop(l Logger) Logger {
+	if l == nil {
+		return nop{}
 	}
-	pairs := make([]string, 0, len(kv)/2)
-	for i := 0; i < len(kv); i += 2 {
-		key := fmt.Sprint(kv[i])
-		val := ""
-		if i+1 < len(kv) {
-			val = fmt.Sprint(kv[i+1])
-		}
-		pairs = append(pairs, fmt.Sprintf("%s=%s", key, val))
+	return l
+}
+
+type correlationKey struct{}
+
+// WithCorrelationID returns ctx carrying id, which every record logged with
+// the context includes as correlation_id.
+func WithCorrelationID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, correlationKey{}, id)
+}
+
+// CorrelationID returns the ID carried by ctx, or "".
+func CorrelationID(ctx context.Context) string {
+	id, _ := ctx.Value(correlationKey{}).(string)
+	return id
+}
+
+// NewCorrelationID returns a random 16-character hex ID.
+func NewCorrelationID() string {
+	b := make([]byte, 8)
+	_, _ = rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+type correlationHandler struct {
+	slog.Handler
+}
+
+func (h correlationHandler) Handle(ctx context.Context, r slog.Record) error {
+	if id := CorrelationID(ctx); id != "" {
+		r.AddAttrs(slog.String("correlation_id", id))
+	}
+	if sc := trace.SpanContextFromContext(ctx); sc.IsValid() {
+		r.AddAttrs(slog.String("trace_id", sc.TraceID().String()))
+	}
+	return h.Handler.Handle(ctx, r)
+}
+
+func (h correlationHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return correlationHandler{h.Handler.WithAttrs(attrs)}
+}
+
+func (h correlationHandler) WithGroup(name string) slog.Handler {
+	return correlationHandler{h.Handler.WithGroup(name)}
+}
+
+// sensitive lists the keys whose values never reach the log: credentials,
+// and contributors and amounts from the financial table.
+var sensitive = map[string]bool{
+	"token":              true,
+	"authorization":      true,
+	"password":           true,
+	"secret":             true,
+	"contributor":        true,
+	"amount":             true,
+	"need_amount":        true,
+	"amount_contributed": true,
+}
+
+func redact(_ []string, a slog.Attr) slog.Attr {
+	if sensitive[strings.ToLower(a.Key)] {
+		return slog.String(a.Key, "[redacted]")
 	}
-	return msg + " " + strings.Join(pairs, " ")
+	return a
 }
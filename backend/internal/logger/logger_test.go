@@ -0,0 +1,95 @@
+package logger_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"strings"
+	"testing"
+
+	"github.com/nomenarkt/vitaltrack/backend/internal/logger"
+)
+
+func TestSlogLogger_JSON(t *testing.T) {
+	var buf bytes.Buffer
+	lg := logger.New(&buf, logger.Options{JSON: true})
+	ctx := logger.WithCorrelationID(context.Background(), "req-42")
+
+	lg.Info(ctx, "contribution recorded", "medicine_id", "rec1", "Contributor", "Alice", "amount", 25.5, "token", "secret-token")
+
+	var rec map[string]any
+	if err := json.Unmarshal(buf.Bytes(), &rec); err != nil {
+		t.Fatalf("not JSON: %v\n%s", err, buf.String())
+	}
+	want := map[string]any{
+		"level":          "INFO",
+		"msg":            "contribution recorded",
+		"correlation_id": "req-42",
+		"medicine_id":    "rec1",
+		"Contributor":    "[redacted]",
+		"amount":         "[redacted]",
+		"token":          "[redacted]",
+	}
+	for k, v := range want {
+		if rec[k] != v {
+			t.Errorf("%s = %v, want %v", k, rec[k], v)
+		}
+	}
+	for _, leaked := range []string{"Alice", "25.5", "secret-token"} {
+		if strings.Contains(buf.String(), leaked) {
+			t.Errorf("%s leaked: %s", leaked, buf.String())
+		}
+	}
+}
+
+func TestSlogLogger_level(t *testing.T) {
+	var buf bytes.Buffer
+	lg := logger.New(&buf, logger.Options{Level: slog.LevelWarn})
+	ctx := context.Background()
+
+	lg.Debug(ctx, "debug")
+	lg.Info(ctx, "info")
+	lg.Warn(ctx, "warn")
+	lg.Error(ctx, "error")
+
+	out := buf.String()
+	for msg, want := range map[string]bool{"debug": false, "info": false, "warn": true, "error": true} {
+		if got := strings.Contains(out, "msg="+msg); got != want {
+			t.Errorf("%s logged = %v, want %v:\n%s", msg, got, want, out)
+		}
+	}
+	if strings.Contains(out, "correlation_id") {
+		t.Errorf("correlation_id without an ID in the context:\n%s", out)
+	}
+}
+
+func TestParseLevel(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    slog.Level
+		wantErr bool
+	}{
+		{"debug", slog.LevelDebug, false},
+		{"INFO", slog.LevelInfo, false},
+		{"warn", slog.LevelWarn, false},
+		{"error", slog.LevelError, false},
+		{"chatty", 0, true},
+	}
+	for _, tt := range tests {
+		got, err := logger.ParseLevel(tt.in)
+		if (err != nil) != tt.wantErr || (!tt.wantErr && got != tt.want) {
+			t.Errorf("ParseLevel(%q) = %v, %v", tt.in, got, err)
+		}
+	}
+}
+
+func TestOrNop(t *testing.T) {
+	lg := logger.OrNop(nil)
+	lg.Error(context.Background(), "discarded") // must not panic
+
+	std := logger.NewStdLogger()
+	if logger.OrNop(std) != logger.Logger(std) {
+		t.Error("OrNop replaced a non-nil logger")
+	}
+}
@@ -0,0 +1,165 @@
+// Package adherence schedules dose reminders and summarizes how many
+// reminded doses were taken.
+package adherence
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"time"
+
+	"github.com/nomenarkt/vitaltrack/backend/internal/domain"
+	"github.com/nomenarkt/vitaltrack/backend/internal/logic/calendar"
+	"github.com/nomenarkt/vitaltrack/backend/internal/util/i18n"
+	"github.com/nomenarkt/vitaltrack/backend/internal/util/richtext"
+)
+
+// Dose is a reminder that comes due.
+type Dose struct {
+	Medicine  domain.Medicine
+	Scheduled time.Time
+	Pills     float64
+}
+
+// Key identifies the dose in the adherence log.
+func (d Dose) Key() string {
+	return fmt.Sprintf("dose:%s:%s", d.Medicine.ID, d.Scheduled.Format("2006-01-02T15:04"))
+}
+
+// Due returns the doses scheduled at the minute of at, which should be in
+// the household timezone. The daily dose is split evenly across the
+// medicine's dose times.
+func Due(meds []domain.Medicine, at time.Time) []Dose {
+	at = at.Truncate(time.Minute)
+	clock := at.Format("15:04")
+	var due []Dose
+	for _, m := range meds {
+		if m.Archived || m.DailyDose <= 0 || len(m.DoseTimes) == 0 {
+			continue
+		}
+		for _, t := range m.DoseTimes {
+			if t == clock {
+				due = append(due, Dose{Medicine: m, Scheduled: at, Pills: m.DailyDose / float64(len(m.DoseTimes))})
+			}
+		}
+	}
+	return due
+}
+
+// WeekStart returns midnight on the Monday of t's week in loc.
+func WeekStart(t time.Time, loc *time.Location) time.Time {
+	day := calendar.Day(t, loc)
+	offset := (int(day.Weekday()) + 6) % 7 // days since Monday
+	return day.AddDate(0, 0, -offset)
+}
+
+// Weekly groups events by patient and week, newest week first. Unanswered
+// reminders count against adherence.
+func Weekly(events []domain.DoseEvent, loc *time.Location) []domain.AdherenceWeek {
+	type key struct {
+		patient string
+		week    time.Time
+	}
+	byKey := map[key]*domain.AdherenceWeek{}
+	for _, e := range events {
+		k := key{e.Patient, WeekStart(e.Scheduled, loc)}
+		w, ok := byKey[k]
+		if !ok {
+			w = &domain.AdherenceWeek{Patient: k.patient, WeekStart: k.week}
+			byKey[k] = w
+		}
+		w.Scheduled++
+		switch e.Status {
+		case domain.DoseTaken:
+			w.Taken++
+		case domain.DoseSkipped:
+			w.Skipped++
+		default:
+			w.Unanswered++
+		}
+	}
+
+	weeks := make([]domain.AdherenceWeek, 0, len(byKey))
+	for _, w := range byKey {
+		w.Percent = math.Round(float64(w.Taken)/float64(w.Scheduled)*1000) / 10
+		weeks = append(weeks, *w)
+	}
+	sort.Slice(weeks, func(i, j int) bool {
+		if !weeks[i].WeekStart.Equal(weeks[j].WeekStart) {
+			return weeks[i].WeekStart.After(weeks[j].WeekStart)
+		}
+		return weeks[i].Patient < weeks[j].Patient
+	})
+	return weeks
+}
+
+// SkippedPills totals the pills of skipped doses per medicine ID.
+func SkippedPills(events []domain.DoseEvent) map[string]float64 {
+	out := map[string]float64{}
+	for _, e := range events {
+		if e.Status == domain.DoseSkipped {
+			out[e.MedicineID] += e.Pills
+		}
+	}
+	return out
+}
+
+// ReminderMessage formats the reminder for e in p's language, with the
+// answer once there is one.
+func ReminderMessage(p i18n.Printer, e domain.DoseEvent, loc *time.Location) richtext.Doc {
+	at := calendar.In(e.Scheduled, loc).Format("15:04")
+	key := "adherence.reminder"
+	if e.Patient != "" {
+		key = "adherence.reminder_patient"
+	}
+	msg := richtext.New(richtext.P(p.Rich(key, e.MedicineName, p.Number(e.Pills, 2), at, e.Patient)...))
+
+	answered := calendar.In(e.RespondedAt, loc).Format("15:04")
+	switch e.Status {
+	case domain.DoseTaken:
+		msg = append(msg, richtext.P(richtext.T(p.T("adherence.taken", answered))))
+	case domain.DoseSkipped:
+		msg = append(msg, richtext.P(richtext.T(p.T("adherence.skipped", answered))))
+	}
+	return msg
+}
+
+// ReminderButtons are the answers offered under a reminder for eventID.
+func ReminderButtons(p i18n.Printer, eventID string) []domain.ReplyButton {
+	return []domain.ReplyButton{
+		{Label: p.T("adherence.button_taken"), Data: CallbackData(eventID, domain.DoseTaken)},
+		{Label: p.T("adherence.button_skipped"), Data: CallbackData(eventID, domain.DoseSkipped)},
+	}
+}
+
+// CallbackData encodes an answer for the bot's button callback.
+func CallbackData(eventID string, status domain.DoseStatus) string {
+	return "dose:" + eventID + ":" + string(status)
+}
+
+// ReportMessage formats weekly adherence as one table in p's language.
+func ReportMessage(p i18n.Printer, weeks []domain.AdherenceWeek) richtext.Doc {
+	if len(weeks) == 0 {
+		return richtext.Text(p.T("adherence.none"))
+	}
+	table := richtext.Table{
+		Header: []string{p.T("adherence.patient"), p.T("adherence.week"), p.T("adherence.doses"), p.T("adherence.rate")},
+		Align:  []richtext.Align{richtext.Left, richtext.Left, richtext.Right, richtext.Right},
+	}
+	for _, w := range weeks {
+		patient := w.Patient
+		if patient == "" {
+			patient = "—"
+		}
+		table.Rows = append(table.Rows, []string{
+			patient,
+			p.Date(w.WeekStart),
+			fmt.Sprintf("%d/%d", w.Taken, w.Scheduled),
+			p.Number(w.Percent, 1) + "%",
+		})
+	}
+	return richtext.New(
+		richtext.P(richtext.B(p.T("adherence.title"))),
+		richtext.CodeBlock{Lang: "text", Lines: table.Lines()},
+	)
+}
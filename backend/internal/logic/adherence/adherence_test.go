@@ -0,0 +1,128 @@
+package adherence_test
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/nomenarkt/vitaltrack/backend/internal/domain"
+	"github.com/nomenarkt/vitaltrack/backend/internal/logic/adherence"
+	"github.com/nomenarkt/vitaltrack/backend/internal/util/i18n"
+	"github.com/nomenarkt/vitaltrack/backend/internal/util/richtext"
+)
+
+func TestDue(t *testing.T) {
+	meds := []domain.Medicine{
+		{ID: "m1", Name: "Aspirin", DailyDose: 3, DoseTimes: domain.DoseTimes{"08:00", "14:00", "20:00"}},
+		{ID: "m2", Name: "Vitamin D", DailyDose: 1, DoseTimes: domain.DoseTimes{"08:00"}},
+		{ID: "m3", Name: "Archived", DailyDose: 1, DoseTimes: domain.DoseTimes{"08:00"}, Archived: true},
+		{ID: "m4", Name: "No times", DailyDose: 1},
+	}
+
+	tests := []struct {
+		name string
+		at   time.Time
+		want []string
+	}{
+		{"two_medicines_due", time.Date(2025, 6, 5, 8, 0, 30, 0, time.UTC), []string{"dose:m1:2025-06-05T08:00", "dose:m2:2025-06-05T08:00"}},
+		{"one_medicine_due", time.Date(2025, 6, 5, 20, 0, 0, 0, time.UTC), []string{"dose:m1:2025-06-05T20:00"}},
+		{"nothing_due", time.Date(2025, 6, 5, 8, 1, 0, 0, time.UTC), nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var keys []string
+			for _, d := range adherence.Due(meds, tt.at) {
+				keys = append(keys, d.Key())
+				if d.Medicine.ID == "m1" && d.Pills != 1 {
+					t.Errorf("m1 pills = %v, want the daily dose split in three", d.Pills)
+				}
+			}
+			if !reflect.DeepEqual(keys, tt.want) {
+				t.Errorf("Due() = %v, want %v", keys, tt.want)
+			}
+		})
+	}
+}
+
+func TestWeekStart(t *testing.T) {
+	tana, err := time.LoadLocation("Indian/Antananarivo")
+	if err != nil {
+		t.Skip(err)
+	}
+	tests := []struct {
+		name string
+		t    time.Time
+		loc  *time.Location
+		want string
+	}{
+		{"wednesday", time.Date(2025, 6, 4, 12, 0, 0, 0, time.UTC), nil, "2025-06-02"},
+		{"monday", time.Date(2025, 6, 2, 0, 0, 0, 0, time.UTC), nil, "2025-06-02"},
+		{"sunday", time.Date(2025, 6, 8, 23, 0, 0, 0, time.UTC), nil, "2025-06-02"},
+		{"sunday_night_utc_is_monday_in_tana", time.Date(2025, 6, 8, 22, 0, 0, 0, time.UTC), tana, "2025-06-09"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := adherence.WeekStart(tt.t, tt.loc).Format("2006-01-02"); got != tt.want {
+				t.Errorf("WeekStart() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestWeekly(t *testing.T) {
+	at := func(day int) time.Time { return time.Date(2025, 6, day, 8, 0, 0, 0, time.UTC) }
+	events := []domain.DoseEvent{
+		{Patient: "Mum", Scheduled: at(2), Status: domain.DoseTaken},
+		{Patient: "Mum", Scheduled: at(3), Status: domain.DoseSkipped},
+		{Patient: "Mum", Scheduled: at(4), Status: domain.DosePending},
+		{Patient: "Dad", Scheduled: at(5), Status: domain.DoseTaken},
+		{Patient: "Mum", Scheduled: at(9), Status: domain.DoseTaken},
+	}
+
+	got := adherence.Weekly(events, nil)
+	want := []domain.AdherenceWeek{
+		{Patient: "Mum", WeekStart: time.Date(2025, 6, 9, 0, 0, 0, 0, time.UTC), Scheduled: 1, Taken: 1, Percent: 100},
+		{Patient: "Dad", WeekStart: time.Date(2025, 6, 2, 0, 0, 0, 0, time.UTC), Scheduled: 1, Taken: 1, Percent: 100},
+		{Patient: "Mum", WeekStart: time.Date(2025, 6, 2, 0, 0, 0, 0, time.UTC), Scheduled: 3, Taken: 1, Skipped: 1, Unanswered: 1, Percent: 33.3},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Weekly() =\n%+v\nwant\n%+v", got, want)
+	}
+}
+
+func TestSkippedPills(t *testing.T) {
+	events := []domain.DoseEvent{
+		{MedicineID: "m1", Pills: 1.5, Status: domain.DoseSkipped},
+		{MedicineID: "m1", Pills: 1.5, Status: domain.DoseSkipped},
+		{MedicineID: "m1", Pills: 1.5, Status: domain.DoseTaken},
+		{MedicineID: "m2", Pills: 1, Status: domain.DosePending},
+	}
+	got := adherence.SkippedPills(events)
+	if want := map[string]float64{"m1": 3}; !reflect.DeepEqual(got, want) {
+		t.Errorf("SkippedPills() = %v, want %v", got, want)
+	}
+}
+
+func TestReminderMessage(t *testing.T) {
+	p := i18n.For(i18n.English)
+	ev := domain.DoseEvent{MedicineName: "Aspirin", Patient: "Mum", Pills: 1, Scheduled: time.Date(2025, 6, 5, 8, 0, 0, 0, time.UTC)}
+
+	got := richtext.Plain(adherence.ReminderMessage(p, ev, nil))
+	for _, want := range []string{"Aspirin", "Mum", "08:00"} {
+		if !strings.Contains(got, want) {
+			t.Errorf("reminder missing %q:\n%s", want, got)
+		}
+	}
+
+	ev.Status = domain.DoseTaken
+	ev.RespondedAt = time.Date(2025, 6, 5, 8, 12, 0, 0, time.UTC)
+	if answered := richtext.Plain(adherence.ReminderMessage(p, ev, nil)); !strings.Contains(answered, "08:12") {
+		t.Errorf("answered reminder missing the answer time:\n%s", answered)
+	}
+
+	buttons := adherence.ReminderButtons(p, "dose7")
+	if len(buttons) != 2 || buttons[0].Data != "dose:dose7:taken" || buttons[1].Data != "dose:dose7:skipped" {
+		t.Errorf("ReminderButtons() = %+v", buttons)
+	}
+}
@@ -0,0 +1,59 @@
+// Package calendar does day arithmetic in the household's timezone, so that
+// "today" means the same calendar day to stock, alerts and the bot.
+package calendar
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// LoadLocation returns the IANA timezone named by name, such as
+// "Indian/Antananarivo". An empty name means UTC.
+func LoadLocation(name string) (*time.Location, error) {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return time.UTC, nil
+	}
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		return nil, fmt.Errorf("unknown timezone %q: %w", name, err)
+	}
+	return loc, nil
+}
+
+// In returns t in loc, or in UTC when loc is nil.
+func In(t time.Time, loc *time.Location) time.Time {
+	if loc == nil {
+		loc = time.UTC
+	}
+	return t.In(loc)
+}
+
+// Day returns midnight at the start of t's calendar day in loc.
+func Day(t time.Time, loc *time.Location) time.Time {
+	t = In(t, loc)
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location())
+}
+
+// Date returns the calendar day in loc that a stored date refers to.
+// Date-only values are parsed as UTC midnight and name that day wherever the
+// household is; timestamps are converted to loc first.
+func Date(t time.Time, loc *time.Location) time.Time {
+	if t.Location() == time.UTC && t.Equal(t.Truncate(24*time.Hour)) {
+		return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, In(t, loc).Location())
+	}
+	return Day(t, loc)
+}
+
+// Days returns the number of calendar days from the date of from to the date
+// of to, negative when to is earlier. Dates are read in each time's own
+// location, so both should come from Day or Date. Days of 23 or 25 hours
+// around DST changes count as one.
+func Days(from, to time.Time) int {
+	fy, fm, fd := from.Date()
+	ty, tm, td := to.Date()
+	a := time.Date(fy, fm, fd, 0, 0, 0, 0, time.UTC)
+	b := time.Date(ty, tm, td, 0, 0, 0, 0, time.UTC)
+	return int(b.Sub(a).Hours() / 24)
+}
@@ -0,0 +1,101 @@
+package calendar_test
+
+import (
+	"testing"
+	"time"
+	_ "time/tzdata"
+
+	"github.com/nomenarkt/vitaltrack/backend/internal/logic/calendar"
+)
+
+func mustLoad(t *testing.T, name string) *time.Location {
+	t.Helper()
+	loc, err := calendar.LoadLocation(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return loc
+}
+
+func TestLoadLocation(t *testing.T) {
+	if loc := mustLoad(t, ""); loc != time.UTC {
+		t.Errorf("empty name = %v, want UTC", loc)
+	}
+	if _, err := calendar.LoadLocation("Mars/Olympus_Mons"); err == nil {
+		t.Error("unknown timezone accepted")
+	}
+}
+
+func TestDay(t *testing.T) {
+	tana := mustLoad(t, "Indian/Antananarivo")
+	paris := mustLoad(t, "Europe/Paris")
+
+	tests := []struct {
+		name string
+		t    time.Time
+		loc  *time.Location
+		want string
+	}{
+		{"utc_nil_location", time.Date(2025, 6, 5, 23, 59, 0, 0, time.UTC), nil, "2025-06-05"},
+		{"evening_utc_is_next_day_in_tana", time.Date(2025, 6, 5, 21, 30, 0, 0, time.UTC), tana, "2025-06-06"},
+		{"before_midnight_in_tana", time.Date(2025, 6, 5, 20, 59, 0, 0, time.UTC), tana, "2025-06-05"},
+		{"dst_start_paris", time.Date(2025, 3, 30, 0, 30, 0, 0, time.UTC), paris, "2025-03-30"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := calendar.Day(tt.t, tt.loc)
+			if got.Format("2006-01-02") != tt.want || got.Hour() != 0 || got.Minute() != 0 {
+				t.Errorf("Day = %v, want midnight of %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDate(t *testing.T) {
+	tana := mustLoad(t, "Indian/Antananarivo")
+	newYork := mustLoad(t, "America/New_York")
+
+	tests := []struct {
+		name string
+		t    time.Time
+		loc  *time.Location
+		want string
+	}{
+		{"date_only_east", time.Date(2025, 6, 5, 0, 0, 0, 0, time.UTC), tana, "2025-06-05"},
+		{"date_only_west", time.Date(2025, 6, 5, 0, 0, 0, 0, time.UTC), newYork, "2025-06-05"},
+		{"timestamp_converted", time.Date(2025, 6, 5, 22, 0, 0, 0, time.UTC), tana, "2025-06-06"},
+		{"offset_timestamp", time.Date(2025, 6, 5, 23, 0, 0, 0, time.FixedZone("EAT", 3*3600)), time.UTC, "2025-06-05"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := calendar.Date(tt.t, tt.loc)
+			if got.Format("2006-01-02") != tt.want || got.Location() != tt.loc {
+				t.Errorf("Date = %v, want %s in %v", got, tt.want, tt.loc)
+			}
+		})
+	}
+}
+
+func TestDays(t *testing.T) {
+	paris := mustLoad(t, "Europe/Paris")
+	day := func(y int, m time.Month, d int) time.Time { return time.Date(y, m, d, 0, 0, 0, 0, paris) }
+
+	tests := []struct {
+		name     string
+		from, to time.Time
+		want     int
+	}{
+		{"same_day", day(2025, 6, 5), day(2025, 6, 5).Add(23 * time.Hour), 0},
+		{"backwards", day(2025, 6, 5), day(2025, 6, 1), -4},
+		{"across_dst_start", day(2025, 3, 29), day(2025, 4, 1), 3},
+		{"across_dst_end", day(2025, 10, 25), day(2025, 10, 28), 3},
+		{"short_day_only", day(2025, 3, 30), day(2025, 3, 31), 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := calendar.Days(tt.from, tt.to); got != tt.want {
+				t.Errorf("Days = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,102 @@
+// Package finance renders monthly contribution reports.
+package finance
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/nomenarkt/vitaltrack/backend/internal/domain"
+	"github.com/nomenarkt/vitaltrack/backend/internal/util/i18n"
+	"github.com/nomenarkt/vitaltrack/backend/internal/util/richtext"
+)
+
+// GenerateFinancialReportMessage formats report in p's language: one block
+// per need with its contributors, then the month's totals.
+func GenerateFinancialReportMessage(p i18n.Printer, report domain.MonthlyFinancialReport) richtext.Doc {
+	msg := richtext.New(richtext.P(richtext.B(p.T("finance.title", p.Month(report.Year, time.Month(report.Month))))))
+	totalNeed := 0.0
+	for _, n := range report.Needs {
+		msg = append(msg, renderNeedBlock(p, n))
+		totalNeed += n.NeedAmount
+	}
+
+	byContributor := richtext.List{}
+	for _, ctb := range report.Contributors {
+		byContributor = append(byContributor, richtext.Item(richtext.T(ctb.Name, " \u2192 ", p.MGA(ctb.Amount))))
+	}
+	msg = append(msg,
+		richtext.P(richtext.T(
+			p.T("finance.summary"), "\n",
+			p.T("finance.total_needs", p.MGA(totalNeed)), "\n",
+			p.T("finance.total_contributed", p.MGA(report.Total)),
+		)),
+		richtext.P(richtext.T(p.T("finance.by_contributor"))),
+		byContributor,
+	)
+	return msg
+}
+
+// renderNeedBlock formats a single need report block in monospaced layout.
+func renderNeedBlock(p i18n.Printer, n domain.NeedReportBlock) richtext.CodeBlock {
+	d, label := splitNeed(n.Need)
+
+	var lines []string
+	lines = append(lines, fmt.Sprintf("📅 %s – %s", p.Date(d), label))
+	lines = append(lines, fmt.Sprintf("%-15s%s", p.T("finance.need"), p.MGA(n.NeedAmount)))
+	lines = append(lines, fmt.Sprintf("%-15s%s", p.T("finance.contributed"), p.MGA(n.Total)))
+	lines = append(lines, "")
+
+	table := richtext.Table{
+		Header: []string{p.T("finance.contributor"), p.T("finance.amount")},
+		Align:  []richtext.Align{richtext.Left, richtext.Right},
+	}
+	// ❌ DO NOT re-sort here. Keep usecase-defined order.
+	for _, ctb := range n.Contributors {
+		table.Rows = append(table.Rows, []string{ctb.Name, p.MGA(ctb.Amount)})
+	}
+	lines = append(lines, table.Lines()...)
+
+	return richtext.CodeBlock{Lang: "text", Lines: lines}
+}
+
+// GenerateDigestMessage formats report for the monthly digest: the full
+// report followed by how much each need is still short.
+func GenerateDigestMessage(p i18n.Printer, report domain.MonthlyFinancialReport) richtext.Doc {
+	msg := richtext.New(richtext.P(richtext.T(p.T("digest.finance_intro"))))
+	msg = append(msg, GenerateFinancialReportMessage(p, report)...)
+
+	table := richtext.Table{
+		Header: []string{p.T("digest.need"), p.T("digest.shortfall")},
+		Align:  []richtext.Align{richtext.Left, richtext.Right},
+	}
+	short := 0.0
+	for _, n := range report.Needs {
+		gap := n.NeedAmount - n.Total
+		if gap <= 0 {
+			continue
+		}
+		d, label := splitNeed(n.Need)
+		table.Rows = append(table.Rows, []string{p.Date(d) + " " + label, p.MGA(gap)})
+		short += gap
+	}
+	if len(table.Rows) == 0 {
+		return append(msg, richtext.P(richtext.T(p.T("digest.all_covered"))))
+	}
+	return append(msg,
+		richtext.P(richtext.B(p.T("digest.shortfall_title"))),
+		richtext.CodeBlock{Lang: "text", Lines: table.Lines()},
+		richtext.P(richtext.T(p.T("digest.total_shortfall", p.MGA(short)))),
+	)
+}
+
+// splitNeed splits a report need key ("2006-01-02 label") into its date and
+// label. The date is zero when the key does not start with one.
+func splitNeed(need string) (time.Time, string) {
+	dateStr, label, _ := strings.Cut(need, " ")
+	d, err := time.Parse("2006-01-02", dateStr)
+	if err != nil {
+		d = time.Time{}
+	}
+	return d, label
+}
@@ -3,88 +3,77 @@ package forecast
 
 import (
 	"fmt"
-	"log"
 	"sort"
 	"time"
 
 	"github.com/nomenarkt/vitaltrack/backend/internal/domain"
-	"github.com/nomenarkt/vitaltrack/backend/internal/domain/ports"
+	"github.com/nomenarkt/vitaltrack/backend/internal/logic/calendar"
 	"github.com/nomenarkt/vitaltrack/backend/internal/logic/stockcalc"
+	"github.com/nomenarkt/vitaltrack/backend/internal/util/i18n"
+	"github.com/nomenarkt/vitaltrack/backend/internal/util/richtext"
 )
 
-// GenerateOutOfStockForecastMessage builds a Markdown-formatted forecast of when
-// each medicine will run out of stock. It optionally updates the forecast date
-// in the provided repository.
-func GenerateOutOfStockForecastMessage(
-	meds []domain.Medicine,
-	entries []domain.StockEntry,
-	now time.Time,
-	repo ports.StockDataPort,
-) string {
-	type medicineForecast struct {
-		Name         string
-		ForecastDate time.Time
-		ShouldUpdate bool
-		ID           string
-	}
-
-	var forecasts []medicineForecast
+// Forecast is the out-of-stock date of one medicine.
+type Forecast struct {
+	MedicineID string
+	Name       string
+	Date       time.Time
+	// Changed is set when Date differs from the forecast saved on the
+	// medicine, or none is saved.
+	Changed bool
+}
 
+// Compute returns the forecast of every active medicine that is in stock
+// and taken daily, soonest first. Dates are calendar days in now's
+// location.
+func Compute(meds []domain.Medicine, entries []domain.StockEntry, now time.Time) []Forecast {
+	var forecasts []Forecast
 	for _, m := range meds {
+		if m.Archived {
+			continue
+		}
 		stock := stockcalc.CurrentStockAt(m, entries, now)
 		if stock <= 0 || m.DailyDose == 0 {
 			continue
 		}
 
-		forecastDate := stockcalc.OutOfStockDateAt(m, stock, now)
-
-		shouldUpdate := true
+		date := stockcalc.OutOfStockDateAt(m, stock, now)
+		changed := true
 		if m.ForecastOutOfStockDate != nil {
-			saved := m.ForecastOutOfStockDate.UTC().Format("2006-01-02")
-			computed := forecastDate.Format("2006-01-02")
-			if saved == computed {
-				shouldUpdate = false
-			}
+			saved := calendar.Date(m.ForecastOutOfStockDate.Time, now.Location())
+			changed = saved.Format("2006-01-02") != date.Format("2006-01-02")
 		}
-
-		forecasts = append(forecasts, medicineForecast{
-			Name:         m.Name,
-			ForecastDate: forecastDate,
-			ShouldUpdate: shouldUpdate,
-			ID:           m.ID,
-		})
+		forecasts = append(forecasts, Forecast{MedicineID: m.ID, Name: m.Name, Date: date, Changed: changed})
 	}
 
-	// Sort by forecast date ascending
-	sort.Slice(forecasts, func(i, j int) bool {
-		return forecasts[i].ForecastDate.Before(forecasts[j].ForecastDate)
+	sort.SliceStable(forecasts, func(i, j int) bool {
+		return forecasts[i].Date.Before(forecasts[j].Date)
 	})
+	return forecasts
+}
 
-	var rows []string
-
-	for _, f := range forecasts {
-		if f.ShouldUpdate && repo != nil {
-			err := repo.UpdateForecastDate(f.ID, f.ForecastDate, now)
-			if err != nil {
-				log.Printf("❌ Failed to update forecast for %s: %v", f.Name, err)
-			} else {
-				log.Printf("🆗 Updated forecast for %s to %s", f.Name, f.ForecastDate.Format("2006-01-02"))
-			}
+// Updates returns the changed forecasts in fs, to be saved.
+func Updates(fs []Forecast) []domain.ForecastUpdate {
+	var updates []domain.ForecastUpdate
+	for _, f := range fs {
+		if f.Changed {
+			updates = append(updates, domain.ForecastUpdate{MedicineID: f.MedicineID, Date: f.Date})
 		}
-		rows = append(rows, fmt.Sprintf("%-22s → %s", f.Name, f.ForecastDate.Format("2006-01-02")))
 	}
-
-	return "*Out-of-Stock Forecast*\n\n```text\n" + stringJoin(rows, "\n") + "\n```"
+	return updates
 }
 
-// Helper for strings.Join without import clutter
-func stringJoin(lines []string, sep string) string {
-	result := ""
-	for i, l := range lines {
-		if i > 0 {
-			result += sep
-		}
-		result += l
+// GenerateOutOfStockForecastMessage builds a formatted forecast of when
+// each medicine will run out of stock, in p's language. Dates are calendar
+// days in now's location.
+func GenerateOutOfStockForecastMessage(p i18n.Printer, meds []domain.Medicine, entries []domain.StockEntry, now time.Time) richtext.Doc {
+	var rows []string
+	for _, f := range Compute(meds, entries, now) {
+		rows = append(rows, fmt.Sprintf("%-22s → %s", f.Name, p.Date(f.Date)))
 	}
-	return result
+
+	return richtext.New(
+		richtext.P(richtext.B(p.T("stock.title"))),
+		richtext.CodeBlock{Lang: "text", Lines: rows},
+	)
 }
@@ -1,60 +1,55 @@
 package forecast_test
 
 import (
+	"strings"
 	"testing"
 	"time"
 
 	"github.com/nomenarkt/vitaltrack/backend/internal/domain"
 	"github.com/nomenarkt/vitaltrack/backend/internal/logic/forecast"
+	"github.com/nomenarkt/vitaltrack/backend/internal/util/i18n"
+	"github.com/nomenarkt/vitaltrack/backend/internal/util/richtext"
 )
 
-// ✅ Complete mock that satisfies StockDataPort
-type mockStockDataPort struct{}
-
-func (m *mockStockDataPort) FetchMedicines() ([]domain.Medicine, error) {
-	return []domain.Medicine{
-		{
-			ID:           "med1",
-			Name:         "Paracetamol",
-			InitialStock: 10,
-			DailyDose:    1,
-			StartDate:    domain.NewFlexibleDate(time.Date(2025, 6, 1, 0, 0, 0, 0, time.UTC)),
-			UnitPerBox:   10,
-		},
-	}, nil
-}
-
-func (m *mockStockDataPort) FetchStockEntries() ([]domain.StockEntry, error) {
-	return []domain.StockEntry{}, nil
-}
+var start = domain.NewFlexibleDate(time.Date(2025, 6, 1, 0, 0, 0, 0, time.UTC))
 
-func (m *mockStockDataPort) CreateStockEntry(_ domain.StockEntry) error {
-	return nil
-}
+func TestGenerateOutOfStockForecastMessage(t *testing.T) {
+	meds := []domain.Medicine{
+		{ID: "med1", Name: "Paracetamol", InitialStock: 10, DailyDose: 1, StartDate: start, UnitPerBox: 10},
+	}
+	now := time.Date(2025, 6, 4, 0, 0, 0, 0, time.UTC)
 
-// ✅ Corrected method signature here
-func (m *mockStockDataPort) UpdateForecastDate(_ string, _ time.Time, _ time.Time) error {
-	return nil
-}
-func (m *mockStockDataPort) FetchFinancialEntries(int, time.Month) ([]domain.FinancialEntry, error) {
-	return nil, nil
+	msg := forecast.GenerateOutOfStockForecastMessage(i18n.For(i18n.Default), meds, nil, now)
+	if len(msg) == 0 {
+		t.Fatal("Expected non-empty forecast message")
+	}
+	if out := richtext.Plain(msg); !strings.Contains(out, "Paracetamol") {
+		t.Errorf("forecast lacks the medicine:\n%s", out)
+	}
 }
 
-func TestGenerateOutOfStockForecastMessage(t *testing.T) {
-	mock := &mockStockDataPort{}
-	now := time.Date(2025, 6, 4, 0, 0, 0, 0, time.UTC)
+func TestCompute(t *testing.T) {
+	saved := domain.NewFlexibleDate(time.Date(2025, 6, 11, 0, 0, 0, 0, time.UTC))
+	meds := []domain.Medicine{
+		{ID: "later", Name: "Later", InitialStock: 30, DailyDose: 1, StartDate: start},
+		{ID: "saved", Name: "Saved", InitialStock: 10, DailyDose: 1, StartDate: start, ForecastOutOfStockDate: &saved},
+		{ID: "empty", Name: "Empty", InitialStock: 0, DailyDose: 1, StartDate: start},
+		{ID: "asneeded", Name: "As needed", InitialStock: 10, StartDate: start},
+		{ID: "archived", Name: "Archived", InitialStock: 10, DailyDose: 1, StartDate: start, Archived: true},
+	}
+	now := time.Date(2025, 6, 3, 9, 0, 0, 0, time.UTC)
 
-	meds, err := mock.FetchMedicines()
-	if err != nil {
-		t.Fatalf("fetch meds: %v", err)
+	fs := forecast.Compute(meds, nil, now)
+	if len(fs) != 2 || fs[0].MedicineID != "saved" || fs[1].MedicineID != "later" {
+		t.Fatalf("forecasts = %+v", fs)
 	}
-	entries, err := mock.FetchStockEntries()
-	if err != nil {
-		t.Fatalf("fetch entries: %v", err)
+	if fs[0].Changed || !fs[1].Changed {
+		t.Errorf("changed = %v, %v; want false, true", fs[0].Changed, fs[1].Changed)
 	}
 
-	msg := forecast.GenerateOutOfStockForecastMessage(meds, entries, now, mock)
-	if msg == "" {
-		t.Error("Expected non-empty forecast message")
+	updates := forecast.Updates(fs)
+	want := domain.ForecastUpdate{MedicineID: "later", Date: time.Date(2025, 7, 1, 0, 0, 0, 0, time.UTC)}
+	if len(updates) != 1 || updates[0].MedicineID != want.MedicineID || !updates[0].Date.Equal(want.Date) {
+		t.Errorf("updates = %+v, want %+v", updates, want)
 	}
 }
@@ -6,39 +6,52 @@ import (
 	"time"
 
 	"github.com/nomenarkt/vitaltrack/backend/internal/domain"
+	"github.com/nomenarkt/vitaltrack/backend/internal/logic/calendar"
+	"github.com/nomenarkt/vitaltrack/backend/internal/logic/units"
 )
 
-// CurrentStockAt computes current pill stock based on:
+// CurrentStockAt computes current stock, in the medicine's base unit, based on:
 // - Initial stock
-// - All past refill entries
+// - All past entries, converted through the medicine's units
 // - Daily dose depletion from start date to now
+// - Pills of doses confirmed skipped (m.SkippedPills)
+//
+// Refills are added, wastage and transfers out removed, and corrections
+// applied with their sign. Entries of unknown kinds are ignored.
+//
+// Days are calendar days in now's location, which should be the household
+// timezone.
 func CurrentStockAt(m domain.Medicine, entries []domain.StockEntry, now time.Time) float64 {
 	stock := m.InitialStock
 
-	startDate := m.StartDate.Time // ✅ Use parsed time directly
-	startDate = startDate.UTC()
-	now = now.UTC()
+	loc := now.Location()
+	today := calendar.Day(now, loc)
 
 	// Subtract consumed doses
-	daysPassed := int(now.Truncate(24*time.Hour).Sub(startDate.Truncate(24*time.Hour)).Hours() / 24)
+	daysPassed := calendar.Days(calendar.Date(m.StartDate.Time, loc), today)
 	if daysPassed > 0 {
 		stock -= float64(daysPassed) * m.DailyDose
 	}
 
-	// Apply refills that occurred up to now (inclusive)
+	stock += m.SkippedPills
+
+	// Apply entries recorded up to today (inclusive)
+	g := units.For(m)
 	for _, e := range entries {
 		if len(e.MedicineID) == 0 || e.MedicineID[0] != m.ID {
 			continue
 		}
-		if e.Date.IsZero() {
-			continue // skip unparsed or missing date entries
+		if e.Date.IsZero() || e.Archived {
+			continue // skip unparsed, missing date or archived entries
 		}
-		if !e.Date.After(now) {
-			qty := e.Quantity
-			if e.Unit == "box" {
-				qty *= m.UnitPerBox
+		if !calendar.Date(e.Date.Time, loc).After(today) {
+			qty, _ := g.ToBase(e.Quantity, e.Unit) // unknown units count as base units
+			switch e.EffectiveKind() {
+			case domain.EntryRefill, domain.EntryCorrection:
+				stock += qty
+			case domain.EntryWastage, domain.EntryTransferOut:
+				stock -= qty
 			}
-			stock += qty
 		}
 	}
 
@@ -48,11 +61,45 @@ func CurrentStockAt(m domain.Medicine, entries []domain.StockEntry, now time.Tim
 	return math.Round(stock*100) / 100
 }
 
-// OutOfStockDateAt projects when the current stock will run out, assuming no future refills.
+// OutOfStockDateAt projects when the current stock will run out, assuming no
+// future refills. The result is midnight of that day in now's location.
 func OutOfStockDateAt(m domain.Medicine, stock float64, now time.Time) time.Time {
+	today := calendar.Day(now, now.Location())
 	if m.DailyDose == 0 {
-		return now.AddDate(100, 0, 0) // effectively "never"
+		return today.AddDate(100, 0, 0) // effectively "never"
+	}
+	daysLeft := int(math.Floor(stock / m.DailyDose))
+	return today.AddDate(0, 0, daysLeft)
+}
+
+// Reorder is a purchase recommendation for a single medicine.
+type Reorder struct {
+	Needed    bool      // stock runs out within the alert threshold
+	ReorderBy time.Time // last day to buy before the threshold is crossed
+	Pills     float64   // base units (pills by default) needed to cover coverDays from now
+	Boxes     int       // Pills rounded up to whole boxes, 0 when box size is unknown
+}
+
+// RecommendReorder suggests how much to buy so that stock lasts coverDays,
+// and flags it as needed once fewer than thresholdDays of stock remain.
+func RecommendReorder(m domain.Medicine, stock float64, now time.Time, thresholdDays, coverDays int) Reorder {
+	if m.DailyDose <= 0 {
+		return Reorder{}
 	}
+
+	outOfStock := OutOfStockDateAt(m, stock, now)
 	daysLeft := int(math.Floor(stock / m.DailyDose))
-	return now.AddDate(0, 0, daysLeft)
+
+	pills := math.Max(float64(coverDays)*m.DailyDose-stock, 0)
+	boxes := 0
+	if perBox, _ := units.For(m).Factor(units.Box); perBox > 0 {
+		boxes = int(math.Ceil(pills / perBox))
+	}
+
+	return Reorder{
+		Needed:    daysLeft <= thresholdDays,
+		ReorderBy: outOfStock.AddDate(0, 0, -thresholdDays),
+		Pills:     math.Round(pills*100) / 100,
+		Boxes:     boxes,
+	}
 }
@@ -3,6 +3,7 @@ package stockcalc_test
 import (
 	"testing"
 	"time"
+	_ "time/tzdata"
 
 	"github.com/nomenarkt/vitaltrack/backend/internal/domain"
 	"github.com/nomenarkt/vitaltrack/backend/internal/logic/stockcalc"
@@ -75,6 +76,64 @@ func TestCurrentStockAt_WithMultipleEntryDates(t *testing.T) {
 	}
 }
 
+func TestCurrentStockAt_ConfiguredUnits(t *testing.T) {
+	now := time.Date(2025, 6, 4, 0, 0, 0, 0, time.UTC)
+	med := domain.Medicine{
+		ID:           "syrup",
+		UnitType:     "ml",
+		Units:        "box = 2 bottle, bottle = 100 ml",
+		DailyDose:    10,
+		StartDate:    mustDate("2025-06-01"),
+		InitialStock: 50,
+	}
+	entries := []domain.StockEntry{
+		{MedicineID: []string{"syrup"}, Quantity: 1, Unit: "box", Date: mustDate("2025-06-02")},    // +200
+		{MedicineID: []string{"syrup"}, Quantity: 1, Unit: "Bottle", Date: mustDate("2025-06-03")}, // +100
+		{MedicineID: []string{"syrup"}, Quantity: 5, Unit: "spoon", Date: mustDate("2025-06-03")},  // unknown: +5
+	}
+
+	if got, want := stockcalc.CurrentStockAt(med, entries, now), 50.0-30+200+100+5; got != want {
+		t.Errorf("stock = %.2f, want %.2f ml", got, want)
+	}
+}
+
+func TestCurrentStockAt_EntryKinds(t *testing.T) {
+	now := time.Date(2025, 6, 4, 0, 0, 0, 0, time.UTC)
+	med := domain.Medicine{ID: "m1", UnitPerBox: 10, DailyDose: 1, StartDate: mustDate("2025-06-01"), InitialStock: 20}
+	entry := func(kind domain.EntryKind, qty float64, unit string) domain.StockEntry {
+		return domain.StockEntry{MedicineID: []string{"m1"}, Kind: kind, Quantity: qty, Unit: unit, Date: mustDate("2025-06-02")}
+	}
+	entries := []domain.StockEntry{
+		entry("", 1, "box"),                          // +10
+		entry(domain.EntryRefill, 5, "pill"),         // +5
+		entry(domain.EntryWastage, 4, "pill"),        // -4
+		entry(domain.EntryTransferOut, 1, "box"),     // -10
+		entry(domain.EntryCorrection, -2, "pill"),    // -2
+		entry(domain.EntryCorrection, 6, "pill"),     // +6
+		entry(domain.EntryKind("theft"), 50, "pill"), // ignored
+	}
+
+	if got, want := stockcalc.CurrentStockAt(med, entries, now), 20.0-3+10+5-4-10-2+6; got != want {
+		t.Errorf("stock = %.2f, want %.2f", got, want)
+	}
+}
+
+func TestCurrentStockAt_SkippedDoses(t *testing.T) {
+	now := time.Date(2025, 6, 4, 0, 0, 0, 0, time.UTC)
+	med := domain.Medicine{
+		ID:           "med123",
+		StartDate:    mustDate("2025-06-01"),
+		InitialStock: 10,
+		DailyDose:    2,
+		SkippedPills: 1,
+	}
+
+	got := stockcalc.CurrentStockAt(med, nil, now)
+	if want := 10 - 6 + 1.0; got != want { // 3 days at 2 pills, one pill not taken
+		t.Errorf("Expected stock %.2f, got %.2f", want, got)
+	}
+}
+
 func TestOutOfStockDateAt(t *testing.T) {
 	now := time.Date(2025, 6, 4, 0, 0, 0, 0, time.UTC)
 
@@ -147,3 +206,97 @@ func TestCurrentStockAt_EntryDateRFC3339Match(t *testing.T) {
 		t.Errorf("Expected %.2f, got %.2f", want, got)
 	}
 }
+
+func TestCurrentStockAt_HouseholdTimezone(t *testing.T) {
+	tana, err := time.LoadLocation("Indian/Antananarivo")
+	if err != nil {
+		t.Fatal(err)
+	}
+	paris, err := time.LoadLocation("Europe/Paris")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	med := domain.Medicine{ID: "m", StartDate: mustDate("2025-06-01"), InitialStock: 20, DailyDose: 1, UnitPerBox: 10}
+	evening := domain.StockEntry{MedicineID: []string{"m"}, Quantity: 1, Unit: "box", Date: domain.NewFlexibleDate(time.Date(2025, 6, 4, 21, 30, 0, 0, time.UTC))}
+	dateOnly := domain.StockEntry{MedicineID: []string{"m"}, Quantity: 1, Unit: "box", Date: mustDate("2025-06-05")}
+
+	tests := []struct {
+		name      string
+		med       domain.Medicine
+		entries   []domain.StockEntry
+		now       time.Time
+		wantStock float64
+		wantOut   string
+	}{
+		// 00:30 on June 5 in Antananarivo is still June 4 in UTC.
+		{"after_local_midnight", med, nil, time.Date(2025, 6, 5, 0, 30, 0, 0, tana), 16, "2025-06-21"},
+		{"before_local_midnight", med, nil, time.Date(2025, 6, 4, 20, 59, 0, 0, time.UTC).In(tana), 17, "2025-06-21"},
+		{"refill_later_today_counts", med, []domain.StockEntry{evening}, time.Date(2025, 6, 5, 0, 10, 0, 0, tana), 26, "2025-07-01"},
+		{"date_only_refill_counts_before_utc_catches_up", med, []domain.StockEntry{dateOnly}, time.Date(2025, 6, 5, 1, 0, 0, 0, tana), 26, "2025-07-01"},
+		{"across_dst_start", domain.Medicine{ID: "m", StartDate: mustDate("2025-03-29"), InitialStock: 20, DailyDose: 1}, nil, time.Date(2025, 3, 31, 0, 30, 0, 0, paris), 18, "2025-04-18"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			stock := stockcalc.CurrentStockAt(tt.med, tt.entries, tt.now)
+			if stock != tt.wantStock {
+				t.Errorf("stock = %.2f, want %.2f", stock, tt.wantStock)
+			}
+			out := stockcalc.OutOfStockDateAt(tt.med, stock, tt.now)
+			if out.Format("2006-01-02") != tt.wantOut || out.Hour() != 0 || out.Location() != tt.now.Location() {
+				t.Errorf("out of stock = %v, want midnight of %s", out, tt.wantOut)
+			}
+		})
+	}
+}
+
+func TestRecommendReorder(t *testing.T) {
+	now := time.Date(2025, 6, 4, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name  string
+		med   domain.Medicine
+		stock float64
+		want  stockcalc.Reorder
+	}{
+		{
+			name:  "plenty_left",
+			med:   domain.Medicine{DailyDose: 1, UnitPerBox: 10},
+			stock: 40,
+			want:  stockcalc.Reorder{Needed: false, ReorderBy: now.AddDate(0, 0, 30), Pills: 0, Boxes: 0},
+		},
+		{
+			name:  "within_threshold",
+			med:   domain.Medicine{DailyDose: 2, UnitPerBox: 28},
+			stock: 10,
+			want:  stockcalc.Reorder{Needed: true, ReorderBy: now.AddDate(0, 0, -5), Pills: 50, Boxes: 2},
+		},
+		{
+			name:  "unknown_box_size",
+			med:   domain.Medicine{DailyDose: 1},
+			stock: 0,
+			want:  stockcalc.Reorder{Needed: true, ReorderBy: now.AddDate(0, 0, -10), Pills: 30, Boxes: 0},
+		},
+		{
+			name:  "configured_box",
+			med:   domain.Medicine{DailyDose: 2, UnitType: "tablet", Units: "box = 3 strip, strip = 10 tablet"},
+			stock: 0,
+			want:  stockcalc.Reorder{Needed: true, ReorderBy: now.AddDate(0, 0, -10), Pills: 60, Boxes: 2},
+		},
+		{
+			name:  "no_dose",
+			med:   domain.Medicine{DailyDose: 0, UnitPerBox: 10},
+			stock: 0,
+			want:  stockcalc.Reorder{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := stockcalc.RecommendReorder(tt.med, tt.stock, now, 10, 30)
+			if got != tt.want {
+				t.Errorf("got %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
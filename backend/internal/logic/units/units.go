@@ -0,0 +1,182 @@
+// Package units converts the quantities of a medicine between the units it
+// is bought, packed and taken in.
+package units
+
+import (
+	"fmt"
+	"math"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/nomenarkt/vitaltrack/backend/internal/domain"
+	"github.com/nomenarkt/vitaltrack/backend/internal/util/i18n"
+)
+
+// Units known as before units could be configured: a pill is the default
+// base unit and a box holds UnitPerBox base units, unless the medicine's
+// conversions define it. Medicines without a UnitPerBox do not know boxes.
+const (
+	Pill = "pill"
+	Box  = "box"
+)
+
+// Graph holds how many base units each known unit of a medicine is worth.
+type Graph struct {
+	base    string
+	factors map[string]float64
+}
+
+// Conversion says that one From holds Factor To.
+type Conversion struct {
+	From   string
+	Factor float64
+	To     string
+}
+
+var conversion = regexp.MustCompile(`^(.+?)\s*=\s*(\d*\.?\d+)\s*(.+)$`)
+
+// ParseConversions reads conversions written as "box = 3 strip, strip = 10
+// tablet", separated by commas, semicolons or new lines. Unit names are
+// case-insensitive.
+func ParseConversions(s string) ([]Conversion, error) {
+	var out []Conversion
+	for _, part := range strings.FieldsFunc(s, func(r rune) bool { return r == ',' || r == ';' || r == '\n' }) {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		m := conversion.FindStringSubmatch(part)
+		if m == nil {
+			return nil, fmt.Errorf("invalid conversion %q: expected \"<unit> = <number> <unit>\"", part)
+		}
+		factor, err := strconv.ParseFloat(m[2], 64)
+		if err != nil || factor <= 0 {
+			return nil, fmt.Errorf("invalid conversion %q: the number must be positive", part)
+		}
+		c := Conversion{From: Normalize(m[1]), Factor: factor, To: Normalize(m[3])}
+		if c.From == c.To {
+			return nil, fmt.Errorf("invalid conversion %q: a unit cannot convert to itself", part)
+		}
+		out = append(out, c)
+	}
+	return out, nil
+}
+
+// Normalize returns the name units are compared by.
+func Normalize(unit string) string {
+	return strings.ToLower(strings.Join(strings.Fields(unit), " "))
+}
+
+// Base returns the unit the stock and daily dose of m are counted in.
+func Base(m domain.Medicine) string {
+	if base := Normalize(m.UnitType); base != "" {
+		return base
+	}
+	return Pill
+}
+
+// Parse builds the graph of m from its conversions, checking that every unit
+// converts to the base unit and that no two conversion paths disagree.
+func Parse(m domain.Medicine) (Graph, error) {
+	convs, err := ParseConversions(m.Units)
+	if err != nil {
+		return legacy(m), err
+	}
+
+	type edge struct {
+		to    string
+		ratio float64 // base units per to, divided by base units per from
+	}
+	adj := map[string][]edge{}
+	for _, c := range convs {
+		adj[c.From] = append(adj[c.From], edge{c.To, 1 / c.Factor})
+		adj[c.To] = append(adj[c.To], edge{c.From, c.Factor})
+	}
+
+	g := Graph{base: Base(m), factors: map[string]float64{Base(m): 1}}
+	queue := []string{g.base}
+	for len(queue) > 0 {
+		u := queue[0]
+		queue = queue[1:]
+		for _, e := range adj[u] {
+			f := g.factors[u] * e.ratio
+			known, ok := g.factors[e.to]
+			if !ok {
+				g.factors[e.to] = f
+				queue = append(queue, e.to)
+				continue
+			}
+			if math.Abs(known-f) > 1e-9*math.Max(known, f) {
+				return legacy(m), fmt.Errorf("conversions of %s disagree: %g or %g %s", e.to, known, f, g.base)
+			}
+		}
+	}
+	for _, u := range sortedUnits(adj) {
+		if _, ok := g.factors[u]; !ok {
+			return legacy(m), fmt.Errorf("%s does not convert to %s", u, g.base)
+		}
+	}
+	g.addLegacy(m)
+	return g, nil
+}
+
+// For returns the graph of m, or only its base and box units when its
+// conversions are invalid.
+func For(m domain.Medicine) Graph {
+	g, _ := Parse(m)
+	return g
+}
+
+func legacy(m domain.Medicine) Graph {
+	g := Graph{base: Base(m), factors: map[string]float64{Base(m): 1}}
+	g.addLegacy(m)
+	return g
+}
+
+func (g Graph) addLegacy(m domain.Medicine) {
+	if _, ok := g.factors[Box]; !ok && m.UnitPerBox > 0 {
+		g.factors[Box] = m.UnitPerBox
+	}
+}
+
+func sortedUnits[V any](m map[string]V) []string {
+	out := make([]string, 0, len(m))
+	for u := range m {
+		out = append(out, u)
+	}
+	slices.Sort(out)
+	return out
+}
+
+// Base returns the unit g converts to.
+func (g Graph) Base() string { return g.base }
+
+// Units returns the units g knows, sorted.
+func (g Graph) Units() []string { return sortedUnits(g.factors) }
+
+// Factor returns how many base units one unit is worth.
+func (g Graph) Factor(unit string) (float64, bool) {
+	f, ok := g.factors[Normalize(unit)]
+	return f, ok
+}
+
+// ToBase converts qty of unit to base units. Quantities in an unknown unit
+// are returned unchanged, counted as base units, with false.
+func (g Graph) ToBase(qty float64, unit string) (float64, bool) {
+	f, ok := g.Factor(unit)
+	if !ok {
+		return qty, false
+	}
+	return qty * f, true
+}
+
+// Label returns unit as shown in p's messages: pills are translated, other
+// units are shown as configured.
+func Label(p i18n.Printer, unit string) string {
+	if Normalize(unit) == Pill {
+		return p.T("unit.pill")
+	}
+	return unit
+}
@@ -0,0 +1,101 @@
+package units_test
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/nomenarkt/vitaltrack/backend/internal/domain"
+	"github.com/nomenarkt/vitaltrack/backend/internal/logic/units"
+	"github.com/nomenarkt/vitaltrack/backend/internal/util/i18n"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name    string
+		med     domain.Medicine
+		want    map[string]float64 // factors, 0 for units that must be unknown
+		wantErr bool
+	}{
+		{
+			name: "legacy",
+			med:  domain.Medicine{UnitPerBox: 30},
+			want: map[string]float64{"pill": 1, "box": 30, "strip": 0},
+		},
+		{
+			name: "legacy_without_boxes",
+			med:  domain.Medicine{},
+			want: map[string]float64{"pill": 1, "box": 0},
+		},
+		{
+			name: "chain",
+			med:  domain.Medicine{UnitType: "Tablet", UnitPerBox: 99, Units: "Box = 3 strip; strip = 10 tablet"},
+			want: map[string]float64{"tablet": 1, "strip": 10, "box": 30, "pill": 0},
+		},
+		{
+			name: "liquid",
+			med:  domain.Medicine{UnitType: "ml", Units: "bottle = 100 ml\nspoon = 2.5 ml"},
+			want: map[string]float64{"ml": 1, "bottle": 100, "spoon": 2.5, "box": 0},
+		},
+		{
+			name: "towards_base",
+			med:  domain.Medicine{UnitType: "box", Units: "box = 20 sachet"},
+			want: map[string]float64{"box": 1, "sachet": 0.05},
+		},
+		{
+			name: "consistent_cycle",
+			med:  domain.Medicine{Units: "box = 2 strip, strip = 14 pill, box = 28 pill"},
+			want: map[string]float64{"box": 28, "strip": 14},
+		},
+		{name: "conflict", med: domain.Medicine{Units: "box = 2 strip, strip = 14 pill, box = 30 pill"}, wantErr: true},
+		{name: "unconnected", med: domain.Medicine{UnitPerBox: 30, Units: "bottle = 100 ml"}, wantErr: true, want: map[string]float64{"box": 30, "bottle": 0}},
+		{name: "syntax", med: domain.Medicine{Units: "box of 30"}, wantErr: true},
+		{name: "zero", med: domain.Medicine{Units: "box = 0 pill"}, wantErr: true},
+		{name: "self", med: domain.Medicine{Units: "box = 2 box"}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g, err := units.Parse(tt.med)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("err = %v, want error %v", err, tt.wantErr)
+			}
+			for unit, want := range tt.want {
+				got, ok := g.Factor(unit)
+				if ok != (want != 0) || got != want {
+					t.Errorf("Factor(%q) = %v, %v; want %v", unit, got, ok, want)
+				}
+			}
+		})
+	}
+}
+
+func TestGraph_ToBase(t *testing.T) {
+	g := units.For(domain.Medicine{UnitType: "tablet", Units: "box = 3 strip, strip = 10 tablet"})
+	for _, tt := range []struct {
+		qty    float64
+		unit   string
+		want   float64
+		wantOK bool
+	}{
+		{2, "box", 60, true},
+		{1.5, "STRIP", 15, true},
+		{4, "pill", 4, false},
+		{4, "bottle", 4, false},
+	} {
+		if got, ok := g.ToBase(tt.qty, tt.unit); got != tt.want || ok != tt.wantOK {
+			t.Errorf("ToBase(%v, %q) = %v, %v; want %v, %v", tt.qty, tt.unit, got, ok, tt.want, tt.wantOK)
+		}
+	}
+	if fmt.Sprint(g.Units()) != "[box strip tablet]" || g.Base() != "tablet" {
+		t.Errorf("units = %v, base = %q", g.Units(), g.Base())
+	}
+}
+
+func TestLabel(t *testing.T) {
+	fr := i18n.For(i18n.French)
+	if got := units.Label(fr, "pill"); got != "comprimés" {
+		t.Errorf("Label(pill) = %q", got)
+	}
+	if got := units.Label(fr, "ml"); got != "ml" {
+		t.Errorf("Label(ml) = %q", got)
+	}
+}
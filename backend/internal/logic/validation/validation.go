@@ -0,0 +1,248 @@
+// Package validation finds the stock and finance records that the other
+// computations skip or misread, and reports them.
+package validation
+
+import (
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/nomenarkt/vitaltrack/backend/internal/domain"
+	"github.com/nomenarkt/vitaltrack/backend/internal/logic/calendar"
+	"github.com/nomenarkt/vitaltrack/backend/internal/logic/units"
+	"github.com/nomenarkt/vitaltrack/backend/internal/util/i18n"
+	"github.com/nomenarkt/vitaltrack/backend/internal/util/richtext"
+)
+
+// Tables named in the issues.
+const (
+	medicinesTable = "medicines"
+	entriesTable   = "entries"
+	financialTable = "financial"
+)
+
+// Stock returns the issues of the medicines and stock entries that are not
+// archived. now, in the household timezone, decides which entries are in
+// the future.
+func Stock(meds []domain.Medicine, entries []domain.StockEntry, now time.Time) []domain.ValidationIssue {
+	var issues []domain.ValidationIssue
+	byID := make(map[string]domain.Medicine, len(meds))
+	graphs := make(map[string]units.Graph, len(meds))
+	for _, m := range meds {
+		byID[m.ID] = m
+		g, err := units.Parse(m)
+		graphs[m.ID] = g
+		if m.Archived {
+			continue
+		}
+		med := func(kind domain.IssueKind, field string, v float64) {
+			issues = append(issues, domain.ValidationIssue{Kind: kind, Table: medicinesTable, RecordID: m.ID, Label: m.Name, Field: field, Value: number(v)})
+		}
+		if err != nil {
+			issues = append(issues, domain.ValidationIssue{Kind: domain.IssueInvalidUnits, Table: medicinesTable, RecordID: m.ID, Label: m.Name, Field: "units", Value: m.Units})
+		}
+		switch {
+		case m.UnitPerBox < 0:
+			med(domain.IssueNegativeAmount, "unit_per_box", m.UnitPerBox)
+		case m.UnitPerBox == 0 && strings.TrimSpace(m.Units) == "":
+			med(domain.IssueMissingUnitPerBox, "unit_per_box", m.UnitPerBox)
+		}
+		if m.DailyDose < 0 {
+			med(domain.IssueNegativeAmount, "daily_dose", m.DailyDose)
+		}
+		if m.InitialStock < 0 {
+			med(domain.IssueNegativeAmount, "initial_stock", m.InitialStock)
+		}
+	}
+
+	loc := now.Location()
+	today := calendar.Day(now, loc)
+	type key struct {
+		medicine string
+		kind     domain.EntryKind
+		day      time.Time
+		quantity float64
+		unit     string
+	}
+	first := map[key]string{}
+	for _, e := range entries {
+		if e.Archived {
+			continue
+		}
+		issue := domain.ValidationIssue{Table: entriesTable, RecordID: e.ID}
+		add := func(kind domain.IssueKind, field, value string) {
+			i := issue
+			i.Kind, i.Field, i.Value = kind, field, value
+			issues = append(issues, i)
+		}
+
+		medicineID := ""
+		if len(e.MedicineID) > 0 {
+			medicineID = e.MedicineID[0]
+		}
+		m, known := byID[medicineID]
+		if known {
+			issue.Label = m.Name
+		} else {
+			add(domain.IssueOrphanEntry, "medicine_id", medicineID)
+		}
+		if e.Date.IsZero() {
+			add(domain.IssueMissingDate, "date", "")
+		}
+		validUnit := e.Unit == units.Pill || e.Unit == units.Box
+		if known {
+			_, validUnit = graphs[medicineID].Factor(e.Unit)
+		}
+		if !validUnit {
+			add(domain.IssueUnknownUnit, "unit", e.Unit)
+		}
+		if !slices.Contains(domain.EntryKinds, e.EffectiveKind()) {
+			add(domain.IssueUnknownKind, "kind", string(e.Kind))
+		}
+		if e.Quantity < 0 && e.EffectiveKind() != domain.EntryCorrection {
+			add(domain.IssueNegativeAmount, "quantity", number(e.Quantity))
+		}
+		if !known || e.Date.IsZero() {
+			continue
+		}
+		day := calendar.Date(e.Date.Time, loc)
+		if day.After(today) {
+			add(domain.IssueFutureEntry, "date", e.Date.Format("2006-01-02"))
+		}
+		k := key{medicineID, e.EffectiveKind(), day, e.Quantity, e.Unit}
+		if id, dup := first[k]; dup {
+			i := issue
+			i.Kind, i.Related = domain.IssueDuplicateEntry, []string{id}
+			issues = append(issues, i)
+			continue
+		}
+		first[k] = e.ID
+	}
+	return Sorted(issues)
+}
+
+// Finance returns the issues of the financial entries that are not
+// archived. Contributions are added up per need as in the monthly report:
+// by date and need label.
+func Finance(entries []domain.FinancialEntry) []domain.ValidationIssue {
+	var issues []domain.ValidationIssue
+	type need struct {
+		key     string
+		amount  float64
+		total   float64
+		records []string
+	}
+	var needs []*need
+	byKey := map[string]*need{}
+	for _, e := range entries {
+		if e.Archived {
+			continue
+		}
+		issue := domain.ValidationIssue{Table: financialTable, RecordID: e.ID, Label: e.NeedLabel}
+		add := func(kind domain.IssueKind, field, value string) {
+			i := issue
+			i.Kind, i.Field, i.Value = kind, field, value
+			issues = append(issues, i)
+		}
+		if e.Date.IsZero() {
+			add(domain.IssueMissingDate, "date", "")
+		}
+		if e.NeedAmount < 0 {
+			add(domain.IssueNegativeAmount, "need_amount", number(e.NeedAmount))
+		}
+		if e.AmountContributed < 0 {
+			add(domain.IssueNegativeAmount, "amount_contributed", number(e.AmountContributed))
+		}
+
+		k := fmt.Sprintf("%s %s", e.Date.Format("2006-01-02"), e.NeedLabel)
+		n, ok := byKey[k]
+		if !ok {
+			n = &need{key: k, amount: e.NeedAmount}
+			byKey[k] = n
+			needs = append(needs, n)
+		}
+		n.total += e.AmountContributed
+		n.records = append(n.records, e.ID)
+	}
+	for _, n := range needs {
+		if n.amount > 0 && n.total > n.amount {
+			issues = append(issues, domain.ValidationIssue{
+				Kind:    domain.IssueOverContribution,
+				Table:   financialTable,
+				Label:   n.key,
+				Field:   "amount_contributed",
+				Value:   number(n.total) + " > " + number(n.amount),
+				Related: n.records,
+			})
+		}
+	}
+	return Sorted(issues)
+}
+
+// Sorted orders issues by kind, keeping the record order within a kind.
+func Sorted(issues []domain.ValidationIssue) []domain.ValidationIssue {
+	slices.SortStableFunc(issues, func(a, b domain.ValidationIssue) int {
+		return slices.Index(domain.IssueKinds, a.Kind) - slices.Index(domain.IssueKinds, b.Kind)
+	})
+	return issues
+}
+
+func number(v float64) string {
+	return strconv.FormatFloat(v, 'f', -1, 64)
+}
+
+// reportExamples is how many records ReportMessage names per kind.
+const reportExamples = 5
+
+// ReportMessage formats report in p's language: how many issues of each
+// kind were found, with the first records concerned.
+func ReportMessage(p i18n.Printer, report domain.ValidationReport) richtext.Doc {
+	month := report.Month
+	if t, err := time.Parse("2006-01", report.Month); err == nil {
+		month = p.Month(t.Year(), t.Month())
+	}
+	checked := p.T("validation.checked", report.Medicines, report.Entries, report.FinancialEntries, month)
+	if len(report.Issues) == 0 {
+		return richtext.Text(p.T("validation.clean") + "\n" + checked)
+	}
+
+	list := richtext.List{}
+	for _, kind := range domain.IssueKinds {
+		var names []string
+		for _, i := range report.Issues {
+			if i.Kind == kind {
+				names = append(names, describe(i))
+			}
+		}
+		if len(names) == 0 {
+			continue
+		}
+		line := p.T("validation."+string(kind), len(names))
+		if len(names) > reportExamples {
+			names = append(names[:reportExamples], p.T("validation.more", len(names)-reportExamples))
+		}
+		list = append(list, richtext.Item(richtext.T(line, ": ", strings.Join(names, ", "))))
+	}
+	return richtext.New(
+		richtext.P(richtext.B(p.T("validation.title"))),
+		richtext.P(richtext.T(checked)),
+		list,
+	)
+}
+
+// describe names the record of i, with the offending value when there is one.
+func describe(i domain.ValidationIssue) string {
+	name := i.RecordID
+	if i.Label != "" {
+		name = i.Label
+		if i.RecordID != "" {
+			name += " " + i.RecordID
+		}
+	}
+	if i.Value != "" {
+		name += " (" + i.Value + ")"
+	}
+	return name
+}
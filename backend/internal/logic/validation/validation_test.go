@@ -0,0 +1,120 @@
+package validation_test
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/nomenarkt/vitaltrack/backend/internal/domain"
+	"github.com/nomenarkt/vitaltrack/backend/internal/logic/validation"
+	"github.com/nomenarkt/vitaltrack/backend/internal/util/i18n"
+	"github.com/nomenarkt/vitaltrack/backend/internal/util/richtext"
+)
+
+func day(d int) domain.FlexibleDate {
+	return domain.NewFlexibleDate(time.Date(2025, 6, d, 0, 0, 0, 0, time.UTC))
+}
+
+// summary renders issues as "kind table/record/value" lines for comparison.
+func summary(issues []domain.ValidationIssue) []string {
+	var out []string
+	for _, i := range issues {
+		s := fmt.Sprintf("%s %s/%s", i.Kind, i.Table, i.RecordID)
+		if i.Value != "" {
+			s += "=" + i.Value
+		}
+		if len(i.Related) > 0 {
+			s += " " + strings.Join(i.Related, ",")
+		}
+		out = append(out, s)
+	}
+	return out
+}
+
+func TestStock(t *testing.T) {
+	meds := []domain.Medicine{
+		{ID: "m1", Name: "Aspirin", UnitPerBox: 30, DailyDose: 1, InitialStock: 10},
+		{ID: "m2", Name: "Insulin", DailyDose: 2, InitialStock: -4},
+		{ID: "m3", Name: "Old", UnitPerBox: 30, Archived: true},
+		{ID: "m4", Name: "Syrup", UnitType: "ml", Units: "bottle = 100 ml"},
+		{ID: "m5", Name: "Drops", Units: "box of 30"},
+	}
+	entries := []domain.StockEntry{
+		{ID: "e1", MedicineID: []string{"m1"}, Quantity: 1, Unit: "box", Date: day(2)},
+		{ID: "e2", MedicineID: []string{"m1"}, Quantity: 1, Unit: "box", Date: day(2)},
+		{ID: "e3", Quantity: 5, Unit: "pill", Date: day(2)},
+		{ID: "e4", MedicineID: []string{"gone"}, Quantity: 5, Unit: "pill", Date: day(2)},
+		{ID: "e5", MedicineID: []string{"m2"}, Quantity: 5, Unit: "tablet"},
+		{ID: "e6", MedicineID: []string{"m1"}, Quantity: -3, Unit: "pill", Date: day(20)},
+		{ID: "e7", MedicineID: []string{"m1"}, Quantity: 1, Unit: "box", Date: day(2), Archived: true},
+		{ID: "e8", MedicineID: []string{"m3"}, Quantity: 1, Unit: "box", Date: day(2)},
+		{ID: "e9", MedicineID: []string{"m4"}, Quantity: 1, Unit: "Bottle", Date: day(3)},
+		{ID: "e10", MedicineID: []string{"m4"}, Quantity: 1, Unit: "box", Date: day(3)},
+		{ID: "e11", MedicineID: []string{"m1"}, Kind: domain.EntryCorrection, Quantity: -3, Unit: "pill", Date: day(2)},
+		{ID: "e12", MedicineID: []string{"m1"}, Kind: "theft", Quantity: 3, Unit: "pill", Date: day(4)},
+		{ID: "e13", MedicineID: []string{"m1"}, Kind: domain.EntryWastage, Quantity: 1, Unit: "box", Date: day(2)},
+		{ID: "e14", MedicineID: []string{"m2"}, Quantity: 1, Unit: "box", Date: day(3)},
+	}
+	now := time.Date(2025, 6, 10, 9, 0, 0, 0, time.UTC)
+
+	got := summary(validation.Stock(meds, entries, now))
+	want := []string{
+		"orphan_entry entries/e3",
+		"orphan_entry entries/e4=gone",
+		"missing_date entries/e5",
+		"unknown_unit entries/e5=tablet",
+		"unknown_unit entries/e10=box",
+		"unknown_unit entries/e14=box",
+		"unknown_kind entries/e12=theft",
+		"invalid_units medicines/m5=box of 30",
+		"missing_unit_per_box medicines/m2=0",
+		"future_entry entries/e6=2025-06-20",
+		"duplicate_entry entries/e2 e1",
+		"negative_amount medicines/m2=-4",
+		"negative_amount entries/e6=-3",
+	}
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("issues:\n%s\nwant:\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+}
+
+func TestFinance(t *testing.T) {
+	entries := []domain.FinancialEntry{
+		{ID: "f1", Date: day(5), NeedLabel: "Rent", NeedAmount: 100, AmountContributed: 60},
+		{ID: "f2", Date: day(5), NeedLabel: "Rent", NeedAmount: 100, AmountContributed: 50},
+		{ID: "f3", Date: day(5), NeedLabel: "Food", NeedAmount: 100, AmountContributed: -5},
+		{ID: "f4", NeedLabel: "Water", NeedAmount: 10, AmountContributed: 5},
+		{ID: "f5", Date: day(5), NeedLabel: "Food", NeedAmount: 100, AmountContributed: 500, Archived: true},
+	}
+
+	got := summary(validation.Finance(entries))
+	want := []string{
+		"missing_date financial/f4",
+		"negative_amount financial/f3=-5",
+		"over_contribution financial/=110 > 100 f1,f2",
+	}
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("issues:\n%s\nwant:\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+}
+
+func TestReportMessage(t *testing.T) {
+	p := i18n.For(i18n.Default)
+	report := domain.ValidationReport{Month: "2025-06", Medicines: 2, Entries: 8, FinancialEntries: 5}
+
+	if out := richtext.Plain(validation.ReportMessage(p, report)); !strings.Contains(out, "No data issues") {
+		t.Errorf("clean report:\n%s", out)
+	}
+
+	for i := range 7 {
+		report.Issues = append(report.Issues, domain.ValidationIssue{Kind: domain.IssueOrphanEntry, Table: "entries", RecordID: fmt.Sprintf("e%d", i)})
+	}
+	report.Issues = append(report.Issues, domain.ValidationIssue{Kind: domain.IssueUnknownUnit, Table: "entries", RecordID: "e9", Label: "Aspirin", Value: "tablet"})
+	out := richtext.Plain(validation.ReportMessage(p, report))
+	for _, want := range []string{"(7): e0, e1, e2, e3, e4, 2 more", "(1): Aspirin e9 (tablet)", "8 stock entries"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("report lacks %q:\n%s", want, out)
+		}
+	}
+}
@@ -0,0 +1,91 @@
+// Package metrics defines the Prometheus metrics served at /metrics.
+package metrics
+
+import (
+	"net/http"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+// Registry holds every VitalTrack metric plus the Go runtime and process
+// collectors.
+var Registry = prometheus.NewRegistry()
+
+var factory = promauto.With(Registry)
+
+var (
+	// AirtableRequestDuration observes Airtable API calls by HTTP method,
+	// table and status code ("error" when no response was received).
+	AirtableRequestDuration = factory.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "vitaltrack_airtable_request_duration_seconds",
+		Help:    "Latency of Airtable API requests.",
+		Buckets: prometheus.DefBuckets,
+	}, []string{"method", "table", "code"})
+
+	// AirtableRequestErrors counts Airtable calls that failed or returned a
+	// non-2xx status.
+	AirtableRequestErrors = factory.NewCounterVec(prometheus.CounterOpts{
+		Name: "vitaltrack_airtable_request_errors_total",
+		Help: "Airtable API requests that failed or returned a non-2xx status.",
+	}, []string{"method", "table"})
+
+	// CacheReads counts reads of cached Airtable tables by table and result:
+	// hit, miss (fetched), stale (fetch failed, earlier rows served) or error.
+	CacheReads = factory.NewCounterVec(prometheus.CounterOpts{
+		Name: "vitaltrack_cache_reads_total",
+		Help: "Reads of cached Airtable tables, by table and result.",
+	}, []string{"table", "result"})
+
+	// TelegramSends counts sendMessage calls by outcome: sent, rate_limited,
+	// rejected (other 4xx), failed (5xx) or error (no response).
+	TelegramSends = factory.NewCounterVec(prometheus.CounterOpts{
+		Name: "vitaltrack_telegram_sends_total",
+		Help: "Telegram sendMessage calls by outcome.",
+	}, []string{"outcome"})
+
+	// TelegramLastPoll is the time of the last successful getUpdates call.
+	TelegramLastPoll = factory.NewGauge(prometheus.GaugeOpts{
+		Name: "vitaltrack_telegram_last_poll_timestamp_seconds",
+		Help: "Unix time of the last successful Telegram getUpdates call.",
+	})
+
+	// CommandsHandled counts bot commands and button presses received.
+	CommandsHandled = factory.NewCounterVec(prometheus.CounterOpts{
+		Name: "vitaltrack_telegram_commands_total",
+		Help: "Telegram bot commands and button presses handled, by command.",
+	}, []string{"command"})
+
+	// AlertsFired counts alerts delivered, by kind.
+	AlertsFired = factory.NewCounterVec(prometheus.CounterOpts{
+		Name: "vitaltrack_alerts_fired_total",
+		Help: "Alerts sent to Telegram, by kind.",
+	}, []string{"kind"})
+
+	// JobDuration observes scheduled job runs by job and result (ok or error).
+	JobDuration = factory.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "vitaltrack_job_duration_seconds",
+		Help:    "Duration of scheduled job runs.",
+		Buckets: []float64{.1, .5, 1, 2.5, 5, 10, 30, 60, 120},
+	}, []string{"job", "result"})
+
+	// JobLastSuccess is the time each job last completed without error.
+	JobLastSuccess = factory.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "vitaltrack_job_last_success_timestamp_seconds",
+		Help: "Unix time each scheduled job last completed without error.",
+	}, []string{"job"})
+)
+
+func init() {
+	Registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+	)
+}
+
+// Handler serves Registry in the Prometheus text format.
+func Handler() http.Handler {
+	return promhttp.HandlerFor(Registry, promhttp.HandlerOpts{})
+}
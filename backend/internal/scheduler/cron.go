@@ -0,0 +1,205 @@
+package scheduler
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Schedule decides when a job runs next.
+type Schedule interface {
+	// Next returns the first run strictly after t, in t's location, or the
+	// zero time when there is none.
+	Next(t time.Time) time.Time
+}
+
+// Parse reads a schedule spec. It accepts five-field cron expressions
+// ("minute hour day-of-month month day-of-week", with lists, ranges, steps
+// and month or weekday names), the shorthands @hourly, @daily, @weekly and
+// @monthly, and "@every <duration>" for fixed intervals.
+func Parse(spec string) (Schedule, error) {
+	spec = strings.TrimSpace(spec)
+	if d, ok := strings.CutPrefix(spec, "@every "); ok {
+		interval, err := time.ParseDuration(strings.TrimSpace(d))
+		if err != nil || interval <= 0 {
+			return nil, fmt.Errorf("invalid interval in %q", spec)
+		}
+		return every(interval), nil
+	}
+	if expr, ok := shorthands[spec]; ok {
+		spec = expr
+	}
+
+	fields := strings.Fields(spec)
+	if len(fields) != 5 {
+		return nil, fmt.Errorf("invalid schedule %q: want 5 fields, got %d", spec, len(fields))
+	}
+	var c cron
+	var err error
+	if c.minute, err = parseField(fields[0], 0, 59, nil); err != nil {
+		return nil, fmt.Errorf("minute: %w", err)
+	}
+	if c.hour, err = parseField(fields[1], 0, 23, nil); err != nil {
+		return nil, fmt.Errorf("hour: %w", err)
+	}
+	if c.dom, err = parseField(fields[2], 1, 31, nil); err != nil {
+		return nil, fmt.Errorf("day of month: %w", err)
+	}
+	if c.month, err = parseField(fields[3], 1, 12, monthNames); err != nil {
+		return nil, fmt.Errorf("month: %w", err)
+	}
+	if c.dow, err = parseField(fields[4], 0, 7, dayNames); err != nil {
+		return nil, fmt.Errorf("day of week: %w", err)
+	}
+	if c.dow&(1<<7) != 0 {
+		c.dow |= 1 // 7 is Sunday too
+	}
+	c.domAny = fields[2] == "*"
+	c.dowAny = fields[4] == "*"
+	return c, nil
+}
+
+var shorthands = map[string]string{
+	"@hourly":  "0 * * * *",
+	"@daily":   "0 0 * * *",
+	"@weekly":  "0 0 * * 0",
+	"@monthly": "0 0 1 * *",
+}
+
+var monthNames = map[string]int{
+	"jan": 1, "feb": 2, "mar": 3, "apr": 4, "may": 5, "jun": 6,
+	"jul": 7, "aug": 8, "sep": 9, "oct": 10, "nov": 11, "dec": 12,
+}
+
+var dayNames = map[string]int{"sun": 0, "mon": 1, "tue": 2, "wed": 3, "thu": 4, "fri": 5, "sat": 6}
+
+// every runs at a fixed interval from the previous run.
+type every time.Duration
+
+func (e every) Next(t time.Time) time.Time {
+	return t.Add(time.Duration(e))
+}
+
+// bits holds one bit per allowed value of a cron field.
+type bits uint64
+
+func (b bits) has(v int) bool { return b&(1<<uint(v)) != 0 }
+
+// cron is a parsed five-field expression. As in classic cron, when both day
+// fields are restricted a day matching either one is enough.
+type cron struct {
+	minute, hour, dom, month, dow bits
+	domAny, dowAny                bool
+}
+
+// searchLimit bounds the search for expressions that never match, such as
+// "0 0 31 2 *".
+const searchLimit = 5 * 366 * 24 * time.Hour
+
+// Next walks forward by wall clock in t's location, skipping whole months,
+// days and hours that cannot match. Times that fall in a DST gap do not
+// exist and are skipped; times repeated when clocks go back run once.
+func (c cron) Next(t time.Time) time.Time {
+	loc := t.Location()
+	next := t.Truncate(time.Minute).Add(time.Minute)
+	limit := t.Add(searchLimit)
+
+	for next.Before(limit) {
+		y, mo, d := next.Date()
+		h, mi := next.Hour(), next.Minute()
+
+		var cand time.Time
+		switch {
+		case !c.month.has(int(mo)):
+			cand = time.Date(y, mo+1, 1, 0, 0, 0, 0, loc)
+		case !c.dayMatches(next):
+			cand = time.Date(y, mo, d+1, 0, 0, 0, 0, loc)
+		case !c.hour.has(h):
+			cand = time.Date(y, mo, d, h+1, 0, 0, 0, loc)
+		case !c.minute.has(mi):
+			cand = time.Date(y, mo, d, h, mi+1, 0, 0, loc)
+		default:
+			return next
+		}
+		// Wall-clock arithmetic can step back into a repeated DST hour.
+		if !cand.After(next) {
+			cand = next.Add(time.Minute)
+		}
+		next = cand
+	}
+	return time.Time{}
+}
+
+func (c cron) dayMatches(t time.Time) bool {
+	dom := c.dom.has(t.Day())
+	dow := c.dow.has(int(t.Weekday()))
+	switch {
+	case c.domAny && c.dowAny:
+		return true
+	case c.domAny:
+		return dow
+	case c.dowAny:
+		return dom
+	default:
+		return dom || dow
+	}
+}
+
+// parseField parses a comma-separated list of values, ranges (a-b), steps
+// (*/n, a-b/n, a/n) and *.
+func parseField(s string, lo, hi int, names map[string]int) (bits, error) {
+	var b bits
+	for _, part := range strings.Split(s, ",") {
+		rng, stepStr, hasStep := strings.Cut(part, "/")
+		step := 1
+		if hasStep {
+			n, err := strconv.Atoi(stepStr)
+			if err != nil || n <= 0 {
+				return 0, fmt.Errorf("invalid step in %q", part)
+			}
+			step = n
+		}
+
+		start, end := lo, hi
+		switch {
+		case rng == "*":
+		case strings.Contains(rng, "-"):
+			a, z, _ := strings.Cut(rng, "-")
+			var err error
+			if start, err = parseValue(a, names); err != nil {
+				return 0, err
+			}
+			if end, err = parseValue(z, names); err != nil {
+				return 0, err
+			}
+		default:
+			v, err := parseValue(rng, names)
+			if err != nil {
+				return 0, err
+			}
+			start = v
+			if !hasStep {
+				end = v
+			}
+		}
+		if start < lo || end > hi || start > end {
+			return 0, fmt.Errorf("%q out of range %d-%d", part, lo, hi)
+		}
+		for v := start; v <= end; v += step {
+			b |= 1 << uint(v)
+		}
+	}
+	return b, nil
+}
+
+func parseValue(s string, names map[string]int) (int, error) {
+	if v, ok := names[strings.ToLower(s)]; ok {
+		return v, nil
+	}
+	v, err := strconv.Atoi(s)
+	if err != nil {
+		return 0, fmt.Errorf("invalid value %q", s)
+	}
+	return v, nil
+}
@@ -0,0 +1,124 @@
+package scheduler_test
+
+import (
+	"testing"
+	"time"
+	_ "time/tzdata"
+
+	"github.com/nomenarkt/vitaltrack/backend/internal/scheduler"
+)
+
+func TestParse_errors(t *testing.T) {
+	for _, spec := range []string{
+		"",
+		"* * * *",
+		"60 * * * *",
+		"* 24 * * *",
+		"* * 0 * *",
+		"* * * 13 *",
+		"* * * * 8",
+		"5-1 * * * *",
+		"*/0 * * * *",
+		"@every",
+		"@every -1m",
+		"@fortnightly",
+	} {
+		if _, err := scheduler.Parse(spec); err == nil {
+			t.Errorf("Parse(%q) accepted an invalid spec", spec)
+		}
+	}
+}
+
+func TestSchedule_Next(t *testing.T) {
+	tana, err := time.LoadLocation("Indian/Antananarivo")
+	if err != nil {
+		t.Fatal(err)
+	}
+	paris, err := time.LoadLocation("Europe/Paris")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name string
+		spec string
+		from time.Time
+		want []time.Time // successive runs
+	}{
+		{
+			name: "every_morning_local",
+			spec: "0 8 * * *",
+			from: time.Date(2025, 6, 5, 8, 0, 0, 0, tana),
+			want: []time.Time{time.Date(2025, 6, 6, 8, 0, 0, 0, tana), time.Date(2025, 6, 7, 8, 0, 0, 0, tana)},
+		},
+		{
+			name: "steps_and_lists",
+			spec: "*/20 9,17 * * *",
+			from: time.Date(2025, 6, 5, 9, 30, 0, 0, time.UTC),
+			want: []time.Time{time.Date(2025, 6, 5, 9, 40, 0, 0, time.UTC), time.Date(2025, 6, 5, 17, 0, 0, 0, time.UTC)},
+		},
+		{
+			name: "first_of_month",
+			spec: "0 9 1 * *",
+			from: time.Date(2025, 1, 31, 12, 0, 0, 0, time.UTC),
+			want: []time.Time{time.Date(2025, 2, 1, 9, 0, 0, 0, time.UTC), time.Date(2025, 3, 1, 9, 0, 0, 0, time.UTC)},
+		},
+		{
+			name: "weekday_names",
+			spec: "30 7 * * mon-fri",
+			from: time.Date(2025, 6, 6, 8, 0, 0, 0, time.UTC), // Friday
+			want: []time.Time{time.Date(2025, 6, 9, 7, 30, 0, 0, time.UTC)},
+		},
+		{
+			name: "day_of_month_or_weekday",
+			spec: "0 0 13 * 5",
+			from: time.Date(2025, 6, 1, 0, 0, 0, 0, time.UTC),
+			want: []time.Time{time.Date(2025, 6, 6, 0, 0, 0, 0, time.UTC), time.Date(2025, 6, 13, 0, 0, 0, 0, time.UTC)},
+		},
+		{
+			name: "sunday_as_7",
+			spec: "0 0 * * 7",
+			from: time.Date(2025, 6, 5, 0, 0, 0, 0, time.UTC),
+			want: []time.Time{time.Date(2025, 6, 8, 0, 0, 0, 0, time.UTC)},
+		},
+		{
+			name: "dst_gap_is_skipped",
+			spec: "30 2 * * *",
+			from: time.Date(2025, 3, 29, 12, 0, 0, 0, paris),
+			want: []time.Time{time.Date(2025, 3, 31, 2, 30, 0, 0, paris)},
+		},
+		{
+			name: "repeated_hour_runs_once",
+			spec: "30 2 * * *",
+			from: time.Date(2025, 10, 26, 0, 0, 0, 0, paris),
+			want: []time.Time{time.Date(2025, 10, 26, 2, 30, 0, 0, paris), time.Date(2025, 10, 27, 2, 30, 0, 0, paris)},
+		},
+		{
+			name: "every_interval",
+			spec: "@every 90m",
+			from: time.Date(2025, 6, 5, 8, 0, 0, 0, time.UTC),
+			want: []time.Time{time.Date(2025, 6, 5, 9, 30, 0, 0, time.UTC)},
+		},
+		{
+			name: "never",
+			spec: "0 0 31 2 *",
+			from: time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC),
+			want: []time.Time{{}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, err := scheduler.Parse(tt.spec)
+			if err != nil {
+				t.Fatal(err)
+			}
+			at := tt.from
+			for i, want := range tt.want {
+				at = s.Next(at)
+				if !at.Equal(want) {
+					t.Fatalf("run %d = %v, want %v", i, at, want)
+				}
+			}
+		})
+	}
+}
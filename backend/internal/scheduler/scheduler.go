@@ -0,0 +1,224 @@
+// Package scheduler runs named background jobs on cron schedules in the
+// household timezone.
+package scheduler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand/v2"
+	"sort"
+	"sync"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/nomenarkt/vitaltrack/backend/internal/logger"
+	"github.com/nomenarkt/vitaltrack/backend/internal/logic/calendar"
+	"github.com/nomenarkt/vitaltrack/backend/internal/metrics"
+	"github.com/nomenarkt/vitaltrack/backend/internal/tracing"
+)
+
+// Func is the work of a job. at is the scheduled run time in the household
+// timezone; ctx is cancelled when the scheduler shuts down.
+type Func func(ctx context.Context, at time.Time) error
+
+// JobStatus reports the state of one job.
+type JobStatus struct {
+	Name     string    `json:"name"`
+	Schedule string    `json:"schedule"`
+	Running  bool      `json:"running"`
+	NextRun  time.Time `json:"next_run,omitzero"`
+	LastRun  time.Time `json:"last_run,omitzero"`
+	LastOK   bool      `json:"last_ok"`
+	LastErr  string    `json:"last_error,omitempty"`
+	Duration string    `json:"last_duration,omitempty"`
+	Runs     int       `json:"runs"`
+	Failures int       `json:"failures"`
+	Skipped  int       `json:"skipped"` // runs dropped because the previous one was still going
+}
+
+type job struct {
+	schedule Schedule
+	run      Func
+	running  bool
+	status   JobStatus
+}
+
+// Scheduler runs registered jobs until its context is cancelled. A job never
+// overlaps itself: a run that comes due while the previous one is still going
+// is skipped.
+type Scheduler struct {
+	Location *time.Location   // household timezone, UTC when nil
+	Jitter   time.Duration    // random delay of up to Jitter before each run
+	Logger   logger.Logger    // required
+	Now      func() time.Time // time.Now when nil
+
+	mu      sync.Mutex
+	jobs    map[string]*job
+	started bool
+}
+
+// New returns a Scheduler for jobs in loc.
+func New(loc *time.Location, lg logger.Logger) *Scheduler {
+	return &Scheduler{Location: loc, Logger: lg, jobs: map[string]*job{}}
+}
+
+// ErrStarted is returned when a job is added to a running Scheduler.
+var ErrStarted = errors.New("scheduler already started")
+
+// Add registers run under name with a schedule spec accepted by Parse.
+func (s *Scheduler) Add(name, spec string, run Func) error {
+	sched, err := Parse(spec)
+	if err != nil {
+		return fmt.Errorf("job %s: %w", name, err)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.started {
+		return ErrStarted
+	}
+	if s.jobs == nil {
+		s.jobs = map[string]*job{}
+	}
+	if _, ok := s.jobs[name]; ok {
+		return fmt.Errorf("job %s already registered", name)
+	}
+	s.jobs[name] = &job{schedule: sched, run: run, status: JobStatus{Name: name, Schedule: spec}}
+	return nil
+}
+
+// Status returns the state of every job, sorted by name.
+func (s *Scheduler) Status() []JobStatus {
+	if s == nil {
+		return []JobStatus{}
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	out := make([]JobStatus, 0, len(s.jobs))
+	for _, j := range s.jobs {
+		st := j.status
+		st.Running = j.running
+		out = append(out, st)
+	}
+	sort.Slice(out, func(i, k int) bool { return out[i].Name < out[k].Name })
+	return out
+}
+
+// Run starts every job and blocks until ctx is cancelled and the runs in
+// progress have returned.
+func (s *Scheduler) Run(ctx context.Context) {
+	s.mu.Lock()
+	s.started = true
+	jobs := make([]*job, 0, len(s.jobs))
+	for _, j := range s.jobs {
+		jobs = append(jobs, j)
+	}
+	s.mu.Unlock()
+
+	var loops, runs sync.WaitGroup
+	for _, j := range jobs {
+		loops.Add(1)
+		go func() {
+			defer loops.Done()
+			s.loop(ctx, j, &runs)
+		}()
+	}
+	loops.Wait()
+	runs.Wait()
+}
+
+func (s *Scheduler) now() time.Time {
+	now := time.Now
+	if s.Now != nil {
+		now = s.Now
+	}
+	return calendar.In(now(), s.Location)
+}
+
+func (s *Scheduler) loop(ctx context.Context, j *job, runs *sync.WaitGroup) {
+	name := j.status.Name
+	for {
+		now := s.now()
+		next := j.schedule.Next(now)
+		if next.IsZero() {
+			s.Logger.Error(ctx, "job has no upcoming run", "job", name)
+			return
+		}
+		s.mu.Lock()
+		j.status.NextRun = next
+		s.mu.Unlock()
+
+		delay := next.Sub(now)
+		if s.Jitter > 0 {
+			delay += rand.N(s.Jitter)
+		}
+		timer := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return
+		case <-timer.C:
+		}
+
+		s.mu.Lock()
+		if j.running {
+			j.status.Skipped++
+			s.mu.Unlock()
+			s.Logger.Info(ctx, "job still running, skipping", "job", name)
+			continue
+		}
+		j.running = true
+		s.mu.Unlock()
+
+		runs.Add(1)
+		go func() {
+			defer runs.Done()
+			s.execute(ctx, j, next)
+		}()
+	}
+}
+
+func (s *Scheduler) execute(ctx context.Context, j *job, at time.Time) {
+	name := j.status.Name
+	if logger.CorrelationID(ctx) == "" {
+		ctx = logger.WithCorrelationID(ctx, name+"-"+logger.NewCorrelationID())
+	}
+	ctx, span := tracing.Start(ctx, "job "+name, trace.WithAttributes(attribute.String("job", name)))
+	defer span.End()
+	start := time.Now()
+	err := func() (err error) {
+		defer func() {
+			if r := recover(); r != nil {
+				err = fmt.Errorf("panic: %v", r)
+			}
+		}()
+		return j.run(ctx, at)
+	}()
+	elapsed := time.Since(start)
+
+	s.mu.Lock()
+	j.running = false
+	j.status.LastRun = s.now()
+	j.status.Duration = elapsed.Round(time.Millisecond).String()
+	j.status.Runs++
+	j.status.LastOK = err == nil
+	j.status.LastErr = ""
+	if err != nil {
+		j.status.Failures++
+		j.status.LastErr = err.Error()
+	}
+	s.mu.Unlock()
+
+	if err != nil {
+		tracing.Fail(span, err)
+		metrics.JobDuration.WithLabelValues(name, "error").Observe(elapsed.Seconds())
+		s.Logger.Error(ctx, "job failed", "job", name, "error", err)
+		return
+	}
+	metrics.JobDuration.WithLabelValues(name, "ok").Observe(elapsed.Seconds())
+	metrics.JobLastSuccess.WithLabelValues(name).SetToCurrentTime()
+	s.Logger.Info(ctx, "job completed", "job", name, "duration", elapsed.Round(time.Millisecond))
+}
@@ -0,0 +1,129 @@
+package scheduler_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/nomenarkt/vitaltrack/backend/internal/scheduler"
+)
+
+type captureLogger struct {
+	mu      sync.Mutex
+	entries []string
+}
+
+func (c *captureLogger) Debug(_ context.Context, msg string, kv ...any) { c.add(msg, kv) }
+func (c *captureLogger) Info(_ context.Context, msg string, kv ...any)  { c.add(msg, kv) }
+func (c *captureLogger) Warn(_ context.Context, msg string, kv ...any)  { c.add(msg, kv) }
+func (c *captureLogger) Error(_ context.Context, msg string, kv ...any) { c.add(msg, kv) }
+
+func (c *captureLogger) add(msg string, kv []any) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.entries = append(c.entries, strings.TrimSpace(msg+" "+fmt.Sprint(kv...)))
+}
+
+func (c *captureLogger) String() string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return strings.Join(c.entries, "\n")
+}
+
+func TestScheduler_Add(t *testing.T) {
+	s := scheduler.New(nil, &captureLogger{})
+	noop := func(context.Context, time.Time) error { return nil }
+	if err := s.Add("a", "0 8 * * *", noop); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Add("a", "0 9 * * *", noop); err == nil {
+		t.Error("duplicate job name accepted")
+	}
+	if err := s.Add("b", "0 25 * * *", noop); err == nil {
+		t.Error("invalid spec accepted")
+	}
+}
+
+func TestScheduler_Run(t *testing.T) {
+	lg := &captureLogger{}
+	s := scheduler.New(time.UTC, lg)
+
+	var mu sync.Mutex
+	var ok, failing int
+	release := make(chan struct{})
+	slowStarted := make(chan struct{}, 1)
+
+	add := func(name string, run scheduler.Func) {
+		if err := s.Add(name, "@every 5ms", run); err != nil {
+			t.Fatal(err)
+		}
+	}
+	add("ok", func(context.Context, time.Time) error {
+		mu.Lock()
+		ok++
+		mu.Unlock()
+		return nil
+	})
+	add("failing", func(context.Context, time.Time) error {
+		mu.Lock()
+		failing++
+		mu.Unlock()
+		return errors.New("boom")
+	})
+	add("panicking", func(context.Context, time.Time) error { panic("oops") })
+	add("slow", func(ctx context.Context, _ time.Time) error {
+		select {
+		case slowStarted <- struct{}{}:
+		default:
+		}
+		select {
+		case <-release:
+		case <-ctx.Done():
+		}
+		return ctx.Err()
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		s.Run(ctx)
+		close(done)
+	}()
+
+	<-slowStarted
+	time.Sleep(40 * time.Millisecond)
+	if err := s.Add("late", "@every 1s", nil); !errors.Is(err, scheduler.ErrStarted) {
+		t.Errorf("Add after Run = %v, want ErrStarted", err)
+	}
+	cancel()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("Run did not return after cancel")
+	}
+
+	status := map[string]scheduler.JobStatus{}
+	for _, st := range s.Status() {
+		status[st.Name] = st
+	}
+
+	if st := status["ok"]; st.Runs < 2 || !st.LastOK || st.Failures != 0 || st.LastRun.IsZero() || st.NextRun.IsZero() {
+		t.Errorf("ok status = %+v", st)
+	}
+	if st := status["failing"]; st.Failures != st.Runs || st.Runs == 0 || st.LastErr != "boom" {
+		t.Errorf("failing status = %+v", st)
+	}
+	if st := status["panicking"]; st.Failures == 0 || !strings.Contains(st.LastErr, "panic: oops") {
+		t.Errorf("panicking status = %+v", st)
+	}
+	if st := status["slow"]; st.Runs != 1 || st.Skipped == 0 || st.Running {
+		t.Errorf("slow job overlapped or was not stopped: %+v", st)
+	}
+	if !strings.Contains(lg.String(), "job still running, skipping") {
+		t.Errorf("missing overlap log:\n%s", lg)
+	}
+}
@@ -0,0 +1,29 @@
+package server
+
+import (
+	fiber "github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/middleware/adaptor"
+
+	"github.com/nomenarkt/vitaltrack/backend/internal/health"
+	"github.com/nomenarkt/vitaltrack/backend/internal/metrics"
+)
+
+// registerHealthRoutes adds the liveness, readiness and metrics endpoints.
+// /healthz only says the process serves HTTP; /readyz runs ready's checks
+// and answers 503 when one fails.
+func registerHealthRoutes(app *fiber.App, ready *health.Checker) {
+	app.Get("/healthz", func(c *fiber.Ctx) error {
+		return c.JSON(fiber.Map{"status": "ok"})
+	})
+
+	app.Get("/readyz", func(c *fiber.Ctx) error {
+		ok, results := ready.Check(c.UserContext())
+		status, code := "ready", fiber.StatusOK
+		if !ok {
+			status, code = "unavailable", fiber.StatusServiceUnavailable
+		}
+		return c.Status(code).JSON(fiber.Map{"status": status, "checks": results})
+	})
+
+	app.Get("/metrics", adaptor.HTTPHandler(metrics.Handler()))
+}
@@ -0,0 +1,69 @@
+package server_test
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	fiber "github.com/gofiber/fiber/v2"
+	"github.com/nomenarkt/vitaltrack/backend/internal/config"
+	"github.com/nomenarkt/vitaltrack/backend/internal/health"
+	"github.com/nomenarkt/vitaltrack/backend/internal/metrics"
+	"github.com/nomenarkt/vitaltrack/backend/internal/server"
+	"github.com/nomenarkt/vitaltrack/backend/internal/testutil"
+	"github.com/nomenarkt/vitaltrack/backend/internal/usecase"
+)
+
+func TestReadyz_unavailable(t *testing.T) {
+	ready := health.New(time.Minute)
+	ready.Add("airtable", func(context.Context) error { return nil })
+	ready.Add("telegram", func(context.Context) error { return errors.New("dial tcp: timeout") })
+
+	store := &testutil.Store{}
+	tg := &nopTelegram{}
+	app := fiber.New()
+	server.SetupRoutes(app, config.Server{}, &usecase.StockChecker{Telegram: tg}, usecase.OutOfStockService{Airtable: store},
+		usecase.MedicineService{Repo: store}, usecase.StockEntryService{Repo: store},
+		usecase.FinancialEntryService{Repo: store}, usecase.AlertLogService{}, usecase.AdherenceService{}, usecase.ValidationService{}, store, tg, nil, ready)
+
+	status, body := doRequest(t, app, "GET", "/readyz", "")
+	if status != fiber.StatusServiceUnavailable || body["status"] != "unavailable" {
+		t.Fatalf("status = %d, body = %v", status, body)
+	}
+	checks, _ := body["checks"].([]any)
+	if len(checks) != 2 || !strings.Contains(checks[1].(map[string]any)["error"].(string), "timeout") {
+		t.Errorf("checks = %v", checks)
+	}
+
+	if status, _ := doRequest(t, app, "GET", "/healthz", ""); status != fiber.StatusOK {
+		t.Errorf("healthz status = %d while not ready", status)
+	}
+}
+
+func TestMetrics(t *testing.T) {
+	app := newTestApp(t, &testutil.Store{})
+	metrics.CommandsHandled.WithLabelValues("/stock").Inc()
+
+	res, err := app.Test(httptest.NewRequest("GET", "/metrics", nil))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		if err := res.Body.Close(); err != nil {
+			t.Fatalf("close body: %v", err)
+		}
+	}()
+	raw, err := io.ReadAll(res.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{`vitaltrack_telegram_commands_total{command="/stock"}`, "go_goroutines"} {
+		if !strings.Contains(string(raw), want) {
+			t.Errorf("metrics missing %s", want)
+		}
+	}
+}
@@ -0,0 +1,24 @@
+package server
+
+import (
+	_ "embed" // embeds the OpenAPI document
+
+	fiber "github.com/gofiber/fiber/v2"
+)
+
+//go:embed openapi.json
+var openAPISpec []byte
+
+// OpenAPISpec returns the OpenAPI 3 document describing every route
+// registered by SetupRoutes. It is maintained by hand next to the handlers;
+// the contract tests fail when the two drift apart.
+func OpenAPISpec() []byte {
+	return openAPISpec
+}
+
+func registerOpenAPIRoute(app *fiber.App) {
+	app.Get("/openapi.json", func(c *fiber.Ctx) error {
+		c.Set(fiber.HeaderContentType, fiber.MIMEApplicationJSON)
+		return c.Send(openAPISpec)
+	})
+}
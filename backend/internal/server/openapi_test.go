@@ -0,0 +1,197 @@
+package server_test
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/getkin/kin-openapi/openapi3filter"
+	"github.com/getkin/kin-openapi/routers"
+	"github.com/getkin/kin-openapi/routers/legacy"
+	fiber "github.com/gofiber/fiber/v2"
+	"github.com/nomenarkt/vitaltrack/backend/internal/domain"
+	"github.com/nomenarkt/vitaltrack/backend/internal/server"
+	"github.com/nomenarkt/vitaltrack/backend/internal/testutil"
+)
+
+func loadSpec(t *testing.T) (*openapi3.T, routers.Router) {
+	t.Helper()
+	doc, err := openapi3.NewLoader().LoadFromData(server.OpenAPISpec())
+	if err != nil {
+		t.Fatalf("load spec: %v", err)
+	}
+	if err := doc.Validate(context.Background()); err != nil {
+		t.Fatalf("invalid spec: %v", err)
+	}
+	router, err := legacy.NewRouter(doc)
+	if err != nil {
+		t.Fatalf("spec router: %v", err)
+	}
+	return doc, router
+}
+
+var fiberParam = regexp.MustCompile(`:([A-Za-z_]+)`)
+
+// TestOpenAPI_coversAllRoutes fails when a route is registered without being
+// documented, or documented without being registered.
+func TestOpenAPI_coversAllRoutes(t *testing.T) {
+	doc, _ := loadSpec(t)
+	app := newTestApp(t, &testutil.Store{})
+
+	registered := map[string]bool{}
+	for _, r := range app.GetRoutes(true) {
+		if r.Method == fiber.MethodHead {
+			continue // Fiber mirrors every GET as HEAD
+		}
+		path := fiberParam.ReplaceAllString(r.Path, "{$1}")
+		key := r.Method + " " + path
+		registered[key] = true
+
+		item := doc.Paths.Value(path)
+		if item == nil || item.GetOperation(r.Method) == nil {
+			t.Errorf("route %s is not documented in openapi.json", key)
+		}
+	}
+
+	for path, item := range doc.Paths.Map() {
+		for method := range item.Operations() {
+			if !registered[method+" "+path] {
+				t.Errorf("openapi.json documents %s %s but no such route is registered", method, path)
+			}
+		}
+	}
+}
+
+// TestOpenAPI_contract replays representative requests and validates both
+// the request and the response against the document.
+func TestOpenAPI_contract(t *testing.T) {
+	_, router := loadSpec(t)
+
+	now := time.Now().UTC()
+	store := &testutil.Store{
+		Meds: []domain.Medicine{
+			{ID: "m1", Name: "Med1", UnitPerBox: 30, DailyDose: 1, InitialStock: 5, StartDate: domain.NewFlexibleDate(now.AddDate(0, 0, -1))},
+		},
+		Entries: []domain.StockEntry{
+			{ID: "e1", MedicineID: []string{"m1"}, Quantity: 1, Unit: "box", Date: domain.NewFlexibleDate(now)},
+		},
+		Financial: []domain.FinancialEntry{
+			{ID: "f1", Date: domain.NewFlexibleDate(time.Date(2025, 6, 5, 0, 0, 0, 0, time.UTC)), NeedLabel: "Med", NeedAmount: 20, AmountContributed: 5, MonthTag: "2025-06", Contributor: "Bob"},
+		},
+	}
+	app := newTestApp(t, store)
+
+	tests := []struct {
+		method string
+		path   string
+		body   string
+		status int
+	}{
+		{"GET", "/openapi.json", "", 200},
+		{"GET", "/healthz", "", 200},
+		{"GET", "/readyz", "", 200},
+		{"GET", "/metrics", "", 200},
+		{"GET", "/check", "", 200},
+		{"GET", "/debug/medicines", "", 200},
+		{"GET", "/debug/entries", "", 200},
+		{"GET", "/debug/outofstock", "", 200},
+		{"GET", "/api/medicines/m1/stock", "", 200},
+		{"GET", "/api/medicines/missing/stock", "", 404},
+		{"POST", "/api/alerts/evaluate?dry_run=true", "", 200},
+		{"POST", "/api/alerts/evaluate", "", 200},
+		{"GET", "/api/alerts?kind=low_stock&limit=5", "", 200},
+		{"GET", "/api/alerts?since=yesterday", "", 400},
+		{"GET", "/api/jobs", "", 200},
+		{"POST", "/api/medicines/m1/entries", `{"quantity":2,"unit":"pill","date":"2025-06-02"}`, 201},
+		{"GET", "/api/v1/medicines?limit=10&offset=0", "", 200},
+		{"POST", "/api/v1/medicines", `{"name":"Med2","daily_dose":2,"unit_per_box":10,"start_date":"2025-06-01","initial_stock":0}`, 201},
+		{"GET", "/api/v1/medicines/m1", "", 200},
+		{"GET", "/api/v1/medicines/missing", "", 404},
+		{"PATCH", "/api/v1/medicines/m1", `{"name":"Med1 forte"}`, 200},
+		{"GET", "/api/v1/entries?medicine_id=m1", "", 200},
+		{"POST", "/api/v1/entries", `{"medicine_id":"m1","quantity":1,"unit":"box","date":"2025-06-03"}`, 201},
+		{"POST", "/api/v1/entries", `{"medicine_id":"m1","kind":"correction","quantity":-2,"unit":"pill","date":"2025-06-03","reason":"recount"}`, 201},
+		{"GET", "/api/v1/entries?kind=correction", "", 200},
+		{"GET", "/api/v1/entries/e1", "", 200},
+		{"PATCH", "/api/v1/entries/e1", `{"quantity":2}`, 200},
+		{"DELETE", "/api/v1/entries/e1", "", 204},
+		{"GET", "/api/v1/financial-entries?month=2025-06", "", 200},
+		{"POST", "/api/v1/financial-entries", `{"Date":"2025-06-07","NeedLabel":"Food","NeedAmount":10,"AmountContributed":10,"Contributor":"Alice"}`, 201},
+		{"GET", "/api/v1/financial-entries/f1", "", 200},
+		{"GET", "/api/v1/adherence?weeks=2", "", 200},
+		{"GET", "/api/v1/validation?month=2025-06", "", 200},
+		{"PATCH", "/api/v1/financial-entries/f1", `{"Archived":false}`, 200},
+		{"DELETE", "/api/v1/financial-entries/f1", "", 204},
+		{"DELETE", "/api/v1/medicines/m1", "", 204},
+		{"POST", "/webhooks/airtable", signedNotification, 204},
+		{"POST", "/webhooks/airtable", `{"base":{"id":"app2"}}`, 401},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.method+" "+tt.path, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, tt.path, strings.NewReader(tt.body))
+			if tt.body != "" {
+				req.Header.Set("Content-Type", "application/json")
+			}
+
+			route, params, err := router.FindRoute(req)
+			if err != nil {
+				t.Fatalf("find route: %v", err)
+			}
+			reqInput := &openapi3filter.RequestValidationInput{Request: req, PathParams: params, Route: route}
+			if err := openapi3filter.ValidateRequest(context.Background(), reqInput); err != nil {
+				t.Fatalf("request does not match spec: %v", err)
+			}
+			req.Body = io.NopCloser(strings.NewReader(tt.body))
+
+			res, err := app.Test(req)
+			if err != nil {
+				t.Fatalf("app.Test: %v", err)
+			}
+			defer func() {
+				if err := res.Body.Close(); err != nil {
+					t.Fatalf("close body: %v", err)
+				}
+			}()
+			body, err := io.ReadAll(res.Body)
+			if err != nil {
+				t.Fatalf("read body: %v", err)
+			}
+			if res.StatusCode != tt.status {
+				t.Fatalf("status = %d, want %d (body=%s)", res.StatusCode, tt.status, body)
+			}
+
+			resInput := &openapi3filter.ResponseValidationInput{
+				RequestValidationInput: reqInput,
+				Status:                 res.StatusCode,
+				Header:                 http.Header{"Content-Type": {res.Header.Get("Content-Type")}},
+				Body:                   io.NopCloser(bytes.NewReader(body)),
+			}
+			if err := openapi3filter.ValidateResponse(context.Background(), resInput); err != nil {
+				t.Errorf("response does not match spec: %v", err)
+			}
+		})
+	}
+}
+
+func TestOpenAPI_rejectsInvalidRequest(t *testing.T) {
+	_, router := loadSpec(t)
+
+	req := httptest.NewRequest("POST", "/api/v1/entries", strings.NewReader(`{"medicine_id":"m1","kind":"theft","quantity":1,"unit":"","date":"2025-06-03"}`))
+	req.Header.Set("Content-Type", "application/json")
+	route, params, err := router.FindRoute(req)
+	if err != nil {
+		t.Fatalf("find route: %v", err)
+	}
+	err = openapi3filter.ValidateRequest(context.Background(), &openapi3filter.RequestValidationInput{Request: req, PathParams: params, Route: route})
+	if err == nil {
+		t.Fatal("expected spec to reject unknown kind and empty unit")
+	}
+}
@@ -0,0 +1,65 @@
+package server
+
+import (
+	"errors"
+	"regexp"
+	"time"
+
+	fiber "github.com/gofiber/fiber/v2"
+
+	"github.com/nomenarkt/vitaltrack/backend/internal/logger"
+)
+
+// RequestIDHeader carries the correlation ID of an HTTP request, in both
+// directions.
+const RequestIDHeader = "X-Request-ID"
+
+// validRequestID limits the IDs accepted from callers, so that they can be
+// logged as they are.
+var validRequestID = regexp.MustCompile(`^[A-Za-z0-9._-]{1,64}$`)
+
+// quietPaths are polled by probes and scrapers; their requests are logged
+// at debug level only.
+var quietPaths = map[string]bool{"/healthz": true, "/readyz": true, "/metrics": true}
+
+// Correlate gives every request a correlation ID: the caller's X-Request-ID
+// when it is usable, a new one otherwise. The ID is put in the request's
+// user context, so that everything logged while handling it carries the ID,
+// and returned in the response header. Each request is logged to lg once it
+// completes.
+func Correlate(lg logger.Logger) fiber.Handler {
+	lg = logger.OrNop(lg)
+	return func(c *fiber.Ctx) error {
+		id := c.Get(RequestIDHeader)
+		if !validRequestID.MatchString(id) {
+			id = logger.NewCorrelationID()
+		}
+		ctx := logger.WithCorrelationID(c.UserContext(), id)
+		c.SetUserContext(ctx)
+		c.Set(RequestIDHeader, id)
+
+		start := time.Now()
+		err := c.Next()
+
+		status := responseStatus(c, err)
+		log := lg.Info
+		if quietPaths[c.Path()] {
+			log = lg.Debug
+		}
+		log(ctx, "http request", "method", c.Method(), "path", c.Path(), "status", status, "duration", time.Since(start).Round(time.Millisecond))
+		return err
+	}
+}
+
+// responseStatus returns the status code the response to c is sent with,
+// given the error returned by the handlers.
+func responseStatus(c *fiber.Ctx, err error) int {
+	if err == nil {
+		return c.Response().StatusCode()
+	}
+	var fe *fiber.Error
+	if errors.As(err, &fe) {
+		return fe.Code
+	}
+	return fiber.StatusInternalServerError
+}
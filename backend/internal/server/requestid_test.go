@@ -0,0 +1,59 @@
+package server_test
+
+import (
+	"bytes"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	fiber "github.com/gofiber/fiber/v2"
+
+	"github.com/nomenarkt/vitaltrack/backend/internal/logger"
+	"github.com/nomenarkt/vitaltrack/backend/internal/server"
+)
+
+func TestCorrelate(t *testing.T) {
+	var buf bytes.Buffer
+	lg := logger.New(&buf, logger.Options{})
+	app := fiber.New()
+	app.Use(server.Correlate(lg))
+	app.Get("/work", func(c *fiber.Ctx) error {
+		lg.Info(c.UserContext(), "handling")
+		return c.SendStatus(fiber.StatusNoContent)
+	})
+
+	tests := []struct {
+		name   string
+		header string
+		keep   bool
+	}{
+		{"caller_id", "abc-123", true},
+		{"missing", "", false},
+		{"unsafe", "bad id\nwith=spaces", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			buf.Reset()
+			req := httptest.NewRequest("GET", "/work", nil)
+			if tt.header != "" {
+				req.Header.Set(server.RequestIDHeader, tt.header)
+			}
+			resp, err := app.Test(req)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			id := resp.Header.Get(server.RequestIDHeader)
+			if id == "" || (tt.keep && id != tt.header) || (!tt.keep && id == tt.header) {
+				t.Fatalf("response ID = %q for request ID %q", id, tt.header)
+			}
+			logs := buf.String()
+			if strings.Count(logs, "correlation_id="+id) != 2 {
+				t.Errorf("handler and access logs should both carry %s:\n%s", id, logs)
+			}
+			if !strings.Contains(logs, `msg="http request" method=GET path=/work status=204`) {
+				t.Errorf("missing access log:\n%s", logs)
+			}
+		})
+	}
+}
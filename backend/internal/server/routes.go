@@ -3,39 +3,53 @@ package server
 
 import (
 	"errors"
-	"fmt"
-	"log"
-	"os"
 	"time"
 
 	fiber "github.com/gofiber/fiber/v2"
+	"github.com/nomenarkt/vitaltrack/backend/internal/config"
 	"github.com/nomenarkt/vitaltrack/backend/internal/domain"
 	"github.com/nomenarkt/vitaltrack/backend/internal/domain/ports"
+	"github.com/nomenarkt/vitaltrack/backend/internal/health"
+	"github.com/nomenarkt/vitaltrack/backend/internal/scheduler"
 	"github.com/nomenarkt/vitaltrack/backend/internal/usecase"
+	"github.com/nomenarkt/vitaltrack/backend/internal/util/richtext"
 )
 
 // SetupRoutes registers all HTTP endpoints with the provided Fiber app.
+// cfg decides which write endpoints are enabled.
 func SetupRoutes(
 	app *fiber.App,
+	cfg config.Server,
 	checker *usecase.StockChecker,
 	forecastSvc usecase.OutOfStockService,
 	medicineSvc usecase.MedicineService,
+	entrySvc usecase.StockEntryService,
+	financialEntrySvc usecase.FinancialEntryService,
+	alertLogSvc usecase.AlertLogService,
+	adherenceSvc usecase.AdherenceService,
+	validationSvc usecase.ValidationService,
 	dataPort ports.StockDataPort,
 	telegramClient ports.TelegramService,
+	jobs *scheduler.Scheduler,
+	ready *health.Checker,
 ) {
-	const stockThreshold = 10.0
-	allowEntryPost := os.Getenv("ENABLE_ENTRY_POST") == "true"
+	allowEntryPost := cfg.EnableEntryPost
+	allowAPIWrites := cfg.EnableAPIWrites
+
+	registerOpenAPIRoute(app)
+	registerHealthRoutes(app, ready)
+	registerV1Routes(app, medicineSvc, entrySvc, financialEntrySvc, adherenceSvc, validationSvc, allowAPIWrites)
 
 	// ✅ New route for manual stock check via HTTP
 	app.Get("/check", func(c *fiber.Ctx) error {
-		if err := checker.CheckAndAlertLowStock(); err != nil {
+		if err := checker.CheckAndAlertLowStock(c.UserContext()); err != nil {
 			return c.Status(500).JSON(fiber.Map{"error": err.Error()})
 		}
 		return c.JSON(fiber.Map{"status": "ok"})
 	})
 
 	app.Get("/debug/medicines", func(c *fiber.Ctx) error {
-		meds, err := dataPort.FetchMedicines()
+		meds, err := dataPort.FetchMedicines(c.UserContext())
 		if err != nil {
 			return c.Status(500).JSON(fiber.Map{"error": err.Error()})
 		}
@@ -43,7 +57,7 @@ func SetupRoutes(
 	})
 
 	app.Get("/debug/entries", func(c *fiber.Ctx) error {
-		entries, err := dataPort.FetchStockEntries()
+		entries, err := dataPort.FetchStockEntries(c.UserContext())
 		if err != nil {
 			return c.Status(500).JSON(fiber.Map{"error": err.Error()})
 		}
@@ -51,10 +65,7 @@ func SetupRoutes(
 	})
 
 	app.Get("/api/medicines/:id/stock", func(c *fiber.Ctx) error {
-		id := c.Params("id")
-		now := time.Now().UTC()
-
-		info, err := medicineSvc.GetStockInfo(id, now)
+		info, err := medicineSvc.GetStockInfo(c.UserContext(), c.Params("id"), time.Now().UTC())
 		if err != nil {
 			if errors.Is(err, usecase.ErrMedicineNotFound) {
 				return c.Status(404).JSON(fiber.Map{"error": err.Error()})
@@ -62,31 +73,86 @@ func SetupRoutes(
 			return c.Status(500).JSON(fiber.Map{"error": err.Error()})
 		}
 
-		if info.CurrentStock < stockThreshold {
-			alert := fmt.Sprintf("⚠️ Stock alert for *%s*:\nOnly %.2f pills left!\nRefill before %s.",
-				id,
-				info.CurrentStock,
-				info.OutOfStockDate.Format("Jan 2, 2006"),
-			)
-			if err := telegramClient.SendTelegramMessage(alert); err != nil {
-				log.Printf("telegram send error: %v", err)
-			}
-		}
-
 		return c.JSON(fiber.Map{
+			"medicine_id":       info.MedicineID,
+			"name":              info.Name,
+			"unit":              info.Unit,
+			"daily_dose":        info.DailyDose,
 			"initial_stock":     info.InitialStock,
 			"consumed_stock":    info.ConsumedStock,
 			"current_stock":     info.CurrentStock,
+			"days_left":         info.DaysLeft,
 			"out_of_stock_date": info.OutOfStockDate.Format("2006-01-02"),
+			"reorder": fiber.Map{
+				"needed":     info.Reorder.Needed,
+				"reorder_by": info.Reorder.ReorderBy.Format("2006-01-02"),
+				"pills":      info.Reorder.Pills,
+				"boxes":      info.Reorder.Boxes,
+			},
+		})
+	})
+
+	app.Post("/api/alerts/evaluate", func(c *fiber.Ctx) error {
+		now := time.Now().UTC()
+		dryRun := c.QueryBool("dry_run")
+
+		alerts, err := checker.EvaluateAlerts(c.UserContext(), now, dryRun)
+		if err != nil {
+			return c.Status(500).JSON(fiber.Map{"error": err.Error()})
+		}
+
+		out := make([]fiber.Map, 0, len(alerts))
+		for _, a := range alerts {
+			out = append(out, fiber.Map{
+				"kind":          a.Kind,
+				"key":           a.Key,
+				"medicine_id":   a.MedicineID,
+				"medicine_name": a.MedicineName,
+				"message":       richtext.MarkdownV2(a.Message),
+				"deduplicated":  a.Deduplicated,
+				"sent":          a.Sent,
+				"queued":        a.Queued,
+			})
+		}
+		return c.JSON(fiber.Map{
+			"dry_run":      dryRun,
+			"evaluated_at": now.Format(time.RFC3339),
+			"alerts":       out,
 		})
 	})
 
+	app.Get("/api/jobs", func(c *fiber.Ctx) error {
+		return c.JSON(fiber.Map{"jobs": jobs.Status()})
+	})
+
+	app.Get("/api/alerts", func(c *fiber.Ctx) error {
+		filter := domain.AlertFilter{
+			MedicineID: c.Query("medicine_id"),
+			Kind:       c.Query("kind"),
+			Status:     domain.DeliveryStatus(c.Query("status")),
+			Page:       pageFromQuery(c),
+		}
+		if v := c.Query("since"); v != "" {
+			since, err := domain.ParseDate(v)
+			if err != nil {
+				return badRequest(c, "since must be YYYY-MM-DD or RFC3339")
+			}
+			filter.Since = since
+		}
+
+		res, err := alertLogSvc.ListAlerts(c.UserContext(), filter)
+		if err != nil {
+			return writeError(c, err)
+		}
+		return c.JSON(listResponse(res))
+	})
+
 	app.Get("/debug/outofstock", func(c *fiber.Ctx) error {
-		msg, err := forecastSvc.GenerateOutOfStockForecastMessage()
+		msg, err := forecastSvc.GenerateOutOfStockForecastMessage(c.UserContext())
 		if err != nil {
 			return c.Status(500).JSON(fiber.Map{"error": err.Error()})
 		}
-		if err := telegramClient.SendTelegramMessage(msg); err != nil {
+		if err := telegramClient.SendTelegramMessage(c.UserContext(), msg); err != nil {
 			return c.Status(500).JSON(fiber.Map{"error": err.Error()})
 		}
 		return c.JSON(fiber.Map{"message": "out-of-stock forecast sent"})
@@ -100,30 +166,10 @@ func SetupRoutes(
 			if err := c.BodyParser(&req); err != nil {
 				return c.Status(400).JSON(fiber.Map{"error": "invalid JSON body"})
 			}
-			if req.Quantity <= 0 || (req.Unit != "box" && req.Unit != "pill") || req.Date == "" {
-				return c.Status(400).JSON(fiber.Map{
-					"error": "quantity must be > 0, unit must be 'box' or 'pill', date must not be empty",
-				})
-			}
+			req.MedicineID = id
 
-			parsedDate, err := time.Parse("2006-01-02", req.Date)
-			if err != nil {
-				parsedDate, err = time.Parse(time.RFC3339, req.Date)
-				if err != nil {
-					return c.Status(400).JSON(fiber.Map{
-						"error": "invalid date format, expected YYYY-MM-DD or RFC3339",
-					})
-				}
-			}
-
-			entry := domain.StockEntry{
-				MedicineID: []string{id},
-				Quantity:   req.Quantity,
-				Unit:       req.Unit,
-				Date:       domain.FlexibleDate{Time: parsedDate},
-			}
-			if err := dataPort.CreateStockEntry(entry); err != nil {
-				return c.Status(500).JSON(fiber.Map{"error": err.Error()})
+			if _, err := entrySvc.CreateEntry(c.UserContext(), req); err != nil {
+				return writeError(c, err)
 			}
 			return c.Status(201).JSON(fiber.Map{"message": "stock entry created"})
 		})
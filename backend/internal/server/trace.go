@@ -0,0 +1,59 @@
+package server
+
+import (
+	fiber "github.com/gofiber/fiber/v2"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/nomenarkt/vitaltrack/backend/internal/tracing"
+)
+
+// Trace records a server span around every request, named after the
+// matched route. A caller that sends a W3C traceparent header has the span
+// added to its trace.
+func Trace() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		ctx := otel.GetTextMapPropagator().Extract(c.UserContext(), headerCarrier{c})
+		ctx, span := tracing.Start(ctx, c.Method()+" "+c.Path(),
+			trace.WithSpanKind(trace.SpanKindServer),
+			trace.WithAttributes(
+				attribute.String("http.request.method", c.Method()),
+				attribute.String("url.path", c.Path()),
+			))
+		defer span.End()
+		c.SetUserContext(ctx)
+
+		err := c.Next()
+
+		route := c.Route().Path
+		status := responseStatus(c, err)
+		span.SetName(c.Method() + " " + route)
+		span.SetAttributes(
+			attribute.String("http.route", route),
+			attribute.Int("http.response.status_code", status),
+		)
+		if status >= fiber.StatusInternalServerError {
+			span.SetStatus(codes.Error, "")
+		}
+		return err
+	}
+}
+
+// headerCarrier reads and writes trace context in the request headers.
+type headerCarrier struct {
+	c *fiber.Ctx
+}
+
+func (h headerCarrier) Get(key string) string { return h.c.Get(key) }
+
+func (h headerCarrier) Set(key, value string) { h.c.Request().Header.Set(key, value) }
+
+func (h headerCarrier) Keys() []string {
+	keys := make([]string, 0, len(h.c.GetReqHeaders()))
+	for k := range h.c.GetReqHeaders() {
+		keys = append(keys, k)
+	}
+	return keys
+}
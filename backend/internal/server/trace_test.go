@@ -0,0 +1,62 @@
+package server_test
+
+import (
+	"net/http/httptest"
+	"testing"
+
+	fiber "github.com/gofiber/fiber/v2"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+
+	"github.com/nomenarkt/vitaltrack/backend/internal/server"
+)
+
+func TestTrace(t *testing.T) {
+	rec := tracetest.NewSpanRecorder()
+	prev, prevProp := otel.GetTracerProvider(), otel.GetTextMapPropagator()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(rec)))
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+	t.Cleanup(func() {
+		otel.SetTracerProvider(prev)
+		otel.SetTextMapPropagator(prevProp)
+	})
+
+	app := fiber.New()
+	app.Use(server.Trace())
+	app.Get("/api/v1/medicines/:id", func(c *fiber.Ctx) error {
+		return fiber.NewError(fiber.StatusBadGateway, "airtable unavailable")
+	})
+
+	req := httptest.NewRequest("GET", "/api/v1/medicines/rec1", nil)
+	req.Header.Set("traceparent", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	if _, err := app.Test(req); err != nil {
+		t.Fatal(err)
+	}
+
+	spans := rec.Ended()
+	if len(spans) != 1 {
+		t.Fatalf("%d spans recorded", len(spans))
+	}
+	s := spans[0]
+	if s.Name() != "GET /api/v1/medicines/:id" {
+		t.Errorf("name = %q", s.Name())
+	}
+	if got := s.SpanContext().TraceID().String(); got != "4bf92f3577b34da6a3ce929d0e0e4736" {
+		t.Errorf("trace ID = %s, want the caller's", got)
+	}
+	if s.Status().Code != codes.Error {
+		t.Errorf("status = %v for a 502", s.Status())
+	}
+	want := attribute.Int("http.response.status_code", fiber.StatusBadGateway)
+	found := false
+	for _, a := range s.Attributes() {
+		found = found || a == want
+	}
+	if !found {
+		t.Errorf("attributes %v lack %v", s.Attributes(), want)
+	}
+}
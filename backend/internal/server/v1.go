@@ -0,0 +1,274 @@
+package server
+
+import (
+	"errors"
+	"slices"
+	"time"
+
+	fiber "github.com/gofiber/fiber/v2"
+	"github.com/nomenarkt/vitaltrack/backend/internal/domain"
+	"github.com/nomenarkt/vitaltrack/backend/internal/usecase"
+)
+
+// errorStatus maps use case errors to HTTP status codes.
+func errorStatus(err error) int {
+	switch {
+	case errors.Is(err, usecase.ErrInvalidInput):
+		return fiber.StatusBadRequest
+	case errors.Is(err, usecase.ErrMedicineNotFound),
+		errors.Is(err, usecase.ErrEntryNotFound),
+		errors.Is(err, usecase.ErrFinancialEntryNotFound),
+		errors.Is(err, usecase.ErrDoseNotFound):
+		return fiber.StatusNotFound
+	default:
+		return fiber.StatusInternalServerError
+	}
+}
+
+func writeError(c *fiber.Ctx, err error) error {
+	return c.Status(errorStatus(err)).JSON(fiber.Map{"error": err.Error()})
+}
+
+func badRequest(c *fiber.Ctx, msg string) error {
+	return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": msg})
+}
+
+func pageFromQuery(c *fiber.Ctx) domain.Page {
+	return domain.Page{Limit: c.QueryInt("limit"), Offset: c.QueryInt("offset")}
+}
+
+func listResponse[T any](res usecase.ListResult[T]) fiber.Map {
+	return fiber.Map{
+		"data":   res.Items,
+		"total":  res.Total,
+		"limit":  res.Limit,
+		"offset": res.Offset,
+	}
+}
+
+// registerV1Routes mounts the versioned CRUD resources for medicines, stock
+// entries and financial entries, and the adherence and validation reports.
+// Write routes are only mounted when allowWrites is set.
+func registerV1Routes(
+	router fiber.Router,
+	medicineSvc usecase.MedicineService,
+	entrySvc usecase.StockEntryService,
+	financialSvc usecase.FinancialEntryService,
+	adherenceSvc usecase.AdherenceService,
+	validationSvc usecase.ValidationService,
+	allowWrites bool,
+) {
+	v1 := router.Group("/api/v1")
+
+	v1.Get("/medicines", func(c *fiber.Ctx) error {
+		res, err := medicineSvc.ListMedicines(c.UserContext(), domain.MedicineFilter{
+			Name:            c.Query("name"),
+			IncludeArchived: c.QueryBool("include_archived"),
+			Page:            pageFromQuery(c),
+		})
+		if err != nil {
+			return writeError(c, err)
+		}
+		return c.JSON(listResponse(res))
+	})
+
+	v1.Get("/medicines/:id", func(c *fiber.Ctx) error {
+		m, err := medicineSvc.GetMedicine(c.UserContext(), c.Params("id"))
+		if err != nil {
+			return writeError(c, err)
+		}
+		return c.JSON(m)
+	})
+
+	v1.Get("/entries", func(c *fiber.Ctx) error {
+		filter := domain.StockEntryFilter{
+			MedicineID:      c.Query("medicine_id"),
+			Kind:            domain.EntryKind(c.Query("kind")),
+			IncludeArchived: c.QueryBool("include_archived"),
+			Page:            pageFromQuery(c),
+		}
+		if filter.Kind != "" && !slices.Contains(domain.EntryKinds, filter.Kind) {
+			return badRequest(c, "kind: expected refill, wastage, transfer_out or correction")
+		}
+		for param, dst := range map[string]*time.Time{"from": &filter.From, "to": &filter.To} {
+			if v := c.Query(param); v != "" {
+				t, err := domain.ParseDate(v)
+				if err != nil {
+					return badRequest(c, param+": expected YYYY-MM-DD or RFC3339")
+				}
+				*dst = t
+			}
+		}
+		res, err := entrySvc.ListEntries(c.UserContext(), filter)
+		if err != nil {
+			return writeError(c, err)
+		}
+		return c.JSON(listResponse(res))
+	})
+
+	v1.Get("/entries/:id", func(c *fiber.Ctx) error {
+		e, err := entrySvc.GetEntry(c.UserContext(), c.Params("id"))
+		if err != nil {
+			return writeError(c, err)
+		}
+		return c.JSON(e)
+	})
+
+	v1.Get("/financial-entries", func(c *fiber.Ctx) error {
+		filter := domain.FinancialEntryFilter{
+			Contributor:     c.Query("contributor"),
+			NeedLabel:       c.Query("need"),
+			IncludeArchived: c.QueryBool("include_archived"),
+			Page:            pageFromQuery(c),
+		}
+		if v := c.Query("month"); v != "" {
+			t, err := time.Parse("2006-01", v)
+			if err != nil {
+				return badRequest(c, "month: expected YYYY-MM")
+			}
+			filter.Year, filter.Month = t.Year(), t.Month()
+		}
+		res, err := financialSvc.ListEntries(c.UserContext(), filter)
+		if err != nil {
+			return writeError(c, err)
+		}
+		return c.JSON(listResponse(res))
+	})
+
+	v1.Get("/financial-entries/:id", func(c *fiber.Ctx) error {
+		e, err := financialSvc.GetEntry(c.UserContext(), c.Params("id"))
+		if err != nil {
+			return writeError(c, err)
+		}
+		return c.JSON(e)
+	})
+
+	v1.Get("/adherence", func(c *fiber.Ctx) error {
+		weeks := c.QueryInt("weeks", 4)
+		if weeks < 1 || weeks > 52 {
+			return badRequest(c, "weeks: expected 1 to 52")
+		}
+		if adherenceSvc.Events == nil {
+			return c.JSON(fiber.Map{"data": []domain.AdherenceWeek{}})
+		}
+		res, err := adherenceSvc.WeeklyReport(c.UserContext(), weeks, time.Now())
+		if err != nil {
+			return writeError(c, err)
+		}
+		return c.JSON(fiber.Map{"data": res})
+	})
+
+	v1.Get("/validation", func(c *fiber.Ctx) error {
+		var year int
+		var month time.Month
+		if v := c.Query("month"); v != "" {
+			t, err := time.Parse("2006-01", v)
+			if err != nil {
+				return badRequest(c, "month: expected YYYY-MM")
+			}
+			year, month = t.Year(), t.Month()
+		}
+		report, err := validationSvc.Validate(c.UserContext(), year, month, time.Now())
+		if err != nil {
+			return writeError(c, err)
+		}
+		return c.JSON(report)
+	})
+
+	if !allowWrites {
+		return
+	}
+
+	v1.Post("/medicines", func(c *fiber.Ctx) error {
+		var req domain.CreateMedicineRequest
+		if err := c.BodyParser(&req); err != nil {
+			return badRequest(c, "invalid JSON body")
+		}
+		m, err := medicineSvc.CreateMedicine(c.UserContext(), req)
+		if err != nil {
+			return writeError(c, err)
+		}
+		return c.Status(fiber.StatusCreated).JSON(m)
+	})
+
+	v1.Patch("/medicines/:id", func(c *fiber.Ctx) error {
+		var patch domain.MedicinePatch
+		if err := c.BodyParser(&patch); err != nil {
+			return badRequest(c, "invalid JSON body")
+		}
+		m, err := medicineSvc.UpdateMedicine(c.UserContext(), c.Params("id"), patch)
+		if err != nil {
+			return writeError(c, err)
+		}
+		return c.JSON(m)
+	})
+
+	v1.Delete("/medicines/:id", func(c *fiber.Ctx) error {
+		if err := medicineSvc.ArchiveMedicine(c.UserContext(), c.Params("id")); err != nil {
+			return writeError(c, err)
+		}
+		return c.SendStatus(fiber.StatusNoContent)
+	})
+
+	v1.Post("/entries", func(c *fiber.Ctx) error {
+		var req domain.CreateStockEntryRequest
+		if err := c.BodyParser(&req); err != nil {
+			return badRequest(c, "invalid JSON body")
+		}
+		e, err := entrySvc.CreateEntry(c.UserContext(), req)
+		if err != nil {
+			return writeError(c, err)
+		}
+		return c.Status(fiber.StatusCreated).JSON(e)
+	})
+
+	v1.Patch("/entries/:id", func(c *fiber.Ctx) error {
+		var patch domain.StockEntryPatch
+		if err := c.BodyParser(&patch); err != nil {
+			return badRequest(c, "invalid JSON body")
+		}
+		e, err := entrySvc.UpdateEntry(c.UserContext(), c.Params("id"), patch)
+		if err != nil {
+			return writeError(c, err)
+		}
+		return c.JSON(e)
+	})
+
+	v1.Delete("/entries/:id", func(c *fiber.Ctx) error {
+		if err := entrySvc.ArchiveEntry(c.UserContext(), c.Params("id")); err != nil {
+			return writeError(c, err)
+		}
+		return c.SendStatus(fiber.StatusNoContent)
+	})
+
+	v1.Post("/financial-entries", func(c *fiber.Ctx) error {
+		var req domain.CreateFinancialEntryRequest
+		if err := c.BodyParser(&req); err != nil {
+			return badRequest(c, "invalid JSON body")
+		}
+		e, err := financialSvc.CreateEntry(c.UserContext(), req)
+		if err != nil {
+			return writeError(c, err)
+		}
+		return c.Status(fiber.StatusCreated).JSON(e)
+	})
+
+	v1.Patch("/financial-entries/:id", func(c *fiber.Ctx) error {
+		var patch domain.FinancialEntryPatch
+		if err := c.BodyParser(&patch); err != nil {
+			return badRequest(c, "invalid JSON body")
+		}
+		e, err := financialSvc.UpdateEntry(c.UserContext(), c.Params("id"), patch)
+		if err != nil {
+			return writeError(c, err)
+		}
+		return c.JSON(e)
+	})
+
+	v1.Delete("/financial-entries/:id", func(c *fiber.Ctx) error {
+		if err := financialSvc.ArchiveEntry(c.UserContext(), c.Params("id")); err != nil {
+			return writeError(c, err)
+		}
+		return c.SendStatus(fiber.StatusNoContent)
+	})
+}
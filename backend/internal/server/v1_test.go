@@ -0,0 +1,349 @@
+package server_test
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	fiber "github.com/gofiber/fiber/v2"
+	"github.com/nomenarkt/vitaltrack/backend/internal/config"
+	"github.com/nomenarkt/vitaltrack/backend/internal/domain"
+	"github.com/nomenarkt/vitaltrack/backend/internal/health"
+	"github.com/nomenarkt/vitaltrack/backend/internal/infra/memstore"
+	"github.com/nomenarkt/vitaltrack/backend/internal/logger"
+	"github.com/nomenarkt/vitaltrack/backend/internal/scheduler"
+	"github.com/nomenarkt/vitaltrack/backend/internal/server"
+	"github.com/nomenarkt/vitaltrack/backend/internal/testutil"
+	"github.com/nomenarkt/vitaltrack/backend/internal/usecase"
+	"github.com/nomenarkt/vitaltrack/backend/internal/util/richtext"
+)
+
+type nopTelegram struct{ sent []string }
+
+func (n *nopTelegram) SendTelegramMessage(_ context.Context, msg richtext.Doc) error {
+	n.sent = append(n.sent, richtext.MarkdownV2(msg))
+	return nil
+}
+func (n *nopTelegram) PollForCommands(context.Context, func(context.Context) ([]domain.Medicine, []domain.StockEntry, error), func(context.Context, int, int) (domain.MonthlyFinancialReport, error), func(context.Context) ([]domain.AlertRecord, error)) {
+}
+
+func newTestApp(t *testing.T, store *testutil.Store) *fiber.App {
+	t.Helper()
+	app := fiber.New()
+	tg := &nopTelegram{}
+	alertLog := memstore.NewAlertLog()
+	doses := memstore.NewDoseLog()
+	if _, err := doses.CreateDoseEvent(context.Background(), domain.DoseEvent{Key: "dose:m1:now", MedicineID: "m1", Patient: "Mum", Scheduled: time.Now(), Pills: 1, Status: domain.DoseTaken}); err != nil {
+		t.Fatal(err)
+	}
+	jobs := scheduler.New(time.UTC, logger.NewStdLogger())
+	if err := jobs.Add("stock-alerts", "0 8 * * *", func(context.Context, time.Time) error { return nil }); err != nil {
+		t.Fatal(err)
+	}
+	ready := health.New(time.Minute)
+	ready.Add("airtable", func(context.Context) error { return nil })
+	server.SetupRoutes(
+		app,
+		config.Server{EnableAPIWrites: true, EnableEntryPost: true},
+		&usecase.StockChecker{Airtable: store, Telegram: tg, Alerts: alertLog},
+		usecase.OutOfStockService{Airtable: store},
+		usecase.MedicineService{Repo: store},
+		usecase.StockEntryService{Repo: store},
+		usecase.FinancialEntryService{Repo: store},
+		usecase.AlertLogService{Log: alertLog},
+		usecase.AdherenceService{Events: doses},
+		usecase.ValidationService{Records: store},
+		store,
+		tg,
+		jobs,
+		ready,
+	)
+	server.RegisterAirtableWebhook(app,
+		func(body []byte, _ string) bool { return string(body) == signedNotification },
+		func() {})
+	return app
+}
+
+// signedNotification is the only webhook body newTestApp's verifier accepts.
+const signedNotification = `{"base":{"id":"app1"},"webhook":{"id":"ach1"},"timestamp":"2025-06-10T09:00:00.000Z"}`
+
+func doRequest(t *testing.T, app *fiber.App, method, path, body string) (int, map[string]any) {
+	t.Helper()
+	req := httptest.NewRequest(method, path, strings.NewReader(body))
+	if body != "" {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	res, err := app.Test(req)
+	if err != nil {
+		t.Fatalf("%s %s: %v", method, path, err)
+	}
+	defer func() {
+		if err := res.Body.Close(); err != nil {
+			t.Fatalf("close body: %v", err)
+		}
+	}()
+	raw, err := io.ReadAll(res.Body)
+	if err != nil {
+		t.Fatalf("read body: %v", err)
+	}
+	out := map[string]any{}
+	if strings.HasPrefix(res.Header.Get("Content-Type"), fiber.MIMEApplicationJSON) {
+		if err := json.Unmarshal(raw, &out); err != nil {
+			t.Fatalf("%s %s: decode %q: %v", method, path, raw, err)
+		}
+	}
+	return res.StatusCode, out
+}
+
+func TestV1Medicines(t *testing.T) {
+	store := &testutil.Store{}
+	app := newTestApp(t, store)
+
+	status, body := doRequest(t, app, "POST", "/api/v1/medicines", `{"name":"MedA","daily_dose":1,"unit_per_box":30,"start_date":"2025-06-01","initial_stock":10}`)
+	if status != fiber.StatusCreated {
+		t.Fatalf("create status = %d body=%v", status, body)
+	}
+	id, _ := body["id"].(string)
+
+	status, body = doRequest(t, app, "POST", "/api/v1/medicines", `{"name":"","start_date":"2025-06-01"}`)
+	if status != fiber.StatusBadRequest {
+		t.Errorf("invalid create status = %d body=%v", status, body)
+	}
+
+	status, body = doRequest(t, app, "PATCH", "/api/v1/medicines/"+id, `{"daily_dose":2}`)
+	if status != fiber.StatusOK || body["daily_dose"] != 2.0 {
+		t.Errorf("patch status = %d body=%v", status, body)
+	}
+
+	status, _ = doRequest(t, app, "GET", "/api/v1/medicines/missing", "")
+	if status != fiber.StatusNotFound {
+		t.Errorf("get missing status = %d", status)
+	}
+
+	status, _ = doRequest(t, app, "DELETE", "/api/v1/medicines/"+id, "")
+	if status != fiber.StatusNoContent {
+		t.Errorf("delete status = %d", status)
+	}
+
+	status, body = doRequest(t, app, "GET", "/api/v1/medicines", "")
+	if status != fiber.StatusOK || body["total"] != 0.0 {
+		t.Errorf("list after delete status = %d body=%v", status, body)
+	}
+	status, body = doRequest(t, app, "GET", "/api/v1/medicines?include_archived=true&limit=5", "")
+	if status != fiber.StatusOK || body["total"] != 1.0 || body["limit"] != 5.0 {
+		t.Errorf("list archived status = %d body=%v", status, body)
+	}
+}
+
+func TestV1Entries(t *testing.T) {
+	store := &testutil.Store{Meds: []domain.Medicine{{ID: "m1", Name: "Med1", UnitPerBox: 30}}}
+	app := newTestApp(t, store)
+
+	status, body := doRequest(t, app, "POST", "/api/v1/entries", `{"medicine_id":"m1","quantity":2,"unit":"box","date":"2025-06-02"}`)
+	if status != fiber.StatusCreated {
+		t.Fatalf("create status = %d body=%v", status, body)
+	}
+	id, _ := body["id"].(string)
+
+	status, _ = doRequest(t, app, "POST", "/api/v1/entries", `{"medicine_id":"nope","quantity":2,"unit":"box","date":"2025-06-02"}`)
+	if status != fiber.StatusNotFound {
+		t.Errorf("unknown medicine status = %d", status)
+	}
+
+	status, body = doRequest(t, app, "GET", "/api/v1/entries?medicine_id=m1&from=2025-06-01&to=2025-06-30", "")
+	if status != fiber.StatusOK || body["total"] != 1.0 {
+		t.Errorf("list status = %d body=%v", status, body)
+	}
+	status, _ = doRequest(t, app, "GET", "/api/v1/entries?from=yesterday", "")
+	if status != fiber.StatusBadRequest {
+		t.Errorf("bad from status = %d", status)
+	}
+
+	status, body = doRequest(t, app, "POST", "/api/v1/entries", `{"medicine_id":"m1","kind":"wastage","quantity":4,"unit":"pill","date":"2025-06-03","reason":"expired"}`)
+	if status != fiber.StatusCreated || body["kind"] != "wastage" || body["reason"] != "expired" {
+		t.Fatalf("wastage status = %d body=%v", status, body)
+	}
+	status, _ = doRequest(t, app, "POST", "/api/v1/entries", `{"medicine_id":"m1","kind":"wastage","quantity":4,"unit":"pill","date":"2025-06-03"}`)
+	if status != fiber.StatusBadRequest {
+		t.Errorf("wastage without reason status = %d", status)
+	}
+	status, body = doRequest(t, app, "GET", "/api/v1/entries?kind=refill", "")
+	if status != fiber.StatusOK || body["total"] != 1.0 {
+		t.Errorf("refills status = %d body=%v", status, body)
+	}
+	status, _ = doRequest(t, app, "GET", "/api/v1/entries?kind=theft", "")
+	if status != fiber.StatusBadRequest {
+		t.Errorf("bad kind status = %d", status)
+	}
+
+	status, body = doRequest(t, app, "PATCH", "/api/v1/entries/"+id, `{"quantity":3}`)
+	if status != fiber.StatusOK || body["quantity"] != 3.0 {
+		t.Errorf("patch status = %d body=%v", status, body)
+	}
+	status, _ = doRequest(t, app, "PATCH", "/api/v1/entries/"+id, `{"unit":"crate"}`)
+	if status != fiber.StatusBadRequest {
+		t.Errorf("invalid patch status = %d", status)
+	}
+
+	status, _ = doRequest(t, app, "DELETE", "/api/v1/entries/"+id, "")
+	if status != fiber.StatusNoContent {
+		t.Errorf("delete status = %d", status)
+	}
+	if !store.Entries[0].Archived {
+		t.Errorf("entry not soft-deleted")
+	}
+
+	status, _ = doRequest(t, app, "POST", "/api/medicines/m1/entries", `{"quantity":1,"unit":"pill","date":"2025-06-03"}`)
+	if status != fiber.StatusCreated {
+		t.Errorf("legacy create status = %d", status)
+	}
+}
+
+func TestV1FinancialEntries(t *testing.T) {
+	store := &testutil.Store{}
+	app := newTestApp(t, store)
+
+	status, body := doRequest(t, app, "POST", "/api/v1/financial-entries", `{"Date":"2025-06-05","NeedLabel":"Med","NeedAmount":20,"AmountContributed":5,"Contributor":"Bob"}`)
+	if status != fiber.StatusCreated || body["MonthTag"] != "2025-06" {
+		t.Fatalf("create status = %d body=%v", status, body)
+	}
+	id, _ := body["id"].(string)
+
+	status, body = doRequest(t, app, "GET", "/api/v1/financial-entries?month=2025-06&contributor=bob", "")
+	if status != fiber.StatusOK || body["total"] != 1.0 {
+		t.Errorf("list status = %d body=%v", status, body)
+	}
+	status, _ = doRequest(t, app, "GET", "/api/v1/financial-entries?month=June", "")
+	if status != fiber.StatusBadRequest {
+		t.Errorf("bad month status = %d", status)
+	}
+
+	status, _ = doRequest(t, app, "GET", "/api/v1/financial-entries/"+id, "")
+	if status != fiber.StatusOK {
+		t.Errorf("get status = %d", status)
+	}
+	status, _ = doRequest(t, app, "DELETE", "/api/v1/financial-entries/"+id, "")
+	if status != fiber.StatusNoContent {
+		t.Errorf("delete status = %d", status)
+	}
+}
+
+func TestV1Adherence(t *testing.T) {
+	app := newTestApp(t, &testutil.Store{})
+
+	status, body := doRequest(t, app, "GET", "/api/v1/adherence", "")
+	if status != fiber.StatusOK {
+		t.Fatalf("status = %d body=%v", status, body)
+	}
+	data, _ := body["data"].([]any)
+	if len(data) != 1 {
+		t.Fatalf("data = %v, want one week", body["data"])
+	}
+	week, _ := data[0].(map[string]any)
+	if week["patient"] != "Mum" || week["taken"] != 1.0 || week["adherence_percent"] != 100.0 {
+		t.Errorf("week = %v", week)
+	}
+
+	for _, q := range []string{"weeks=0", "weeks=53"} {
+		if status, _ := doRequest(t, app, "GET", "/api/v1/adherence?"+q, ""); status != fiber.StatusBadRequest {
+			t.Errorf("%s status = %d, want 400", q, status)
+		}
+	}
+}
+
+func TestV1Validation(t *testing.T) {
+	date := domain.NewFlexibleDate(time.Date(2025, 6, 5, 0, 0, 0, 0, time.UTC))
+	app := newTestApp(t, &testutil.Store{
+		Meds:    []domain.Medicine{{ID: "m1", Name: "Med1", UnitPerBox: 30, DailyDose: 1, StartDate: date}},
+		Entries: []domain.StockEntry{{ID: "e1", MedicineID: []string{"m1"}, Quantity: 1, Unit: "tablet", Date: date}},
+		Financial: []domain.FinancialEntry{
+			{ID: "f1", Date: date, NeedLabel: "Med", NeedAmount: 20, AmountContributed: 30, MonthTag: "2025-06"},
+		},
+	})
+
+	status, body := doRequest(t, app, "GET", "/api/v1/validation?month=2025-06", "")
+	if status != fiber.StatusOK || body["month"] != "2025-06" || body["financial_entries"] != 1.0 {
+		t.Fatalf("status = %d body=%v", status, body)
+	}
+	issues, _ := body["issues"].([]any)
+	var kinds []string
+	for _, i := range issues {
+		kinds = append(kinds, i.(map[string]any)["kind"].(string))
+	}
+	if strings.Join(kinds, ",") != "unknown_unit,over_contribution" {
+		t.Errorf("issue kinds = %v", kinds)
+	}
+
+	if status, _ := doRequest(t, app, "GET", "/api/v1/validation?month=June", ""); status != fiber.StatusBadRequest {
+		t.Errorf("bad month status = %d", status)
+	}
+}
+
+func TestV1WritesDisabledByDefault(t *testing.T) {
+	store := &testutil.Store{}
+	app := fiber.New()
+	tg := &nopTelegram{}
+	server.SetupRoutes(app, config.Server{}, &usecase.StockChecker{Telegram: tg}, usecase.OutOfStockService{Airtable: store},
+		usecase.MedicineService{Repo: store}, usecase.StockEntryService{Repo: store},
+		usecase.FinancialEntryService{Repo: store}, usecase.AlertLogService{}, usecase.AdherenceService{}, usecase.ValidationService{}, store, tg, nil, nil)
+
+	status, _ := doRequest(t, app, "POST", "/api/v1/medicines", `{"name":"MedA","start_date":"2025-06-01"}`)
+	if status != fiber.StatusNotFound && status != fiber.StatusMethodNotAllowed {
+		t.Errorf("write route mounted without ENABLE_API_WRITES: status = %d", status)
+	}
+}
+
+func TestStockEndpointHasNoSideEffects(t *testing.T) {
+	now := time.Now().UTC()
+	store := &testutil.Store{Meds: []domain.Medicine{
+		{ID: "m1", Name: "Med1", UnitPerBox: 30, DailyDose: 1, InitialStock: 3, StartDate: domain.NewFlexibleDate(now)},
+	}}
+	app := fiber.New()
+	tg := &nopTelegram{}
+	alertLog := memstore.NewAlertLog()
+	server.SetupRoutes(app, config.Server{}, &usecase.StockChecker{Airtable: store, Telegram: tg, Alerts: alertLog}, usecase.OutOfStockService{Airtable: store},
+		usecase.MedicineService{Repo: store}, usecase.StockEntryService{Repo: store},
+		usecase.FinancialEntryService{Repo: store}, usecase.AlertLogService{Log: alertLog}, usecase.AdherenceService{}, usecase.ValidationService{}, store, tg, nil, nil)
+
+	status, body := doRequest(t, app, "GET", "/api/medicines/m1/stock", "")
+	if status != fiber.StatusOK {
+		t.Fatalf("status = %d body=%v", status, body)
+	}
+	reorder, _ := body["reorder"].(map[string]any)
+	if reorder["needed"] != true || reorder["boxes"] != 1.0 {
+		t.Errorf("unexpected reorder: %v", body)
+	}
+	if len(tg.sent) != 0 {
+		t.Errorf("GET stock sent %d Telegram messages", len(tg.sent))
+	}
+
+	status, body = doRequest(t, app, "POST", "/api/alerts/evaluate?dry_run=true", "")
+	alerts, _ := body["alerts"].([]any)
+	if status != fiber.StatusOK || len(alerts) != 1 || len(tg.sent) != 0 {
+		t.Errorf("dry run status = %d body=%v sent=%d", status, body, len(tg.sent))
+	}
+
+	status, _ = doRequest(t, app, "POST", "/api/alerts/evaluate", "")
+	if status != fiber.StatusOK || len(tg.sent) != 1 {
+		t.Errorf("evaluate status = %d sent=%d", status, len(tg.sent))
+	}
+
+	status, body = doRequest(t, app, "POST", "/api/alerts/evaluate", "")
+	alerts, _ = body["alerts"].([]any)
+	if status != fiber.StatusOK || len(tg.sent) != 1 || len(alerts) != 1 || alerts[0].(map[string]any)["deduplicated"] != true {
+		t.Errorf("repeat evaluate status = %d body=%v sent=%d", status, body, len(tg.sent))
+	}
+
+	status, body = doRequest(t, app, "GET", "/api/alerts?medicine_id=m1", "")
+	logged, _ := body["data"].([]any)
+	if status != fiber.StatusOK || len(logged) != 1 {
+		t.Fatalf("alert log status = %d body=%v", status, body)
+	}
+	if rec := logged[0].(map[string]any); rec["status"] != "sent" || rec["attempts"] != 1.0 || rec["channel"] != "telegram" {
+		t.Errorf("unexpected alert record: %v", rec)
+	}
+}
@@ -0,0 +1,23 @@
+package server
+
+import (
+	fiber "github.com/gofiber/fiber/v2"
+	"github.com/nomenarkt/vitaltrack/backend/internal/infra/airtable"
+)
+
+// AirtableWebhookPath receives the notifications of the Airtable webhook.
+const AirtableWebhookPath = "/webhooks/airtable"
+
+// RegisterAirtableWebhook adds the route Airtable notifies when stock
+// entries change. Notifications whose signature verify rejects answer 401;
+// the others call notify, which must not block, and answer 204 at once as
+// Airtable expects.
+func RegisterAirtableWebhook(app *fiber.App, verify func(body []byte, mac string) bool, notify func()) {
+	app.Post(AirtableWebhookPath, func(c *fiber.Ctx) error {
+		if !verify(c.Body(), c.Get(airtable.MACHeader)) {
+			return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "invalid signature"})
+		}
+		notify()
+		return c.SendStatus(fiber.StatusNoContent)
+	})
+}
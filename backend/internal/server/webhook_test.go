@@ -0,0 +1,49 @@
+package server_test
+
+import (
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	fiber "github.com/gofiber/fiber/v2"
+	"github.com/nomenarkt/vitaltrack/backend/internal/infra/airtable"
+	"github.com/nomenarkt/vitaltrack/backend/internal/server"
+)
+
+func TestAirtableWebhook(t *testing.T) {
+	notified := 0
+	app := fiber.New()
+	server.RegisterAirtableWebhook(app,
+		func(body []byte, mac string) bool {
+			return string(body) == `{"base":{"id":"app1"}}` && mac == "hmac-sha256=ok"
+		},
+		func() { notified++ })
+
+	tests := []struct {
+		name     string
+		mac      string
+		status   int
+		notified int
+	}{
+		{"signed", "hmac-sha256=ok", fiber.StatusNoContent, 1},
+		{"bad_signature", "hmac-sha256=bad", fiber.StatusUnauthorized, 1},
+		{"unsigned", "", fiber.StatusUnauthorized, 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest("POST", server.AirtableWebhookPath, strings.NewReader(`{"base":{"id":"app1"}}`))
+			req.Header.Set("Content-Type", "application/json")
+			if tt.mac != "" {
+				req.Header.Set(airtable.MACHeader, tt.mac)
+			}
+			res, err := app.Test(req)
+			if err != nil {
+				t.Fatal(err)
+			}
+			_ = res.Body.Close()
+			if res.StatusCode != tt.status || notified != tt.notified {
+				t.Errorf("status = %d, notified %d times; want %d, %d", res.StatusCode, notified, tt.status, tt.notified)
+			}
+		})
+	}
+}
@@ -0,0 +1,198 @@
+// Package testutil holds fakes shared by the tests of several packages.
+package testutil
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/nomenarkt/vitaltrack/backend/internal/domain"
+)
+
+// Store is an in-memory AirtableService, StockDataPort, FinancialDataPort and
+// RecordScanPort. Tests needing other behaviour embed it and override the
+// methods they watch.
+type Store struct {
+	Meds      []domain.Medicine
+	Entries   []domain.StockEntry
+	Financial []domain.FinancialEntry
+	// Forecasts lists the forecasts saved, in order.
+	Forecasts []domain.ForecastUpdate
+
+	nextID int
+}
+
+func (s *Store) id() string {
+	s.nextID++
+	return fmt.Sprintf("rec%d", s.nextID)
+}
+
+// FetchMedicines returns the medicines of s.
+func (s *Store) FetchMedicines(context.Context) ([]domain.Medicine, error) { return s.Meds, nil }
+
+// FetchStockEntries returns the stock entries of s.
+func (s *Store) FetchStockEntries(context.Context) ([]domain.StockEntry, error) {
+	return s.Entries, nil
+}
+
+// FetchFinancialEntries returns the financial entries tagged with the month.
+func (s *Store) FetchFinancialEntries(_ context.Context, year int, month time.Month) ([]domain.FinancialEntry, error) {
+	var out []domain.FinancialEntry
+	for _, e := range s.Financial {
+		if e.MonthTag == fmt.Sprintf("%04d-%02d", year, month) {
+			out = append(out, e)
+		}
+	}
+	return out, nil
+}
+
+// ScanRecords returns the records of s, with the financial entries tagged
+// with the month. Its dates are never invalid.
+func (s *Store) ScanRecords(ctx context.Context, year int, month time.Month) (domain.RecordScan, error) {
+	financial, err := s.FetchFinancialEntries(ctx, year, month)
+	return domain.RecordScan{Medicines: s.Meds, Entries: s.Entries, Financial: financial}, err
+}
+
+// UpdateForecastDate records the forecast of medicineID.
+func (s *Store) UpdateForecastDate(_ context.Context, medicineID string, date, _ time.Time) error {
+	s.Forecasts = append(s.Forecasts, domain.ForecastUpdate{MedicineID: medicineID, Date: date})
+	return nil
+}
+
+// UpdateForecastDates records updates.
+func (s *Store) UpdateForecastDates(_ context.Context, updates []domain.ForecastUpdate, _ time.Time) error {
+	s.Forecasts = append(s.Forecasts, updates...)
+	return nil
+}
+
+// UpdateMedicineLastAlertedDate does nothing.
+func (s *Store) UpdateMedicineLastAlertedDate(context.Context, string, time.Time) error {
+	return nil
+}
+
+// UpdateLastAlertedDates does nothing.
+func (s *Store) UpdateLastAlertedDates(context.Context, []string, time.Time) error {
+	return nil
+}
+
+// GetMedicine returns the medicine id, or domain.ErrNotFound.
+func (s *Store) GetMedicine(_ context.Context, id string) (domain.Medicine, error) {
+	for _, med := range s.Meds {
+		if med.ID == id {
+			return med, nil
+		}
+	}
+	return domain.Medicine{}, domain.ErrNotFound
+}
+
+// CreateMedicine adds med with a new ID.
+func (s *Store) CreateMedicine(_ context.Context, med domain.Medicine) (domain.Medicine, error) {
+	med.ID = s.id()
+	s.Meds = append(s.Meds, med)
+	return med, nil
+}
+
+// UpdateMedicine applies the name, dose, dose times, units and archived
+// fields of p.
+func (s *Store) UpdateMedicine(_ context.Context, id string, p domain.MedicinePatch) (domain.Medicine, error) {
+	for i := range s.Meds {
+		if s.Meds[i].ID != id {
+			continue
+		}
+		if p.Name != nil {
+			s.Meds[i].Name = *p.Name
+		}
+		if p.DailyDose != nil {
+			s.Meds[i].DailyDose = *p.DailyDose
+		}
+		if p.DoseTimes != nil {
+			s.Meds[i].DoseTimes = *p.DoseTimes
+		}
+		if p.Units != nil {
+			s.Meds[i].Units = *p.Units
+		}
+		if p.Archived != nil {
+			s.Meds[i].Archived = *p.Archived
+		}
+		return s.Meds[i], nil
+	}
+	return domain.Medicine{}, domain.ErrNotFound
+}
+
+// CreateStockEntry adds e with a new ID.
+func (s *Store) CreateStockEntry(_ context.Context, e domain.StockEntry) (domain.StockEntry, error) {
+	e.ID = s.id()
+	s.Entries = append(s.Entries, e)
+	return e, nil
+}
+
+// GetStockEntry returns the stock entry id, or domain.ErrNotFound.
+func (s *Store) GetStockEntry(_ context.Context, id string) (domain.StockEntry, error) {
+	for _, e := range s.Entries {
+		if e.ID == id {
+			return e, nil
+		}
+	}
+	return domain.StockEntry{}, domain.ErrNotFound
+}
+
+// UpdateStockEntry applies the kind, quantity, reason and archived fields
+// of p.
+func (s *Store) UpdateStockEntry(_ context.Context, id string, p domain.StockEntryPatch) (domain.StockEntry, error) {
+	for i := range s.Entries {
+		if s.Entries[i].ID != id {
+			continue
+		}
+		if p.Kind != nil {
+			s.Entries[i].Kind = *p.Kind
+		}
+		if p.Quantity != nil {
+			s.Entries[i].Quantity = *p.Quantity
+		}
+		if p.Reason != nil {
+			s.Entries[i].Reason = *p.Reason
+		}
+		if p.Archived != nil {
+			s.Entries[i].Archived = *p.Archived
+		}
+		return s.Entries[i], nil
+	}
+	return domain.StockEntry{}, domain.ErrNotFound
+}
+
+// GetFinancialEntry returns the financial entry id, or domain.ErrNotFound.
+func (s *Store) GetFinancialEntry(_ context.Context, id string) (domain.FinancialEntry, error) {
+	for _, e := range s.Financial {
+		if e.ID == id {
+			return e, nil
+		}
+	}
+	return domain.FinancialEntry{}, domain.ErrNotFound
+}
+
+// CreateFinancialEntry adds e with a new ID.
+func (s *Store) CreateFinancialEntry(_ context.Context, e domain.FinancialEntry) (domain.FinancialEntry, error) {
+	e.ID = s.id()
+	s.Financial = append(s.Financial, e)
+	return e, nil
+}
+
+// UpdateFinancialEntry applies the date, month tag and archived fields of p.
+func (s *Store) UpdateFinancialEntry(_ context.Context, id string, p domain.FinancialEntryPatch) (domain.FinancialEntry, error) {
+	for i := range s.Financial {
+		if s.Financial[i].ID != id {
+			continue
+		}
+		if p.Date != nil {
+			s.Financial[i].Date = *p.Date
+		}
+		if p.MonthTag != nil {
+			s.Financial[i].MonthTag = *p.MonthTag
+		}
+		if p.Archived != nil {
+			s.Financial[i].Archived = *p.Archived
+		}
+		return s.Financial[i], nil
+	}
+	return domain.FinancialEntry{}, domain.ErrNotFound
+}
@@ -0,0 +1,100 @@
+// Package tracing sets up OpenTelemetry tracing and starts the spans
+// recorded around HTTP handlers, bot commands, Airtable calls and job runs.
+package tracing
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// instrumentation names the tracer that records every VitalTrack span.
+const instrumentation = "github.com/nomenarkt/vitaltrack/backend"
+
+// Exporters accepted by Setup.
+const (
+	ExporterNone   = "none"
+	ExporterOTLP   = "otlp"
+	ExporterStdout = "stdout"
+)
+
+// Options configure where spans are exported.
+type Options struct {
+	// Exporter is none, otlp (OTLP over HTTP) or stdout, which writes one
+	// JSON object per span to File, or to standard output when File is empty.
+	Exporter string
+	Endpoint string // OTLP collector URL, http://localhost:4318 when empty
+	File     string
+}
+
+// Setup installs the global tracer provider for opts and the W3C trace
+// context propagator. The returned function flushes the spans still
+// buffered and stops the exporter. With the none exporter spans are not
+// recorded.
+func Setup(ctx context.Context, opts Options) (shutdown func(context.Context) error, err error) {
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+
+	var exp sdktrace.SpanExporter
+	var closer io.Closer
+	switch opts.Exporter {
+	case "", ExporterNone:
+		return func(context.Context) error { return nil }, nil
+	case ExporterOTLP:
+		var o []otlptracehttp.Option
+		if opts.Endpoint != "" {
+			o = append(o, otlptracehttp.WithEndpointURL(opts.Endpoint))
+		}
+		exp, err = otlptracehttp.New(ctx, o...)
+	case ExporterStdout:
+		var w io.Writer = os.Stdout
+		if opts.File != "" {
+			f, ferr := os.OpenFile(opts.File, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+			if ferr != nil {
+				return nil, fmt.Errorf("open trace file: %w", ferr)
+			}
+			w, closer = f, f
+		}
+		exp, err = stdouttrace.New(stdouttrace.WithWriter(w))
+	default:
+		return nil, fmt.Errorf("unknown trace exporter %q", opts.Exporter)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("create %s trace exporter: %w", opts.Exporter, err)
+	}
+
+	tp := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exp),
+		sdktrace.WithResource(resource.NewSchemaless(attribute.String("service.name", "vitaltrack"))),
+	)
+	otel.SetTracerProvider(tp)
+	return func(ctx context.Context) error {
+		err := tp.Shutdown(ctx)
+		if closer != nil {
+			err = errors.Join(err, closer.Close())
+		}
+		return err
+	}, nil
+}
+
+// Start starts a span named name as a child of the span in ctx, if any.
+func Start(ctx context.Context, name string, opts ...trace.SpanStartOption) (context.Context, trace.Span) {
+	return otel.Tracer(instrumentation).Start(ctx, name, opts...)
+}
+
+// Fail records err on span and marks the span as failed.
+func Fail(span trace.Span, err error) {
+	span.RecordError(err)
+	span.SetStatus(codes.Error, err.Error())
+}
@@ -0,0 +1,88 @@
+package tracing_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"go.opentelemetry.io/otel"
+
+	"github.com/nomenarkt/vitaltrack/backend/internal/tracing"
+)
+
+func TestSetup_stdoutFile(t *testing.T) {
+	prev := otel.GetTracerProvider()
+	t.Cleanup(func() { otel.SetTracerProvider(prev) })
+
+	path := filepath.Join(t.TempDir(), "spans.json")
+	shutdown, err := tracing.Setup(context.Background(), tracing.Options{Exporter: tracing.ExporterStdout, File: path})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, parent := tracing.Start(context.Background(), "finance report")
+	_, child := tracing.Start(ctx, "airtable GET fin")
+	tracing.Fail(child, errors.New("503 Service Unavailable"))
+	child.End()
+	parent.End()
+	if err := shutdown(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	b, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	type span struct {
+		Name        string
+		SpanContext struct{ TraceID string }
+		Parent      struct{ SpanID string }
+		Status      struct{ Code string }
+	}
+	var spans []span
+	for _, line := range strings.Split(strings.TrimSpace(string(b)), "\n") {
+		var s span
+		if err := json.Unmarshal([]byte(line), &s); err != nil {
+			t.Fatalf("span is not a JSON line: %v\n%s", err, line)
+		}
+		spans = append(spans, s)
+	}
+	if len(spans) != 2 || spans[0].Name != "airtable GET fin" || spans[1].Name != "finance report" {
+		t.Fatalf("spans = %+v", spans)
+	}
+	if spans[0].SpanContext.TraceID != spans[1].SpanContext.TraceID {
+		t.Error("child span is in another trace")
+	}
+	if spans[0].Status.Code != "Error" {
+		t.Errorf("failed span status = %q", spans[0].Status.Code)
+	}
+}
+
+func TestSetup_exporters(t *testing.T) {
+	prev := otel.GetTracerProvider()
+	t.Cleanup(func() { otel.SetTracerProvider(prev) })
+
+	tests := []struct {
+		exporter string
+		wantErr  bool
+	}{
+		{"", false},
+		{tracing.ExporterNone, false},
+		{tracing.ExporterOTLP, false},
+		{"jaeger", true},
+	}
+	for _, tt := range tests {
+		shutdown, err := tracing.Setup(context.Background(), tracing.Options{Exporter: tt.exporter, Endpoint: "http://127.0.0.1:1"})
+		if (err != nil) != tt.wantErr {
+			t.Errorf("Setup(%q) error = %v", tt.exporter, err)
+			continue
+		}
+		if err == nil {
+			_ = shutdown(context.Background())
+		}
+	}
+}
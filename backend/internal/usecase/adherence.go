@@ -0,0 +1,174 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/nomenarkt/vitaltrack/backend/internal/domain"
+	"github.com/nomenarkt/vitaltrack/backend/internal/domain/ports"
+	"github.com/nomenarkt/vitaltrack/backend/internal/logger"
+	"github.com/nomenarkt/vitaltrack/backend/internal/logic/adherence"
+	"github.com/nomenarkt/vitaltrack/backend/internal/logic/calendar"
+	"github.com/nomenarkt/vitaltrack/backend/internal/util/i18n"
+)
+
+// ErrDoseNotFound is returned when a dose event ID does not exist.
+var ErrDoseNotFound = errors.New("dose not found")
+
+// AdherenceService sends dose reminders and records whether doses were
+// taken.
+type AdherenceService struct {
+	Airtable ports.StockDataPort
+	Events   ports.AdherencePort
+	Sender   ports.PromptSender
+	Chat     string              // chat that receives reminders
+	Locale   func() i18n.Printer // English when nil
+	Location *time.Location      // household timezone, UTC when nil
+	Logger   logger.Logger       // discards when nil
+}
+
+func (s AdherenceService) printer() i18n.Printer {
+	if s.Locale == nil {
+		return i18n.For(i18n.Default)
+	}
+	return s.Locale()
+}
+
+// ReminderRetryWindow is how long after its time a dose whose reminder
+// could not be sent is retried.
+const ReminderRetryWindow = time.Hour
+
+// SendDueReminders sends a reminder with Taken and Skipped buttons for every
+// dose scheduled at the minute of at. Each dose is logged as pending first,
+// so it is reminded once even if the job runs twice, and marked sent to
+// s.Chat once its reminder is delivered. Reminders not delivered within
+// ReminderRetryWindow of their dose are sent again.
+func (s AdherenceService) SendDueReminders(ctx context.Context, at time.Time) error {
+	meds, err := s.Airtable.FetchMedicines(ctx)
+	if err != nil {
+		return fmt.Errorf("fetch medicines failed: %w", err)
+	}
+
+	now := calendar.In(at, s.Location)
+	unsent, err := s.Events.FetchDoseEvents(ctx, now.Add(-ReminderRetryWindow))
+	if err != nil {
+		return fmt.Errorf("fetch dose events failed: %w", err)
+	}
+	unsent = slices.DeleteFunc(unsent, func(ev domain.DoseEvent) bool {
+		return ev.Status != domain.DosePending || ev.ChatID != "" || ev.Scheduled.After(now)
+	})
+
+	var errs []error
+	for _, d := range adherence.Due(meds, now) {
+		key := d.Key()
+		if _, err := s.Events.FindDoseEvent(ctx, key); err == nil {
+			continue
+		} else if !errors.Is(err, domain.ErrNotFound) {
+			errs = append(errs, fmt.Errorf("find dose %s: %w", key, err))
+			continue
+		}
+
+		ev, err := s.Events.CreateDoseEvent(ctx, domain.DoseEvent{
+			Key:          key,
+			MedicineID:   d.Medicine.ID,
+			MedicineName: d.Medicine.Name,
+			Patient:      d.Medicine.Patient,
+			Scheduled:    d.Scheduled,
+			Pills:        d.Pills,
+			Status:       domain.DosePending,
+			CreatedAt:    time.Now().UTC(),
+		})
+		if err != nil {
+			errs = append(errs, fmt.Errorf("log dose %s: %w", key, err))
+			continue
+		}
+		unsent = append(unsent, ev)
+	}
+
+	p := s.printer()
+	for _, ev := range unsent {
+		if err := s.remind(ctx, p, ev); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// remind sends the reminder of ev to s.Chat and records it as sent.
+func (s AdherenceService) remind(ctx context.Context, p i18n.Printer, ev domain.DoseEvent) error {
+	msg := adherence.ReminderMessage(p, ev, s.Location)
+	if err := s.Sender.SendWithButtons(ctx, s.Chat, msg, adherence.ReminderButtons(p, ev.ID)); err != nil {
+		return fmt.Errorf("send reminder %s: %w", ev.Key, err)
+	}
+	if _, err := s.Events.UpdateDoseEvent(ctx, ev.ID, domain.DoseEventPatch{ChatID: s.Chat}); err != nil {
+		return fmt.Errorf("record reminder %s sent: %w", ev.Key, err)
+	}
+	logger.OrNop(s.Logger).Info(ctx, "dose reminder sent", "medicine_id", ev.MedicineID, "scheduled", calendar.In(ev.Scheduled, s.Location).Format("15:04"))
+	return nil
+}
+
+// Respond records the answer to the reminder for event id. A later answer
+// replaces an earlier one.
+func (s AdherenceService) Respond(ctx context.Context, id string, status domain.DoseStatus, at time.Time) (domain.DoseEvent, error) {
+	if status != domain.DoseTaken && status != domain.DoseSkipped {
+		return domain.DoseEvent{}, fmt.Errorf("%w: status must be taken or skipped", ErrInvalidInput)
+	}
+	ev, err := s.Events.UpdateDoseEvent(ctx, id, domain.DoseEventPatch{Status: status, RespondedAt: at.UTC()})
+	if errors.Is(err, domain.ErrNotFound) {
+		return domain.DoseEvent{}, ErrDoseNotFound
+	}
+	if err != nil {
+		return domain.DoseEvent{}, fmt.Errorf("record dose failed: %w", err)
+	}
+	return ev, nil
+}
+
+// WeeklyReport summarizes adherence per patient for the last weeks weeks,
+// including the current one.
+func (s AdherenceService) WeeklyReport(ctx context.Context, weeks int, now time.Time) ([]domain.AdherenceWeek, error) {
+	if weeks <= 0 {
+		return nil, fmt.Errorf("%w: weeks must be positive", ErrInvalidInput)
+	}
+	since := adherence.WeekStart(now, s.Location).AddDate(0, 0, -7*(weeks-1))
+	events, err := s.Events.FetchDoseEvents(ctx, since)
+	if err != nil {
+		return nil, fmt.Errorf("fetch dose events failed: %w", err)
+	}
+	return adherence.Weekly(events, s.Location), nil
+}
+
+// StockStore is the medicine and stock storage used by stock calculations
+// and alerts.
+type StockStore interface {
+	ports.StockDataPort
+	ports.AirtableService
+}
+
+// SkippedDoseStock is a StockStore whose medicines carry the pills of their
+// skipped doses, so stock calculations do not count doses that were never
+// taken. Every read fetches the whole history from Events, which should be
+// cached, e.g. by cache.Stock.DoseLog.
+type SkippedDoseStock struct {
+	StockStore
+	Events ports.AdherencePort
+}
+
+// FetchMedicines returns the medicines with SkippedPills filled in.
+func (s SkippedDoseStock) FetchMedicines(ctx context.Context) ([]domain.Medicine, error) {
+	meds, err := s.StockStore.FetchMedicines(ctx)
+	if err != nil {
+		return nil, err
+	}
+	events, err := s.Events.FetchDoseEvents(ctx, time.Time{})
+	if err != nil {
+		return nil, fmt.Errorf("fetch dose events failed: %w", err)
+	}
+	skipped := adherence.SkippedPills(events)
+	for i := range meds {
+		meds[i].SkippedPills = skipped[meds[i].ID]
+	}
+	return meds, nil
+}
@@ -0,0 +1,186 @@
+package usecase_test
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/nomenarkt/vitaltrack/backend/internal/domain"
+	"github.com/nomenarkt/vitaltrack/backend/internal/infra/memstore"
+	"github.com/nomenarkt/vitaltrack/backend/internal/testutil"
+	"github.com/nomenarkt/vitaltrack/backend/internal/usecase"
+	"github.com/nomenarkt/vitaltrack/backend/internal/util/richtext"
+)
+
+// promptSender records reminders and the buttons sent with them.
+type promptSender struct {
+	sent    []string
+	buttons [][]domain.ReplyButton
+}
+
+func (s *promptSender) SendWithButtons(_ context.Context, _ string, msg richtext.Doc, buttons []domain.ReplyButton) error {
+	s.sent = append(s.sent, richtext.Plain(msg))
+	s.buttons = append(s.buttons, buttons)
+	return nil
+}
+
+func TestAdherenceService_SendDueReminders(t *testing.T) {
+	repo := &testutil.Store{Meds: []domain.Medicine{
+		{ID: "m1", Name: "Aspirin", Patient: "Mum", DailyDose: 2, DoseTimes: domain.DoseTimes{"08:00", "20:00"}},
+		{ID: "m2", Name: "Vitamin D", DailyDose: 1, DoseTimes: domain.DoseTimes{"09:00"}},
+	}}
+	doses := memstore.NewDoseLog()
+	sender := &promptSender{}
+	svc := usecase.AdherenceService{Airtable: repo, Events: doses, Sender: sender, Chat: "1"}
+
+	at := time.Date(2025, 6, 5, 8, 0, 0, 0, time.UTC)
+	if err := svc.SendDueReminders(context.Background(), at); err != nil {
+		t.Fatal(err)
+	}
+	// A second run in the same minute does not remind again.
+	if err := svc.SendDueReminders(context.Background(), at.Add(20*time.Second)); err != nil {
+		t.Fatal(err)
+	}
+	if len(sender.sent) != 1 {
+		t.Fatalf("sent %d reminders, want 1: %v", len(sender.sent), sender.sent)
+	}
+	if !strings.Contains(sender.sent[0], "Aspirin") || !strings.Contains(sender.sent[0], "Mum") {
+		t.Errorf("reminder = %q", sender.sent[0])
+	}
+
+	ev, err := doses.FindDoseEvent(context.Background(), "dose:m1:2025-06-05T08:00")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ev.Status != domain.DosePending || ev.Pills != 1 || ev.ChatID != "1" {
+		t.Errorf("logged event = %+v", ev)
+	}
+	if b := sender.buttons[0]; len(b) != 2 || b[0].Data != "dose:"+ev.ID+":taken" {
+		t.Errorf("buttons = %+v", b)
+	}
+}
+
+// failingSender fails its first fail sends.
+type failingSender struct {
+	promptSender
+	fail int
+}
+
+func (s *failingSender) SendWithButtons(ctx context.Context, chat string, msg richtext.Doc, buttons []domain.ReplyButton) error {
+	if s.fail > 0 {
+		s.fail--
+		return errors.New("telegram unavailable")
+	}
+	return s.promptSender.SendWithButtons(ctx, chat, msg, buttons)
+}
+
+func TestAdherenceService_SendDueReminders_retried(t *testing.T) {
+	repo := &testutil.Store{Meds: []domain.Medicine{
+		{ID: "m1", Name: "Aspirin", DailyDose: 1, DoseTimes: domain.DoseTimes{"08:00"}},
+	}}
+	doses := memstore.NewDoseLog()
+	sender := &failingSender{fail: 1}
+	svc := usecase.AdherenceService{Airtable: repo, Events: doses, Sender: sender, Chat: "1"}
+
+	at := time.Date(2025, 6, 5, 8, 0, 0, 0, time.UTC)
+	if err := svc.SendDueReminders(context.Background(), at); err == nil {
+		t.Fatal("failed send not reported")
+	}
+	ev, err := doses.FindDoseEvent(context.Background(), "dose:m1:2025-06-05T08:00")
+	if err != nil || ev.ChatID != "" {
+		t.Fatalf("event after failed send = %+v, %v; want logged, not sent", ev, err)
+	}
+
+	for _, next := range []time.Time{at.Add(time.Minute), at.Add(2 * time.Minute)} {
+		if err := svc.SendDueReminders(context.Background(), next); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if len(sender.sent) != 1 || !strings.Contains(sender.sent[0], "Aspirin") {
+		t.Fatalf("sent %v, want the reminder once", sender.sent)
+	}
+	if ev, _ := doses.FindDoseEvent(context.Background(), ev.Key); ev.ChatID != "1" || sender.buttons[0][0].Data != "dose:"+ev.ID+":taken" {
+		t.Errorf("event after retry = %+v, buttons %+v", ev, sender.buttons[0])
+	}
+
+	late := &failingSender{}
+	svc.Sender = late
+	stale, _ := doses.CreateDoseEvent(context.Background(), domain.DoseEvent{Key: "dose:m1:2025-06-05T06:00", MedicineID: "m1", Scheduled: at.Add(-2 * time.Hour), Status: domain.DosePending})
+	if err := svc.SendDueReminders(context.Background(), at.Add(3*time.Minute)); err != nil {
+		t.Fatal(err)
+	}
+	if len(late.sent) != 0 {
+		t.Errorf("reminder %s resent after ReminderRetryWindow: %v", stale.Key, late.sent)
+	}
+}
+
+func TestAdherenceService_Respond(t *testing.T) {
+	doses := memstore.NewDoseLog()
+	ev, _ := doses.CreateDoseEvent(context.Background(), domain.DoseEvent{Key: "dose:m1:2025-06-05T08:00", MedicineID: "m1", Status: domain.DosePending})
+	svc := usecase.AdherenceService{Events: doses}
+	at := time.Date(2025, 6, 5, 8, 5, 0, 0, time.UTC)
+
+	got, err := svc.Respond(context.Background(), ev.ID, domain.DoseSkipped, at)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.Status != domain.DoseSkipped || !got.RespondedAt.Equal(at) {
+		t.Errorf("Respond() = %+v", got)
+	}
+
+	if _, err := svc.Respond(context.Background(), ev.ID, domain.DosePending, at); !errors.Is(err, usecase.ErrInvalidInput) {
+		t.Errorf("pending answer err = %v, want ErrInvalidInput", err)
+	}
+	if _, err := svc.Respond(context.Background(), "dose99", domain.DoseTaken, at); !errors.Is(err, usecase.ErrDoseNotFound) {
+		t.Errorf("unknown dose err = %v, want ErrDoseNotFound", err)
+	}
+}
+
+func TestAdherenceService_WeeklyReport(t *testing.T) {
+	doses := memstore.NewDoseLog()
+	for _, e := range []domain.DoseEvent{
+		{Key: "a", Patient: "Mum", Scheduled: time.Date(2025, 5, 20, 8, 0, 0, 0, time.UTC), Status: domain.DoseTaken},
+		{Key: "b", Patient: "Mum", Scheduled: time.Date(2025, 6, 3, 8, 0, 0, 0, time.UTC), Status: domain.DoseTaken},
+		{Key: "c", Patient: "Mum", Scheduled: time.Date(2025, 6, 4, 8, 0, 0, 0, time.UTC), Status: domain.DoseSkipped},
+	} {
+		if _, err := doses.CreateDoseEvent(context.Background(), e); err != nil {
+			t.Fatal(err)
+		}
+	}
+	svc := usecase.AdherenceService{Events: doses}
+
+	weeks, err := svc.WeeklyReport(context.Background(), 2, time.Date(2025, 6, 5, 12, 0, 0, 0, time.UTC))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(weeks) != 1 || weeks[0].Scheduled != 2 || weeks[0].Percent != 50 {
+		t.Errorf("WeeklyReport() = %+v, want one week at 50%%", weeks)
+	}
+	if _, err := svc.WeeklyReport(context.Background(), 0, time.Now()); !errors.Is(err, usecase.ErrInvalidInput) {
+		t.Errorf("zero weeks err = %v, want ErrInvalidInput", err)
+	}
+}
+
+func TestSkippedDoseStock(t *testing.T) {
+	repo := &testutil.Store{Meds: []domain.Medicine{{ID: "m1", Name: "Aspirin"}, {ID: "m2", Name: "Vitamin D"}}}
+	doses := memstore.NewDoseLog()
+	for _, e := range []domain.DoseEvent{
+		{Key: "a", MedicineID: "m1", Pills: 1, Status: domain.DoseSkipped},
+		{Key: "b", MedicineID: "m1", Pills: 1, Status: domain.DoseSkipped},
+		{Key: "c", MedicineID: "m2", Pills: 1, Status: domain.DoseTaken},
+	} {
+		if _, err := doses.CreateDoseEvent(context.Background(), e); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	meds, err := usecase.SkippedDoseStock{StockStore: repo, Events: doses}.FetchMedicines(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if meds[0].SkippedPills != 2 || meds[1].SkippedPills != 0 {
+		t.Errorf("skipped pills = %v, %v; want 2, 0", meds[0].SkippedPills, meds[1].SkippedPills)
+	}
+}
@@ -2,156 +2,261 @@
 package usecase
 
 import (
+	"context"
 	"fmt"
-	"log"
-	"strings"
+	"slices"
 	"time"
 
 	"github.com/nomenarkt/vitaltrack/backend/internal/domain"
 	"github.com/nomenarkt/vitaltrack/backend/internal/domain/ports"
+	"github.com/nomenarkt/vitaltrack/backend/internal/logger"
+	"github.com/nomenarkt/vitaltrack/backend/internal/logic/calendar"
 	"github.com/nomenarkt/vitaltrack/backend/internal/logic/forecast"
 	"github.com/nomenarkt/vitaltrack/backend/internal/logic/stockcalc"
-	"github.com/nomenarkt/vitaltrack/backend/internal/util"
+	"github.com/nomenarkt/vitaltrack/backend/internal/logic/units"
+	"github.com/nomenarkt/vitaltrack/backend/internal/util/i18n"
+	"github.com/nomenarkt/vitaltrack/backend/internal/util/richtext"
 )
 
+// LowStockThresholdDays is how close to running out a medicine must be
+// before the low-stock rule fires.
+const LowStockThresholdDays = 10
+
 // StockChecker handles alerting when stock is near depletion.
 type StockChecker struct {
 	Airtable ports.AirtableService
 	Telegram ports.TelegramService
+	Alerts   ports.AlertLogPort  // optional delivery log used for dedupe
+	Locale   func() i18n.Printer // language of the alert chat, English when nil
+	Location *time.Location      // household timezone for day boundaries, UTC when nil
+	Logger   logger.Logger       // discards when nil
+}
+
+// AlertKind identifies the rule that produced an alert.
+type AlertKind string
+
+// Alert rule kinds.
+const (
+	AlertLowStock AlertKind = "low_stock"
+	AlertRefill   AlertKind = "refill"
+)
+
+// Alert is a notification produced by an alert rule.
+type Alert struct {
+	Kind         AlertKind
+	Key          string // dedupe key in the alert log
+	MedicineID   string
+	MedicineName string
+	Message      richtext.Doc
+	Deduplicated bool // already delivered, will not fire again
+	Sent         bool
+	Queued       bool // handed to the outbox, delivered in the background
+}
+
+func (s *StockChecker) notifier() AlertNotifier {
+	return AlertNotifier{Log: s.Alerts, Telegram: s.Telegram, Logger: s.Logger}
+}
+
+func (s *StockChecker) printer() i18n.Printer {
+	if s.Locale == nil {
+		return i18n.For(i18n.Default)
+	}
+	return s.Locale()
 }
 
 // CheckAndAlertLowStock scans medicines and alerts if 10 days from out-of-stock.
-func (s *StockChecker) CheckAndAlertLowStock() error {
-	now := time.Now().UTC()
-	log.Printf("📡 Starting CheckAndAlertLowStock...")
+func (s *StockChecker) CheckAndAlertLowStock(ctx context.Context) error {
+	_, err := s.EvaluateAlerts(ctx, time.Now(), false)
+	return err
+}
 
-	meds, err := s.Airtable.FetchMedicines()
+// EvaluateAlerts runs the low-stock and refill rules at now and returns every
+// alert they produce. Alerts already delivered are returned with Deduplicated
+// set. Unless dryRun is set, the remaining alerts are sent to Telegram and
+// recorded in the alert log. Days are counted in the household timezone.
+func (s *StockChecker) EvaluateAlerts(ctx context.Context, now time.Time, dryRun bool) ([]Alert, error) {
+	lg := logger.OrNop(s.Logger)
+	lg.Info(ctx, "evaluating alerts", "dry_run", dryRun)
+	now = calendar.In(now, s.Location)
+	today := calendar.Day(now, s.Location)
+
+	meds, err := s.Airtable.FetchMedicines(ctx)
 	if err != nil {
-		return fmt.Errorf("fetch medicines failed: %w", err)
+		return nil, fmt.Errorf("fetch medicines failed: %w", err)
 	}
-	log.Printf("📋 Fetched %d medicines", len(meds))
 
-	entries, err := s.Airtable.FetchStockEntries()
+	entries, err := s.Airtable.FetchStockEntries(ctx)
 	if err != nil {
-		return fmt.Errorf("fetch stock entries failed: %w", err)
+		return nil, fmt.Errorf("fetch stock entries failed: %w", err)
 	}
-	log.Printf("📦 Fetched %d stock entries", len(entries))
+	lg.Debug(ctx, "stock data fetched", "medicines", len(meds), "entries", len(entries))
 
+	n := s.notifier()
+	p := s.printer()
+	var alerts []Alert
+	var alerted []string // medicines delivered an alert, whose last alert date is saved
 	for _, m := range meds {
+		if m.Archived {
+			continue
+		}
 		stock := stockcalc.CurrentStockAt(m, entries, now)
 		if stock <= 0 || m.DailyDose == 0 {
 			continue
 		}
 
 		forecastDate := stockcalc.OutOfStockDateAt(m, stock, now)
-		daysLeft := int(forecastDate.Truncate(24*time.Hour).Sub(now.Truncate(24*time.Hour)).Hours() / 24)
-
-		log.Printf("🔍 %s: stock=%.2f, forecast=%s, daysLeft=%d", m.Name, stock, forecastDate.Format("2006-01-02"), daysLeft)
-		log.Printf("🧪 Candidate: %s - daysLeft=%d (threshold=10)", m.Name, daysLeft)
-		log.Printf("🧾 Stock: %.2f, DailyDose: %.2f", stock, m.DailyDose)
-
-		if daysLeft <= 10 {
-			// 🔬 DEBUG: Log the existing LastAlertedDate
-			if m.LastAlertedDate != nil {
-				log.Printf("🔬 LastAlertedDate for %s = %s", m.Name, m.LastAlertedDate.Format("2006-01-02"))
-			} else {
-				log.Printf("🔬 LastAlertedDate for %s is nil", m.Name)
-			}
+		daysLeft := calendar.Days(today, forecastDate)
 
-			log.Printf("🔬 LastAlertedDate for %s: %v", m.Name, m.LastAlertedDate)
+		lg.Debug(ctx, "stock forecast", "medicine_id", m.ID, "stock", stock, "forecast", forecastDate.Format("2006-01-02"), "days_left", daysLeft)
 
-			if m.LastAlertedDate != nil && m.LastAlertedDate.Format("2006-01-02") == now.Format("2006-01-02") {
-				log.Printf("ℹ️ Already alerted for %s today, skipping.", m.Name)
-				continue
-			}
+		if daysLeft > LowStockThresholdDays {
+			continue
+		}
 
-			alert := fmt.Sprintf(
-				"*%s* will run out in %d day(s)\\!\nRefill before *%s*\nCurrently: *%.2f* pills left\\.",
-				util.EscapeMarkdown(m.Name),
-				daysLeft,
-				forecastDate.Format("2006-01-02"),
-				stock,
-			)
-
-			log.Printf("📲 Sending alert for %s", m.Name)
-			if err := s.Telegram.SendTelegramMessage(alert); err != nil {
-				log.Printf("❌ Telegram send failed: %v", err)
-			} else {
-				log.Printf("✅ Telegram message sent")
-			}
+		alert := Alert{
+			Kind:         AlertLowStock,
+			Key:          LowStockAlertKey(m.ID, now),
+			MedicineID:   m.ID,
+			MedicineName: m.Name,
+			Message: richtext.New(richtext.P(
+				p.Rich("alert.low_stock", m.Name, daysLeft, p.Date(forecastDate), p.Number(stock, 2), units.Label(p, units.Base(m)))...,
+			)),
+		}
+
+		if m.LastAlertedDate != nil && calendar.Date(m.LastAlertedDate.Time, s.Location).Equal(today) {
+			lg.Info(ctx, "already alerted today, skipping", "medicine_id", m.ID)
+			alert.Deduplicated = true
+			alerts = append(alerts, alert)
+			continue
+		}
 
-			log.Printf("🧪 Calling UpdateMedicineLastAlertedDate for recordID=%s", m.ID)
-			if err := s.Airtable.UpdateMedicineLastAlertedDate(m.ID, now); err != nil {
-				log.Printf("⚠️ Failed to update LastAlertedDate for %s: %v", m.Name, err)
+		if dryRun {
+			if alert.Deduplicated, err = n.Delivered(ctx, alert.Key, now); err != nil {
+				return nil, err
 			}
+			alerts = append(alerts, alert)
+			continue
+		}
 
+		if alert, err = n.Notify(ctx, alert, now); err != nil {
+			return nil, err
+		}
+		// A queued alert may still be dead-lettered: the alert log, not
+		// LastAlertedDate, keeps it from being sent twice until the outbox
+		// reports its delivery.
+		if alert.Sent {
+			alerted = append(alerted, m.ID)
+		}
+		alerts = append(alerts, alert)
+	}
+	if len(alerted) > 0 {
+		if err := s.Airtable.UpdateLastAlertedDates(ctx, alerted, now); err != nil {
+			lg.Warn(ctx, "failed to update last_alerted_date", "medicines", len(alerted), "error", err)
 		}
 	}
 
 	// 👇 Refill notification logic
-	refillsToday := map[string][]domain.StockEntry{}
-	for _, entry := range entries {
-		if len(entry.MedicineID) == 0 {
-			continue
-		}
-		if entry.Date.UTC().Format("2006-01-02") == now.Format("2006-01-02") {
-			refillsToday[entry.MedicineID[0]] = append(refillsToday[entry.MedicineID[0]], entry)
+	medByID := make(map[string]domain.Medicine, len(meds))
+	for _, m := range meds {
+		if !m.Archived {
+			medByID[m.ID] = m
 		}
 	}
-
-	for medID, todayEntries := range refillsToday {
-		var med *domain.Medicine
-		for i, m := range meds {
-			if m.ID == medID {
-				med = &meds[i]
-				break
-			}
+	for _, e := range entries {
+		if len(e.MedicineID) == 0 || !e.IsRefill() || e.Archived {
+			continue
 		}
-		if med == nil {
+		if e.Date.IsZero() || !calendar.Date(e.Date.Time, s.Location).Equal(today) {
 			continue
 		}
-
-		var lines []string
-		for _, e := range todayEntries {
-			lines = append(lines,
-				fmt.Sprintf("• %.2f %s on %s",
-					e.Quantity,
-					util.EscapeMarkdown(e.Unit),
-					e.Date.Format("2006-01-02")),
-			)
+		med, ok := medByID[e.MedicineID[0]]
+		if !ok {
+			continue
 		}
 
-		msg := fmt.Sprintf(
-			"*Refill recorded for %s*\\:\n%s",
-			util.EscapeMarkdown(med.Name),
-			strings.Join(lines, "\n"),
-		)
+		alert := s.refillAlert(p, med, e)
 
-		log.Printf("📲 Notifying refill for %s", med.Name)
-		if err := s.Telegram.SendTelegramMessage(msg); err != nil {
-			log.Printf("❌ Refill Telegram send failed: %v", err)
+		if dryRun {
+			if alert.Deduplicated, err = n.Delivered(ctx, alert.Key, now); err != nil {
+				return nil, err
+			}
 		} else {
-			log.Printf("✅ Refill message sent for %s", med.Name)
+			if alert, err = n.Notify(ctx, alert, now); err != nil {
+				return nil, err
+			}
 		}
+		alerts = append(alerts, alert)
 	}
-	return nil
+	return alerts, nil
 }
 
 // OutOfStockService wraps forecast generation logic.
 type OutOfStockService struct {
 	Airtable ports.StockDataPort
+	Locale   func() i18n.Printer // English when nil
+	Location *time.Location      // household timezone, UTC when nil
+	Logger   logger.Logger       // discards when nil
 }
 
 // GenerateOutOfStockForecastMessage returns a summary of stock depletion.
-func (s OutOfStockService) GenerateOutOfStockForecastMessage() (string, error) {
-	meds, err := s.Airtable.FetchMedicines()
+// Forecast dates are not saved; see SyncForecastDates.
+func (s OutOfStockService) GenerateOutOfStockForecastMessage(ctx context.Context) (richtext.Doc, error) {
+	meds, entries, err := s.fetch(ctx)
 	if err != nil {
-		return "", fmt.Errorf("fetch medicines failed: %w", err)
+		return nil, err
+	}
+
+	p := i18n.For(i18n.Default)
+	if s.Locale != nil {
+		p = s.Locale()
 	}
-	entries, err := s.Airtable.FetchStockEntries()
+	return forecast.GenerateOutOfStockForecastMessage(p, meds, entries, calendar.In(time.Now(), s.Location)), nil
+}
+
+// SyncForecastDates recomputes the out-of-stock dates as of now and saves
+// the ones that changed, in batches. It returns how many were saved.
+func (s OutOfStockService) SyncForecastDates(ctx context.Context, now time.Time) (int, error) {
+	return s.syncForecasts(ctx, now, func(string) bool { return true })
+}
+
+// SyncForecastDatesOf is SyncForecastDates for the medicines in medicineIDs
+// only.
+func (s OutOfStockService) SyncForecastDatesOf(ctx context.Context, now time.Time, medicineIDs []string) (int, error) {
+	return s.syncForecasts(ctx, now, func(id string) bool { return slices.Contains(medicineIDs, id) })
+}
+
+func (s OutOfStockService) syncForecasts(ctx context.Context, now time.Time, include func(medicineID string) bool) (int, error) {
+	meds, entries, err := s.fetch(ctx)
 	if err != nil {
-		return "", fmt.Errorf("fetch stock entries failed: %w", err)
+		return 0, err
+	}
+	var included []domain.Medicine
+	for _, m := range meds {
+		if include(m.ID) {
+			included = append(included, m)
+		}
+	}
+
+	now = calendar.In(now, s.Location)
+	updates := forecast.Updates(forecast.Compute(included, entries, now))
+	if len(updates) == 0 {
+		return 0, nil
+	}
+	if err := s.Airtable.UpdateForecastDates(ctx, updates, now); err != nil {
+		return 0, fmt.Errorf("save forecast dates failed: %w", err)
 	}
+	logger.OrNop(s.Logger).Info(ctx, "forecast dates saved", "medicines", len(updates))
+	return len(updates), nil
+}
 
-	return forecast.GenerateOutOfStockForecastMessage(meds, entries, time.Now().UTC(), s.Airtable), nil
+func (s OutOfStockService) fetch(ctx context.Context) ([]domain.Medicine, []domain.StockEntry, error) {
+	meds, err := s.Airtable.FetchMedicines(ctx)
+	if err != nil {
+		return nil, nil, fmt.Errorf("fetch medicines failed: %w", err)
+	}
+	entries, err := s.Airtable.FetchStockEntries(ctx)
+	if err != nil {
+		return nil, nil, fmt.Errorf("fetch stock entries failed: %w", err)
+	}
+	return meds, entries, nil
 }
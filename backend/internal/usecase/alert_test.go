@@ -2,47 +2,47 @@ package usecase_test
 
 import (
 	"bytes"
-	"log"
+	"context"
 	"strings"
 	"testing"
 	"time"
+	_ "time/tzdata"
 
 	"github.com/nomenarkt/vitaltrack/backend/internal/domain"
+	"github.com/nomenarkt/vitaltrack/backend/internal/logger"
+	"github.com/nomenarkt/vitaltrack/backend/internal/testutil"
 	"github.com/nomenarkt/vitaltrack/backend/internal/usecase"
+	"github.com/nomenarkt/vitaltrack/backend/internal/util/i18n"
+	"github.com/nomenarkt/vitaltrack/backend/internal/util/richtext"
 )
 
 type mockAirtable struct {
-	meds        []domain.Medicine
-	entries     []domain.StockEntry
-	updatedID   string
+	testutil.Store
+	updatedIDs  []string
 	updatedDate time.Time
+	batches     int
 }
 
-func (m *mockAirtable) FetchMedicines() ([]domain.Medicine, error) {
-	return m.meds, nil
+func (m *mockAirtable) UpdateMedicineLastAlertedDate(_ context.Context, medicineID string, date time.Time) error {
+	return m.UpdateLastAlertedDates(context.Background(), []string{medicineID}, date)
 }
-func (m *mockAirtable) FetchStockEntries() ([]domain.StockEntry, error) {
-	return m.entries, nil
-}
-func (m *mockAirtable) UpdateMedicineLastAlertedDate(medicineID string, date time.Time) error {
-	m.updatedID = medicineID
+func (m *mockAirtable) UpdateLastAlertedDates(_ context.Context, medicineIDs []string, date time.Time) error {
+	m.updatedIDs = append(m.updatedIDs, medicineIDs...)
 	m.updatedDate = date
+	m.batches++
 	return nil
 }
-func (m *mockAirtable) FetchFinancialEntries(int, time.Month) ([]domain.FinancialEntry, error) {
-	return nil, nil
-}
 
 type mockTelegram struct {
 	sent []string
 }
 
-func (m *mockTelegram) SendTelegramMessage(msg string) error {
-	m.sent = append(m.sent, msg)
+func (m *mockTelegram) SendTelegramMessage(_ context.Context, msg richtext.Doc) error {
+	m.sent = append(m.sent, richtext.MarkdownV2(msg))
 	return nil
 }
 
-func (m *mockTelegram) PollForCommands(_ func() ([]domain.Medicine, []domain.StockEntry, error), _ func(int, int) (domain.MonthlyFinancialReport, error)) {
+func (m *mockTelegram) PollForCommands(_ context.Context, _ func(context.Context) ([]domain.Medicine, []domain.StockEntry, error), _ func(context.Context, int, int) (domain.MonthlyFinancialReport, error), _ func(context.Context) ([]domain.AlertRecord, error)) {
 	// no-op
 }
 
@@ -94,6 +94,19 @@ func TestCheckAndAlertLowStock_Table(t *testing.T) {
 			expectAlert: true,
 			expectText:  "*Med1* will run out",
 		},
+		{
+			name: "escaped_once",
+			med: domain.Medicine{
+				ID:           "mede",
+				Name:         "Med_E (10mg)",
+				StartDate:    domain.NewFlexibleDate(now),
+				InitialStock: 2,
+				DailyDose:    2,
+				UnitPerBox:   10,
+			},
+			expectAlert: true,
+			expectText:  "*Med\\_E \\(10mg\\)* will run out in 1 day\\(s\\)\\!\n",
+		},
 		{
 			name: "refill_today",
 			med: domain.Medicine{
@@ -113,16 +126,16 @@ func TestCheckAndAlertLowStock_Table(t *testing.T) {
 				},
 			},
 			expectAlert: true,
-			expectText:  "*Refill recorded for RefillMed*",
+			expectText:  "Refill received: RefillMed",
 		},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			at := &mockAirtable{
-				meds:    []domain.Medicine{tt.med},
-				entries: tt.entries,
-			}
+			at := &mockAirtable{Store: testutil.Store{
+				Meds:    []domain.Medicine{tt.med},
+				Entries: tt.entries,
+			}}
 			tg := &mockTelegram{}
 
 			checker := usecase.StockChecker{
@@ -130,7 +143,7 @@ func TestCheckAndAlertLowStock_Table(t *testing.T) {
 				Telegram: tg,
 			}
 
-			err := checker.CheckAndAlertLowStock()
+			err := checker.CheckAndAlertLowStock(context.Background())
 			if err != nil {
 				t.Fatalf("unexpected error: %v", err)
 			}
@@ -160,8 +173,8 @@ func TestCheckAndAlertLowStock_Table(t *testing.T) {
 func TestCheckAndAlertLowStock_UpdatesLastAlerted(t *testing.T) {
 	now := time.Now().UTC().Truncate(24 * time.Hour)
 
-	at := &mockAirtable{
-		meds: []domain.Medicine{
+	at := &mockAirtable{Store: testutil.Store{
+		Meds: []domain.Medicine{
 			{
 				ID:           "rec99",
 				Name:         "Med99",
@@ -170,25 +183,158 @@ func TestCheckAndAlertLowStock_UpdatesLastAlerted(t *testing.T) {
 				DailyDose:    1,
 				UnitPerBox:   10,
 			},
+			{
+				ID:           "rec98",
+				Name:         "Med98",
+				StartDate:    domain.NewFlexibleDate(now),
+				InitialStock: 5,
+				DailyDose:    1,
+				UnitPerBox:   10,
+			},
 		},
-	}
+	}}
 	tg := &mockTelegram{}
-	checker := usecase.StockChecker{Airtable: at, Telegram: tg}
-
 	var buf bytes.Buffer
-	orig := log.Writer()
-	log.SetOutput(&buf)
-	defer log.SetOutput(orig)
+	checker := usecase.StockChecker{Airtable: at, Telegram: tg, Logger: logger.New(&buf, logger.Options{})}
 
-	if err := checker.CheckAndAlertLowStock(); err != nil {
+	ctx := logger.WithCorrelationID(context.Background(), "req-1")
+	if err := checker.CheckAndAlertLowStock(ctx); err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
 
-	if at.updatedID != "rec99" {
-		t.Errorf("expected update for rec99, got %s", at.updatedID)
+	if got := strings.Join(at.updatedIDs, ","); got != "rec99,rec98" || at.batches != 1 {
+		t.Errorf("updated %s in %d requests, want rec99,rec98 in one", got, at.batches)
 	}
 	logs := buf.String()
-	if !strings.Contains(logs, "Calling UpdateMedicineLastAlertedDate") {
-		t.Errorf("missing update log: %s", logs)
+	if !strings.Contains(logs, `msg="alert sent"`) || !strings.Contains(logs, "correlation_id=req-1") {
+		t.Errorf("missing alert log: %s", logs)
+	}
+}
+
+func TestEvaluateAlerts(t *testing.T) {
+	now := time.Date(2025, 6, 10, 9, 0, 0, 0, time.UTC)
+	today := now.Truncate(24 * time.Hour)
+
+	lowMed := domain.Medicine{ID: "low", Name: "Low", StartDate: domain.NewFlexibleDate(today), InitialStock: 5, DailyDose: 1, UnitPerBox: 10}
+	alerted := lowMed
+	alerted.ID, alerted.Name, alerted.LastAlertedDate = "seen", "Seen", &domain.FlexibleDate{Time: today}
+
+	tests := []struct {
+		name      string
+		meds      []domain.Medicine
+		dryRun    bool
+		wantKinds []usecase.AlertKind
+		wantDedup []bool
+		wantSent  int
+	}{
+		{name: "dry_run_sends_nothing", meds: []domain.Medicine{lowMed}, dryRun: true, wantKinds: []usecase.AlertKind{usecase.AlertLowStock}, wantDedup: []bool{false}},
+		{name: "live_sends", meds: []domain.Medicine{lowMed}, wantKinds: []usecase.AlertKind{usecase.AlertLowStock}, wantDedup: []bool{false}, wantSent: 1},
+		{name: "already_alerted_today", meds: []domain.Medicine{alerted}, wantKinds: []usecase.AlertKind{usecase.AlertLowStock}, wantDedup: []bool{true}},
+		{name: "archived_ignored", meds: []domain.Medicine{{ID: "a", Name: "A", StartDate: domain.NewFlexibleDate(today), InitialStock: 5, DailyDose: 1, Archived: true}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			at := &mockAirtable{Store: testutil.Store{Meds: tt.meds}}
+			tg := &mockTelegram{}
+			checker := usecase.StockChecker{Airtable: at, Telegram: tg}
+
+			alerts, err := checker.EvaluateAlerts(context.Background(), now, tt.dryRun)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if len(alerts) != len(tt.wantKinds) {
+				t.Fatalf("got %d alerts, want %d: %+v", len(alerts), len(tt.wantKinds), alerts)
+			}
+			for i, a := range alerts {
+				if a.Kind != tt.wantKinds[i] || a.Deduplicated != tt.wantDedup[i] {
+					t.Errorf("alert[%d] = %+v", i, a)
+				}
+				if len(a.Message) == 0 {
+					t.Errorf("alert[%d] has no message", i)
+				}
+			}
+			if len(tg.sent) != tt.wantSent {
+				t.Errorf("sent %d messages, want %d", len(tg.sent), tt.wantSent)
+			}
+			if tt.dryRun && len(at.updatedIDs) > 0 {
+				t.Errorf("dry run recorded LastAlertedDate for %v", at.updatedIDs)
+			}
+		})
+	}
+}
+
+func TestEvaluateAlerts_localized(t *testing.T) {
+	now := time.Date(2025, 6, 10, 8, 0, 0, 0, time.UTC)
+	at := &mockAirtable{Store: testutil.Store{
+		Meds: []domain.Medicine{{ID: "m1", Name: "Med1", StartDate: domain.NewFlexibleDate(now), InitialStock: 1500, DailyDose: 500, UnitPerBox: 10}},
+		Entries: []domain.StockEntry{
+			{ID: "e1", MedicineID: []string{"m1"}, Quantity: 1, Unit: "box", Date: domain.NewFlexibleDate(now)},
+		},
+	}}
+	tg := &mockTelegram{}
+	checker := usecase.StockChecker{
+		Airtable: at,
+		Telegram: tg,
+		Locale:   func() i18n.Printer { return i18n.For(i18n.French) },
+	}
+
+	if _, err := checker.EvaluateAlerts(context.Background(), now, false); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := []string{
+		"*Med1* sera épuisé dans 3 jour\\(s\\) \\!\nÀ racheter avant le *13/06/2025*\nActuellement : *1\u202f510,00* comprimés restants\\.",
+		"✅ Réapprovisionnement reçu : Med1\n\n• Quantité : 1 box\n• Soit : 10 comprimés\n• Date : 10/06/2025",
 	}
+	if strings.Join(tg.sent, "\n---\n") != strings.Join(want, "\n---\n") {
+		t.Errorf("sent:\n%s\nwant:\n%s", strings.Join(tg.sent, "\n---\n"), strings.Join(want, "\n---\n"))
+	}
+}
+
+func TestEvaluateAlerts_householdTimezone(t *testing.T) {
+	tana, err := time.LoadLocation("Indian/Antananarivo")
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 01:00 on June 10 in Antananarivo, still June 9 in UTC.
+	now := time.Date(2025, 6, 9, 22, 0, 0, 0, time.UTC)
+	today := domain.NewFlexibleDate(time.Date(2025, 6, 10, 0, 0, 0, 0, time.UTC))
+
+	med := domain.Medicine{ID: "m1", Name: "Med1", StartDate: domain.NewFlexibleDate(time.Date(2025, 6, 1, 0, 0, 0, 0, time.UTC)), InitialStock: 12, DailyDose: 1, UnitPerBox: 10}
+	entry := domain.StockEntry{ID: "e1", MedicineID: []string{"m1"}, Quantity: 1, Unit: "pill", Date: today}
+
+	t.Run("alerts_on_local_day", func(t *testing.T) {
+		at := &mockAirtable{Store: testutil.Store{Meds: []domain.Medicine{med}, Entries: []domain.StockEntry{entry}}}
+		checker := usecase.StockChecker{Airtable: at, Telegram: &mockTelegram{}, Location: tana}
+
+		alerts, err := checker.EvaluateAlerts(context.Background(), now, true)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if len(alerts) != 2 || alerts[1].Kind != usecase.AlertRefill {
+			t.Fatalf("want low-stock and refill alerts, got %+v", alerts)
+		}
+		if alerts[0].Key != "low_stock:m1:2025-06-10" {
+			t.Errorf("key = %q", alerts[0].Key)
+		}
+		// 12 pills - 9 days + 1 refilled = 4 left, out on June 14.
+		if got := richtext.Plain(alerts[0].Message); !strings.Contains(got, "run out in 4 day(s)") || !strings.Contains(got, "2025-06-14") {
+			t.Errorf("message = %q", got)
+		}
+	})
+
+	t.Run("last_alerted_is_local_today", func(t *testing.T) {
+		alerted := med
+		alerted.LastAlertedDate = &today
+		at := &mockAirtable{Store: testutil.Store{Meds: []domain.Medicine{alerted}}}
+		checker := usecase.StockChecker{Airtable: at, Telegram: &mockTelegram{}, Location: tana}
+
+		alerts, err := checker.EvaluateAlerts(context.Background(), now, false)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if len(alerts) != 1 || !alerts[0].Deduplicated {
+			t.Errorf("want a deduplicated alert, got %+v", alerts)
+		}
+	})
 }
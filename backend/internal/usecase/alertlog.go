@@ -0,0 +1,296 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/nomenarkt/vitaltrack/backend/internal/domain"
+	"github.com/nomenarkt/vitaltrack/backend/internal/domain/ports"
+	"github.com/nomenarkt/vitaltrack/backend/internal/logger"
+	"github.com/nomenarkt/vitaltrack/backend/internal/metrics"
+	"github.com/nomenarkt/vitaltrack/backend/internal/util/richtext"
+)
+
+// MaxAlertAttempts caps how many times a failed alert is retried before it is
+// treated as delivered for dedupe purposes.
+const MaxAlertAttempts = 5
+
+// AlertLease is how long a pending record blocks other runs from sending its
+// alert. A record still pending after that, left behind by a crash or a
+// failed log update, counts as a failed attempt and is retried.
+const AlertLease = 10 * time.Minute
+
+// AlertQueueLease is how long a queued record waits for the outbox to report
+// its delivery, after which the alert is presumed lost, e.g. with an
+// in-memory outbox across a restart, and is retried.
+const AlertQueueLease = time.Hour
+
+// ChannelTelegram is the delivery channel recorded for Telegram alerts.
+const ChannelTelegram = "telegram"
+
+// LowStockAlertKey is the dedupe key for a low-stock alert: at most one per
+// medicine per calendar day of now, which should be in the household timezone.
+func LowStockAlertKey(medicineID string, now time.Time) string {
+	return fmt.Sprintf("%s:%s:%s", AlertLowStock, medicineID, now.Format("2006-01-02"))
+}
+
+// RefillAlertKey is the dedupe key for a refill notification: one per entry.
+func RefillAlertKey(e domain.StockEntry) string {
+	if e.ID != "" {
+		return fmt.Sprintf("%s:%s", AlertRefill, e.ID)
+	}
+	medID := ""
+	if len(e.MedicineID) > 0 {
+		medID = e.MedicineID[0]
+	}
+	return fmt.Sprintf("%s:%s:%s:%g%s", AlertRefill, medID, e.Date.Format("2006-01-02"), e.Quantity, e.Unit)
+}
+
+// AlertNotifier sends alerts to Telegram at most once per key, recording every
+// attempt in the alert log. Without a Log it sends unconditionally. When
+// Telegram is a ports.AlertSender that queues the alert, it is recorded as
+// queued and the outbox reports the outcome through ReportAlert.
+type AlertNotifier struct {
+	Log      ports.AlertLogPort
+	Telegram ports.TelegramService
+	Logger   logger.Logger // discards when nil
+}
+
+func (n AlertNotifier) log() logger.Logger { return logger.OrNop(n.Logger) }
+
+// Delivered reports whether an alert for key needs no further delivery at
+// now: it was sent, is being sent by another run, or has exhausted its
+// retries.
+func (n AlertNotifier) Delivered(ctx context.Context, key string, now time.Time) (bool, error) {
+	if n.Log == nil {
+		return false, nil
+	}
+	rec, err := n.Log.FindAlert(ctx, key)
+	if errors.Is(err, domain.ErrNotFound) {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("find alert %s failed: %w", key, err)
+	}
+	return handled(rec, now), nil
+}
+
+// handled reports whether rec needs no further delivery at now.
+func handled(rec domain.AlertRecord, now time.Time) bool {
+	switch {
+	case rec.Attempts >= MaxAlertAttempts:
+		return true
+	case rec.Status == domain.DeliveryFailed:
+		return false
+	case rec.Status == domain.DeliveryPending:
+		return now.Sub(rec.UpdatedAt) < AlertLease
+	case rec.Status == domain.DeliveryQueued:
+		return now.Sub(rec.UpdatedAt) < AlertQueueLease
+	}
+	return true
+}
+
+// Notify delivers a unless its key was already delivered, in which case it is
+// returned with Deduplicated set. A failed send is recorded and retried on
+// the next call, it is not returned as an error.
+func (n AlertNotifier) Notify(ctx context.Context, a Alert, now time.Time) (Alert, error) {
+	if n.Log == nil {
+		queued, err := n.send(ctx, a)
+		a.Sent, a.Queued = err == nil && !queued, queued
+		return a, nil
+	}
+
+	rec, err := n.Log.FindAlert(ctx, a.Key)
+	switch {
+	case errors.Is(err, domain.ErrNotFound):
+		rec, err = n.claim(ctx, a, now)
+		if err != nil {
+			return a, err
+		}
+		if rec.Status == domain.DeliveryDuplicate {
+			a.Deduplicated = true
+			return a, nil
+		}
+	case err != nil:
+		return a, fmt.Errorf("find alert %s failed: %w", a.Key, err)
+	case handled(rec, now):
+		n.log().Info(ctx, "alert already handled, skipping", "key", a.Key, "status", rec.Status)
+		a.Deduplicated = true
+		return a, nil
+	default:
+		if rec.Status == domain.DeliveryPending || rec.Status == domain.DeliveryQueued {
+			n.log().Warn(ctx, "alert delivery lease expired, retrying", "key", a.Key, "status", rec.Status, "since", rec.UpdatedAt)
+			rec.Attempts++ // the abandoned attempt
+		}
+		status := domain.DeliveryPending
+		if rec.Attempts >= MaxAlertAttempts {
+			status = domain.DeliveryFailed
+		}
+		if _, err := n.Log.UpdateAlert(ctx, rec.ID, domain.AlertRecordPatch{Status: &status, Attempts: &rec.Attempts, UpdatedAt: now}); err != nil {
+			return a, fmt.Errorf("claim alert %s failed: %w", a.Key, err)
+		}
+		if status == domain.DeliveryFailed {
+			return a, nil
+		}
+	}
+
+	attempts := rec.Attempts + 1
+	status := domain.DeliverySent
+	lastErr := ""
+	queued, err := n.send(ctx, a)
+	switch {
+	case err != nil:
+		status = domain.DeliveryFailed
+		lastErr = err.Error()
+	case queued:
+		status = domain.DeliveryQueued
+		attempts = rec.Attempts // counted when the outcome is reported
+		a.Queued = true
+	default:
+		a.Sent = true
+	}
+
+	if _, err := n.Log.UpdateAlert(ctx, rec.ID, domain.AlertRecordPatch{
+		Status:    &status,
+		Attempts:  &attempts,
+		LastError: &lastErr,
+		UpdatedAt: now,
+	}); err != nil {
+		n.log().Warn(ctx, "failed to record alert delivery", "key", a.Key, "error", err)
+	}
+	return a, nil
+}
+
+// claim records a pending delivery for a. If another run claimed the same key
+// first, the new record is marked duplicate and returned as such.
+func (n AlertNotifier) claim(ctx context.Context, a Alert, now time.Time) (domain.AlertRecord, error) {
+	rec, err := n.Log.CreateAlert(ctx, domain.AlertRecord{
+		Key:          a.Key,
+		Kind:         string(a.Kind),
+		MedicineID:   a.MedicineID,
+		MedicineName: a.MedicineName,
+		Payload:      richtext.MarkdownV2(a.Message),
+		Channel:      ChannelTelegram,
+		Status:       domain.DeliveryPending,
+		CreatedAt:    now,
+		UpdatedAt:    now,
+	})
+	if err != nil {
+		return domain.AlertRecord{}, fmt.Errorf("record alert %s failed: %w", a.Key, err)
+	}
+
+	first, err := n.Log.FindAlert(ctx, a.Key)
+	if err != nil {
+		return domain.AlertRecord{}, fmt.Errorf("find alert %s failed: %w", a.Key, err)
+	}
+	if first.ID == rec.ID {
+		return rec, nil
+	}
+
+	n.log().Info(ctx, "alert claimed by another run, skipping", "key", a.Key)
+	status := domain.DeliveryDuplicate
+	if _, err := n.Log.UpdateAlert(ctx, rec.ID, domain.AlertRecordPatch{Status: &status, UpdatedAt: now}); err != nil {
+		n.log().Warn(ctx, "failed to mark alert duplicate", "record_id", rec.ID, "error", err)
+	}
+	rec.Status = status
+	return rec, nil
+}
+
+// send delivers a, or queues it when Telegram is an AlertSender with an
+// outbox, in which case true is returned.
+func (n AlertNotifier) send(ctx context.Context, a Alert) (bool, error) {
+	var queued bool
+	var err error
+	if s, ok := n.Telegram.(ports.AlertSender); ok {
+		queued, err = s.SendAlert(ctx, a.Key, a.Message)
+	} else {
+		err = n.Telegram.SendTelegramMessage(ctx, a.Message)
+	}
+	switch {
+	case err != nil:
+		n.log().Error(ctx, "alert send failed", "key", a.Key, "error", err)
+	case queued:
+		n.log().Info(ctx, "alert queued", "key", a.Key)
+	default:
+		n.log().Info(ctx, "alert sent", "key", a.Key)
+		metrics.AlertsFired.WithLabelValues(string(a.Kind)).Inc()
+	}
+	return queued, err
+}
+
+// ReportAlert records the outcome of the queued alert for key: sent when
+// sendErr is nil, failed and retried on the next run otherwise. Records no
+// longer queued, e.g. retried after AlertQueueLease, are left unchanged.
+func (n AlertNotifier) ReportAlert(ctx context.Context, key string, sendErr error, at time.Time) error {
+	if n.Log == nil {
+		return nil
+	}
+	rec, err := n.Log.FindAlert(ctx, key)
+	if err != nil {
+		return fmt.Errorf("find alert %s failed: %w", key, err)
+	}
+	if rec.Status != domain.DeliveryQueued {
+		n.log().Warn(ctx, "delivery reported for an alert not queued", "key", key, "status", rec.Status)
+		return nil
+	}
+
+	attempts := rec.Attempts + 1
+	status := domain.DeliverySent
+	lastErr := ""
+	if sendErr != nil {
+		status = domain.DeliveryFailed
+		lastErr = sendErr.Error()
+		n.log().Error(ctx, "queued alert not delivered", "key", key, "error", sendErr)
+	} else {
+		n.log().Info(ctx, "alert sent", "key", key)
+		metrics.AlertsFired.WithLabelValues(rec.Kind).Inc()
+	}
+	if _, err := n.Log.UpdateAlert(ctx, rec.ID, domain.AlertRecordPatch{
+		Status:    &status,
+		Attempts:  &attempts,
+		LastError: &lastErr,
+		UpdatedAt: at,
+	}); err != nil {
+		return fmt.Errorf("record alert %s delivery failed: %w", key, err)
+	}
+	return nil
+}
+
+// AlertLogService exposes the alert delivery log for review.
+type AlertLogService struct {
+	Log ports.AlertLogPort
+}
+
+// ListAlerts returns logged alerts matching filter, most recent first.
+func (s AlertLogService) ListAlerts(ctx context.Context, filter domain.AlertFilter) (ListResult[domain.AlertRecord], error) {
+	if s.Log == nil {
+		return paginate([]domain.AlertRecord{}, filter.Page), nil
+	}
+	records, err := s.Log.FetchAlerts(ctx, filter.Since)
+	if err != nil {
+		return ListResult[domain.AlertRecord]{}, fmt.Errorf("fetch alerts failed: %w", err)
+	}
+
+	matched := []domain.AlertRecord{}
+	for _, r := range records {
+		if filter.MedicineID != "" && r.MedicineID != filter.MedicineID {
+			continue
+		}
+		if filter.Kind != "" && r.Kind != filter.Kind {
+			continue
+		}
+		if filter.Status != "" && r.Status != filter.Status {
+			continue
+		}
+		if !filter.Since.IsZero() && r.CreatedAt.Before(filter.Since) {
+			continue
+		}
+		matched = append(matched, r)
+	}
+	sort.SliceStable(matched, func(i, j int) bool { return matched[i].CreatedAt.After(matched[j].CreatedAt) })
+
+	return paginate(matched, filter.Page), nil
+}
@@ -0,0 +1,327 @@
+package usecase_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/nomenarkt/vitaltrack/backend/internal/domain"
+	"github.com/nomenarkt/vitaltrack/backend/internal/infra/memstore"
+	"github.com/nomenarkt/vitaltrack/backend/internal/testutil"
+	"github.com/nomenarkt/vitaltrack/backend/internal/usecase"
+	"github.com/nomenarkt/vitaltrack/backend/internal/util/richtext"
+)
+
+type flakyTelegram struct {
+	mockTelegram
+	failures int
+}
+
+func (f *flakyTelegram) SendTelegramMessage(_ context.Context, msg richtext.Doc) error {
+	if f.failures > 0 {
+		f.failures--
+		return errors.New("telegram down")
+	}
+	return f.mockTelegram.SendTelegramMessage(context.Background(), msg)
+}
+
+// queuingTelegram queues alerts like the client does with an outbox.
+type queuingTelegram struct {
+	mockTelegram
+	queued []string
+}
+
+func (q *queuingTelegram) SendAlert(_ context.Context, key string, _ richtext.Doc) (bool, error) {
+	q.queued = append(q.queued, key)
+	return true, nil
+}
+
+// racingLog simulates another replica claiming the same key between our
+// lookup and our claim.
+type racingLog struct {
+	*memstore.AlertLog
+	raced bool
+}
+
+func (r *racingLog) CreateAlert(_ context.Context, rec domain.AlertRecord) (domain.AlertRecord, error) {
+	if !r.raced {
+		r.raced = true
+		other := rec
+		other.Status = domain.DeliverySent
+		if _, err := r.AlertLog.CreateAlert(context.Background(), other); err != nil {
+			return domain.AlertRecord{}, err
+		}
+	}
+	return r.AlertLog.CreateAlert(context.Background(), rec)
+}
+
+func TestAlertNotifier_Notify(t *testing.T) {
+	now := time.Date(2025, 6, 10, 9, 0, 0, 0, time.UTC)
+	alert := usecase.Alert{Kind: usecase.AlertLowStock, Key: usecase.LowStockAlertKey("m1", now), MedicineID: "m1", MedicineName: "Med1", Message: richtext.Text("low")}
+
+	t.Run("sends_once", func(t *testing.T) {
+		tg := &mockTelegram{}
+		n := usecase.AlertNotifier{Log: memstore.NewAlertLog(), Telegram: tg}
+
+		first, err := n.Notify(context.Background(), alert, now)
+		if err != nil || !first.Sent {
+			t.Fatalf("first notify = %+v, %v", first, err)
+		}
+		second, err := n.Notify(context.Background(), alert, now.Add(time.Hour))
+		if err != nil || second.Sent || !second.Deduplicated {
+			t.Fatalf("second notify = %+v, %v", second, err)
+		}
+		if len(tg.sent) != 1 {
+			t.Errorf("sent %d messages, want 1", len(tg.sent))
+		}
+	})
+
+	t.Run("retries_failed", func(t *testing.T) {
+		tg := &flakyTelegram{failures: 1}
+		alertLog := memstore.NewAlertLog()
+		n := usecase.AlertNotifier{Log: alertLog, Telegram: tg}
+
+		first, err := n.Notify(context.Background(), alert, now)
+		if err != nil || first.Sent || first.Deduplicated {
+			t.Fatalf("first notify = %+v, %v", first, err)
+		}
+		if delivered, _ := n.Delivered(context.Background(), alert.Key, now); delivered {
+			t.Fatalf("failed alert reported as delivered")
+		}
+		second, err := n.Notify(context.Background(), alert, now)
+		if err != nil || !second.Sent {
+			t.Fatalf("retry = %+v, %v", second, err)
+		}
+
+		rec, err := alertLog.FindAlert(context.Background(), alert.Key)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if rec.Status != domain.DeliverySent || rec.Attempts != 2 || rec.LastError != "" {
+			t.Errorf("unexpected record after retry: %+v", rec)
+		}
+	})
+
+	t.Run("gives_up_after_max_attempts", func(t *testing.T) {
+		tg := &flakyTelegram{failures: usecase.MaxAlertAttempts + 1}
+		n := usecase.AlertNotifier{Log: memstore.NewAlertLog(), Telegram: tg}
+		for i := 0; i < usecase.MaxAlertAttempts+1; i++ {
+			if _, err := n.Notify(context.Background(), alert, now); err != nil {
+				t.Fatal(err)
+			}
+		}
+		if tg.failures != 1 {
+			t.Errorf("attempted %d sends, want %d", usecase.MaxAlertAttempts+1-tg.failures, usecase.MaxAlertAttempts)
+		}
+	})
+
+	t.Run("pending_left_behind", func(t *testing.T) {
+		tg := &mockTelegram{}
+		alertLog := memstore.NewAlertLog()
+		n := usecase.AlertNotifier{Log: alertLog, Telegram: tg}
+		claimed := now.Add(-time.Minute)
+		if _, err := alertLog.CreateAlert(context.Background(), domain.AlertRecord{Key: alert.Key, Status: domain.DeliveryPending, CreatedAt: claimed, UpdatedAt: claimed}); err != nil {
+			t.Fatal(err)
+		}
+
+		got, err := n.Notify(context.Background(), alert, now)
+		if err != nil || got.Sent || !got.Deduplicated {
+			t.Fatalf("notify within lease = %+v, %v", got, err)
+		}
+
+		later := claimed.Add(usecase.AlertLease)
+		if delivered, _ := n.Delivered(context.Background(), alert.Key, later); delivered {
+			t.Fatalf("expired lease reported as delivered")
+		}
+		got, err = n.Notify(context.Background(), alert, later)
+		if err != nil || !got.Sent {
+			t.Fatalf("notify after lease = %+v, %v", got, err)
+		}
+		rec, err := alertLog.FindAlert(context.Background(), alert.Key)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if rec.Status != domain.DeliverySent || rec.Attempts != 2 || len(tg.sent) != 1 {
+			t.Errorf("record = %+v, sent %d", rec, len(tg.sent))
+		}
+	})
+
+	t.Run("queued_until_reported", func(t *testing.T) {
+		tg := &queuingTelegram{}
+		alertLog := memstore.NewAlertLog()
+		n := usecase.AlertNotifier{Log: alertLog, Telegram: tg}
+		record := func() domain.AlertRecord {
+			t.Helper()
+			rec, err := alertLog.FindAlert(context.Background(), alert.Key)
+			if err != nil {
+				t.Fatal(err)
+			}
+			return rec
+		}
+
+		got, err := n.Notify(context.Background(), alert, now)
+		if err != nil || got.Sent || !got.Queued {
+			t.Fatalf("notify = %+v, %v", got, err)
+		}
+		if rec := record(); rec.Status != domain.DeliveryQueued || rec.Attempts != 0 {
+			t.Fatalf("record = %+v", rec)
+		}
+		if got, _ := n.Notify(context.Background(), alert, now.Add(time.Minute)); !got.Deduplicated {
+			t.Fatalf("queued alert sent again: %+v", got)
+		}
+
+		if err := n.ReportAlert(context.Background(), alert.Key, errors.New("dead-lettered"), now); err != nil {
+			t.Fatal(err)
+		}
+		if rec := record(); rec.Status != domain.DeliveryFailed || rec.Attempts != 1 || rec.LastError != "dead-lettered" {
+			t.Fatalf("record after dead letter = %+v", rec)
+		}
+
+		if got, _ := n.Notify(context.Background(), alert, now.Add(time.Hour)); !got.Queued {
+			t.Fatalf("failed alert not retried: %+v", got)
+		}
+		if err := n.ReportAlert(context.Background(), alert.Key, nil, now.Add(time.Hour)); err != nil {
+			t.Fatal(err)
+		}
+		if rec := record(); rec.Status != domain.DeliverySent || rec.Attempts != 2 || len(tg.queued) != 2 {
+			t.Errorf("record after delivery = %+v, queued %v", rec, tg.queued)
+		}
+	})
+
+	t.Run("queued_lost", func(t *testing.T) {
+		tg := &queuingTelegram{}
+		alertLog := memstore.NewAlertLog()
+		n := usecase.AlertNotifier{Log: alertLog, Telegram: tg}
+		if _, err := n.Notify(context.Background(), alert, now); err != nil {
+			t.Fatal(err)
+		}
+
+		got, err := n.Notify(context.Background(), alert, now.Add(usecase.AlertQueueLease))
+		if err != nil || !got.Queued || len(tg.queued) != 2 {
+			t.Fatalf("notify after queue lease = %+v, %v, queued %v", got, err, tg.queued)
+		}
+		rec, err := alertLog.FindAlert(context.Background(), alert.Key)
+		if err != nil || rec.Attempts != 1 {
+			t.Errorf("record = %+v, %v", rec, err)
+		}
+	})
+
+	t.Run("loses_race", func(t *testing.T) {
+		tg := &mockTelegram{}
+		alertLog := &racingLog{AlertLog: memstore.NewAlertLog()}
+		n := usecase.AlertNotifier{Log: alertLog, Telegram: tg}
+
+		got, err := n.Notify(context.Background(), alert, now)
+		if err != nil || got.Sent || !got.Deduplicated {
+			t.Fatalf("notify = %+v, %v", got, err)
+		}
+		if len(tg.sent) != 0 {
+			t.Errorf("sent despite losing the claim")
+		}
+		records, _ := alertLog.FetchAlerts(context.Background(), time.Time{})
+		if len(records) != 2 || records[1].Status != domain.DeliveryDuplicate {
+			t.Errorf("unexpected log: %+v", records)
+		}
+	})
+}
+
+func TestCheckAndAlertNewRefills_dedupedByLog(t *testing.T) {
+	now := time.Now().UTC()
+	at := &testutil.Store{
+		Meds:    []domain.Medicine{{ID: "m1", Name: "Med1", UnitPerBox: 28}},
+		Entries: []domain.StockEntry{{ID: "e1", MedicineID: []string{"m1"}, Quantity: 1, Unit: "box", Date: domain.NewFlexibleDate(now)}},
+	}
+	tg := &mockTelegramRefill{}
+	checker := usecase.StockChecker{Airtable: at, Telegram: tg, Alerts: memstore.NewAlertLog()}
+
+	for i := 0; i < 2; i++ {
+		if err := checker.CheckAndAlertNewRefills(context.Background()); err != nil {
+			t.Fatalf("run %d: %v", i, err)
+		}
+	}
+	if len(tg.msgs) != 1 {
+		t.Errorf("sent %d refill messages across two runs, want 1", len(tg.msgs))
+	}
+
+	alerts, err := checker.EvaluateAlerts(context.Background(), now, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(alerts) != 1 || !alerts[0].Deduplicated {
+		t.Errorf("refill should be deduplicated across rule paths: %+v", alerts)
+	}
+}
+
+func TestAlertLogService_ListAlerts(t *testing.T) {
+	alertLog := memstore.NewAlertLog()
+	base := time.Date(2025, 6, 10, 0, 0, 0, 0, time.UTC)
+	for i, r := range []domain.AlertRecord{
+		{Key: "a", Kind: "low_stock", MedicineID: "m1", Status: domain.DeliverySent},
+		{Key: "b", Kind: "refill", MedicineID: "m1", Status: domain.DeliveryFailed},
+		{Key: "c", Kind: "low_stock", MedicineID: "m2", Status: domain.DeliverySent},
+	} {
+		r.CreatedAt = base.AddDate(0, 0, i)
+		if _, err := alertLog.CreateAlert(context.Background(), r); err != nil {
+			t.Fatal(err)
+		}
+	}
+	svc := usecase.AlertLogService{Log: alertLog}
+
+	tests := []struct {
+		name     string
+		filter   domain.AlertFilter
+		wantKeys string
+	}{
+		{name: "all_newest_first", wantKeys: "cba"},
+		{name: "by_medicine", filter: domain.AlertFilter{MedicineID: "m1"}, wantKeys: "ba"},
+		{name: "by_kind", filter: domain.AlertFilter{Kind: "low_stock"}, wantKeys: "ca"},
+		{name: "by_status", filter: domain.AlertFilter{Status: domain.DeliveryFailed}, wantKeys: "b"},
+		{name: "since", filter: domain.AlertFilter{Since: base.AddDate(0, 0, 1)}, wantKeys: "cb"},
+		{name: "paged", filter: domain.AlertFilter{Page: domain.Page{Limit: 1, Offset: 1}}, wantKeys: "b"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			res, err := svc.ListAlerts(context.Background(), tt.filter)
+			if err != nil {
+				t.Fatal(err)
+			}
+			got := ""
+			for _, r := range res.Items {
+				got += r.Key
+			}
+			if got != tt.wantKeys {
+				t.Errorf("keys = %q, want %q", got, tt.wantKeys)
+			}
+		})
+	}
+}
+
+func TestEvaluateAlerts_deadLetteredRetried(t *testing.T) {
+	now := time.Date(2025, 6, 10, 9, 0, 0, 0, time.UTC)
+	at := &mockAirtable{Store: testutil.Store{
+		Meds: []domain.Medicine{{ID: "low", Name: "Low", StartDate: domain.NewFlexibleDate(now), InitialStock: 5, DailyDose: 1, UnitPerBox: 10}},
+	}}
+	tg := &queuingTelegram{}
+	checker := usecase.StockChecker{Airtable: at, Telegram: tg, Alerts: memstore.NewAlertLog()}
+
+	if _, err := checker.EvaluateAlerts(context.Background(), now, false); err != nil {
+		t.Fatal(err)
+	}
+	if len(at.updatedIDs) != 0 {
+		t.Fatalf("queued alert recorded LastAlertedDate for %v", at.updatedIDs)
+	}
+	key := usecase.LowStockAlertKey("low", now)
+	n := usecase.AlertNotifier{Log: checker.Alerts, Telegram: tg}
+	if err := n.ReportAlert(context.Background(), key, errors.New("dead-lettered"), now); err != nil {
+		t.Fatal(err)
+	}
+
+	alerts, err := checker.EvaluateAlerts(context.Background(), now.Add(time.Minute), false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(alerts) != 1 || !alerts[0].Queued || len(tg.queued) != 2 {
+		t.Errorf("alerts = %+v, queued %v, want the dead-lettered alert queued again", alerts, tg.queued)
+	}
+}
@@ -0,0 +1,121 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/nomenarkt/vitaltrack/backend/internal/domain"
+	"github.com/nomenarkt/vitaltrack/backend/internal/domain/ports"
+	"github.com/nomenarkt/vitaltrack/backend/internal/logger"
+	"github.com/nomenarkt/vitaltrack/backend/internal/logic/calendar"
+	"github.com/nomenarkt/vitaltrack/backend/internal/logic/finance"
+	"github.com/nomenarkt/vitaltrack/backend/internal/logic/forecast"
+	"github.com/nomenarkt/vitaltrack/backend/internal/util/i18n"
+	"github.com/nomenarkt/vitaltrack/backend/internal/util/richtext"
+)
+
+// DigestService pushes the scheduled stock and finance summaries to every
+// chat subscribed to them.
+type DigestService struct {
+	Airtable    ports.StockDataPort
+	Finance     FinancialReportService
+	Prefs       ports.ChatPrefsPort
+	Sender      ports.ChatMessenger
+	DefaultChat string                           // alert chat, subscribed to every digest unless it opts out
+	OtherChats  []string                         // other chats allowed to subscribe
+	Locale      func(chatID string) i18n.Printer // English when nil
+	Location    *time.Location                   // household timezone, UTC when nil
+	Logger      logger.Logger                    // discards when nil
+}
+
+// Recipients returns the chats subscribed to kind: the default chat unless
+// it turned kind off, and any of OtherChats that turned it on.
+func (s DigestService) Recipients(kind domain.DigestKind) ([]string, error) {
+	var chats []domain.ChatPrefs
+	if s.Prefs != nil {
+		var err error
+		if chats, err = s.Prefs.ListChatPrefs(); err != nil {
+			return nil, fmt.Errorf("list chat preferences: %w", err)
+		}
+	}
+
+	var ids []string
+	seenDefault := false
+	for _, c := range chats {
+		isDefault := c.ChatID == s.DefaultChat
+		seenDefault = seenDefault || isDefault
+		if !isDefault && !slices.Contains(s.OtherChats, c.ChatID) {
+			continue
+		}
+		if c.Subscribed(kind, isDefault) {
+			ids = append(ids, c.ChatID)
+		}
+	}
+	if !seenDefault && s.DefaultChat != "" {
+		ids = append([]string{s.DefaultChat}, ids...)
+	}
+	return ids, nil
+}
+
+// SendStockDigest sends the out-of-stock forecast as of now to every stock
+// digest subscriber. Forecast dates are not saved; the forecast sync job
+// does that.
+func (s DigestService) SendStockDigest(ctx context.Context, now time.Time) error {
+	ids, err := s.Recipients(domain.DigestStock)
+	if err != nil || len(ids) == 0 {
+		return err
+	}
+	meds, err := s.Airtable.FetchMedicines(ctx)
+	if err != nil {
+		return fmt.Errorf("fetch medicines failed: %w", err)
+	}
+	entries, err := s.Airtable.FetchStockEntries(ctx)
+	if err != nil {
+		return fmt.Errorf("fetch stock entries failed: %w", err)
+	}
+
+	now = calendar.In(now, s.Location)
+	return s.deliver(ctx, ids, domain.DigestStock, func(p i18n.Printer) richtext.Doc {
+		msg := richtext.New(richtext.P(richtext.T(p.T("digest.stock_intro"))))
+		return append(msg, forecast.GenerateOutOfStockForecastMessage(p, meds, entries, now)...)
+	})
+}
+
+// SendFinanceDigest sends the report for the month before now, with the
+// shortfall per need, to every finance digest subscriber.
+func (s DigestService) SendFinanceDigest(ctx context.Context, now time.Time) error {
+	ids, err := s.Recipients(domain.DigestFinance)
+	if err != nil || len(ids) == 0 {
+		return err
+	}
+	today := calendar.Day(now, s.Location)
+	last := today.AddDate(0, 0, -today.Day())
+	report, err := s.Finance.GenerateFinancialReport(ctx, last.Year(), int(last.Month()))
+	if err != nil {
+		return err
+	}
+	return s.deliver(ctx, ids, domain.DigestFinance, func(p i18n.Printer) richtext.Doc {
+		return finance.GenerateDigestMessage(p, report)
+	})
+}
+
+// deliver renders the digest in each chat's language and sends it. A failed
+// chat does not stop the others; the errors are returned together.
+func (s DigestService) deliver(ctx context.Context, ids []string, kind domain.DigestKind, render func(i18n.Printer) richtext.Doc) error {
+	var errs []error
+	for _, id := range ids {
+		p := i18n.For(i18n.Default)
+		if s.Locale != nil {
+			p = s.Locale(id)
+		}
+		if err := s.Sender.SendToChat(ctx, id, render(p)); err != nil {
+			errs = append(errs, fmt.Errorf("send %s digest to chat %s: %w", kind, id, err))
+			continue
+		}
+		logger.OrNop(s.Logger).Info(ctx, "digest sent", "kind", kind, "chat_id", id)
+	}
+	return errors.Join(errs...)
+}
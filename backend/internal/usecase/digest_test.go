@@ -0,0 +1,169 @@
+package usecase_test
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/nomenarkt/vitaltrack/backend/internal/domain"
+	"github.com/nomenarkt/vitaltrack/backend/internal/infra/filestore"
+	"github.com/nomenarkt/vitaltrack/backend/internal/testutil"
+	"github.com/nomenarkt/vitaltrack/backend/internal/usecase"
+	"github.com/nomenarkt/vitaltrack/backend/internal/util/i18n"
+	"github.com/nomenarkt/vitaltrack/backend/internal/util/richtext"
+)
+
+// chatSender records messages per chat and fails for chats in fail.
+type chatSender struct {
+	sent map[string]string
+	fail map[string]bool
+}
+
+func (s *chatSender) SendToChat(_ context.Context, chatID string, msg richtext.Doc) error {
+	if s.fail[chatID] {
+		return errors.New("chat unreachable")
+	}
+	if s.sent == nil {
+		s.sent = map[string]string{}
+	}
+	s.sent[chatID] = richtext.Plain(msg)
+	return nil
+}
+
+func digestPrefs(t *testing.T, chats ...domain.ChatPrefs) *filestore.Prefs {
+	t.Helper()
+	prefs, err := filestore.OpenPrefs("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, c := range chats {
+		if err := prefs.SaveChatPrefs(c); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return prefs
+}
+
+func TestDigestService_Recipients(t *testing.T) {
+	on := func(id string, lang string, digests map[domain.DigestKind]bool) domain.ChatPrefs {
+		return domain.ChatPrefs{ChatID: id, Language: lang, Digests: digests}
+	}
+	tests := []struct {
+		name  string
+		chats []domain.ChatPrefs
+		kind  domain.DigestKind
+		want  []string
+	}{
+		{"default_chat_without_prefs", nil, domain.DigestStock, []string{"1"}},
+		{"default_chat_with_language_only", []domain.ChatPrefs{on("1", "fr", nil)}, domain.DigestFinance, []string{"1"}},
+		{"default_chat_opted_out", []domain.ChatPrefs{on("1", "", map[domain.DigestKind]bool{domain.DigestStock: false})}, domain.DigestStock, nil},
+		{"other_chat_opted_in", []domain.ChatPrefs{
+			on("7", "", map[domain.DigestKind]bool{domain.DigestFinance: true}),
+			on("8", "mg", nil),
+		}, domain.DigestFinance, []string{"1", "7"}},
+		{"other_kind_unaffected", []domain.ChatPrefs{on("7", "", map[domain.DigestKind]bool{domain.DigestFinance: true})}, domain.DigestStock, []string{"1"}},
+		{"unknown_chat_opted_in", []domain.ChatPrefs{on("666", "", map[domain.DigestKind]bool{domain.DigestFinance: true})}, domain.DigestFinance, []string{"1"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			svc := usecase.DigestService{Prefs: digestPrefs(t, tt.chats...), DefaultChat: "1", OtherChats: []string{"7", "8"}}
+			got, err := svc.Recipients(tt.kind)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Recipients(%s) = %v, want %v", tt.kind, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDigestService_SendFinanceDigest(t *testing.T) {
+	day := func(s string) domain.FlexibleDate {
+		d, _ := time.Parse("2006-01-02", s)
+		return domain.NewFlexibleDate(d)
+	}
+	repo := &testutil.Store{Financial: []domain.FinancialEntry{
+		{Date: day("2025-05-03"), NeedLabel: "Pharmacy", NeedAmount: 50000, AmountContributed: 30000, MonthTag: "2025-05", Contributor: "Onja"},
+		{Date: day("2025-05-20"), NeedLabel: "Rent", NeedAmount: 20000, AmountContributed: 20000, MonthTag: "2025-05", Contributor: "Tafita"},
+		{Date: day("2025-06-01"), NeedLabel: "Pharmacy", NeedAmount: 99000, AmountContributed: 1000, MonthTag: "2025-06", Contributor: "Onja"},
+	}}
+	prefs := digestPrefs(t, domain.ChatPrefs{ChatID: "7", Language: "fr", Digests: map[domain.DigestKind]bool{domain.DigestFinance: true}})
+	sender := &chatSender{fail: map[string]bool{"9": true}}
+	svc := usecase.DigestService{
+		Finance:     usecase.FinancialReportService{Repo: repo},
+		Prefs:       prefs,
+		Sender:      sender,
+		DefaultChat: "1",
+		OtherChats:  []string{"7", "9"},
+		Locale: func(chatID string) i18n.Printer {
+			c, _ := prefs.GetChatPrefs(chatID)
+			lang, ok := i18n.Parse(c.Language)
+			if !ok {
+				lang = i18n.English
+			}
+			return i18n.For(lang)
+		},
+	}
+
+	// The 1st of June 2025 reports on May.
+	if err := svc.SendFinanceDigest(context.Background(), time.Date(2025, 6, 1, 9, 0, 0, 0, time.UTC)); err != nil {
+		t.Fatal(err)
+	}
+	en := sender.sent["1"]
+	for _, want := range []string{"Monthly finance digest", "Financial Report 2025-05", "Still missing", "2025-05-03 Pharmacy", "20,000\u202fMGA", "Total shortfall: 20,000\u202fMGA"} {
+		if !strings.Contains(en, want) {
+			t.Errorf("alert chat digest missing %q:\n%s", want, en)
+		}
+	}
+	if strings.Contains(en, "Rent |") || strings.Contains(en, "99") {
+		t.Errorf("digest lists a covered need or another month:\n%s", en)
+	}
+	if fr := sender.sent["7"]; !strings.Contains(fr, "Résumé financier du mois") || !strings.Contains(fr, "mai 2025") {
+		t.Errorf("subscribed chat digest not in French:\n%s", fr)
+	}
+
+	// A chat that cannot be reached does not stop the others.
+	if err := prefs.SaveChatPrefs(domain.ChatPrefs{ChatID: "9", Digests: map[domain.DigestKind]bool{domain.DigestFinance: true}}); err != nil {
+		t.Fatal(err)
+	}
+	sender.sent = nil
+	err := svc.SendFinanceDigest(context.Background(), time.Date(2025, 6, 1, 9, 0, 0, 0, time.UTC))
+	if err == nil || !strings.Contains(err.Error(), "chat 9") {
+		t.Errorf("err = %v, want the failed chat reported", err)
+	}
+	if len(sender.sent) != 2 {
+		t.Errorf("sent to %d chats, want 2", len(sender.sent))
+	}
+}
+
+func TestDigestService_SendStockDigest(t *testing.T) {
+	start := domain.NewFlexibleDate(time.Date(2025, 6, 1, 0, 0, 0, 0, time.UTC))
+	forecast := domain.NewFlexibleDate(time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC))
+	repo := &testutil.Store{Meds: []domain.Medicine{
+		{ID: "m1", Name: "Aspirin", DailyDose: 2, InitialStock: 20, StartDate: start, ForecastOutOfStockDate: &forecast},
+	}}
+	sender := &chatSender{}
+	svc := usecase.DigestService{
+		Airtable:    repo,
+		Prefs:       digestPrefs(t, domain.ChatPrefs{ChatID: "7", Digests: map[domain.DigestKind]bool{domain.DigestFinance: true}}),
+		Sender:      sender,
+		DefaultChat: "1",
+	}
+
+	if err := svc.SendStockDigest(context.Background(), time.Date(2025, 6, 3, 9, 0, 0, 0, time.UTC)); err != nil {
+		t.Fatal(err)
+	}
+	if len(sender.sent) != 1 {
+		t.Fatalf("sent to %v, want the alert chat only", sender.sent)
+	}
+	got := sender.sent["1"]
+	for _, want := range []string{"Weekly stock digest", "Out-of-Stock Forecast", "Aspirin", "2025-06-11"} {
+		if !strings.Contains(got, want) {
+			t.Errorf("stock digest missing %q:\n%s", want, got)
+		}
+	}
+}
@@ -0,0 +1,213 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/nomenarkt/vitaltrack/backend/internal/domain"
+	"github.com/nomenarkt/vitaltrack/backend/internal/domain/ports"
+	"github.com/nomenarkt/vitaltrack/backend/internal/logic/units"
+)
+
+// ErrEntryNotFound is returned when a stock entry ID does not exist.
+var ErrEntryNotFound = errors.New("stock entry not found")
+
+// StockEntryService manages refill records.
+type StockEntryService struct {
+	Repo ports.StockDataPort
+}
+
+// checkUnit returns unit as stored, or ErrInvalidInput when m does not
+// define it.
+func checkUnit(m domain.Medicine, unit string) (string, error) {
+	g := units.For(m)
+	if _, ok := g.Factor(unit); !ok {
+		return "", fmt.Errorf("%w: unit must be one of %s", ErrInvalidInput, strings.Join(g.Units(), ", "))
+	}
+	return units.Normalize(unit), nil
+}
+
+// checkKind verifies that the kind of e is known, that its quantity suits
+// the kind and that entries other than refills give a reason.
+func checkKind(e domain.StockEntry) error {
+	kind := e.EffectiveKind()
+	if !slices.Contains(domain.EntryKinds, kind) {
+		return fmt.Errorf("%w: kind must be one of refill, wastage, transfer_out, correction", ErrInvalidInput)
+	}
+	if !e.ValidQuantity() {
+		if kind == domain.EntryCorrection {
+			return fmt.Errorf("%w: quantity of a correction must not be 0", ErrInvalidInput)
+		}
+		return fmt.Errorf("%w: quantity must be > 0", ErrInvalidInput)
+	}
+	if kind != domain.EntryRefill && e.Reason == "" {
+		return fmt.Errorf("%w: reason must not be empty for %s entries", ErrInvalidInput, kind)
+	}
+	return nil
+}
+
+// ListEntries returns stock entries matching filter, most recent first.
+func (s StockEntryService) ListEntries(ctx context.Context, filter domain.StockEntryFilter) (ListResult[domain.StockEntry], error) {
+	entries, err := s.Repo.FetchStockEntries(ctx)
+	if err != nil {
+		return ListResult[domain.StockEntry]{}, fmt.Errorf("fetch stock entries failed: %w", err)
+	}
+
+	matched := []domain.StockEntry{}
+	for _, e := range entries {
+		if e.Archived && !filter.IncludeArchived {
+			continue
+		}
+		if filter.MedicineID != "" && (len(e.MedicineID) == 0 || e.MedicineID[0] != filter.MedicineID) {
+			continue
+		}
+		if filter.Kind != "" && e.EffectiveKind() != filter.Kind {
+			continue
+		}
+		if !filter.From.IsZero() && e.Date.Before(filter.From) {
+			continue
+		}
+		if !filter.To.IsZero() && e.Date.After(filter.To) {
+			continue
+		}
+		matched = append(matched, e)
+	}
+	sort.SliceStable(matched, func(i, j int) bool { return matched[i].Date.After(matched[j].Date.Time) })
+
+	return paginate(matched, filter.Page), nil
+}
+
+// GetEntry returns a single stock entry by ID.
+func (s StockEntryService) GetEntry(ctx context.Context, id string) (domain.StockEntry, error) {
+	e, err := s.Repo.GetStockEntry(ctx, id)
+	if errors.Is(err, domain.ErrNotFound) {
+		return domain.StockEntry{}, ErrEntryNotFound
+	}
+	if err != nil {
+		return domain.StockEntry{}, fmt.Errorf("get stock entry failed: %w", err)
+	}
+	return e, nil
+}
+
+// CreateEntry validates req and records a refill, or another kind of entry,
+// for an active medicine.
+func (s StockEntryService) CreateEntry(ctx context.Context, req domain.CreateStockEntryRequest) (domain.StockEntry, error) {
+	if req.MedicineID == "" {
+		return domain.StockEntry{}, fmt.Errorf("%w: medicine_id must not be empty", ErrInvalidInput)
+	}
+	entry := domain.StockEntry{Kind: req.Kind, Quantity: req.Quantity, Reason: strings.TrimSpace(req.Reason)}
+	entry.Kind = entry.EffectiveKind()
+	if err := checkKind(entry); err != nil {
+		return domain.StockEntry{}, err
+	}
+	if req.Date == "" {
+		return domain.StockEntry{}, fmt.Errorf("%w: date must not be empty", ErrInvalidInput)
+	}
+	date, err := domain.ParseDate(req.Date)
+	if err != nil {
+		return domain.StockEntry{}, fmt.Errorf("%w: invalid date format, expected YYYY-MM-DD or RFC3339", ErrInvalidInput)
+	}
+
+	med, err := getMedicine(ctx, s.Repo, req.MedicineID)
+	if err != nil {
+		return domain.StockEntry{}, err
+	}
+	if med.Archived {
+		return domain.StockEntry{}, fmt.Errorf("%w: medicine %s is archived", ErrInvalidInput, med.ID)
+	}
+	if entry.Unit, err = checkUnit(med, req.Unit); err != nil {
+		return domain.StockEntry{}, err
+	}
+	entry.MedicineID = []string{med.ID}
+	entry.Date = domain.NewFlexibleDate(date)
+
+	e, err := s.Repo.CreateStockEntry(ctx, entry)
+	if err != nil {
+		return domain.StockEntry{}, fmt.Errorf("create stock entry failed: %w", err)
+	}
+	return e, nil
+}
+
+// UpdateEntry validates and applies patch to an existing stock entry. Like
+// CreateEntry, it refuses an empty date and moving the entry to an archived
+// medicine.
+func (s StockEntryService) UpdateEntry(ctx context.Context, id string, patch domain.StockEntryPatch) (domain.StockEntry, error) {
+	if patch.Date != nil && patch.Date.IsZero() {
+		return domain.StockEntry{}, fmt.Errorf("%w: date must not be empty", ErrInvalidInput)
+	}
+	if patch.Reason != nil {
+		reason := strings.TrimSpace(*patch.Reason)
+		patch.Reason = &reason
+	}
+	if patch.MedicineID != nil || patch.Kind != nil || patch.Quantity != nil || patch.Unit != nil || patch.Reason != nil {
+		unit, err := s.checkPatch(ctx, id, patch)
+		if err != nil {
+			return domain.StockEntry{}, err
+		}
+		if patch.Unit != nil {
+			patch.Unit = &unit
+		}
+	}
+
+	e, err := s.Repo.UpdateStockEntry(ctx, id, patch)
+	if errors.Is(err, domain.ErrNotFound) {
+		return domain.StockEntry{}, ErrEntryNotFound
+	}
+	if err != nil {
+		return domain.StockEntry{}, fmt.Errorf("update stock entry failed: %w", err)
+	}
+	return e, nil
+}
+
+// checkPatch checks entry id once patched: its kind, quantity and reason,
+// that a new medicine is not archived and that its medicine defines its
+// unit. It returns the unit as stored.
+func (s StockEntryService) checkPatch(ctx context.Context, id string, patch domain.StockEntryPatch) (string, error) {
+	e, err := s.GetEntry(ctx, id)
+	if err != nil {
+		return "", err
+	}
+	if patch.MedicineID != nil {
+		e.MedicineID = []string{*patch.MedicineID}
+	}
+	if patch.Kind != nil {
+		e.Kind = *patch.Kind
+	}
+	if patch.Quantity != nil {
+		e.Quantity = *patch.Quantity
+	}
+	if patch.Unit != nil {
+		e.Unit = *patch.Unit
+	}
+	if patch.Reason != nil {
+		e.Reason = *patch.Reason
+	}
+	if err := checkKind(e); err != nil {
+		return "", err
+	}
+	if patch.Unit == nil && patch.MedicineID == nil {
+		return e.Unit, nil
+	}
+
+	var med domain.Medicine
+	if len(e.MedicineID) > 0 {
+		if med, err = getMedicine(ctx, s.Repo, e.MedicineID[0]); err != nil {
+			return "", err
+		}
+	}
+	if patch.MedicineID != nil && med.Archived {
+		return "", fmt.Errorf("%w: medicine %s is archived", ErrInvalidInput, med.ID)
+	}
+	return checkUnit(med, e.Unit)
+}
+
+// ArchiveEntry soft-deletes a stock entry so it no longer counts towards stock.
+func (s StockEntryService) ArchiveEntry(ctx context.Context, id string) error {
+	archived := true
+	_, err := s.UpdateEntry(ctx, id, domain.StockEntryPatch{Archived: &archived})
+	return err
+}
@@ -0,0 +1,254 @@
+package usecase_test
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/nomenarkt/vitaltrack/backend/internal/domain"
+	"github.com/nomenarkt/vitaltrack/backend/internal/testutil"
+	"github.com/nomenarkt/vitaltrack/backend/internal/usecase"
+)
+
+func TestStockEntryService_CreateEntry(t *testing.T) {
+	tests := []struct {
+		name    string
+		req     domain.CreateStockEntryRequest
+		wantErr error
+	}{
+		{name: "ok", req: domain.CreateStockEntryRequest{MedicineID: "m1", Quantity: 1, Unit: "box", Date: "2025-06-02"}},
+		{name: "rfc3339", req: domain.CreateStockEntryRequest{MedicineID: "m1", Quantity: 3, Unit: "pill", Date: "2025-06-02T08:00:00Z"}},
+		{name: "zero_quantity", req: domain.CreateStockEntryRequest{MedicineID: "m1", Quantity: 0, Unit: "box", Date: "2025-06-02"}, wantErr: usecase.ErrInvalidInput},
+		{name: "bad_unit", req: domain.CreateStockEntryRequest{MedicineID: "m1", Quantity: 1, Unit: "crate", Date: "2025-06-02"}, wantErr: usecase.ErrInvalidInput},
+		{name: "negative_refill", req: domain.CreateStockEntryRequest{MedicineID: "m1", Quantity: -2, Unit: "pill", Date: "2025-06-02"}, wantErr: usecase.ErrInvalidInput},
+		{name: "wastage", req: domain.CreateStockEntryRequest{MedicineID: "m1", Kind: domain.EntryWastage, Quantity: 2, Unit: "pill", Date: "2025-06-02", Reason: "expired"}},
+		{name: "wastage_without_reason", req: domain.CreateStockEntryRequest{MedicineID: "m1", Kind: domain.EntryWastage, Quantity: 2, Unit: "pill", Date: "2025-06-02", Reason: " "}, wantErr: usecase.ErrInvalidInput},
+		{name: "negative_wastage", req: domain.CreateStockEntryRequest{MedicineID: "m1", Kind: domain.EntryTransferOut, Quantity: -2, Unit: "pill", Date: "2025-06-02", Reason: "neighbour"}, wantErr: usecase.ErrInvalidInput},
+		{name: "negative_correction", req: domain.CreateStockEntryRequest{MedicineID: "m1", Kind: domain.EntryCorrection, Quantity: -3, Unit: "pill", Date: "2025-06-02", Reason: "recount"}},
+		{name: "zero_correction", req: domain.CreateStockEntryRequest{MedicineID: "m1", Kind: domain.EntryCorrection, Quantity: 0, Unit: "pill", Date: "2025-06-02", Reason: "recount"}, wantErr: usecase.ErrInvalidInput},
+		{name: "unknown_kind", req: domain.CreateStockEntryRequest{MedicineID: "m1", Kind: "theft", Quantity: 2, Unit: "pill", Date: "2025-06-02", Reason: "?"}, wantErr: usecase.ErrInvalidInput},
+		{name: "medicine_unit", req: domain.CreateStockEntryRequest{MedicineID: "syrup", Quantity: 2, Unit: "Bottle", Date: "2025-06-02"}},
+		{name: "box_without_unit_per_box", req: domain.CreateStockEntryRequest{MedicineID: "loose", Quantity: 1, Unit: "box", Date: "2025-06-02"}, wantErr: usecase.ErrInvalidInput},
+		{name: "unit_of_other_medicine", req: domain.CreateStockEntryRequest{MedicineID: "m1", Quantity: 2, Unit: "bottle", Date: "2025-06-02"}, wantErr: usecase.ErrInvalidInput},
+		{name: "bad_date", req: domain.CreateStockEntryRequest{MedicineID: "m1", Quantity: 1, Unit: "box", Date: "02/06/2025"}, wantErr: usecase.ErrInvalidInput},
+		{name: "missing_medicine_id", req: domain.CreateStockEntryRequest{Quantity: 1, Unit: "box", Date: "2025-06-02"}, wantErr: usecase.ErrInvalidInput},
+		{name: "unknown_medicine", req: domain.CreateStockEntryRequest{MedicineID: "nope", Quantity: 1, Unit: "box", Date: "2025-06-02"}, wantErr: usecase.ErrMedicineNotFound},
+		{name: "archived_medicine", req: domain.CreateStockEntryRequest{MedicineID: "old", Quantity: 1, Unit: "box", Date: "2025-06-02"}, wantErr: usecase.ErrInvalidInput},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := &testutil.Store{Meds: []domain.Medicine{
+				{ID: "m1", Name: "Med1", UnitPerBox: 30},
+				{ID: "loose", Name: "Loose"},
+				{ID: "old", Name: "Old", Archived: true},
+				{ID: "syrup", Name: "Syrup", UnitType: "ml", Units: "bottle = 100 ml"},
+			}}
+			svc := usecase.StockEntryService{Repo: repo}
+
+			e, err := svc.CreateEntry(context.Background(), tt.req)
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("err = %v, want %v", err, tt.wantErr)
+				}
+				if len(repo.Entries) != 0 {
+					t.Fatalf("entry stored despite error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if e.ID == "" || len(e.MedicineID) != 1 || e.MedicineID[0] != tt.req.MedicineID || e.Unit != strings.ToLower(tt.req.Unit) {
+				t.Errorf("unexpected entry: %+v", e)
+			}
+			if want := cmp.Or(tt.req.Kind, domain.EntryRefill); e.Kind != want {
+				t.Errorf("kind = %q, want %q", e.Kind, want)
+			}
+		})
+	}
+}
+
+func TestStockEntryService_ListEntries(t *testing.T) {
+	day := func(d int) domain.FlexibleDate {
+		return domain.NewFlexibleDate(time.Date(2025, 6, d, 0, 0, 0, 0, time.UTC))
+	}
+	repo := &testutil.Store{Entries: []domain.StockEntry{
+		{ID: "e1", MedicineID: []string{"m1"}, Quantity: 1, Unit: "box", Date: day(1)},
+		{ID: "e2", MedicineID: []string{"m2"}, Kind: domain.EntryWastage, Quantity: 1, Unit: "box", Date: day(2), Reason: "expired"},
+		{ID: "e3", MedicineID: []string{"m1"}, Quantity: 1, Unit: "box", Date: day(3)},
+		{ID: "e4", MedicineID: []string{"m1"}, Quantity: 1, Unit: "box", Date: day(4), Archived: true},
+	}}
+	svc := usecase.StockEntryService{Repo: repo}
+
+	tests := []struct {
+		name      string
+		filter    domain.StockEntryFilter
+		wantIDs   []string
+		wantTotal int
+	}{
+		{name: "all_active", wantIDs: []string{"e3", "e2", "e1"}, wantTotal: 3},
+		{name: "include_archived", filter: domain.StockEntryFilter{IncludeArchived: true}, wantIDs: []string{"e4", "e3", "e2", "e1"}, wantTotal: 4},
+		{name: "by_medicine", filter: domain.StockEntryFilter{MedicineID: "m1"}, wantIDs: []string{"e3", "e1"}, wantTotal: 2},
+		{name: "by_kind", filter: domain.StockEntryFilter{Kind: domain.EntryWastage}, wantIDs: []string{"e2"}, wantTotal: 1},
+		{name: "refills", filter: domain.StockEntryFilter{Kind: domain.EntryRefill}, wantIDs: []string{"e3", "e1"}, wantTotal: 2},
+		{name: "date_range", filter: domain.StockEntryFilter{From: day(2).Time, To: day(3).Time}, wantIDs: []string{"e3", "e2"}, wantTotal: 2},
+		{name: "paged", filter: domain.StockEntryFilter{Page: domain.Page{Limit: 1, Offset: 1}}, wantIDs: []string{"e2"}, wantTotal: 3},
+		{name: "offset_past_end", filter: domain.StockEntryFilter{Page: domain.Page{Offset: 10}}, wantIDs: []string{}, wantTotal: 3},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			res, err := svc.ListEntries(context.Background(), tt.filter)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if res.Total != tt.wantTotal {
+				t.Errorf("total = %d, want %d", res.Total, tt.wantTotal)
+			}
+			var got []string
+			for _, e := range res.Items {
+				got = append(got, e.ID)
+			}
+			if fmt.Sprint(got) != fmt.Sprint(tt.wantIDs) {
+				t.Errorf("ids = %v, want %v", got, tt.wantIDs)
+			}
+		})
+	}
+}
+
+func TestStockEntryService_ArchiveEntry(t *testing.T) {
+	repo := &testutil.Store{Entries: []domain.StockEntry{{ID: "e1", MedicineID: []string{"m1"}, Quantity: 1, Unit: "box"}}}
+	svc := usecase.StockEntryService{Repo: repo}
+
+	if err := svc.ArchiveEntry(context.Background(), "e1"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !repo.Entries[0].Archived {
+		t.Errorf("entry not archived")
+	}
+	if err := svc.ArchiveEntry(context.Background(), "missing"); !errors.Is(err, usecase.ErrEntryNotFound) {
+		t.Errorf("err = %v, want ErrEntryNotFound", err)
+	}
+}
+
+func TestStockEntryService_UpdateEntry_kind(t *testing.T) {
+	repo := &testutil.Store{
+		Meds:    []domain.Medicine{{ID: "m1", Name: "Med1"}},
+		Entries: []domain.StockEntry{{ID: "e1", MedicineID: []string{"m1"}, Quantity: 1, Unit: "box"}},
+	}
+	svc := usecase.StockEntryService{Repo: repo}
+	wastage, correction := domain.EntryWastage, domain.EntryCorrection
+	reason, minus := "spilled", -4.0
+
+	if _, err := svc.UpdateEntry(context.Background(), "e1", domain.StockEntryPatch{Kind: &wastage}); !errors.Is(err, usecase.ErrInvalidInput) {
+		t.Fatalf("wastage without reason: err = %v, want ErrInvalidInput", err)
+	}
+	if _, err := svc.UpdateEntry(context.Background(), "e1", domain.StockEntryPatch{Quantity: &minus}); !errors.Is(err, usecase.ErrInvalidInput) {
+		t.Fatalf("negative refill: err = %v, want ErrInvalidInput", err)
+	}
+	e, err := svc.UpdateEntry(context.Background(), "e1", domain.StockEntryPatch{Kind: &correction, Quantity: &minus, Reason: &reason})
+	if err != nil {
+		t.Fatalf("correction: %v", err)
+	}
+	if e.Kind != correction || e.Quantity != -4 || e.Reason != reason {
+		t.Errorf("unexpected entry: %+v", e)
+	}
+	if _, err := svc.UpdateEntry(context.Background(), "missing", domain.StockEntryPatch{Reason: &reason}); !errors.Is(err, usecase.ErrEntryNotFound) {
+		t.Errorf("missing entry: err = %v, want ErrEntryNotFound", err)
+	}
+}
+
+func TestStockEntryService_UpdateEntry_unit(t *testing.T) {
+	repo := &testutil.Store{
+		Meds: []domain.Medicine{
+			{ID: "m1", Name: "Med1", UnitPerBox: 30},
+			{ID: "syrup", Name: "Syrup", UnitType: "ml", Units: "bottle = 100 ml"},
+			{ID: "old", Name: "Old", UnitPerBox: 30, Archived: true},
+		},
+		Entries: []domain.StockEntry{{ID: "e1", MedicineID: []string{"m1"}, Quantity: 1, Unit: "box"}},
+	}
+	svc := usecase.StockEntryService{Repo: repo}
+	ptr := func(s string) *string { return &s }
+
+	tests := []struct {
+		name    string
+		patch   domain.StockEntryPatch
+		wantErr error
+	}{
+		{name: "unit", patch: domain.StockEntryPatch{Unit: ptr("pill")}},
+		{name: "unknown_unit", patch: domain.StockEntryPatch{Unit: ptr("bottle")}, wantErr: usecase.ErrInvalidInput},
+		{name: "medicine_without_unit", patch: domain.StockEntryPatch{MedicineID: ptr("syrup")}, wantErr: usecase.ErrInvalidInput},
+		{name: "medicine_and_unit", patch: domain.StockEntryPatch{MedicineID: ptr("syrup"), Unit: ptr("bottle")}},
+		{name: "unknown_medicine", patch: domain.StockEntryPatch{MedicineID: ptr("nope"), Unit: ptr("pill")}, wantErr: usecase.ErrMedicineNotFound},
+		{name: "archived_medicine", patch: domain.StockEntryPatch{MedicineID: ptr("old")}, wantErr: usecase.ErrInvalidInput},
+		{name: "empty_date", patch: domain.StockEntryPatch{Date: &domain.FlexibleDate{}}, wantErr: usecase.ErrInvalidInput},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := svc.UpdateEntry(context.Background(), "e1", tt.patch); !errors.Is(err, tt.wantErr) {
+				t.Errorf("err = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestFinancialEntryService_CreateAndList(t *testing.T) {
+	repo := &testutil.Store{}
+	svc := usecase.FinancialEntryService{Repo: repo}
+
+	if _, err := svc.CreateEntry(context.Background(), domain.CreateFinancialEntryRequest{Date: "2025-06-05", NeedLabel: "Med", NeedAmount: 20, AmountContributed: 5, Contributor: "Bob"}); err != nil {
+		t.Fatalf("create: %v", err)
+	}
+	if _, err := svc.CreateEntry(context.Background(), domain.CreateFinancialEntryRequest{Date: "2025-06-03", NeedLabel: "Food", NeedAmount: 10, AmountContributed: 10, Contributor: "Alice"}); err != nil {
+		t.Fatalf("create: %v", err)
+	}
+	if _, err := svc.CreateEntry(context.Background(), domain.CreateFinancialEntryRequest{Date: "2025-06-03", NeedLabel: "Food", AmountContributed: -1, Contributor: "Alice"}); !errors.Is(err, usecase.ErrInvalidInput) {
+		t.Fatalf("err = %v, want ErrInvalidInput", err)
+	}
+	if repo.Financial[0].MonthTag != "2025-06" {
+		t.Errorf("MonthTag = %q, want derived 2025-06", repo.Financial[0].MonthTag)
+	}
+	moved := domain.NewFlexibleDate(time.Date(2025, 7, 1, 0, 0, 0, 0, time.UTC))
+	if e, err := svc.UpdateEntry(context.Background(), repo.Financial[1].ID, domain.FinancialEntryPatch{Date: &moved}); err != nil || e.MonthTag != "2025-07" {
+		t.Fatalf("moved entry = %+v, %v; want MonthTag 2025-07", e, err)
+	}
+	if _, err := svc.UpdateEntry(context.Background(), repo.Financial[1].ID, domain.FinancialEntryPatch{Date: &domain.FlexibleDate{}}); !errors.Is(err, usecase.ErrInvalidInput) {
+		t.Fatalf("empty date: err = %v, want ErrInvalidInput", err)
+	}
+	back := domain.NewFlexibleDate(time.Date(2025, 6, 3, 0, 0, 0, 0, time.UTC))
+	if _, err := svc.UpdateEntry(context.Background(), repo.Financial[1].ID, domain.FinancialEntryPatch{Date: &back}); err != nil {
+		t.Fatal(err)
+	}
+
+	res, err := svc.ListEntries(context.Background(), domain.FinancialEntryFilter{Year: 2025, Month: time.June})
+	if err != nil {
+		t.Fatalf("list: %v", err)
+	}
+	if res.Total != 2 || res.Items[0].NeedLabel != "Food" {
+		t.Fatalf("unexpected listing: %+v", res)
+	}
+
+	res, err = svc.ListEntries(context.Background(), domain.FinancialEntryFilter{Year: 2025, Month: time.June, Contributor: "bob"})
+	if err != nil {
+		t.Fatalf("list: %v", err)
+	}
+	if res.Total != 1 || res.Items[0].Contributor != "Bob" {
+		t.Fatalf("unexpected contributor filter result: %+v", res)
+	}
+
+	if err := svc.ArchiveEntry(context.Background(), res.Items[0].ID); err != nil {
+		t.Fatalf("archive: %v", err)
+	}
+	res, err = svc.ListEntries(context.Background(), domain.FinancialEntryFilter{Year: 2025, Month: time.June})
+	if err != nil {
+		t.Fatalf("list: %v", err)
+	}
+	if res.Total != 1 {
+		t.Errorf("archived entry still listed: %+v", res)
+	}
+}
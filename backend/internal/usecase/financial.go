@@ -1,12 +1,17 @@
 package usecase
 
 import (
+	"context"
 	"fmt"
 	"sort"
 	"time"
 
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+
 	"github.com/nomenarkt/vitaltrack/backend/internal/domain"
 	"github.com/nomenarkt/vitaltrack/backend/internal/domain/ports"
+	"github.com/nomenarkt/vitaltrack/backend/internal/tracing"
 )
 
 // FinancialReportService handles aggregation of financial entries.
@@ -15,12 +20,21 @@ type FinancialReportService struct {
 }
 
 // GenerateFinancialReport groups financial entries by need and contributor.
-func (s FinancialReportService) GenerateFinancialReport(year, month int) (domain.MonthlyFinancialReport, error) {
-	entries, err := s.Repo.FetchFinancialEntries(year, time.Month(month))
+// Fetching and aggregating are traced as separate spans.
+func (s FinancialReportService) GenerateFinancialReport(ctx context.Context, year, month int) (domain.MonthlyFinancialReport, error) {
+	ctx, span := tracing.Start(ctx, "finance report", trace.WithAttributes(attribute.String("month", fmt.Sprintf("%04d-%02d", year, month))))
+	defer span.End()
+
+	entries, err := s.Repo.FetchFinancialEntries(ctx, year, time.Month(month))
 	if err != nil {
-		return domain.MonthlyFinancialReport{}, fmt.Errorf("fetch financial entries failed: %w", err)
+		err = fmt.Errorf("fetch financial entries failed: %w", err)
+		tracing.Fail(span, err)
+		return domain.MonthlyFinancialReport{}, err
 	}
 
+	_, aggregate := tracing.Start(ctx, "finance aggregate", trace.WithAttributes(attribute.Int("entries", len(entries))))
+	defer aggregate.End()
+
 	// 👥 Define contributor display order
 	desiredOrder := []string{"Onja", "Tafita", "Henintsoa", "Mahandry"}
 	orderIndex := map[string]int{}
@@ -36,6 +50,9 @@ func (s FinancialReportService) GenerateFinancialReport(year, month int) (domain
 	total := 0.0
 
 	for _, e := range entries {
+		if e.Archived {
+			continue
+		}
 		key := fmt.Sprintf("%s %s", e.Date.Format("2006-01-02"), e.NeedLabel)
 
 		if _, ok := breakdown[key]; !ok {
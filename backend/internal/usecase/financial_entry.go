@@ -0,0 +1,134 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/nomenarkt/vitaltrack/backend/internal/domain"
+	"github.com/nomenarkt/vitaltrack/backend/internal/domain/ports"
+	"github.com/nomenarkt/vitaltrack/backend/internal/logic/calendar"
+)
+
+// ErrFinancialEntryNotFound is returned when a financial entry ID does not exist.
+var ErrFinancialEntryNotFound = errors.New("financial entry not found")
+
+// FinancialEntryService manages contribution records.
+type FinancialEntryService struct {
+	Repo     ports.FinancialDataPort
+	Location *time.Location // household timezone for the current month, UTC when nil
+}
+
+// ListEntries returns the month's financial entries matching filter, ordered
+// by date. A zero Year selects the current month in the household timezone.
+func (s FinancialEntryService) ListEntries(ctx context.Context, filter domain.FinancialEntryFilter) (ListResult[domain.FinancialEntry], error) {
+	if filter.Year == 0 {
+		now := calendar.In(time.Now(), s.Location)
+		filter.Year, filter.Month = now.Year(), now.Month()
+	}
+	entries, err := s.Repo.FetchFinancialEntries(ctx, filter.Year, filter.Month)
+	if err != nil {
+		return ListResult[domain.FinancialEntry]{}, fmt.Errorf("fetch financial entries failed: %w", err)
+	}
+
+	matched := []domain.FinancialEntry{}
+	for _, e := range entries {
+		if e.Archived && !filter.IncludeArchived {
+			continue
+		}
+		if filter.Contributor != "" && !strings.EqualFold(e.Contributor, filter.Contributor) {
+			continue
+		}
+		if filter.NeedLabel != "" && !strings.EqualFold(e.NeedLabel, filter.NeedLabel) {
+			continue
+		}
+		matched = append(matched, e)
+	}
+	sort.SliceStable(matched, func(i, j int) bool { return matched[i].Date.Before(matched[j].Date.Time) })
+
+	return paginate(matched, filter.Page), nil
+}
+
+// GetEntry returns a single financial entry by ID.
+func (s FinancialEntryService) GetEntry(ctx context.Context, id string) (domain.FinancialEntry, error) {
+	e, err := s.Repo.GetFinancialEntry(ctx, id)
+	if errors.Is(err, domain.ErrNotFound) {
+		return domain.FinancialEntry{}, ErrFinancialEntryNotFound
+	}
+	if err != nil {
+		return domain.FinancialEntry{}, fmt.Errorf("get financial entry failed: %w", err)
+	}
+	return e, nil
+}
+
+// CreateEntry validates req and records a contribution. MonthTag defaults to
+// the month of Date.
+func (s FinancialEntryService) CreateEntry(ctx context.Context, req domain.CreateFinancialEntryRequest) (domain.FinancialEntry, error) {
+	if strings.TrimSpace(req.NeedLabel) == "" || strings.TrimSpace(req.Contributor) == "" {
+		return domain.FinancialEntry{}, fmt.Errorf("%w: NeedLabel and Contributor must not be empty", ErrInvalidInput)
+	}
+	if req.NeedAmount < 0 || req.AmountContributed < 0 {
+		return domain.FinancialEntry{}, fmt.Errorf("%w: amounts must not be negative", ErrInvalidInput)
+	}
+	date, err := domain.ParseDate(req.Date)
+	if err != nil {
+		return domain.FinancialEntry{}, fmt.Errorf("%w: Date: expected YYYY-MM-DD or RFC3339", ErrInvalidInput)
+	}
+	monthTag := req.MonthTag
+	if monthTag == "" {
+		monthTag = date.Format("2006-01")
+	}
+
+	e, err := s.Repo.CreateFinancialEntry(ctx, domain.FinancialEntry{
+		Date:              domain.NewFlexibleDate(date),
+		NeedLabel:         strings.TrimSpace(req.NeedLabel),
+		NeedAmount:        req.NeedAmount,
+		AmountContributed: req.AmountContributed,
+		MonthTag:          monthTag,
+		Contributor:       strings.TrimSpace(req.Contributor),
+	})
+	if err != nil {
+		return domain.FinancialEntry{}, fmt.Errorf("create financial entry failed: %w", err)
+	}
+	return e, nil
+}
+
+// UpdateEntry validates and applies patch to an existing financial entry. A
+// new Date, which must not be empty, also sets MonthTag to its month, unless
+// patch sets MonthTag.
+func (s FinancialEntryService) UpdateEntry(ctx context.Context, id string, patch domain.FinancialEntryPatch) (domain.FinancialEntry, error) {
+	if patch.Date != nil && patch.Date.IsZero() {
+		return domain.FinancialEntry{}, fmt.Errorf("%w: Date must not be empty", ErrInvalidInput)
+	}
+	if (patch.NeedLabel != nil && strings.TrimSpace(*patch.NeedLabel) == "") ||
+		(patch.Contributor != nil && strings.TrimSpace(*patch.Contributor) == "") {
+		return domain.FinancialEntry{}, fmt.Errorf("%w: NeedLabel and Contributor must not be empty", ErrInvalidInput)
+	}
+	if (patch.NeedAmount != nil && *patch.NeedAmount < 0) ||
+		(patch.AmountContributed != nil && *patch.AmountContributed < 0) {
+		return domain.FinancialEntry{}, fmt.Errorf("%w: amounts must not be negative", ErrInvalidInput)
+	}
+	if patch.Date != nil && patch.MonthTag == nil {
+		monthTag := patch.Date.Format("2006-01")
+		patch.MonthTag = &monthTag
+	}
+
+	e, err := s.Repo.UpdateFinancialEntry(ctx, id, patch)
+	if errors.Is(err, domain.ErrNotFound) {
+		return domain.FinancialEntry{}, ErrFinancialEntryNotFound
+	}
+	if err != nil {
+		return domain.FinancialEntry{}, fmt.Errorf("update financial entry failed: %w", err)
+	}
+	return e, nil
+}
+
+// ArchiveEntry soft-deletes a financial entry so it no longer counts in reports.
+func (s FinancialEntryService) ArchiveEntry(ctx context.Context, id string) error {
+	archived := true
+	_, err := s.UpdateEntry(ctx, id, domain.FinancialEntryPatch{Archived: &archived})
+	return err
+}
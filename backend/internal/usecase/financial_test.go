@@ -1,6 +1,7 @@
 package usecase_test
 
 import (
+	"context"
 	"reflect"
 	"sort"
 	"testing"
@@ -14,10 +15,20 @@ type mockFinanceRepo struct {
 	entries []domain.FinancialEntry
 }
 
-func (m mockFinanceRepo) FetchFinancialEntries(_ int, _ time.Month) ([]domain.FinancialEntry, error) {
+func (m mockFinanceRepo) FetchFinancialEntries(_ context.Context, _ int, _ time.Month) ([]domain.FinancialEntry, error) {
 	return m.entries, nil
 }
 
+func (m mockFinanceRepo) GetFinancialEntry(context.Context, string) (domain.FinancialEntry, error) {
+	return domain.FinancialEntry{}, nil
+}
+func (m mockFinanceRepo) CreateFinancialEntry(context.Context, domain.FinancialEntry) (domain.FinancialEntry, error) {
+	return domain.FinancialEntry{}, nil
+}
+func (m mockFinanceRepo) UpdateFinancialEntry(context.Context, string, domain.FinancialEntryPatch) (domain.FinancialEntry, error) {
+	return domain.FinancialEntry{}, nil
+}
+
 func sortContributors(input []domain.ContributorAmount, order []string) []domain.ContributorAmount {
 	orderMap := map[string]int{}
 	for i, name := range order {
@@ -191,7 +202,7 @@ func TestGenerateFinancialReport(t *testing.T) {
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			svc := usecase.FinancialReportService{Repo: mockFinanceRepo{entries: tt.entries}}
-			rep, err := svc.GenerateFinancialReport(tt.year, tt.month)
+			rep, err := svc.GenerateFinancialReport(context.Background(), tt.year, tt.month)
 			if err != nil {
 				t.Fatalf("unexpected error: %v", err)
 			}
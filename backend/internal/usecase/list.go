@@ -0,0 +1,49 @@
+package usecase
+
+import (
+	"errors"
+
+	"github.com/nomenarkt/vitaltrack/backend/internal/domain"
+)
+
+// ErrInvalidInput wraps validation failures on create and update requests.
+var ErrInvalidInput = errors.New("invalid input")
+
+const (
+	defaultPageLimit = 50
+	maxPageLimit     = 200
+)
+
+// ListResult is one page of a filtered listing along with the unpaged total.
+type ListResult[T any] struct {
+	Items  []T
+	Total  int
+	Limit  int
+	Offset int
+}
+
+// paginate slices items according to p, applying default and maximum limits.
+func paginate[T any](items []T, p domain.Page) ListResult[T] {
+	limit := p.Limit
+	if limit <= 0 {
+		limit = defaultPageLimit
+	}
+	if limit > maxPageLimit {
+		limit = maxPageLimit
+	}
+	offset := p.Offset
+	if offset < 0 {
+		offset = 0
+	}
+
+	res := ListResult[T]{Items: []T{}, Total: len(items), Limit: limit, Offset: offset}
+	if offset >= len(items) {
+		return res
+	}
+	end := offset + limit
+	if end > len(items) {
+		end = len(items)
+	}
+	res.Items = items[offset:end]
+	return res
+}
@@ -1,46 +1,63 @@
 package usecase
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"math"
+	"sort"
+	"strings"
 	"time"
 
 	"github.com/nomenarkt/vitaltrack/backend/internal/domain"
 	"github.com/nomenarkt/vitaltrack/backend/internal/domain/ports"
+	"github.com/nomenarkt/vitaltrack/backend/internal/logic/calendar"
 	"github.com/nomenarkt/vitaltrack/backend/internal/logic/stockcalc"
+	"github.com/nomenarkt/vitaltrack/backend/internal/logic/units"
 )
 
 // MedicineService provides stock related operations.
 type MedicineService struct {
-	Repo ports.StockDataPort
+	Repo     ports.StockDataPort
+	Location *time.Location // household timezone for day boundaries, UTC when nil
 }
 
 // ErrMedicineNotFound is returned when a medicine ID does not exist.
 var ErrMedicineNotFound = errors.New("medicine not found")
 
+// ReorderCoverDays is how many days of treatment a reorder recommendation covers.
+const ReorderCoverDays = 30
+
 // StockInfo summarizes current stock information for a medicine.
 type StockInfo struct {
+	MedicineID     string
+	Name           string
+	Unit           string // base unit of the stock and dose amounts
+	DailyDose      float64
 	InitialStock   float64
 	ConsumedStock  float64
 	CurrentStock   float64
+	DaysLeft       int
 	OutOfStockDate time.Time
+	Reorder        stockcalc.Reorder
 }
 
-// GetStockInfo computes current stock and forecast for the given medicine.
-func (s MedicineService) GetStockInfo(id string, now time.Time) (StockInfo, error) {
-	meds, err := s.Repo.FetchMedicines()
+// GetStockInfo computes current stock, forecast and a reorder recommendation
+// for the given medicine. It has no side effects.
+func (s MedicineService) GetStockInfo(ctx context.Context, id string, now time.Time) (StockInfo, error) {
+	now = calendar.In(now, s.Location)
+	meds, err := s.Repo.FetchMedicines(ctx)
 	if err != nil {
 		return StockInfo{}, fmt.Errorf("fetch medicines failed: %w", err)
 	}
-	entries, err := s.Repo.FetchStockEntries()
+	entries, err := s.Repo.FetchStockEntries(ctx)
 	if err != nil {
 		return StockInfo{}, fmt.Errorf("fetch stock entries failed: %w", err)
 	}
 
 	var med *domain.Medicine
 	for _, m := range meds {
-		if m.ID == id {
+		if m.ID == id && !m.Archived {
 			tmp := m
 			med = &tmp
 			break
@@ -53,11 +70,169 @@ func (s MedicineService) GetStockInfo(id string, now time.Time) (StockInfo, erro
 	stock := stockcalc.CurrentStockAt(*med, entries, now)
 	forecast := stockcalc.OutOfStockDateAt(*med, stock, now)
 
+	daysLeft := 0
+	if med.DailyDose > 0 {
+		daysLeft = int(math.Floor(stock / med.DailyDose))
+	}
+
 	info := StockInfo{
+		MedicineID:     med.ID,
+		Name:           med.Name,
+		Unit:           units.Base(*med),
+		DailyDose:      med.DailyDose,
 		InitialStock:   med.InitialStock,
 		ConsumedStock:  math.Max(med.InitialStock-stock, 0),
 		CurrentStock:   stock,
+		DaysLeft:       daysLeft,
 		OutOfStockDate: forecast,
+		Reorder:        stockcalc.RecommendReorder(*med, stock, now, LowStockThresholdDays, ReorderCoverDays),
 	}
 	return info, nil
 }
+
+// ListMedicines returns medicines matching filter, sorted by name.
+func (s MedicineService) ListMedicines(ctx context.Context, filter domain.MedicineFilter) (ListResult[domain.Medicine], error) {
+	meds, err := s.Repo.FetchMedicines(ctx)
+	if err != nil {
+		return ListResult[domain.Medicine]{}, fmt.Errorf("fetch medicines failed: %w", err)
+	}
+
+	name := strings.ToLower(filter.Name)
+	matched := []domain.Medicine{}
+	for _, m := range meds {
+		if m.Archived && !filter.IncludeArchived {
+			continue
+		}
+		if name != "" && !strings.Contains(strings.ToLower(m.Name), name) {
+			continue
+		}
+		matched = append(matched, m)
+	}
+	sort.SliceStable(matched, func(i, j int) bool { return matched[i].Name < matched[j].Name })
+
+	return paginate(matched, filter.Page), nil
+}
+
+// GetMedicine returns a single medicine by ID.
+func (s MedicineService) GetMedicine(ctx context.Context, id string) (domain.Medicine, error) {
+	return getMedicine(ctx, s.Repo, id)
+}
+
+// getMedicine returns the medicine with id from repo, or ErrMedicineNotFound.
+func getMedicine(ctx context.Context, repo ports.StockDataPort, id string) (domain.Medicine, error) {
+	m, err := repo.GetMedicine(ctx, id)
+	if errors.Is(err, domain.ErrNotFound) {
+		return domain.Medicine{}, ErrMedicineNotFound
+	}
+	if err != nil {
+		return domain.Medicine{}, fmt.Errorf("get medicine failed: %w", err)
+	}
+	return m, nil
+}
+
+// CreateMedicine validates req and stores a new medicine.
+func (s MedicineService) CreateMedicine(ctx context.Context, req domain.CreateMedicineRequest) (domain.Medicine, error) {
+	if strings.TrimSpace(req.Name) == "" {
+		return domain.Medicine{}, fmt.Errorf("%w: name must not be empty", ErrInvalidInput)
+	}
+	if req.DailyDose < 0 || req.UnitPerBox < 0 || req.InitialStock < 0 {
+		return domain.Medicine{}, fmt.Errorf("%w: daily_dose, unit_per_box and initial_stock must not be negative", ErrInvalidInput)
+	}
+	start, err := domain.ParseDate(req.StartDate)
+	if err != nil {
+		return domain.Medicine{}, fmt.Errorf("%w: start_date: expected YYYY-MM-DD or RFC3339", ErrInvalidInput)
+	}
+
+	doseTimes, err := domain.ParseDoseTimes(req.DoseTimes)
+	if err != nil {
+		return domain.Medicine{}, fmt.Errorf("%w: dose_times: %v", ErrInvalidInput, err)
+	}
+
+	med := domain.Medicine{
+		Name:         strings.TrimSpace(req.Name),
+		UnitType:     req.UnitType,
+		UnitPerBox:   req.UnitPerBox,
+		Units:        strings.TrimSpace(req.Units),
+		DailyDose:    req.DailyDose,
+		StartDate:    domain.NewFlexibleDate(start),
+		InitialStock: req.InitialStock,
+		Patient:      strings.TrimSpace(req.Patient),
+		DoseTimes:    doseTimes,
+	}
+	if _, err := units.Parse(med); err != nil {
+		return domain.Medicine{}, fmt.Errorf("%w: units: %v", ErrInvalidInput, err)
+	}
+
+	m, err := s.Repo.CreateMedicine(ctx, med)
+	if err != nil {
+		return domain.Medicine{}, fmt.Errorf("create medicine failed: %w", err)
+	}
+	return m, nil
+}
+
+// UpdateMedicine validates and applies patch to an existing medicine.
+func (s MedicineService) UpdateMedicine(ctx context.Context, id string, patch domain.MedicinePatch) (domain.Medicine, error) {
+	if patch.Name != nil && strings.TrimSpace(*patch.Name) == "" {
+		return domain.Medicine{}, fmt.Errorf("%w: name must not be empty", ErrInvalidInput)
+	}
+	for _, v := range []*float64{patch.DailyDose, patch.UnitPerBox, patch.InitialStock} {
+		if v != nil && *v < 0 {
+			return domain.Medicine{}, fmt.Errorf("%w: daily_dose, unit_per_box and initial_stock must not be negative", ErrInvalidInput)
+		}
+	}
+	if patch.DoseTimes != nil {
+		times, err := domain.ParseDoseTimes(*patch.DoseTimes)
+		if err != nil {
+			return domain.Medicine{}, fmt.Errorf("%w: dose_times: %v", ErrInvalidInput, err)
+		}
+		normalized := []string(times)
+		patch.DoseTimes = &normalized
+	}
+	if patch.Patient != nil {
+		patient := strings.TrimSpace(*patch.Patient)
+		patch.Patient = &patient
+	}
+	if patch.UnitType != nil || patch.UnitPerBox != nil || patch.Units != nil {
+		if err := s.checkUnits(ctx, id, patch); err != nil {
+			return domain.Medicine{}, err
+		}
+	}
+	m, err := s.Repo.UpdateMedicine(ctx, id, patch)
+	if errors.Is(err, domain.ErrNotFound) {
+		return domain.Medicine{}, ErrMedicineNotFound
+	}
+	if err != nil {
+		return domain.Medicine{}, fmt.Errorf("update medicine failed: %w", err)
+	}
+	return m, nil
+}
+
+// checkUnits verifies that the conversions of medicine id, once patched,
+// convert every unit to its base unit.
+func (s MedicineService) checkUnits(ctx context.Context, id string, patch domain.MedicinePatch) error {
+	m, err := s.GetMedicine(ctx, id)
+	if err != nil {
+		return err
+	}
+	if patch.UnitType != nil {
+		m.UnitType = *patch.UnitType
+	}
+	if patch.UnitPerBox != nil {
+		m.UnitPerBox = *patch.UnitPerBox
+	}
+	if patch.Units != nil {
+		m.Units = *patch.Units
+	}
+	if _, err := units.Parse(m); err != nil {
+		return fmt.Errorf("%w: units: %v", ErrInvalidInput, err)
+	}
+	return nil
+}
+
+// ArchiveMedicine soft-deletes a medicine so it no longer appears in
+// listings, forecasts or alerts.
+func (s MedicineService) ArchiveMedicine(ctx context.Context, id string) error {
+	archived := true
+	_, err := s.UpdateMedicine(ctx, id, domain.MedicinePatch{Archived: &archived})
+	return err
+}
@@ -1,31 +1,16 @@
 package usecase_test
 
 import (
+	"context"
 	"errors"
 	"testing"
 	"time"
 
 	"github.com/nomenarkt/vitaltrack/backend/internal/domain"
+	"github.com/nomenarkt/vitaltrack/backend/internal/testutil"
 	"github.com/nomenarkt/vitaltrack/backend/internal/usecase"
 )
 
-type mockRepo struct {
-	meds    []domain.Medicine
-	entries []domain.StockEntry
-}
-
-func (m mockRepo) FetchMedicines() ([]domain.Medicine, error) {
-	return m.meds, nil
-}
-func (m mockRepo) FetchStockEntries() ([]domain.StockEntry, error) {
-	return m.entries, nil
-}
-func (m mockRepo) FetchFinancialEntries(int, time.Month) ([]domain.FinancialEntry, error) {
-	return nil, nil
-}
-func (m mockRepo) CreateStockEntry(domain.StockEntry) error              { return nil }
-func (m mockRepo) UpdateForecastDate(string, time.Time, time.Time) error { return nil }
-
 func TestGetStockInfo(t *testing.T) {
 	now := time.Date(2025, 6, 4, 0, 0, 0, 0, time.UTC)
 	med := domain.Medicine{
@@ -34,26 +19,26 @@ func TestGetStockInfo(t *testing.T) {
 	}
 	tests := []struct {
 		name      string
-		repo      mockRepo
+		repo      *testutil.Store
 		wantStock float64
 		wantDate  time.Time
 		wantErr   bool
 	}{
 		{
 			name:      "found",
-			repo:      mockRepo{meds: []domain.Medicine{med}},
+			repo:      &testutil.Store{Meds: []domain.Medicine{med}},
 			wantStock: 7,
 			wantDate:  time.Date(2025, 6, 11, 0, 0, 0, 0, time.UTC),
 		},
 		{
 			name:      "withEntries",
-			repo:      mockRepo{meds: []domain.Medicine{med}, entries: []domain.StockEntry{{MedicineID: []string{"m1"}, Quantity: 1, Unit: "box", Date: domain.NewFlexibleDate(now)}}},
+			repo:      &testutil.Store{Meds: []domain.Medicine{med}, Entries: []domain.StockEntry{{MedicineID: []string{"m1"}, Quantity: 1, Unit: "box", Date: domain.NewFlexibleDate(now)}}},
 			wantStock: 17,
 			wantDate:  time.Date(2025, 6, 21, 0, 0, 0, 0, time.UTC),
 		},
 		{
 			name:    "not_found",
-			repo:    mockRepo{meds: []domain.Medicine{}},
+			repo:    &testutil.Store{Meds: []domain.Medicine{}},
 			wantErr: true,
 		},
 	}
@@ -61,7 +46,7 @@ func TestGetStockInfo(t *testing.T) {
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			svc := usecase.MedicineService{Repo: tt.repo}
-			info, err := svc.GetStockInfo("m1", now)
+			info, err := svc.GetStockInfo(context.Background(), "m1", now)
 			if tt.wantErr {
 				if err == nil {
 					t.Fatalf("expected error")
@@ -83,3 +68,90 @@ func TestGetStockInfo(t *testing.T) {
 		})
 	}
 }
+
+func TestMedicineService_CRUD(t *testing.T) {
+	repo := &testutil.Store{}
+	svc := usecase.MedicineService{Repo: repo}
+
+	if _, err := svc.CreateMedicine(context.Background(), domain.CreateMedicineRequest{Name: " ", StartDate: "2025-06-01"}); !errors.Is(err, usecase.ErrInvalidInput) {
+		t.Fatalf("empty name: err = %v, want ErrInvalidInput", err)
+	}
+	if _, err := svc.CreateMedicine(context.Background(), domain.CreateMedicineRequest{Name: "Bad", DailyDose: -1, StartDate: "2025-06-01"}); !errors.Is(err, usecase.ErrInvalidInput) {
+		t.Fatalf("negative dose: err = %v, want ErrInvalidInput", err)
+	}
+	if _, err := svc.CreateMedicine(context.Background(), domain.CreateMedicineRequest{Name: "Bad", DailyDose: 1, StartDate: "2025-06-01", DoseTimes: []string{"8h"}}); !errors.Is(err, usecase.ErrInvalidInput) {
+		t.Fatalf("bad dose time: err = %v, want ErrInvalidInput", err)
+	}
+
+	b, err := svc.CreateMedicine(context.Background(), domain.CreateMedicineRequest{Name: "Beta", DailyDose: 1, UnitPerBox: 30, StartDate: "2025-06-01"})
+	if err != nil {
+		t.Fatalf("create: %v", err)
+	}
+	if _, err := svc.CreateMedicine(context.Background(), domain.CreateMedicineRequest{Name: "Alpha", DailyDose: 2, StartDate: "2025-06-01"}); err != nil {
+		t.Fatalf("create: %v", err)
+	}
+
+	if _, err := svc.CreateMedicine(context.Background(), domain.CreateMedicineRequest{Name: "Syrup", UnitType: "ml", Units: "bottle = 100 ml, box = 2 blister", StartDate: "2025-06-01"}); !errors.Is(err, usecase.ErrInvalidInput) {
+		t.Fatalf("unconnected units: err = %v, want ErrInvalidInput", err)
+	}
+
+	res, err := svc.ListMedicines(context.Background(), domain.MedicineFilter{})
+	if err != nil {
+		t.Fatalf("list: %v", err)
+	}
+	if res.Total != 2 || res.Items[0].Name != "Alpha" {
+		t.Fatalf("expected name-sorted listing, got %+v", res.Items)
+	}
+
+	newDose := 3.0
+	updated, err := svc.UpdateMedicine(context.Background(), b.ID, domain.MedicinePatch{DailyDose: &newDose})
+	if err != nil {
+		t.Fatalf("update: %v", err)
+	}
+	if updated.DailyDose != 3 {
+		t.Errorf("dose = %v, want 3", updated.DailyDose)
+	}
+
+	times := []string{"20:00", "8:00"}
+	updated, err = svc.UpdateMedicine(context.Background(), b.ID, domain.MedicinePatch{DoseTimes: &times})
+	if err != nil {
+		t.Fatalf("update dose times: %v", err)
+	}
+	if updated.DoseTimes.String() != "08:00, 20:00" {
+		t.Errorf("dose times = %q, want normalized and sorted", updated.DoseTimes)
+	}
+
+	units := "box = 3 strip, strip = 10 pill, box = 20 pill"
+	if _, err := svc.UpdateMedicine(context.Background(), b.ID, domain.MedicinePatch{Units: &units}); !errors.Is(err, usecase.ErrInvalidInput) {
+		t.Fatalf("conflicting units: err = %v, want ErrInvalidInput", err)
+	}
+	units = "box = 3 strip, strip = 10 pill"
+	if updated, err = svc.UpdateMedicine(context.Background(), b.ID, domain.MedicinePatch{Units: &units}); err != nil || updated.Units != units {
+		t.Fatalf("update units: %+v, %v", updated, err)
+	}
+
+	if err := svc.ArchiveMedicine(context.Background(), b.ID); err != nil {
+		t.Fatalf("archive: %v", err)
+	}
+	res, err = svc.ListMedicines(context.Background(), domain.MedicineFilter{Name: "bet"})
+	if err != nil {
+		t.Fatalf("list: %v", err)
+	}
+	if res.Total != 0 {
+		t.Errorf("archived medicine listed: %+v", res.Items)
+	}
+	res, err = svc.ListMedicines(context.Background(), domain.MedicineFilter{Name: "bet", IncludeArchived: true})
+	if err != nil {
+		t.Fatalf("list: %v", err)
+	}
+	if res.Total != 1 {
+		t.Errorf("expected archived medicine with include_archived, got %+v", res.Items)
+	}
+
+	if _, err := svc.GetMedicine(context.Background(), "missing"); !errors.Is(err, usecase.ErrMedicineNotFound) {
+		t.Errorf("err = %v, want ErrMedicineNotFound", err)
+	}
+	if _, err := svc.GetStockInfo(context.Background(), b.ID, time.Now()); !errors.Is(err, usecase.ErrMedicineNotFound) {
+		t.Errorf("stock info for archived medicine: err = %v, want ErrMedicineNotFound", err)
+	}
+}
@@ -1,42 +1,66 @@
 package usecase
 
 import (
+	"context"
 	"fmt"
-	"log"
+	"slices"
 	"time"
 
 	"github.com/nomenarkt/vitaltrack/backend/internal/domain"
-	"github.com/nomenarkt/vitaltrack/backend/internal/util"
+	"github.com/nomenarkt/vitaltrack/backend/internal/logger"
+	"github.com/nomenarkt/vitaltrack/backend/internal/logic/calendar"
+	"github.com/nomenarkt/vitaltrack/backend/internal/logic/units"
+	"github.com/nomenarkt/vitaltrack/backend/internal/util/i18n"
+	"github.com/nomenarkt/vitaltrack/backend/internal/util/richtext"
 )
 
-// CheckAndAlertNewRefills notifies when new stock entries are recorded for today.
+// CheckAndAlertNewRefills notifies when new stock entries are recorded for
+// today in the household timezone.
 // It fetches medicines and stock entries, filters today's refills and sends a
-// Telegram alert per entry.
-func (s *StockChecker) CheckAndAlertNewRefills() error {
-	now := time.Now().UTC()
-	log.Printf("📡 Starting CheckAndAlertNewRefills...")
+// Telegram alert per entry. Entries already notified, by this or any other
+// run, are skipped via the alert log.
+func (s *StockChecker) CheckAndAlertNewRefills(ctx context.Context) error {
+	logger.OrNop(s.Logger).Info(ctx, "checking new refills")
+	return s.checkRefills(ctx, func(string) bool { return true })
+}
+
+// CheckAndAlertRefillsOf is CheckAndAlertNewRefills for the medicines in
+// medicineIDs only.
+func (s *StockChecker) CheckAndAlertRefillsOf(ctx context.Context, medicineIDs []string) error {
+	logger.OrNop(s.Logger).Info(ctx, "checking new refills", "medicines", len(medicineIDs))
+	return s.checkRefills(ctx, func(id string) bool { return slices.Contains(medicineIDs, id) })
+}
 
-	meds, err := s.Airtable.FetchMedicines()
+// checkRefills notifies today's refills of the medicines for which include
+// is true.
+func (s *StockChecker) checkRefills(ctx context.Context, include func(medicineID string) bool) error {
+	now := calendar.In(time.Now(), s.Location)
+	lg := logger.OrNop(s.Logger)
+
+	meds, err := s.Airtable.FetchMedicines(ctx)
 	if err != nil {
 		return fmt.Errorf("fetch medicines failed: %w", err)
 	}
-	entries, err := s.Airtable.FetchStockEntries()
+	entries, err := s.Airtable.FetchStockEntries(ctx)
 	if err != nil {
 		return fmt.Errorf("fetch stock entries failed: %w", err)
 	}
 
+	p := s.printer()
 	medMap := make(map[string]domain.Medicine)
 	for _, m := range meds {
-		medMap[m.ID] = m
+		if !m.Archived && include(m.ID) {
+			medMap[m.ID] = m
+		}
 	}
 
-	today := now.Format("2006-01-02")
+	today := calendar.Day(now, s.Location)
 
 	for _, e := range entries {
-		if len(e.MedicineID) == 0 || e.Quantity <= 0 || e.Date.IsZero() {
+		if len(e.MedicineID) == 0 || !e.IsRefill() || e.Quantity <= 0 || e.Date.IsZero() || e.Archived {
 			continue
 		}
-		if e.Date.UTC().Format("2006-01-02") != today {
+		if !calendar.Date(e.Date.Time, s.Location).Equal(today) {
 			continue
 		}
 
@@ -44,30 +68,40 @@ func (s *StockChecker) CheckAndAlertNewRefills() error {
 		if !ok {
 			continue
 		}
-		if med.LastAlertedDate != nil && med.LastAlertedDate.UTC().Format("2006-01-02") == today {
-			continue
-		}
 
-		qty := e.Quantity
-		if e.Unit == "box" {
-			qty *= med.UnitPerBox
+		alert, err := s.notifier().Notify(ctx, s.refillAlert(p, med, e), now)
+		if err != nil {
+			return err
 		}
-
-		msg := fmt.Sprintf(
-			"✅ Refill received: %s\n• Quantity: %.0f %s\n• Converted: %.0f pills\n• Date: %s",
-			util.EscapeMarkdown(med.Name),
-			e.Quantity,
-			util.EscapeMarkdown(e.Unit),
-			qty,
-			e.Date.Format("2006-01-02"),
-		)
-
-		if err := s.Telegram.SendTelegramMessage(msg); err != nil {
-			log.Printf("❌ Telegram send failed: %v", err)
-		} else {
-			log.Printf("✅ Refill alert sent: %s", med.Name)
+		switch {
+		case alert.Sent:
+			lg.Info(ctx, "refill alert sent", "medicine_id", med.ID)
+		case alert.Queued:
+			lg.Info(ctx, "refill alert queued", "medicine_id", med.ID)
 		}
 	}
 
 	return nil
 }
+
+// refillAlert returns the alert for the refill e of med. EvaluateAlerts
+// sends the same one, so whichever runs first, each entry is announced once
+// and in the same words.
+func (s *StockChecker) refillAlert(p i18n.Printer, med domain.Medicine, e domain.StockEntry) Alert {
+	g := units.For(med)
+	qty, _ := g.ToBase(e.Quantity, e.Unit)
+	return Alert{
+		Kind:         AlertRefill,
+		Key:          RefillAlertKey(e),
+		MedicineID:   med.ID,
+		MedicineName: med.Name,
+		Message: richtext.New(
+			richtext.P(richtext.T(p.T("refill.received", med.Name))),
+			richtext.List{
+				richtext.Item(richtext.T(p.T("refill.quantity", p.Number(e.Quantity, 0), e.Unit))),
+				richtext.Item(richtext.T(p.T("refill.converted", p.Number(qty, 0), units.Label(p, g.Base())))),
+				richtext.Item(richtext.T(p.T("refill.date", p.Date(calendar.Date(e.Date.Time, s.Location))))),
+			},
+		),
+	}
+}
@@ -1,36 +1,23 @@
 package usecase_test
 
 import (
+	"context"
 	"testing"
 	"time"
 
 	"github.com/nomenarkt/vitaltrack/backend/internal/domain"
+	"github.com/nomenarkt/vitaltrack/backend/internal/testutil"
 	"github.com/nomenarkt/vitaltrack/backend/internal/usecase"
+	"github.com/nomenarkt/vitaltrack/backend/internal/util/richtext"
 )
 
-// Mocks for Airtable and Telegram
-
-type mockAirtableRefill struct {
-	meds    []domain.Medicine
-	entries []domain.StockEntry
-}
-
-func (m *mockAirtableRefill) FetchMedicines() ([]domain.Medicine, error)      { return m.meds, nil }
-func (m *mockAirtableRefill) FetchStockEntries() ([]domain.StockEntry, error) { return m.entries, nil }
-func (m *mockAirtableRefill) FetchFinancialEntries(int, time.Month) ([]domain.FinancialEntry, error) {
-	return nil, nil
-}
-func (m *mockAirtableRefill) UpdateMedicineLastAlertedDate(string, time.Time) error { return nil }
-func (m *mockAirtableRefill) CreateStockEntry(domain.StockEntry) error              { return nil }
-func (m *mockAirtableRefill) UpdateForecastDate(string, time.Time, time.Time) error { return nil }
-
 type mockTelegramRefill struct{ msgs []string }
 
-func (m *mockTelegramRefill) SendTelegramMessage(msg string) error {
-	m.msgs = append(m.msgs, msg)
+func (m *mockTelegramRefill) SendTelegramMessage(_ context.Context, msg richtext.Doc) error {
+	m.msgs = append(m.msgs, richtext.MarkdownV2(msg))
 	return nil
 }
-func (m *mockTelegramRefill) PollForCommands(func() ([]domain.Medicine, []domain.StockEntry, error), func(int, int) (domain.MonthlyFinancialReport, error)) {
+func (m *mockTelegramRefill) PollForCommands(context.Context, func(context.Context) ([]domain.Medicine, []domain.StockEntry, error), func(context.Context, int, int) (domain.MonthlyFinancialReport, error), func(context.Context) ([]domain.AlertRecord, error)) {
 }
 
 func TestCheckAndAlertNewRefills(t *testing.T) {
@@ -66,6 +53,15 @@ func TestCheckAndAlertNewRefills(t *testing.T) {
 			entries:     []domain.StockEntry{{MedicineID: []string{"m1"}, Quantity: 0, Unit: "box", Date: domain.NewFlexibleDate(now)}},
 			expectCount: 0,
 		},
+		{
+			name: "not_refills",
+			meds: []domain.Medicine{med},
+			entries: []domain.StockEntry{
+				{MedicineID: []string{"m1"}, Kind: domain.EntryWastage, Quantity: 4, Unit: "pill", Date: domain.NewFlexibleDate(now), Reason: "expired"},
+				{MedicineID: []string{"m1"}, Kind: domain.EntryCorrection, Quantity: 2, Unit: "pill", Date: domain.NewFlexibleDate(now), Reason: "recount"},
+			},
+			expectCount: 0,
+		},
 		{
 			name:        "missing_medicine_id",
 			meds:        []domain.Medicine{med},
@@ -73,20 +69,21 @@ func TestCheckAndAlertNewRefills(t *testing.T) {
 			expectCount: 0,
 		},
 		{
-			name:        "already_alerted_today",
+			// LastAlertedDate records low stock alerts, not refills.
+			name:        "low_stock_alerted_today",
 			meds:        []domain.Medicine{{ID: "m1", Name: "Med1", UnitPerBox: 28, LastAlertedDate: &domain.FlexibleDate{Time: now}}},
 			entries:     []domain.StockEntry{{MedicineID: []string{"m1"}, Quantity: 1, Unit: "box", Date: domain.NewFlexibleDate(now)}},
-			expectCount: 0,
+			expectCount: 1,
 		},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			at := &mockAirtableRefill{meds: tt.meds, entries: tt.entries}
+			at := &testutil.Store{Meds: tt.meds, Entries: tt.entries}
 			tg := &mockTelegramRefill{}
 
 			checker := usecase.StockChecker{Airtable: at, Telegram: tg}
-			if err := checker.CheckAndAlertNewRefills(); err != nil {
+			if err := checker.CheckAndAlertNewRefills(context.Background()); err != nil {
 				t.Fatalf("unexpected error: %v", err)
 			}
 			if len(tg.msgs) != tt.expectCount {
@@ -0,0 +1,104 @@
+package usecase
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/nomenarkt/vitaltrack/backend/internal/domain/ports"
+	"github.com/nomenarkt/vitaltrack/backend/internal/logger"
+)
+
+// StockChanges reacts to stock entries recorded or corrected outside the
+// server, e.g. in the Airtable interface, without waiting for the scheduled
+// jobs: it sends the refill alerts and saves the forecasts of the medicines
+// concerned. Notify asks Run to read the changes from Feed; notifications
+// arriving while changes are handled lead to one more read.
+type StockChanges struct {
+	Feed       ports.EntryChangesPort
+	Store      ports.StockDataPort // where the changed entries are read
+	Invalidate func()              // drops cached stock entries, when set
+	Refills    *StockChecker
+	Forecasts  OutOfStockService
+	Logger     logger.Logger // discards when nil
+
+	once    sync.Once
+	pending chan struct{}
+}
+
+func (s *StockChanges) wake() chan struct{} {
+	s.once.Do(func() { s.pending = make(chan struct{}, 1) })
+	return s.pending
+}
+
+// Notify tells Run that entries changed. It does not block.
+func (s *StockChanges) Notify() {
+	select {
+	case s.wake() <- struct{}{}:
+	default: // a read is already pending
+	}
+}
+
+// Run handles the changes after each Notify until ctx is cancelled.
+func (s *StockChanges) Run(ctx context.Context) {
+	lg := logger.OrNop(s.Logger)
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-s.wake():
+		}
+		if _, err := s.Handle(ctx, time.Now()); err != nil {
+			lg.Error(ctx, "stock changes not handled", "error", err)
+		}
+	}
+}
+
+// Handle reads the changes from Feed, then checks the refills and saves the
+// forecasts, as of now, of the medicines whose entries were created or
+// updated. It returns those medicines. The medicine of a deleted entry is
+// unknown, so a deletion recomputes every forecast.
+func (s *StockChanges) Handle(ctx context.Context, now time.Time) ([]string, error) {
+	changes, err := s.Feed.EntryChanges(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("read entry changes: %w", err)
+	}
+	if changes.Empty() {
+		return nil, nil
+	}
+	if s.Invalidate != nil {
+		s.Invalidate()
+	}
+
+	entries, err := s.Store.FetchStockEntries(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("fetch stock entries failed: %w", err)
+	}
+	var medicineIDs []string
+	for _, e := range entries {
+		if len(e.MedicineID) == 0 || !slices.Contains(changes.Upserted, e.ID) {
+			continue
+		}
+		if !slices.Contains(medicineIDs, e.MedicineID[0]) {
+			medicineIDs = append(medicineIDs, e.MedicineID[0])
+		}
+	}
+	slices.Sort(medicineIDs)
+	logger.OrNop(s.Logger).Info(ctx, "stock entries changed",
+		"upserted", len(changes.Upserted), "destroyed", len(changes.Destroyed), "medicines", medicineIDs)
+
+	if len(medicineIDs) > 0 {
+		if err := s.Refills.CheckAndAlertRefillsOf(ctx, medicineIDs); err != nil {
+			return medicineIDs, err
+		}
+	}
+	switch {
+	case len(changes.Destroyed) > 0:
+		_, err = s.Forecasts.SyncForecastDates(ctx, now)
+	case len(medicineIDs) > 0:
+		_, err = s.Forecasts.SyncForecastDatesOf(ctx, now, medicineIDs)
+	}
+	return medicineIDs, err
+}
@@ -0,0 +1,109 @@
+package usecase_test
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/nomenarkt/vitaltrack/backend/internal/domain"
+	"github.com/nomenarkt/vitaltrack/backend/internal/testutil"
+	"github.com/nomenarkt/vitaltrack/backend/internal/usecase"
+)
+
+type fakeFeed struct{ changes domain.EntryChanges }
+
+func (f *fakeFeed) EntryChanges(context.Context) (domain.EntryChanges, error) {
+	c := f.changes
+	f.changes = domain.EntryChanges{}
+	return c, nil
+}
+
+func TestStockChanges_Handle(t *testing.T) {
+	now := time.Now().UTC()
+	start := domain.NewFlexibleDate(now.AddDate(0, 0, -10))
+	meds := []domain.Medicine{
+		{ID: "m1", Name: "Med1", UnitPerBox: 28, DailyDose: 1, InitialStock: 30, StartDate: start},
+		{ID: "m2", Name: "Med2", UnitPerBox: 28, DailyDose: 1, InitialStock: 60, StartDate: start},
+	}
+	entries := []domain.StockEntry{
+		{ID: "recA", MedicineID: []string{"m1"}, Quantity: 1, Unit: "box", Date: domain.NewFlexibleDate(now)},
+		{ID: "recB", MedicineID: []string{"m2"}, Quantity: 1, Unit: "box", Date: domain.NewFlexibleDate(now.AddDate(0, 0, -3))},
+	}
+
+	tests := []struct {
+		name          string
+		changes       domain.EntryChanges
+		wantMedicines []string
+		wantRefills   int
+		wantSaved     []string
+	}{
+		{name: "none"},
+		{name: "refill_added", changes: domain.EntryChanges{Upserted: []string{"recA"}}, wantMedicines: []string{"m1"}, wantRefills: 1, wantSaved: []string{"m1"}},
+		{name: "old_entry_corrected", changes: domain.EntryChanges{Upserted: []string{"recB"}}, wantMedicines: []string{"m2"}, wantSaved: []string{"m2"}},
+		{name: "entry_deleted", changes: domain.EntryChanges{Destroyed: []string{"recZ"}}, wantSaved: []string{"m1", "m2"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store := &testutil.Store{Meds: meds, Entries: entries}
+			tg := &mockTelegramRefill{}
+			invalidated := false
+			s := &usecase.StockChanges{
+				Feed:       &fakeFeed{changes: tt.changes},
+				Store:      store,
+				Invalidate: func() { invalidated = true },
+				Refills:    &usecase.StockChecker{Airtable: store, Telegram: tg},
+				Forecasts:  usecase.OutOfStockService{Airtable: store},
+			}
+
+			got, err := s.Handle(context.Background(), now)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got, tt.wantMedicines) {
+				t.Errorf("medicines = %v, want %v", got, tt.wantMedicines)
+			}
+			if len(tg.msgs) != tt.wantRefills {
+				t.Errorf("%d refill alerts, want %d", len(tg.msgs), tt.wantRefills)
+			}
+			var saved []string
+			for _, f := range store.Forecasts {
+				saved = append(saved, f.MedicineID)
+			}
+			if !reflect.DeepEqual(saved, tt.wantSaved) {
+				t.Errorf("forecasts saved for %v, want %v", saved, tt.wantSaved)
+			}
+			if invalidated != !tt.changes.Empty() {
+				t.Errorf("invalidated = %v", invalidated)
+			}
+		})
+	}
+}
+
+func TestStockChanges_Notify(t *testing.T) {
+	feed := &fakeFeed{changes: domain.EntryChanges{Destroyed: []string{"recZ"}}}
+	handled := make(chan struct{}, 3)
+	s := &usecase.StockChanges{
+		Feed:       feed,
+		Store:      &testutil.Store{},
+		Invalidate: func() { handled <- struct{}{} },
+		Refills:    &usecase.StockChecker{},
+		Forecasts:  usecase.OutOfStockService{Airtable: &testutil.Store{}},
+	}
+	s.Notify()
+	s.Notify() // merged with the first
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		s.Run(ctx)
+	}()
+	select {
+	case <-handled:
+	case <-time.After(time.Second):
+		t.Fatal("changes not handled")
+	}
+	cancel()
+	<-done
+}
@@ -0,0 +1,55 @@
+package usecase
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/nomenarkt/vitaltrack/backend/internal/domain"
+	"github.com/nomenarkt/vitaltrack/backend/internal/domain/ports"
+	"github.com/nomenarkt/vitaltrack/backend/internal/logic/calendar"
+	"github.com/nomenarkt/vitaltrack/backend/internal/logic/validation"
+)
+
+// ValidationService reports the records that stock and finance
+// computations skip or misread, so they can be corrected in Airtable.
+type ValidationService struct {
+	Records  ports.RecordScanPort
+	Location *time.Location // household timezone for "today" and the current month, UTC when nil
+}
+
+// Validate checks every medicine and stock entry, and the financial entries
+// of the month. A zero year selects the current month in the household
+// timezone. Dates that do not parse are reported as invalid rather than
+// failing the check.
+func (s ValidationService) Validate(ctx context.Context, year int, month time.Month, now time.Time) (domain.ValidationReport, error) {
+	now = calendar.In(now, s.Location)
+	if year == 0 {
+		year, month = now.Year(), now.Month()
+	}
+
+	scan, err := s.Records.ScanRecords(ctx, year, month)
+	if err != nil {
+		return domain.ValidationReport{}, fmt.Errorf("scan records failed: %w", err)
+	}
+
+	issues := slices.Concat(scan.Issues, validation.Stock(scan.Medicines, scan.Entries, now), validation.Finance(scan.Financial))
+	// An invalid date is read as missing; report it once.
+	issues = slices.DeleteFunc(issues, func(i domain.ValidationIssue) bool {
+		return i.Kind == domain.IssueMissingDate && slices.ContainsFunc(scan.Issues, func(d domain.ValidationIssue) bool {
+			return d.Table == i.Table && d.RecordID == i.RecordID && d.Field == i.Field
+		})
+	})
+	issues = validation.Sorted(issues)
+	if issues == nil {
+		issues = []domain.ValidationIssue{}
+	}
+	return domain.ValidationReport{
+		Month:            fmt.Sprintf("%04d-%02d", year, month),
+		Medicines:        len(scan.Medicines),
+		Entries:          len(scan.Entries),
+		FinancialEntries: len(scan.Financial),
+		Issues:           issues,
+	}, nil
+}
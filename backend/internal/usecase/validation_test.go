@@ -0,0 +1,86 @@
+package usecase_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/nomenarkt/vitaltrack/backend/internal/domain"
+	"github.com/nomenarkt/vitaltrack/backend/internal/testutil"
+	"github.com/nomenarkt/vitaltrack/backend/internal/usecase"
+)
+
+func TestValidationService_Validate(t *testing.T) {
+	date := domain.NewFlexibleDate(time.Date(2025, 6, 2, 0, 0, 0, 0, time.UTC))
+	repo := &testutil.Store{
+		Meds: []domain.Medicine{{ID: "m1", Name: "Aspirin", UnitPerBox: 30, DailyDose: 1}},
+		Entries: []domain.StockEntry{
+			{ID: "e1", MedicineID: []string{"m1"}, Quantity: 1, Unit: "box", Date: date},
+			{ID: "e2", MedicineID: []string{"m9"}, Quantity: 1, Unit: "box", Date: date},
+		},
+		Financial: []domain.FinancialEntry{
+			{ID: "f1", Date: date, NeedLabel: "Rent", NeedAmount: 10, AmountContributed: 20, MonthTag: "2025-06"},
+			{ID: "f2", Date: date, NeedLabel: "Rent", NeedAmount: 10, AmountContributed: -1, MonthTag: "2025-05"},
+		},
+	}
+	svc := usecase.ValidationService{Records: repo}
+	now := time.Date(2025, 6, 10, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name  string
+		year  int
+		month time.Month
+		want  domain.ValidationReport
+	}{
+		{"current_month", 0, 0, domain.ValidationReport{Month: "2025-06", Medicines: 1, Entries: 2, FinancialEntries: 1, Issues: []domain.ValidationIssue{
+			{Kind: domain.IssueOrphanEntry, Table: "entries", RecordID: "e2", Field: "medicine_id", Value: "m9"},
+			{Kind: domain.IssueOverContribution, Table: "financial", Label: "2025-06-02 Rent", Field: "amount_contributed", Value: "20 > 10", Related: []string{"f1"}},
+		}}},
+		{"given_month", 2025, time.May, domain.ValidationReport{Month: "2025-05", Medicines: 1, Entries: 2, FinancialEntries: 1, Issues: []domain.ValidationIssue{
+			{Kind: domain.IssueOrphanEntry, Table: "entries", RecordID: "e2", Field: "medicine_id", Value: "m9"},
+			{Kind: domain.IssueNegativeAmount, Table: "financial", RecordID: "f2", Label: "Rent", Field: "amount_contributed", Value: "-1"},
+		}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := svc.Validate(context.Background(), tt.year, tt.month, now)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got.Month != tt.want.Month || got.Medicines != tt.want.Medicines || got.Entries != tt.want.Entries || got.FinancialEntries != tt.want.FinancialEntries {
+				t.Errorf("report = %+v, want %+v", got, tt.want)
+			}
+			if len(got.Issues) != len(tt.want.Issues) {
+				t.Fatalf("issues = %+v, want %+v", got.Issues, tt.want.Issues)
+			}
+			for i, want := range tt.want.Issues {
+				g := got.Issues[i]
+				if g.Kind != want.Kind || g.RecordID != want.RecordID || g.Label != want.Label || g.Value != want.Value || len(g.Related) != len(want.Related) {
+					t.Errorf("issue %d = %+v, want %+v", i, g, want)
+				}
+			}
+		})
+	}
+}
+
+// scanned is a RecordScanPort returning a fixed scan.
+type scanned domain.RecordScan
+
+func (s scanned) ScanRecords(context.Context, int, time.Month) (domain.RecordScan, error) {
+	return domain.RecordScan(s), nil
+}
+
+func TestValidationService_invalidDate(t *testing.T) {
+	svc := usecase.ValidationService{Records: scanned{
+		Medicines: []domain.Medicine{{ID: "m1", Name: "Aspirin", UnitPerBox: 30}},
+		Entries:   []domain.StockEntry{{ID: "e1", MedicineID: []string{"m1"}, Quantity: 1, Unit: "box"}},
+		Issues:    []domain.ValidationIssue{{Kind: domain.IssueInvalidDate, Table: "entries", RecordID: "e1", Field: "date", Value: "02/06/2025"}},
+	}}
+	got, err := svc.Validate(context.Background(), 2025, time.June, time.Now())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got.Issues) != 1 || got.Issues[0].Kind != domain.IssueInvalidDate || got.Entries != 1 {
+		t.Errorf("report = %+v, want the invalid date alone", got)
+	}
+}
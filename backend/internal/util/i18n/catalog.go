@@ -0,0 +1,284 @@
+package i18n
+
+// english is the reference catalogue; every key must be defined here.
+var english = map[string]string{
+	"lang.name":        "English",
+	"lang.current":     "🌐 Language: %s",
+	"lang.usage":       "Choose with /lang en, /lang fr or /lang mg.",
+	"lang.unknown":     "⚠️ Unknown language %q.",
+	"lang.set":         "✅ Language set to %s.",
+	"lang.save_failed": "⚠️ Could not save your language.",
+
+	"command.not_allowed": "⛔ This chat cannot use this command.",
+
+	"stock.fetch_failed": "⚠️ Failed to fetch stock data.",
+	"stock.no_data":      "⚠️ No medicine or stock data found.",
+	"stock.all_good":     "✅ All medicines are well stocked.",
+	"stock.title":        "Out-of-Stock Forecast",
+	"stock.row":          "%-22s → %s (%s left)",
+	"stock.skipped":      "⚠️ Some records were skipped due to data issues. See /validate.",
+
+	"finance.fetch_failed":      "⚠️ Failed to fetch financial data.",
+	"finance.title":             "Financial Report %s",
+	"finance.need":              "Need:",
+	"finance.contributed":       "Contributed:",
+	"finance.contributor":       "Contributor",
+	"finance.amount":            "Amount",
+	"finance.summary":           "🧮 Monthly Summary",
+	"finance.total_needs":       "💰 Total Needs: %s",
+	"finance.total_contributed": "💵 Total Contributed: %s",
+	"finance.by_contributor":    "👤 By Contributor:",
+
+	"digest.stock_intro":     "📬 Weekly stock digest",
+	"digest.finance_intro":   "📬 Monthly finance digest",
+	"digest.shortfall_title": "Still missing",
+	"digest.need":            "Need",
+	"digest.shortfall":       "Shortfall",
+	"digest.total_shortfall": "🔻 Total shortfall: %s",
+	"digest.all_covered":     "✅ Every need was fully covered.",
+	"digest.current":         "📬 Digests for this chat: %s",
+	"digest.usage":           "Change with /digest stock on|off or /digest finance on|off.",
+	"digest.unknown":         "⚠️ Unknown digest %q.",
+	"digest.set":             "✅ Digest %s turned %s.",
+	"digest.save_failed":     "⚠️ Could not save your digest settings.",
+	"digest.not_allowed":     "⛔ This chat cannot receive digests.",
+	"digest.on":              "on",
+	"digest.off":             "off",
+	"digest.name_stock":      "stock",
+	"digest.name_finance":    "finance",
+
+	"adherence.reminder":         "💊 Time for *%[1]s*: %[2]s pill(s) at %[3]s",
+	"adherence.reminder_patient": "💊 Time for *%[1]s* (%[4]s): %[2]s pill(s) at %[3]s",
+	"adherence.button_taken":     "✅ Taken",
+	"adherence.button_skipped":   "⏭️ Skipped",
+	"adherence.taken":            "✅ Taken at %s",
+	"adherence.skipped":          "⏭️ Skipped at %s",
+	"adherence.recorded":         "Answer recorded",
+	"adherence.record_failed":    "⚠️ Could not record your answer.",
+	"adherence.fetch_failed":     "⚠️ Failed to fetch the adherence log.",
+	"adherence.none":             "ℹ️ No dose reminders sent yet.",
+	"adherence.title":            "Adherence by week",
+	"adherence.patient":          "Patient",
+	"adherence.week":             "Week of",
+	"adherence.doses":            "Taken",
+	"adherence.rate":             "Rate",
+
+	"alerts.fetch_failed": "⚠️ Failed to fetch alert history.",
+	"alerts.none":         "ℹ️ No alerts sent yet.",
+	"alerts.title":        "Recent Alerts",
+	"alerts.attempts":     " (%d attempts)",
+
+	"alert.low_stock": "*%[1]s* will run out in %[2]d day(s)!\nRefill before *%[3]s*\nCurrently: *%[4]s* %[5]s left.",
+
+	"refill.received":  "✅ Refill received: %s",
+	"refill.quantity":  "Quantity: %s %s",
+	"refill.converted": "Converted: %s %s",
+	"refill.date":      "Date: %s",
+
+	"unit.pill": "pills",
+
+	"validation.fetch_failed":         "⚠️ Failed to fetch the records to check.",
+	"validation.title":                "Data issues",
+	"validation.clean":                "✅ No data issues found.",
+	"validation.checked":              "Checked %d medicines, %d stock entries and %d contributions of %s.",
+	"validation.more":                 "%d more",
+	"validation.orphan_entry":         "Entries without a known medicine (%d)",
+	"validation.missing_date":         "Records without a date (%d)",
+	"validation.invalid_date":         "Dates that cannot be read (%d)",
+	"validation.unknown_unit":         "Entries in a unit their medicine does not define, counted as base units (%d)",
+	"validation.unknown_kind":         "Entries of an unknown kind, ignored (%d)",
+	"validation.invalid_units":        "Medicines with conversions that cannot be used (%d)",
+	"validation.missing_unit_per_box": "Medicines without pills per box or other units (%d)",
+	"validation.future_entry":         "Entries dated in the future (%d)",
+	"validation.duplicate_entry":      "Duplicate entries (%d)",
+	"validation.negative_amount":      "Negative amounts (%d)",
+	"validation.over_contribution":    "Needs with more contributed than needed (%d)",
+}
+
+var french = map[string]string{
+	"lang.name":        "Français",
+	"lang.current":     "🌐 Langue : %s",
+	"lang.usage":       "Choisissez avec /lang en, /lang fr ou /lang mg.",
+	"lang.unknown":     "⚠️ Langue inconnue %q.",
+	"lang.set":         "✅ Langue choisie : %s.",
+	"lang.save_failed": "⚠️ Impossible d'enregistrer votre langue.",
+
+	"command.not_allowed": "⛔ Ce chat ne peut pas utiliser cette commande.",
+
+	"stock.fetch_failed": "⚠️ Impossible de récupérer les stocks.",
+	"stock.no_data":      "⚠️ Aucun médicament ni stock trouvé.",
+	"stock.all_good":     "✅ Tous les médicaments sont bien approvisionnés.",
+	"stock.title":        "Prévision de rupture de stock",
+	"stock.row":          "%-22s → %s (%s restants)",
+	"stock.skipped":      "⚠️ Certaines lignes incomplètes ont été ignorées. Voir /validate.",
+
+	"finance.fetch_failed":      "⚠️ Impossible de récupérer les données financières.",
+	"finance.title":             "Rapport financier %s",
+	"finance.need":              "Besoin :",
+	"finance.contributed":       "Versé :",
+	"finance.contributor":       "Contributeur",
+	"finance.amount":            "Montant",
+	"finance.summary":           "🧮 Bilan du mois",
+	"finance.total_needs":       "💰 Total des besoins : %s",
+	"finance.total_contributed": "💵 Total versé : %s",
+	"finance.by_contributor":    "👤 Par contributeur :",
+
+	"digest.stock_intro":     "📬 Résumé hebdomadaire des stocks",
+	"digest.finance_intro":   "📬 Résumé financier du mois",
+	"digest.shortfall_title": "Reste à financer",
+	"digest.need":            "Besoin",
+	"digest.shortfall":       "Manque",
+	"digest.total_shortfall": "🔻 Manque total : %s",
+	"digest.all_covered":     "✅ Tous les besoins ont été couverts.",
+	"digest.current":         "📬 Résumés pour ce chat : %s",
+	"digest.usage":           "Modifiez avec /digest stock on|off ou /digest finance on|off.",
+	"digest.unknown":         "⚠️ Résumé inconnu %q.",
+	"digest.set":             "✅ Résumé %s : %s.",
+	"digest.save_failed":     "⚠️ Impossible d'enregistrer vos résumés.",
+	"digest.not_allowed":     "⛔ Ce chat ne peut pas recevoir de résumés.",
+	"digest.on":              "activé",
+	"digest.off":             "désactivé",
+	"digest.name_stock":      "stocks",
+	"digest.name_finance":    "finances",
+
+	"adherence.reminder":         "💊 C'est l'heure de *%[1]s* : %[2]s comprimé(s) à %[3]s",
+	"adherence.reminder_patient": "💊 C'est l'heure de *%[1]s* (%[4]s) : %[2]s comprimé(s) à %[3]s",
+	"adherence.button_taken":     "✅ Pris",
+	"adherence.button_skipped":   "⏭️ Sauté",
+	"adherence.taken":            "✅ Pris à %s",
+	"adherence.skipped":          "⏭️ Sauté à %s",
+	"adherence.recorded":         "Réponse enregistrée",
+	"adherence.record_failed":    "⚠️ Impossible d'enregistrer votre réponse.",
+	"adherence.fetch_failed":     "⚠️ Impossible de récupérer le suivi des prises.",
+	"adherence.none":             "ℹ️ Aucun rappel de prise envoyé pour l'instant.",
+	"adherence.title":            "Observance par semaine",
+	"adherence.patient":          "Patient",
+	"adherence.week":             "Semaine du",
+	"adherence.doses":            "Prises",
+	"adherence.rate":             "Taux",
+
+	"alerts.fetch_failed": "⚠️ Impossible de récupérer l'historique des alertes.",
+	"alerts.none":         "ℹ️ Aucune alerte envoyée pour l'instant.",
+	"alerts.title":        "Alertes récentes",
+	"alerts.attempts":     " (%d tentatives)",
+
+	"alert.low_stock": "*%[1]s* sera épuisé dans %[2]d jour(s) !\nÀ racheter avant le *%[3]s*\nActuellement : *%[4]s* %[5]s restants.",
+
+	"refill.received":  "✅ Réapprovisionnement reçu : %s",
+	"refill.quantity":  "Quantité : %s %s",
+	"refill.converted": "Soit : %s %s",
+	"refill.date":      "Date : %s",
+
+	"unit.pill": "comprimés",
+
+	"validation.fetch_failed":         "⚠️ Impossible de récupérer les lignes à vérifier.",
+	"validation.title":                "Problèmes de données",
+	"validation.clean":                "✅ Aucun problème de données trouvé.",
+	"validation.checked":              "%d médicaments, %d entrées de stock et %d contributions de %s vérifiés.",
+	"validation.more":                 "%d de plus",
+	"validation.orphan_entry":         "Entrées sans médicament connu (%d)",
+	"validation.missing_date":         "Lignes sans date (%d)",
+	"validation.invalid_date":         "Dates illisibles (%d)",
+	"validation.unknown_unit":         "Entrées dans une unité inconnue du médicament, comptées en unités de base (%d)",
+	"validation.unknown_kind":         "Entrées d'un type inconnu, ignorées (%d)",
+	"validation.invalid_units":        "Médicaments aux conversions inutilisables (%d)",
+	"validation.missing_unit_per_box": "Médicaments sans nombre de comprimés par boîte ni autres unités (%d)",
+	"validation.future_entry":         "Entrées datées dans le futur (%d)",
+	"validation.duplicate_entry":      "Entrées en double (%d)",
+	"validation.negative_amount":      "Montants négatifs (%d)",
+	"validation.over_contribution":    "Besoins dépassés par les contributions (%d)",
+}
+
+var malagasy = map[string]string{
+	"lang.name":        "Malagasy",
+	"lang.current":     "🌐 Fiteny: %s",
+	"lang.usage":       "Misafidiana amin'ny /lang en, /lang fr na /lang mg.",
+	"lang.unknown":     "⚠️ Fiteny tsy fantatra %q.",
+	"lang.set":         "✅ Voafidy ny fiteny: %s.",
+	"lang.save_failed": "⚠️ Tsy voatahiry ny fiteny nofidianao.",
+
+	"command.not_allowed": "⛔ Tsy azon'ity resaka ity ampiasaina io baiko io.",
+
+	"stock.fetch_failed": "⚠️ Tsy azo ny momba ny tahiry.",
+	"stock.no_data":      "⚠️ Tsy misy fanafody na tahiry hita.",
+	"stock.all_good":     "✅ Ampy tsara ny fanafody rehetra.",
+	"stock.title":        "Vinavina fahalanian'ny fanafody",
+	"stock.row":          "%-22s → %s (%s sisa)",
+	"stock.skipped":      "⚠️ Nisy firaketana tsy feno tsy noraisina. Jereo /validate.",
+
+	"finance.fetch_failed":      "⚠️ Tsy azo ny momba ny vola.",
+	"finance.title":             "Tatitra ara-bola %s",
+	"finance.need":              "Filana:",
+	"finance.contributed":       "Nomena:",
+	"finance.contributor":       "Mpanome",
+	"finance.amount":            "Vola",
+	"finance.summary":           "🧮 Famintinana isam-bolana",
+	"finance.total_needs":       "💰 Totalin'ny filana: %s",
+	"finance.total_contributed": "💵 Totalin'ny nomena: %s",
+	"finance.by_contributor":    "👤 Isaky ny mpanome:",
+
+	"digest.stock_intro":     "📬 Famintinana isan-kerinandro ny tahiry",
+	"digest.finance_intro":   "📬 Famintinana ara-bola isam-bolana",
+	"digest.shortfall_title": "Mbola tsy ampy",
+	"digest.need":            "Filana",
+	"digest.shortfall":       "Tsy ampy",
+	"digest.total_shortfall": "🔻 Totalin'ny tsy ampy: %s",
+	"digest.all_covered":     "✅ Voarakotra tanteraka ny filana rehetra.",
+	"digest.current":         "📬 Famintinana ho an'ity resaka ity: %s",
+	"digest.usage":           "Ovay amin'ny /digest stock on|off na /digest finance on|off.",
+	"digest.unknown":         "⚠️ Famintinana tsy fantatra %q.",
+	"digest.set":             "✅ Famintinana %s: %s.",
+	"digest.save_failed":     "⚠️ Tsy voatahiry ny safidinao.",
+	"digest.not_allowed":     "⛔ Tsy afaka mandray famintinana ity resaka ity.",
+	"digest.on":              "mandeha",
+	"digest.off":             "tsy mandeha",
+	"digest.name_stock":      "tahiry",
+	"digest.name_finance":    "vola",
+
+	"adherence.reminder":         "💊 Fotoana hihinanana *%[1]s*: pilina %[2]s amin'ny %[3]s",
+	"adherence.reminder_patient": "💊 Fotoana hihinanana *%[1]s* (%[4]s): pilina %[2]s amin'ny %[3]s",
+	"adherence.button_taken":     "✅ Nohanina",
+	"adherence.button_skipped":   "⏭️ Tsy nohanina",
+	"adherence.taken":            "✅ Nohanina tamin'ny %s",
+	"adherence.skipped":          "⏭️ Tsy nohanina (%s)",
+	"adherence.recorded":         "Voaray ny valinteninao",
+	"adherence.record_failed":    "⚠️ Tsy voatahiry ny valinteninao.",
+	"adherence.fetch_failed":     "⚠️ Tsy azo ny firaketana fihinanana fanafody.",
+	"adherence.none":             "ℹ️ Mbola tsy nisy fampahatsiahivana nalefa.",
+	"adherence.title":            "Fanarahana isan-kerinandro",
+	"adherence.patient":          "Marary",
+	"adherence.week":             "Herinandro",
+	"adherence.doses":            "Nohanina",
+	"adherence.rate":             "Taha",
+
+	"alerts.fetch_failed": "⚠️ Tsy azo ny tantaran'ny fampitandremana.",
+	"alerts.none":         "ℹ️ Mbola tsy nisy fampitandremana nalefa.",
+	"alerts.title":        "Fampitandremana farany",
+	"alerts.attempts":     " (in-%d nandefasana)",
+
+	"alert.low_stock": "Ho lany afaka %[2]d andro ny *%[1]s*!\nVidio alohan'ny *%[3]s*\nAmin'izao: *%[4]s* %[5]s sisa.",
+
+	"refill.received":  "✅ Voaray ny fanampiana: %s",
+	"refill.quantity":  "Isa: %s %s",
+	"refill.converted": "Raha avadika: %s %s",
+	"refill.date":      "Daty: %s",
+
+	"unit.pill": "pilina",
+
+	"validation.fetch_failed":         "⚠️ Tsy azo ireo firaketana hojerena.",
+	"validation.title":                "Olana amin'ny firaketana",
+	"validation.clean":                "✅ Tsy misy olana hita amin'ny firaketana.",
+	"validation.checked":              "Fanafody %d, fidirana tahiry %d ary fanomezana %d amin'ny %s no nojerena.",
+	"validation.more":                 "%d hafa",
+	"validation.orphan_entry":         "Fidirana tsy misy fanafody fantatra (%d)",
+	"validation.missing_date":         "Firaketana tsy misy daty (%d)",
+	"validation.invalid_date":         "Daty tsy azo vakina (%d)",
+	"validation.unknown_unit":         "Fidirana amin'ny singa tsy fantatry ny fanafody, isaina ho singa fototra (%d)",
+	"validation.unknown_kind":         "Fidirana tsy fantatra ny karazany, tsy raisina (%d)",
+	"validation.invalid_units":        "Fanafody tsy azo ampiasaina ny fanovana singany (%d)",
+	"validation.missing_unit_per_box": "Fanafody tsy voalaza ny isan'ny pilina isaky ny boaty na singa hafa (%d)",
+	"validation.future_entry":         "Fidirana misy daty mbola ho avy (%d)",
+	"validation.duplicate_entry":      "Fidirana miverina (%d)",
+	"validation.negative_amount":      "Isa latsaky ny aotra (%d)",
+	"validation.over_contribution":    "Filana nihoaran'ny fanomezana (%d)",
+}
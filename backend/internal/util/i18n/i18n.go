@@ -0,0 +1,178 @@
+// Package i18n holds the bot's message catalogue and the locale-aware
+// formatting of dates and numbers.
+package i18n
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/nomenarkt/vitaltrack/backend/internal/util/richtext"
+)
+
+// Lang is a supported message language.
+type Lang string
+
+// Supported languages.
+const (
+	English  Lang = "en"
+	French   Lang = "fr"
+	Malagasy Lang = "mg"
+)
+
+// Default is used when no preference is set or a string is missing from a
+// catalogue.
+const Default = English
+
+// Langs lists the supported languages in display order.
+var Langs = []Lang{English, French, Malagasy}
+
+// Parse returns the language named by s, such as "fr" or "fr-FR".
+func Parse(s string) (Lang, bool) {
+	s = strings.ToLower(strings.TrimSpace(s))
+	if i := strings.IndexAny(s, "-_"); i >= 0 {
+		s = s[:i]
+	}
+	for _, l := range Langs {
+		if string(l) == s {
+			return l, true
+		}
+	}
+	return "", false
+}
+
+// Name is the language's name in that language.
+func (l Lang) Name() string {
+	return For(l).T("lang.name")
+}
+
+// locale holds the formatting conventions of a language.
+type locale struct {
+	date    string // time layout for dates
+	group   string // thousands separator
+	decimal string
+	months  [12]string // empty to format months numerically
+	catalog map[string]string
+}
+
+var locales = map[Lang]locale{
+	English: {date: "2006-01-02", group: ",", decimal: ".", catalog: english},
+	French: {
+		date: "02/01/2006", group: "\u202f", decimal: ",", catalog: french,
+		months: [12]string{"janvier", "février", "mars", "avril", "mai", "juin", "juillet", "août", "septembre", "octobre", "novembre", "décembre"},
+	},
+	Malagasy: {
+		date: "02/01/2006", group: "\u202f", decimal: ",", catalog: malagasy,
+		months: [12]string{"Janoary", "Febroary", "Martsa", "Aprily", "Mey", "Jona", "Jolay", "Aogositra", "Septambra", "Oktobra", "Novambra", "Desambra"},
+	},
+}
+
+// Printer formats messages for one language. The zero Printer uses Default.
+type Printer struct {
+	lang Lang
+}
+
+// For returns a Printer for l, or for Default when l is not supported.
+func For(l Lang) Printer {
+	if _, ok := locales[l]; !ok {
+		l = Default
+	}
+	return Printer{lang: l}
+}
+
+// Lang returns the printer's language.
+func (p Printer) Lang() Lang {
+	if p.lang == "" {
+		return Default
+	}
+	return p.lang
+}
+
+func (p Printer) template(key string) string {
+	loc := locales[p.Lang()]
+	if s, ok := loc.catalog[key]; ok {
+		return s
+	}
+	if s, ok := locales[Default].catalog[key]; ok {
+		return s
+	}
+	return key
+}
+
+// T returns the catalogue entry for key formatted with a, as fmt.Sprintf.
+func (p Printer) T(key string, a ...any) string {
+	if len(a) == 0 {
+		return strings.ReplaceAll(p.template(key), "%%", "%")
+	}
+	return fmt.Sprintf(p.template(key), a...)
+}
+
+// Rich is T for entries that mark bold text with *asterisks*. Such entries
+// must index every verb (%[1]s) since each run is formatted on its own.
+func (p Printer) Rich(key string, a ...any) []richtext.Span {
+	var spans []richtext.Span
+	for i, run := range strings.Split(p.template(key), "*") {
+		text := strings.ReplaceAll(run, "%%", "")
+		if strings.Contains(text, "%") {
+			text = fmt.Sprintf(run, a...)
+		} else {
+			text = strings.ReplaceAll(run, "%%", "%")
+		}
+		style := richtext.Regular
+		if i%2 == 1 {
+			style = richtext.Bold
+		}
+		spans = append(spans, richtext.Span{Text: text, Style: style})
+	}
+	return spans
+}
+
+// Date formats the calendar date of t.
+func (p Printer) Date(t time.Time) string {
+	return t.Format(locales[p.Lang()].date)
+}
+
+// DateTime formats t as a date and a 24-hour clock time.
+func (p Printer) DateTime(t time.Time) string {
+	return p.Date(t) + " " + t.Format("15:04")
+}
+
+// Month formats a calendar month, such as "juin 2025".
+func (p Printer) Month(year int, month time.Month) string {
+	loc := locales[p.Lang()]
+	if loc.months[0] == "" || month < time.January || month > time.December {
+		return fmt.Sprintf("%d-%02d", year, month)
+	}
+	return fmt.Sprintf("%s %d", loc.months[month-1], year)
+}
+
+// Number formats v with the given number of decimals and the language's
+// digit grouping and decimal separator.
+func (p Printer) Number(v float64, decimals int) string {
+	loc := locales[p.Lang()]
+	s := strconv.FormatFloat(math.Abs(v), 'f', decimals, 64)
+	whole, frac, _ := strings.Cut(s, ".")
+
+	var sb strings.Builder
+	if v < 0 && strings.Trim(s, "0.") != "" {
+		sb.WriteByte('-')
+	}
+	for i, d := range whole {
+		if i > 0 && (len(whole)-i)%3 == 0 {
+			sb.WriteString(loc.group)
+		}
+		sb.WriteRune(d)
+	}
+	if frac != "" {
+		sb.WriteString(loc.decimal + frac)
+	}
+	return sb.String()
+}
+
+// MGA formats an amount of Malagasy ariary, with a narrow no-break space
+// before the currency code.
+func (p Printer) MGA(v float64) string {
+	return p.Number(v, 0) + "\u202fMGA"
+}
@@ -0,0 +1,116 @@
+package i18n
+
+import (
+	"regexp"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/nomenarkt/vitaltrack/backend/internal/util/richtext"
+)
+
+var verb = regexp.MustCompile(`%(\[\d+\])?[-+# 0]*\d*(\.\d+)?[a-zA-Z]`)
+
+func verbs(s string) []string {
+	v := verb.FindAllString(strings.ReplaceAll(s, "%%", ""), -1)
+	sort.Strings(v)
+	return v
+}
+
+func TestCatalogsComplete(t *testing.T) {
+	for _, l := range Langs {
+		cat := locales[l].catalog
+		for key, en := range english {
+			tr, ok := cat[key]
+			if !ok {
+				t.Errorf("%s: missing %q", l, key)
+				continue
+			}
+			if strings.Join(verbs(tr), " ") != strings.Join(verbs(en), " ") {
+				t.Errorf("%s %q: verbs %v, want %v", l, key, verbs(tr), verbs(en))
+			}
+			if strings.Count(tr, "*")%2 != 0 {
+				t.Errorf("%s %q: unbalanced bold markers", l, key)
+			}
+			if strings.Contains(tr, "*") {
+				for _, v := range verbs(tr) {
+					if !strings.HasPrefix(v, "%[") {
+						t.Errorf("%s %q: verb %s must be indexed in a rich entry", l, key, v)
+					}
+				}
+			}
+		}
+		for key := range cat {
+			if _, ok := english[key]; !ok {
+				t.Errorf("%s: %q is not in the English catalogue", l, key)
+			}
+		}
+	}
+}
+
+func TestPrinter_formatting(t *testing.T) {
+	ts := time.Date(2025, 6, 5, 9, 30, 0, 0, time.UTC)
+	tests := []struct {
+		lang   Lang
+		number string
+		mga    string
+		date   string
+		month  string
+	}{
+		{English, "-1,234,567.50", "1,234,568\u202fMGA", "2025-06-05", "2025-06"},
+		{French, "-1\u202f234\u202f567,50", "1\u202f234\u202f568\u202fMGA", "05/06/2025", "juin 2025"},
+		{Malagasy, "-1\u202f234\u202f567,50", "1\u202f234\u202f568\u202fMGA", "05/06/2025", "Jona 2025"},
+	}
+	for _, tt := range tests {
+		t.Run(string(tt.lang), func(t *testing.T) {
+			p := For(tt.lang)
+			if got := p.Number(-1234567.5, 2); got != tt.number {
+				t.Errorf("Number = %q, want %q", got, tt.number)
+			}
+			if got := p.MGA(1234567.5); got != tt.mga {
+				t.Errorf("MGA = %q, want %q", got, tt.mga)
+			}
+			if got := p.Date(ts); got != tt.date {
+				t.Errorf("Date = %q, want %q", got, tt.date)
+			}
+			if got := p.Month(2025, time.June); got != tt.month {
+				t.Errorf("Month = %q, want %q", got, tt.month)
+			}
+		})
+	}
+
+	for v, want := range map[float64]string{0: "0", 999: "999", 1000: "1,000", -0.001: "0"} {
+		if got := For(English).Number(v, 0); got != want {
+			t.Errorf("Number(%v) = %q, want %q", v, got, want)
+		}
+	}
+}
+
+func TestPrinter_lookup(t *testing.T) {
+	if got := For("de").Lang(); got != Default {
+		t.Errorf("unsupported language resolved to %s", got)
+	}
+	if got := (Printer{}).T("alerts.attempts", 3); got != " (3 attempts)" {
+		t.Errorf("zero Printer = %q", got)
+	}
+	if got := For(French).T("no.such.key"); got != "no.such.key" {
+		t.Errorf("missing key = %q", got)
+	}
+	for in, want := range map[string]Lang{"fr": French, "FR-fr": French, " mg ": Malagasy, "en_GB": English} {
+		if got, ok := Parse(in); !ok || got != want {
+			t.Errorf("Parse(%q) = %q, %v", in, got, ok)
+		}
+	}
+	if _, ok := Parse("klingon"); ok {
+		t.Error("Parse accepted an unsupported language")
+	}
+}
+
+func TestPrinter_Rich(t *testing.T) {
+	got := richtext.MarkdownV2(richtext.New(richtext.P(For(Malagasy).Rich("alert.low_stock", "Med_1", 3, "08/06/2025", "1,50", "pilina")...)))
+	want := "Ho lany afaka 3 andro ny *Med\\_1*\\!\nVidio alohan'ny *08/06/2025*\nAmin'izao: *1,50* pilina sisa\\."
+	if got != want {
+		t.Errorf("got  %q\nwant %q", got, want)
+	}
+}
@@ -0,0 +1,140 @@
+package richtext
+
+import (
+	"strings"
+)
+
+// markup describes how one output format writes inline text and code blocks.
+type markup struct {
+	span func(Span) string
+	pre  func(lang string, lines []string) string
+}
+
+func (m markup) render(d Doc) string {
+	var out []string
+	for _, b := range d {
+		switch b := b.(type) {
+		case Paragraph:
+			out = append(out, m.spans(b))
+		case List:
+			if len(b) == 0 {
+				continue
+			}
+			items := make([]string, len(b))
+			for i, item := range b {
+				items[i] = m.span(T("• ")) + m.spans(item)
+			}
+			out = append(out, strings.Join(items, "\n"))
+		case CodeBlock:
+			out = append(out, m.pre(b.Lang, b.Lines))
+		case Table:
+			out = append(out, m.pre("text", b.Lines()))
+		}
+	}
+	return strings.Join(out, "\n\n")
+}
+
+func (m markup) spans(spans []Span) string {
+	var sb strings.Builder
+	for _, s := range spans {
+		if s.Text != "" {
+			sb.WriteString(m.span(s))
+		}
+	}
+	return sb.String()
+}
+
+var markdownV2 = markup{
+	span: func(s Span) string {
+		switch s.Style {
+		case Bold:
+			return "*" + escapeMarkdownV2(s.Text) + "*"
+		case Italic:
+			return "_" + escapeMarkdownV2(s.Text) + "_"
+		case Code:
+			return "`" + escapeMarkdownV2Code(s.Text) + "`"
+		}
+		return escapeMarkdownV2(s.Text)
+	},
+	pre: func(lang string, lines []string) string {
+		return "```" + lang + "\n" + escapeMarkdownV2Code(strings.Join(lines, "\n")) + "\n```"
+	},
+}
+
+var html = markup{
+	span: func(s Span) string {
+		text := escapeHTML(s.Text)
+		switch s.Style {
+		case Bold:
+			return "<b>" + text + "</b>"
+		case Italic:
+			return "<i>" + text + "</i>"
+		case Code:
+			return "<code>" + text + "</code>"
+		}
+		return text
+	},
+	pre: func(lang string, lines []string) string {
+		code := "<code>"
+		if lang != "" {
+			code = `<code class="language-` + escapeHTML(lang) + `">`
+		}
+		return "<pre>" + code + escapeHTML(strings.Join(lines, "\n")) + "</code></pre>"
+	},
+}
+
+var plain = markup{
+	span: func(s Span) string { return s.Text },
+	pre:  func(_ string, lines []string) string { return strings.Join(lines, "\n") },
+}
+
+// MarkdownV2 renders d for Telegram's MarkdownV2 parse mode.
+func MarkdownV2(d Doc) string { return markdownV2.render(d) }
+
+// HTML renders d for Telegram's HTML parse mode.
+func HTML(d Doc) string { return html.render(d) }
+
+// Plain renders d as unformatted text.
+func Plain(d Doc) string { return plain.render(d) }
+
+var markdownV2Escaper = strings.NewReplacer(
+	"\\", "\\\\",
+	"_", "\\_",
+	"*", "\\*",
+	"[", "\\[",
+	"]", "\\]",
+	"(", "\\(",
+	")", "\\)",
+	"~", "\\~",
+	"`", "\\`",
+	">", "\\>",
+	"#", "\\#",
+	"+", "\\+",
+	"-", "\\-",
+	"=", "\\=",
+	"|", "\\|",
+	"{", "\\{",
+	"}", "\\}",
+	".", "\\.",
+	"!", "\\!",
+)
+
+// escapeMarkdownV2 escapes every character MarkdownV2 reserves outside code,
+// so raw text renders literally.
+func escapeMarkdownV2(text string) string {
+	return markdownV2Escaper.Replace(text)
+}
+
+var markdownV2CodeEscaper = strings.NewReplacer("\\", "\\\\", "`", "\\`")
+
+// escapeMarkdownV2Code escapes text inside inline code and code blocks, where
+// only backslashes and backticks are special.
+func escapeMarkdownV2Code(text string) string {
+	return markdownV2CodeEscaper.Replace(text)
+}
+
+var htmlEscaper = strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;")
+
+func escapeHTML(text string) string {
+	return htmlEscaper.Replace(text)
+}
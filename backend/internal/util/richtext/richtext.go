@@ -0,0 +1,137 @@
+// Package richtext models formatted bot messages independently of the markup
+// used to send them. Messages are built from blocks and inline spans holding
+// raw text; each renderer applies its own escaping.
+package richtext
+
+import (
+	"fmt"
+	"strings"
+	"unicode/utf8"
+)
+
+// Style is the inline formatting of a span.
+type Style int
+
+// Inline styles.
+const (
+	Regular Style = iota
+	Bold
+	Italic
+	Code
+)
+
+// Span is a run of raw text with a single style.
+type Span struct {
+	Text  string
+	Style Style
+}
+
+// T returns a regular span. Arguments are formatted as with fmt.Sprint.
+func T(a ...any) Span { return Span{Text: fmt.Sprint(a...)} }
+
+// B returns a bold span.
+func B(a ...any) Span { return Span{Text: fmt.Sprint(a...), Style: Bold} }
+
+// I returns an italic span.
+func I(a ...any) Span { return Span{Text: fmt.Sprint(a...), Style: Italic} }
+
+// C returns an inline code span.
+func C(a ...any) Span { return Span{Text: fmt.Sprint(a...), Style: Code} }
+
+// Block is a top-level element of a Doc.
+type Block interface{ block() }
+
+// Paragraph is a run of inline spans. Newlines in span text are kept.
+type Paragraph []Span
+
+// List is a bulleted list, one item per entry.
+type List [][]Span
+
+// CodeBlock is preformatted text shown in a monospaced font.
+type CodeBlock struct {
+	Lang  string
+	Lines []string
+}
+
+// Align is the alignment of a table column.
+type Align int
+
+// Column alignments.
+const (
+	Left Align = iota
+	Right
+)
+
+// Table is a monospaced grid with a header row. Align may be shorter than
+// the number of columns; missing entries are Left.
+type Table struct {
+	Header []string
+	Rows   [][]string
+	Align  []Align
+}
+
+func (Paragraph) block() {}
+func (List) block()      {}
+func (CodeBlock) block() {}
+func (Table) block()     {}
+
+// P returns a paragraph of spans.
+func P(spans ...Span) Paragraph { return Paragraph(spans) }
+
+// Item returns a list item made of spans.
+func Item(spans ...Span) []Span { return spans }
+
+// Doc is a message made of blocks separated by blank lines.
+type Doc []Block
+
+// New returns a Doc holding blocks.
+func New(blocks ...Block) Doc { return Doc(blocks) }
+
+// Text returns a Doc holding a single regular paragraph, for simple replies.
+func Text(s string) Doc {
+	return New(P(T(s)))
+}
+
+// Lines lays the table out as aligned rows separated from the header by a
+// rule, the way it appears inside a code block.
+func (t Table) Lines() []string {
+	widths := make([]int, len(t.Header))
+	for i, h := range t.Header {
+		widths[i] = utf8.RuneCountInString(h)
+	}
+	for _, row := range t.Rows {
+		for i, cell := range row {
+			if i < len(widths) {
+				widths[i] = max(widths[i], utf8.RuneCountInString(cell))
+			}
+		}
+	}
+
+	format := func(row []string) string {
+		cells := make([]string, len(widths))
+		for i, w := range widths {
+			cell := ""
+			if i < len(row) {
+				cell = row[i]
+			}
+			pad := strings.Repeat(" ", w-utf8.RuneCountInString(cell))
+			if i < len(t.Align) && t.Align[i] == Right {
+				cells[i] = pad + cell
+			} else {
+				cells[i] = cell + pad
+			}
+		}
+		return "| " + strings.Join(cells, " | ") + " |"
+	}
+
+	rules := make([]string, len(widths))
+	for i, w := range widths {
+		rules[i] = strings.Repeat("-", w+2)
+	}
+
+	lines := []string{format(t.Header), "|" + strings.Join(rules, "|") + "|"}
+	for _, row := range t.Rows {
+		lines = append(lines, format(row))
+	}
+	return lines
+}
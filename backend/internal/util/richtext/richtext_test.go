@@ -0,0 +1,95 @@
+package richtext_test
+
+import (
+	"strings"
+	"testing"
+
+	rt "github.com/nomenarkt/vitaltrack/backend/internal/util/richtext"
+)
+
+func TestRender(t *testing.T) {
+	doc := rt.New(
+		rt.P(rt.B("Stock for ", "Med_1*"), rt.T(" runs out on "), rt.I("2025-06-10"), rt.T("!")),
+		rt.List{
+			rt.Item(rt.T("a < b & c")),
+			rt.Item(rt.C("x`y\\z")),
+		},
+		rt.CodeBlock{Lang: "text", Lines: []string{"foo - bar", "back`tick"}},
+	)
+
+	tests := []struct {
+		name   string
+		render func(rt.Doc) string
+		want   string
+	}{
+		{
+			name:   "markdown_v2",
+			render: rt.MarkdownV2,
+			want: "*Stock for Med\\_1\\** runs out on _2025\\-06\\-10_\\!\n\n" +
+				"• a < b & c\n• `x\\`y\\\\z`\n\n" +
+				"```text\nfoo - bar\nback\\`tick\n```",
+		},
+		{
+			name:   "html",
+			render: rt.HTML,
+			want: "<b>Stock for Med_1*</b> runs out on <i>2025-06-10</i>!\n\n" +
+				"• a &lt; b &amp; c\n• <code>x`y\\z</code>\n\n" +
+				"<pre><code class=\"language-text\">foo - bar\nback`tick</code></pre>",
+		},
+		{
+			name:   "plain",
+			render: rt.Plain,
+			want: "Stock for Med_1* runs out on 2025-06-10!\n\n" +
+				"• a < b & c\n• x`y\\z\n\n" +
+				"foo - bar\nback`tick",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.render(doc); got != tt.want {
+				t.Errorf("got:\n%s\nwant:\n%s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMarkdownV2_escapesText(t *testing.T) {
+	tests := []struct {
+		in   string
+		want string
+	}{
+		{"a_b", "a\\_b"},
+		{"(test)", "\\(test\\)"},
+		{"dash - dash", "dash \\- dash"},
+		{"ends with !", "ends with \\!"},
+		{"already \\! escaped", "already \\\\\\! escaped"},
+		{"*not bold*", "\\*not bold\\*"},
+	}
+	for _, tt := range tests {
+		if got := rt.MarkdownV2(rt.Text(tt.in)); got != tt.want {
+			t.Errorf("MarkdownV2(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestTable_Lines(t *testing.T) {
+	table := rt.Table{
+		Header: []string{"Name", "Amount"},
+		Rows:   [][]string{{"Alice", "10"}, {"Bé", "5"}},
+		Align:  []rt.Align{rt.Left, rt.Right},
+	}
+	want := []string{
+		"| Name  | Amount |",
+		"|-------|--------|",
+		"| Alice |     10 |",
+		"| Bé    |      5 |",
+	}
+	if got := table.Lines(); strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("got:\n%s\nwant:\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+
+	md := rt.MarkdownV2(rt.New(table))
+	if !strings.HasPrefix(md, "```text\n| Name") || !strings.HasSuffix(md, "|\n```") {
+		t.Errorf("table not rendered as a code block: %q", md)
+	}
+}